@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// 合约账户的余额和保证金，金额以USDT计
+type AccountSummary struct {
+	WalletBalance     float64 // 钱包余额
+	UnrealizedProfit  float64 // 总未实现盈亏
+	MarginBalance     float64 // 保证金余额 = 钱包余额 + 未实现盈亏
+	AvailableBalance  float64 // 可用保证金
+	InitialMargin     float64 // 持仓和挂单占用的起始保证金
+	MaintenanceMargin float64 // 维持保证金
+	PositionNotional  float64 // 所有持仓名义价值的绝对值之和
+	Assets            []AssetBalance
+}
+
+// 单个保证金资产的余额，只包含有余额或未实现盈亏的资产
+type AssetBalance struct {
+	Asset            string  `json:"asset"`
+	WalletBalance    float64 `json:"wallet_balance"`
+	MarginBalance    float64 `json:"margin_balance"`
+	AvailableBalance float64 `json:"available_balance"`
+	UnrealizedProfit float64 `json:"unrealized_pnl"`
+}
+
+func fetchAccountSummary(client *futures.Client) (*AccountSummary, error) {
+	account, err := client.NewGetAccountService().Do(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf(T("获取账户信息失败: %v"), err)
+	}
+
+	parse := func(s string) float64 {
+		v, _ := strconv.ParseFloat(s, 64)
+		return v
+	}
+	summary := &AccountSummary{
+		WalletBalance:     parse(account.TotalWalletBalance),
+		UnrealizedProfit:  parse(account.TotalUnrealizedProfit),
+		MarginBalance:     parse(account.TotalMarginBalance),
+		AvailableBalance:  parse(account.AvailableBalance),
+		InitialMargin:     parse(account.TotalInitialMargin),
+		MaintenanceMargin: parse(account.TotalMaintMargin),
+	}
+	for _, a := range account.Assets {
+		asset := AssetBalance{
+			Asset:            a.Asset,
+			WalletBalance:    parse(a.WalletBalance),
+			MarginBalance:    parse(a.MarginBalance),
+			AvailableBalance: parse(a.AvailableBalance),
+			UnrealizedProfit: parse(a.UnrealizedProfit),
+		}
+		if asset.WalletBalance != 0 || asset.UnrealizedProfit != 0 {
+			summary.Assets = append(summary.Assets, asset)
+		}
+	}
+	for _, p := range account.Positions {
+		summary.PositionNotional += math.Abs(parse(p.Notional))
+	}
+	return summary, nil
+}
+
+// 账户整体杠杆，持仓名义价值与保证金余额之比
+func (a *AccountSummary) Leverage() float64 {
+	if a.MarginBalance <= 0 {
+		return 0
+	}
+	return a.PositionNotional / a.MarginBalance
+}
+
+// 保证金使用率，起始保证金占保证金余额的百分比
+func (a *AccountSummary) MarginUsage() float64 {
+	if a.MarginBalance <= 0 {
+		return 0
+	}
+	return a.InitialMargin / a.MarginBalance * 100
+}
+
+// 维持保证金率，达到100%时触发强平
+func (a *AccountSummary) MaintenanceRatio() float64 {
+	if a.MarginBalance <= 0 {
+		return 0
+	}
+	return a.MaintenanceMargin / a.MarginBalance * 100
+}
+
+// 账户面板中显示的内容
+func (a *AccountSummary) String() string {
+	var s strings.Builder
+
+	s.WriteString(fmt.Sprintf(T("钱包余额: %.2f\n"), a.WalletBalance))
+	s.WriteString(fmt.Sprintf(T("可用保证金: %.2f\n"), a.AvailableBalance))
+	s.WriteString(fmt.Sprintf(T("未实现盈亏: %+.2f\n"), a.UnrealizedProfit))
+	s.WriteString(fmt.Sprintf(T("保证金使用率: %.2f%%\n"), a.MarginUsage()))
+	s.WriteString(fmt.Sprintf(T("维持保证金率: %.2f%%"), a.MaintenanceRatio()))
+
+	return s.String()
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 活动记录文件，每行一条JSON，界面和命令行都追加写入
+const activityFile = "activity.jsonl"
+
+// 自动化决策的类型
+const (
+	ActivityTakeProfit = "take_profit" // 自动设置止盈单
+	ActivityStopLoss   = "stop_loss"   // 自动设置止损单
+	ActivityProtect    = "protect"     // 保护止盈市价平仓
+	ActivityCancel     = "cancel"      // 撤销止盈止损单
+	ActivityKill       = "killswitch"  // 紧急停止: 撤销所有订单并平仓
+)
+
+var activityActions = []struct {
+	Action string
+	Label  string
+}{
+	{ActivityTakeProfit, "设置止盈"},
+	{ActivityStopLoss, "设置止损"},
+	{ActivityProtect, "保护止盈平仓"},
+	{ActivityCancel, "撤销止盈止损"},
+	{ActivityKill, "紧急平仓"},
+}
+
+func activityActionNames() []string {
+	var names []string
+	for _, a := range activityActions {
+		names = append(names, a.Action)
+	}
+	return names
+}
+
+func activityLabel(action string) string {
+	for _, a := range activityActions {
+		if a.Action == action {
+			return T(a.Label)
+		}
+	}
+	return action
+}
+
+// 一条自动化决策：做了什么、为什么，以及做决定时用到的数据
+type Activity struct {
+	Time     time.Time          `json:"time"`
+	Source   string             `json:"source"` // ui或cli
+	Symbol   string             `json:"symbol"`
+	Action   string             `json:"action"`
+	Price    float64            `json:"price,omitempty"`
+	Quantity float64            `json:"quantity,omitempty"`
+	Reason   string             `json:"reason"`
+	Inputs   map[string]float64 `json:"inputs,omitempty"`
+	Error    string             `json:"error,omitempty"` // 执行失败时的错误
+}
+
+// 例如 "21:03 SOLUSDC 设置止损 1.5000 @ 134.20: 没有找到有效止损单 (entry=135.20 stop_loss=1.00)"
+func (a Activity) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %s %s", a.Time.Format("01-02 15:04:05"), a.Symbol, activityLabel(a.Action))
+	if a.Quantity > 0 {
+		fmt.Fprintf(&sb, " %.4f", a.Quantity)
+	}
+	if a.Price > 0 {
+		fmt.Fprintf(&sb, " @ %.4f", a.Price)
+	}
+	if a.Reason != "" {
+		fmt.Fprintf(&sb, ": %s", a.Reason)
+	}
+	if len(a.Inputs) > 0 {
+		keys := make([]string, 0, len(a.Inputs))
+		for k := range a.Inputs {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var inputs []string
+		for _, k := range keys {
+			inputs = append(inputs, fmt.Sprintf("%s=%.4g", k, a.Inputs[k]))
+		}
+		fmt.Fprintf(&sb, " (%s)", strings.Join(inputs, " "))
+	}
+	if a.Error != "" {
+		fmt.Fprintf(&sb, T(" 失败: %s"), a.Error)
+	}
+	return sb.String()
+}
+
+// 查询条件，零值表示不限
+type ActivityQuery struct {
+	Symbol string
+	Action string
+	Since  time.Time
+	Limit  int // 只返回最近的Limit条
+}
+
+func (q ActivityQuery) match(a Activity) bool {
+	if q.Symbol != "" && a.Symbol != q.Symbol {
+		return false
+	}
+	if q.Action != "" && a.Action != q.Action {
+		return false
+	}
+	return q.Since.IsZero() || !a.Time.Before(q.Since)
+}
+
+// 自动化决策的活动记录，保存在JSONL文件中
+type ActivityLog struct {
+	path   string
+	source string
+
+	// 每条记录写入后调用，监控程序用来推送通知
+	OnRecord func(Activity)
+
+	mu sync.Mutex
+}
+
+// source标记记录来自界面还是命令行
+func NewActivityLog(path, source string) *ActivityLog {
+	return &ActivityLog{path: path, source: source}
+}
+
+// 追加一条记录，写入失败只打印错误，不影响自动化本身
+func (l *ActivityLog) Record(a Activity) {
+	if a.Time.IsZero() {
+		a.Time = time.Now()
+	}
+	if a.Source == "" {
+		a.Source = l.source
+	}
+	if l.OnRecord != nil {
+		defer l.OnRecord(a)
+	}
+	data, err := json.Marshal(a)
+	if err != nil {
+		fmt.Printf(T("保存活动记录失败: %v\n"), err)
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		fmt.Printf(T("保存活动记录失败: %v\n"), err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		fmt.Printf(T("保存活动记录失败: %v\n"), err)
+	}
+}
+
+// 按时间顺序返回符合条件的记录，文件不存在时返回空
+func (l *ActivityLog) Query(q ActivityQuery) ([]Activity, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var result []Activity
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var a Activity
+		if err := json.Unmarshal(scanner.Bytes(), &a); err != nil {
+			continue // 跳过写了一半的行
+		}
+		if q.match(a) {
+			result = append(result, a)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if q.Limit > 0 && len(result) > q.Limit {
+		result = result[len(result)-q.Limit:]
+	}
+	return result, nil
+}
+
+// 持仓方向的文字，用于决策原因
+func positionDirection(amt float64) string {
+	if amt > 0 {
+		return T("多仓")
+	}
+	return T("空仓")
+}
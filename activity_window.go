@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 活动窗口的刷新间隔和显示条数
+const (
+	activityViewRefresh = 2 * time.Second
+	activityViewLimit   = 500
+)
+
+// 活动记录窗口：按时间倒序显示自动化做了什么、为什么，可以按交易对和类型筛选。
+// 记录保存在文件中，命令行的activity子命令可以查询同一份记录
+func (ui *TraderUI) showActivity() {
+	w := ui.app.NewWindow(T("活动记录"))
+
+	var activities []Activity
+	list := widget.NewList(
+		func() int { return len(activities) },
+		func() fyne.CanvasObject {
+			return widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			a := activities[id]
+			label := o.(*widget.Label)
+			label.Importance = widget.MediumImportance
+			if a.Error != "" {
+				label.Importance = widget.DangerImportance
+			} else if a.Action == ActivityProtect {
+				label.Importance = widget.WarningImportance
+			}
+			label.SetText(fmt.Sprintf("[%s] %s", a.Source, a))
+		},
+	)
+
+	symbolEntry := widget.NewEntry()
+	symbolEntry.SetPlaceHolder(T("交易对，为空时显示全部"))
+	actionLabels := []string{T("全部")}
+	for _, a := range activityActions {
+		actionLabels = append(actionLabels, T(a.Label))
+	}
+	actionSelect := widget.NewSelect(actionLabels, nil)
+	count := widget.NewLabel("")
+
+	refresh := func() {
+		q := ActivityQuery{Symbol: strings.ToUpper(strings.TrimSpace(symbolEntry.Text)), Limit: activityViewLimit}
+		for _, a := range activityActions {
+			if T(a.Label) == actionSelect.Selected {
+				q.Action = a.Action
+			}
+		}
+		result, err := ui.activity.Query(q)
+		if err != nil {
+			count.SetText(fmt.Sprintf(T("读取活动记录失败: %v"), err))
+			return
+		}
+		// 最新的记录在最上面
+		for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+			result[i], result[j] = result[j], result[i]
+		}
+		activities = result
+		count.SetText(fmt.Sprintf(T("%d条"), len(activities)))
+		list.Refresh()
+	}
+	symbolEntry.OnChanged = func(string) { refresh() }
+	actionSelect.OnChanged = func(string) { refresh() }
+	actionSelect.SetSelected(actionLabels[0])
+
+	stopC := make(chan struct{})
+	w.SetOnClosed(func() { close(stopC) })
+	go func() {
+		ticker := time.NewTicker(activityViewRefresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopC:
+				return
+			case <-ticker.C:
+				fyne.Do(refresh)
+			}
+		}
+	}()
+
+	w.SetContent(container.NewBorder(
+		container.NewBorder(nil, nil, actionSelect, count, symbolEntry),
+		nil, nil, nil,
+		list,
+	))
+	w.Resize(ui.scaled(900, 500))
+	w.Show()
+}
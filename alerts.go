@@ -0,0 +1,467 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// 提醒类型
+type AlertType string
+
+const (
+	AlertPrice    AlertType = "price"    // 价格
+	AlertRSI      AlertType = "rsi"      // RSI(14)
+	AlertPnL      AlertType = "pnl"      // 未实现盈亏
+	AlertDrawdown AlertType = "drawdown" // 从最高盈利回撤的百分比
+	AlertProtect  AlertType = "protect"  // 保护止盈启动/触发
+)
+
+// 类型的中文说明
+func (t AlertType) Label() string {
+	switch t {
+	case AlertPrice:
+		return T("价格")
+	case AlertRSI:
+		return "RSI"
+	case AlertPnL:
+		return T("未实现盈亏")
+	case AlertDrawdown:
+		return T("盈利回撤%")
+	case AlertProtect:
+		return T("保护止盈")
+	}
+	return string(t)
+}
+
+// 提醒条件
+type AlertCondition string
+
+const (
+	AlertAbove AlertCondition = "above" // 数值高于阈值
+	AlertBelow AlertCondition = "below" // 数值低于阈值
+	AlertCross AlertCondition = "cross" // 数值穿过阈值（任意方向）
+
+	AlertArmed AlertCondition = "armed" // 保护止盈启动（最高盈利达到触发线）
+	AlertFired AlertCondition = "fired" // 保护止盈平仓
+)
+
+// 条件的中文说明
+func (c AlertCondition) Label() string {
+	switch c {
+	case AlertAbove:
+		return T("高于")
+	case AlertBelow:
+		return T("低于")
+	case AlertCross:
+		return T("穿过")
+	case AlertArmed:
+		return T("启动")
+	case AlertFired:
+		return T("触发")
+	}
+	return string(c)
+}
+
+// 每种提醒类型可用的条件
+func alertConditions(t AlertType) []AlertCondition {
+	if t == AlertProtect {
+		return []AlertCondition{AlertArmed, AlertFired}
+	}
+	return []AlertCondition{AlertAbove, AlertBelow, AlertCross}
+}
+
+// 提醒。阈值类提醒触发一次后保留记录但不再触发，设置了Repeat时在条件解除后重新生效；
+// 保护止盈事件提醒每次发生都会触发
+type Alert struct {
+	ID          int64          `json:"id"`
+	Symbol      string         `json:"symbol"`
+	Type        AlertType      `json:"type"`
+	Condition   AlertCondition `json:"condition"`
+	Value       float64        `json:"value"`
+	Repeat      bool           `json:"repeat,omitempty"`
+	Channels    []string       `json:"channels,omitempty"` // 通知渠道，为空时发送到所有渠道
+	Triggered   bool           `json:"triggered"`
+	CreatedAt   time.Time      `json:"created_at"`
+	TriggeredAt time.Time      `json:"triggered_at,omitempty"`
+}
+
+func (a *Alert) String() string {
+	status := T("等待中")
+	if a.Triggered {
+		status = T("已触发 ") + a.TriggeredAt.Format("01-02 15:04:05")
+	} else if !a.TriggeredAt.IsZero() {
+		status = T("上次触发 ") + a.TriggeredAt.Format("01-02 15:04:05")
+	}
+
+	var text string
+	if a.Type == AlertProtect {
+		text = fmt.Sprintf("#%d %s %s%s", a.ID, a.Symbol, a.Type.Label(), a.Condition.Label())
+	} else {
+		text = fmt.Sprintf("#%d %s %s %s %.4f", a.ID, a.Symbol, a.Type.Label(), a.Condition.Label(), a.Value)
+	}
+	if a.Repeat {
+		text += T(" [重复]")
+	}
+	if len(a.Channels) > 0 {
+		text += " -> " + strings.Join(a.Channels, ",")
+	}
+	return fmt.Sprintf("%s (%s)", text, status)
+}
+
+// 提醒触发时的通知方式
+type AlertChannel func(alert *Alert, value float64)
+
+// 提醒管理，提醒保存在alerts.json中，重启后保留
+type AlertManager struct {
+	path string
+
+	mu        sync.Mutex
+	alerts    []*Alert
+	modTime   time.Time
+	lastValue map[string]float64
+	channels  map[string]AlertChannel
+}
+
+func LoadAlertManager(path string) (*AlertManager, error) {
+	m := &AlertManager{
+		path:      path,
+		lastValue: make(map[string]float64),
+		channels:  make(map[string]AlertChannel),
+	}
+	if err := m.load(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// 注册通知渠道
+func (m *AlertManager) RegisterChannel(name string, channel AlertChannel) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.channels[name] = channel
+}
+
+// 已注册的通知渠道名称
+func (m *AlertManager) ChannelNames() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var names []string
+	for name := range m.channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (m *AlertManager) load() error {
+	info, err := os.Stat(m.path)
+	if os.IsNotExist(err) {
+		m.alerts = nil
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf(T("读取提醒文件失败: %v"), err)
+	}
+
+	data, err := os.ReadFile(m.path)
+	if err != nil {
+		return fmt.Errorf(T("读取提醒文件失败: %v"), err)
+	}
+
+	var alerts []*Alert
+	if err := json.Unmarshal(data, &alerts); err != nil {
+		return fmt.Errorf(T("解析提醒文件失败: %v"), err)
+	}
+	for _, a := range alerts {
+		if a.Type == "" {
+			a.Type = AlertPrice
+		}
+	}
+
+	m.alerts = alerts
+	m.modTime = info.ModTime()
+	return nil
+}
+
+// 文件被其他进程（如CLI）修改过时重新加载
+func (m *AlertManager) reloadIfChanged() {
+	info, err := os.Stat(m.path)
+	if err != nil || !info.ModTime().After(m.modTime) {
+		return
+	}
+	if err := m.load(); err != nil {
+		fmt.Printf(T("重新加载提醒失败: %v\n"), err)
+	}
+}
+
+func (m *AlertManager) save() error {
+	data, err := json.MarshalIndent(m.alerts, "", "  ")
+	if err != nil {
+		return fmt.Errorf(T("序列化提醒失败: %v"), err)
+	}
+	if err := os.WriteFile(m.path, data, 0644); err != nil {
+		return fmt.Errorf(T("保存提醒文件失败: %v"), err)
+	}
+	if info, err := os.Stat(m.path); err == nil {
+		m.modTime = info.ModTime()
+	}
+	return nil
+}
+
+// 添加提醒
+func (m *AlertManager) Add(alert Alert) (*Alert, error) {
+	if alert.Type == "" {
+		alert.Type = AlertPrice
+	}
+	valid := false
+	for _, c := range alertConditions(alert.Type) {
+		if c == alert.Condition {
+			valid = true
+		}
+	}
+	if !valid {
+		return nil, fmt.Errorf(T("%s提醒不支持条件: %s"), alert.Type.Label(), alert.Condition)
+	}
+	if alert.Type == AlertPrice && alert.Value <= 0 {
+		return nil, errors.New(T("提醒价格必须大于0"))
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reloadIfChanged()
+
+	var id int64 = 1
+	for _, a := range m.alerts {
+		if a.ID >= id {
+			id = a.ID + 1
+		}
+	}
+
+	alert.ID = id
+	alert.Triggered = false
+	alert.CreatedAt = time.Now()
+	alert.TriggeredAt = time.Time{}
+	m.alerts = append(m.alerts, &alert)
+	return &alert, m.save()
+}
+
+// 删除提醒
+func (m *AlertManager) Remove(id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reloadIfChanged()
+
+	for i, a := range m.alerts {
+		if a.ID == id {
+			m.alerts = append(m.alerts[:i], m.alerts[i+1:]...)
+			return m.save()
+		}
+	}
+	return fmt.Errorf(T("未找到提醒 #%d"), id)
+}
+
+// 删除所有提醒，symbol不为空时只删除该交易对的提醒，返回删除的数量
+func (m *AlertManager) Clear(symbol string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reloadIfChanged()
+
+	kept := m.alerts[:0]
+	for _, a := range m.alerts {
+		if symbol != "" && a.Symbol != symbol {
+			kept = append(kept, a)
+		}
+	}
+	removed := len(m.alerts) - len(kept)
+	m.alerts = kept
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, m.save()
+}
+
+// 列出所有提醒
+func (m *AlertManager) List() []*Alert {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reloadIfChanged()
+
+	alerts := make([]*Alert, len(m.alerts))
+	copy(alerts, m.alerts)
+	return alerts
+}
+
+// 有等待触发的指定类型提醒的交易对
+func (m *AlertManager) ActiveSymbols(alertType AlertType) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reloadIfChanged()
+
+	var symbols []string
+	for _, a := range m.alerts {
+		if a.Type == alertType && (!a.Triggered || a.Repeat) && !slices.Contains(symbols, a.Symbol) {
+			symbols = append(symbols, a.Symbol)
+		}
+	}
+	return symbols
+}
+
+// 获取所有有等待中价格或RSI提醒的交易对的行情并检查，不限于正在监控的交易对。
+// 价格一次请求获取全部标记价格，RSI按交易对获取5分钟K线
+func checkMarketAlerts(client *futures.Client, alerts *AlertManager) error {
+	var errs []error
+	if symbols := alerts.ActiveSymbols(AlertPrice); len(symbols) > 0 {
+		prices, err := client.NewPremiumIndexService().Do(context.Background())
+		if err != nil {
+			errs = append(errs, fmt.Errorf(T("获取价格失败: %v"), err))
+		}
+		for _, p := range prices {
+			if !slices.Contains(symbols, p.Symbol) {
+				continue
+			}
+			price, err := strconv.ParseFloat(p.MarkPrice, 64)
+			if err != nil {
+				errs = append(errs, fmt.Errorf(T("解析价格失败: %v"), err))
+				continue
+			}
+			alerts.CheckPrice(p.Symbol, price)
+		}
+	}
+
+	for _, symbol := range alerts.ActiveSymbols(AlertRSI) {
+		klines, err := fetchKlines(client, symbol, "5m", 50)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", symbol, err))
+			continue
+		}
+		alerts.Check(symbol, AlertRSI, calculateRSI(klines, 14))
+	}
+	return errors.Join(errs...)
+}
+
+// 用最新价格检查价格提醒
+func (m *AlertManager) CheckPrice(symbol string, price float64) {
+	m.Check(symbol, AlertPrice, price)
+}
+
+// 用最新数值检查阈值类提醒是否触发
+func (m *AlertManager) Check(symbol string, alertType AlertType, value float64) {
+	m.mu.Lock()
+	m.reloadIfChanged()
+
+	key := symbol + "/" + string(alertType)
+	last, hasLast := m.lastValue[key]
+	m.lastValue[key] = value
+
+	var fired []*Alert
+	changed := false
+	for _, a := range m.alerts {
+		if a.Symbol != symbol || a.Type != alertType {
+			continue
+		}
+
+		hit := false
+		switch a.Condition {
+		case AlertAbove:
+			hit = value >= a.Value
+		case AlertBelow:
+			hit = value <= a.Value
+		case AlertCross:
+			hit = hasLast && last != value && (last-a.Value)*(value-a.Value) <= 0
+		}
+
+		if a.Triggered {
+			// 可重复的提醒在条件解除后重新生效
+			if a.Repeat && !hit {
+				a.Triggered = false
+				changed = true
+			}
+			continue
+		}
+		if hit {
+			a.Triggered = true
+			a.TriggeredAt = time.Now()
+			fired = append(fired, a)
+			changed = true
+		}
+	}
+
+	if changed {
+		if err := m.save(); err != nil {
+			fmt.Printf("%v\n", err)
+		}
+	}
+	m.mu.Unlock()
+
+	m.dispatch(fired, value)
+}
+
+// 保护止盈启动或平仓时触发对应的事件提醒
+func (m *AlertManager) Fire(symbol string, condition AlertCondition, value float64) {
+	m.mu.Lock()
+	m.reloadIfChanged()
+
+	var fired []*Alert
+	for _, a := range m.alerts {
+		if a.Symbol == symbol && a.Type == AlertProtect && a.Condition == condition {
+			a.TriggeredAt = time.Now()
+			fired = append(fired, a)
+		}
+	}
+	if len(fired) > 0 {
+		if err := m.save(); err != nil {
+			fmt.Printf("%v\n", err)
+		}
+	}
+	m.mu.Unlock()
+
+	m.dispatch(fired, value)
+}
+
+// 把触发的提醒发送到对应的通知渠道
+func (m *AlertManager) dispatch(fired []*Alert, value float64) {
+	if len(fired) == 0 {
+		return
+	}
+
+	m.mu.Lock()
+	channels := make(map[string]AlertChannel, len(m.channels))
+	for name, c := range m.channels {
+		channels[name] = c
+	}
+	m.mu.Unlock()
+
+	for _, a := range fired {
+		if len(a.Channels) == 0 {
+			for _, c := range channels {
+				c(a, value)
+			}
+			continue
+		}
+		for _, name := range a.Channels {
+			if c, ok := channels[name]; ok {
+				c(a, value)
+			}
+		}
+	}
+}
+
+// 提醒触发时的通知文字
+func (a *Alert) Message(value float64) string {
+	if a.Type == AlertProtect {
+		return fmt.Sprintf(T("%s %s%s，当前盈利 %.2f"), a.Symbol, a.Type.Label(), a.Condition.Label(), value)
+	}
+	return fmt.Sprintf("%s %s %.4f %s %.4f", a.Symbol, a.Type.Label(), value, a.Condition.Label(), a.Value)
+}
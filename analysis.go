@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 分析所需的数据，由调用方填充，没有的数据为nil
+type AnalysisInput struct {
+	Symbol       string
+	Interval     string // K线周期
+	Klines       []Kline
+	Sentiment    *MarketSentiment
+	Volatility   *VolatilityMetrics
+	Session      *SessionStats
+	Basis        *Basis
+	Liquidations *LiquidationMonitor
+}
+
+// 分析面板中的一个部分
+type AnalysisSection struct {
+	Title string
+	Body  string
+}
+
+// 分析器接口，每个分析器向分析面板贡献若干部分
+type AnalysisProvider interface {
+	Name() string
+	Analyze(input *AnalysisInput) ([]AnalysisSection, error)
+}
+
+var analysisProviders = make(map[string]AnalysisProvider)
+
+// 默认启用的分析器
+var defaultAnalysisProviders = []string{"ta", "stats", "market"}
+
+// 注册分析器，可在配置中按名称选择
+func RegisterAnalysisProvider(p AnalysisProvider) {
+	analysisProviders[p.Name()] = p
+}
+
+// 已注册的分析器名称
+func AnalysisProviderNames() []string {
+	var names []string
+	for name := range analysisProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	RegisterAnalysisProvider(taAnalysisProvider{})
+	RegisterAnalysisProvider(statsAnalysisProvider{})
+	RegisterAnalysisProvider(marketAnalysisProvider{})
+}
+
+// 依次运行选中的分析器，拼接成分析面板文本
+func runAnalysis(names []string, input *AnalysisInput) string {
+	if len(names) == 0 {
+		names = defaultAnalysisProviders
+	}
+
+	var sections []AnalysisSection
+	for _, name := range names {
+		p, ok := analysisProviders[name]
+		if !ok {
+			sections = append(sections, AnalysisSection{Body: fmt.Sprintf(T("未知的分析器: %s\n"), name)})
+			continue
+		}
+		s, err := p.Analyze(input)
+		if err != nil {
+			sections = append(sections, AnalysisSection{Title: name, Body: fmt.Sprintf(T("分析失败: %v\n"), err)})
+			continue
+		}
+		sections = append(sections, s...)
+	}
+
+	var analysis strings.Builder
+	for i, s := range sections {
+		if i > 0 {
+			analysis.WriteString("\n")
+		}
+		if s.Title != "" {
+			analysis.WriteString(s.Title + ":\n")
+		}
+		analysis.WriteString(s.Body)
+	}
+	return analysis.String()
+}
+
+// 技术分析：涨跌幅、成交量、RSI和成交量分布
+type taAnalysisProvider struct{}
+
+func (taAnalysisProvider) Name() string { return "ta" }
+
+func (taAnalysisProvider) Analyze(input *AnalysisInput) ([]AnalysisSection, error) {
+	klines := input.Klines
+	if len(klines) < 2 {
+		return []AnalysisSection{{Body: T("数据不足以进行分析\n")}}, nil
+	}
+
+	// 计算最近一根K线的涨跌幅
+	lastClose := klines[len(klines)-1].Close
+	prevClose := klines[len(klines)-2].Close
+	candleChange := (lastClose - prevClose) / prevClose * 100
+
+	// 计算24小时涨跌幅，K线不足24小时时按整个区间计算
+	changeLabel := T("24h涨跌幅")
+	interval := intervalDuration(input.Interval)
+	start := 0
+	if interval > 0 && len(klines)-1 > int(24*time.Hour/interval) {
+		start = len(klines) - 1 - int(24*time.Hour/interval)
+	} else {
+		changeLabel = fmt.Sprintf(T("区间涨跌幅(%s)"), formatSpan(time.Duration(len(klines)-1)*interval))
+	}
+	change := (lastClose - klines[start].Close) / klines[start].Close * 100
+
+	// 计算成交量变化
+	lastVol := klines[len(klines)-1].Volume
+	prevVol := klines[len(klines)-2].Volume
+	volChange := (lastVol - prevVol) / prevVol * 100
+
+	// 计算RSI
+	rsi := calculateRSI(klines, 14)
+
+	var summary strings.Builder
+	summary.WriteString(fmt.Sprintf(T("%s涨跌幅: %.2f%%\n"), input.Interval, candleChange))
+	summary.WriteString(fmt.Sprintf("%s: %.2f%%\n", changeLabel, change))
+	summary.WriteString(fmt.Sprintf(T("成交量变化: %.2f%%\n"), volChange))
+	summary.WriteString(fmt.Sprintf("RSI(14): %.2f\n", rsi))
+
+	// 添加简单分析结论
+	var conclusion strings.Builder
+	if change > 0 {
+		conclusion.WriteString(T("- 价格呈上涨趋势\n"))
+	} else {
+		conclusion.WriteString(T("- 价格呈下跌趋势\n"))
+	}
+
+	if volChange > 0 {
+		conclusion.WriteString(T("- 成交量放大，市场活跃度增加\n"))
+	} else {
+		conclusion.WriteString(T("- 成交量萎缩，市场活跃度下降\n"))
+	}
+
+	if rsi > 70 {
+		conclusion.WriteString(T("- RSI超买，可能存在回调风险\n"))
+	} else if rsi < 30 {
+		conclusion.WriteString(T("- RSI超卖，可能存在反弹机会\n"))
+	} else {
+		conclusion.WriteString(T("- RSI处于中性区间\n"))
+	}
+
+	sections := []AnalysisSection{
+		{Body: summary.String()},
+		{Title: T("市场分析"), Body: conclusion.String()},
+	}
+
+	// 添加成交量分布
+	if profile := calculateVolumeProfile(klines, 24); profile != nil {
+		sections = append(sections, AnalysisSection{Title: T("成交量分布"), Body: profile.Analysis(lastClose)})
+	}
+
+	return sections, nil
+}
+
+// 把时长格式化为 3天4小时 / 4小时10分钟 的形式
+func formatSpan(d time.Duration) string {
+	days := int(d / (24 * time.Hour))
+	hours := int(d % (24 * time.Hour) / time.Hour)
+	minutes := int(d % time.Hour / time.Minute)
+
+	switch {
+	case days > 0 && hours > 0:
+		return fmt.Sprintf(T("%d天%d小时"), days, hours)
+	case days > 0:
+		return fmt.Sprintf(T("%d天"), days)
+	case hours > 0 && minutes > 0:
+		return fmt.Sprintf(T("%d小时%d分钟"), hours, minutes)
+	case hours > 0:
+		return fmt.Sprintf(T("%d小时"), hours)
+	}
+	return fmt.Sprintf(T("%d分钟"), minutes)
+}
+
+// 统计分析：已实现波动率、ATR和日内统计
+type statsAnalysisProvider struct{}
+
+func (statsAnalysisProvider) Name() string { return "stats" }
+
+func (statsAnalysisProvider) Analyze(input *AnalysisInput) ([]AnalysisSection, error) {
+	var sections []AnalysisSection
+	if input.Volatility != nil {
+		sections = append(sections, AnalysisSection{Body: input.Volatility.Analysis()})
+	}
+	if input.Session != nil && len(input.Klines) > 0 {
+		price := input.Klines[len(input.Klines)-1].Close
+		sections = append(sections, AnalysisSection{Title: T("日内统计"), Body: input.Session.Analysis(price)})
+	}
+	return sections, nil
+}
+
+// 市场数据：多空比、期现基差和强平
+type marketAnalysisProvider struct{}
+
+func (marketAnalysisProvider) Name() string { return "market" }
+
+func (marketAnalysisProvider) Analyze(input *AnalysisInput) ([]AnalysisSection, error) {
+	var sections []AnalysisSection
+	if input.Sentiment != nil {
+		sections = append(sections, AnalysisSection{Body: input.Sentiment.Analysis()})
+	}
+	if input.Basis != nil {
+		sections = append(sections, AnalysisSection{Body: input.Basis.Analysis()})
+	}
+	if input.Liquidations != nil {
+		sections = append(sections, AnalysisSection{Body: input.Liquidations.Analysis()})
+	}
+	return sections, nil
+}
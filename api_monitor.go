@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 期货接口每分钟的请求权重上限
+const weightLimit = 2400
+
+// 记录API请求的延迟和交易所返回的已用请求权重，作为期货客户端的Transport
+type APIMonitor struct {
+	base http.RoundTripper
+
+	mu         sync.Mutex
+	latency    time.Duration
+	usedWeight int
+}
+
+func NewAPIMonitor() *APIMonitor {
+	return &APIMonitor{base: http.DefaultTransport}
+}
+
+func (m *APIMonitor) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := m.base.RoundTrip(req)
+	metrics.Inc(metricAPIRequests)
+	if err != nil {
+		metrics.Inc(metricAPIErrors)
+		return resp, err
+	}
+	if resp.StatusCode >= 400 {
+		metrics.Inc(metricAPIErrors)
+	}
+
+	m.mu.Lock()
+	m.latency = time.Since(start)
+	if weight, err := strconv.Atoi(resp.Header.Get("X-Mbx-Used-Weight-1m")); err == nil {
+		m.usedWeight = weight
+	}
+	m.mu.Unlock()
+	return resp, nil
+}
+
+// 最近一次请求的延迟和当前一分钟内已用的请求权重
+func (m *APIMonitor) Stats() (latency time.Duration, usedWeight int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.latency, m.usedWeight
+}
+
+// 已用权重超过max时等到下一分钟权重重置，返回等待的时间。批量下载时在每次请求后调用
+func (m *APIMonitor) WaitForWeight(max int) time.Duration {
+	if _, used := m.Stats(); used <= max {
+		return 0
+	}
+	wait := time.Until(time.Now().Truncate(time.Minute).Add(time.Minute))
+	time.Sleep(wait)
+	m.mu.Lock()
+	m.usedWeight = 0
+	m.mu.Unlock()
+	return wait
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// 配置文件名
+const configFileName = "config.json"
+
+// 命令行和界面共用的配置加载规则，优先级从高到低:
+//
+//  1. 命令行参数: --config PATH、--profile NAME，以及各子命令自己的参数
+//  2. 环境变量: PROTECT_CONFIG、BINANCE_API_KEY、BINANCE_SECRET_KEY、BINANCE_PROFILE、
+//     TRADER_LANG、LIQUIDATION_ALERT、SPIKE_ZSCORE、SPIKE_TIGHTEN、PROTECT_CONTROL、
+//     TELEGRAM_BOT_TOKEN、TELEGRAM_CHAT_ID
+//  3. 配置文件: 当前目录的config.json，不存在时使用$XDG_CONFIG_HOME/protect/config.json
+//     (未设置XDG_CONFIG_HOME时为~/.config/protect/config.json)
+//  4. 默认值
+//
+// 环境变量和命令行参数只覆盖本次运行，界面保存配置时不会写回文件
+var configFlags struct {
+	Path    string
+	Profile string
+}
+
+// 从命令行参数中取出--config和--profile，返回其余参数
+func parseConfigFlags(args []string) []string {
+	configFlags.Profile, args = extractFlag(args, "profile")
+	configFlags.Path, args = extractFlag(args, "config")
+	return args
+}
+
+// 使用的账户名，--profile优先于BINANCE_PROFILE和配置文件中的profile
+func activeProfile(fileProfile string) string {
+	if configFlags.Profile != "" {
+		return configFlags.Profile
+	}
+	return envString("BINANCE_PROFILE", fileProfile)
+}
+
+// 当前使用的配置文件路径。都不存在时返回XDG目录下的路径，设置向导在那里创建配置
+func configPath() string {
+	if configFlags.Path != "" {
+		return configFlags.Path
+	}
+	if path := os.Getenv("PROTECT_CONFIG"); path != "" {
+		return path
+	}
+	if _, err := os.Stat(configFileName); err == nil {
+		return configFileName
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return configFileName
+	}
+	return filepath.Join(dir, "protect", configFileName)
+}
+
+// 配置文件是否存在，首次运行时不存在
+func configExists() bool {
+	_, err := os.Stat(configPath())
+	return !errors.Is(err, os.ErrNotExist)
+}
+
+// 配置文件中命令行也使用的字段，界面读取完整的Config
+type appConfig struct {
+	profileConfig
+	Profile          string          `json:"profile,omitempty"`
+	Language         string          `json:"language"`
+	LiquidationAlert float64         `json:"liquidation_alert"`
+	Spike            SpikeConfig     `json:"spike"`
+	Protect          ProtectConfig   `json:"protect"`
+	Strategies       []ProtectConfig `json:"strategies,omitempty"` // 同时管理的其他交易对，没有填写的参数沿用protect
+	Control          string          `json:"control,omitempty"`    // 监控程序控制接口的监听地址，off表示不开启
+	Notify           NotifyConfig    `json:"notify"`               // 监控程序的推送通知
+	MQTT             *MQTTConfig     `json:"mqtt,omitempty"`       // 把价格、持仓和事件发布到MQTT broker
+	PIN              PINConfig       `json:"pin"`                  // 界面中设置的PIN，命令行修改杠杆和保证金模式时也需要输入
+
+	PausedRules map[string][]AutomationRule `json:"paused_rules,omitempty"` // 界面中按交易对暂停的自动化规则，监控程序启动时读取
+}
+
+// 读取配置文件并应用环境变量和命令行参数。配置文件不存在时只使用环境变量
+func loadAppConfig(path string) (*appConfig, error) {
+	var config appConfig
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf(T("读取配置文件失败: %v"), err)
+	default:
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf(T("解析配置文件失败: %v"), err)
+		}
+	}
+	config.profileConfig = config.profileConfig.withEnv()
+	config.Profile = activeProfile(config.Profile)
+	config.Language = envString("TRADER_LANG", config.Language)
+	config.LiquidationAlert = envFloat("LIQUIDATION_ALERT", config.LiquidationAlert)
+	config.Spike = config.Spike.withEnv()
+	config.Control = envString("PROTECT_CONTROL", config.Control)
+	config.Notify = config.Notify.withEnv()
+	return &config, nil
+}
+
+// 用BINANCE_API_KEY和BINANCE_SECRET_KEY覆盖默认账户的密钥
+func (c profileConfig) withEnv() profileConfig {
+	c.APIKey = envString("BINANCE_API_KEY", c.APIKey)
+	c.SecretKey = envString("BINANCE_SECRET_KEY", c.SecretKey)
+	return c
+}
+
+// 设置了SPIKE_ZSCORE时检测价格异动，SPIKE_TIGHTEN=1时异动后收紧保护止盈
+func (c SpikeConfig) withEnv() SpikeConfig {
+	c.ZScore = envFloat("SPIKE_ZSCORE", c.ZScore)
+	if v := os.Getenv("SPIKE_TIGHTEN"); v != "" {
+		c.TightenStops = v == "1"
+	}
+	return c
+}
+
+// 设置了TELEGRAM_BOT_TOKEN时覆盖Telegram机器人的令牌，TELEGRAM_CHAT_ID覆盖接收通知的聊天
+func (c NotifyConfig) withEnv() NotifyConfig {
+	token := os.Getenv("TELEGRAM_BOT_TOKEN")
+	chatID, _ := strconv.ParseInt(os.Getenv("TELEGRAM_CHAT_ID"), 10, 64)
+	if token == "" && chatID == 0 {
+		return c
+	}
+	telegram := TelegramConfig{}
+	if c.Telegram != nil {
+		telegram = *c.Telegram
+	}
+	if token != "" {
+		telegram.Token = token
+	}
+	if chatID != 0 {
+		telegram.ChatID = chatID
+	}
+	c.Telegram = &telegram
+	return c
+}
+
+// 环境变量，未设置时返回fallback
+func envString(name, fallback string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// 数字环境变量，未设置或无法解析时返回fallback
+func envFloat(name string, fallback float64) float64 {
+	v, err := strconv.ParseFloat(os.Getenv(name), 64)
+	if err != nil {
+		return fallback
+	}
+	return v
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"sort"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 暂停或恢复全部自动化，同步托盘菜单的勾选状态
+func (ui *TraderUI) setPaused(paused bool) {
+	ui.paused.Store(paused)
+	if ui.pipeline != nil {
+		ui.pipeline.SetPaused(paused)
+	}
+	if ui.trayMenu != nil {
+		ui.trayPause.Checked = paused
+		ui.trayMenu.Refresh()
+	}
+}
+
+// 自动化控制面板：全部暂停开关，以及每个交易对单独暂停自动止损、自动止盈和保护止盈
+func (ui *TraderUI) showAutomationPanel() {
+	w := ui.app.NewWindow(T("自动化控制"))
+	protect := ui.protectConfig()
+
+	// 设置了PIN时暂停和关闭自动止损需要输入PIN，取消时恢复勾选状态
+	var pausedCheck *widget.Check
+	pausedCheck = widget.NewCheck(T("暂停全部自动化"), func(paused bool) {
+		if paused == ui.paused.Load() {
+			return
+		}
+		if !paused {
+			ui.setPaused(false)
+			return
+		}
+		ui.requirePIN(T("暂停自动化"), func() {
+			ui.setPaused(true)
+		}, func() {
+			pausedCheck.SetChecked(false)
+		})
+	})
+	pausedCheck.SetChecked(ui.paused.Load())
+
+	// 自动管理的交易对排在最前，然后是命令行strategies中的交易对，其余为自选列表中的交易对。
+	// 修改保存到配置文件，命令行的监控程序启动时按交易对读取
+	symbols := []string{protect.Symbol}
+	cliManaged := make(map[string]bool)
+	for _, s := range ui.config.Strategies {
+		if s.Symbol != protect.Symbol && !cliManaged[s.Symbol] {
+			cliManaged[s.Symbol] = true
+			symbols = append(symbols, s.Symbol)
+		}
+	}
+	var others []string
+	for _, s := range ui.config.Watchlist {
+		if s != protect.Symbol && !cliManaged[s] {
+			others = append(others, s)
+		}
+	}
+	sort.Strings(others)
+	symbols = append(symbols, others...)
+
+	grid := container.NewGridWithColumns(len(automationRules) + 1)
+	grid.Add(widget.NewLabelWithStyle(T("交易对"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+	for _, r := range automationRules {
+		grid.Add(widget.NewLabelWithStyle(T(r.Label), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+	}
+	for _, symbol := range symbols {
+		label := symbol
+		if symbol == protect.Symbol {
+			label += " " + T("(自动管理)")
+		} else if cliManaged[symbol] {
+			label += " " + T("(命令行管理)")
+		}
+		grid.Add(widget.NewLabel(label))
+		for _, r := range automationRules {
+			symbol, rule := symbol, r.Rule
+			check := widget.NewCheck(T("启用"), nil)
+			check.SetChecked(ui.automation.Enabled(symbol, rule))
+			check.OnChanged = func(enabled bool) {
+				if enabled == ui.automation.Enabled(symbol, rule) {
+					return
+				}
+				apply := func() {
+					ui.automation.SetEnabled(symbol, rule, enabled)
+					ui.config.PausedRules = ui.automation.Paused()
+					if err := ui.saveConfig(); err != nil {
+						ui.notify(NoticeError, "%v", err)
+					}
+				}
+				if enabled || rule != RuleStopLoss {
+					apply()
+					return
+				}
+				ui.requirePIN(T("关闭自动止损"), apply, func() {
+					check.SetChecked(true)
+				})
+			}
+			grid.Add(check)
+		}
+	}
+
+	note := widget.NewLabel(T("自动止盈止损和保护止盈只管理标记为自动管理的交易对，可在参数设置中修改"))
+	note.Wrapping = fyne.TextWrapWord
+	w.SetContent(container.NewBorder(
+		pausedCheck, note, nil, nil,
+		container.NewVScroll(grid),
+	))
+	w.Resize(ui.scaled(520, 360))
+	w.Show()
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+// 可以单独暂停的自动化规则
+type AutomationRule string
+
+const (
+	RuleStopLoss   AutomationRule = "stop_loss"   // 自动设置止损
+	RuleTakeProfit AutomationRule = "take_profit" // 自动设置止盈
+	RuleProtect    AutomationRule = "protect"     // 保护止盈平仓
+)
+
+var automationRules = []struct {
+	Rule  AutomationRule
+	Label string
+}{
+	{RuleStopLoss, "自动止损"},
+	{RuleTakeProfit, "自动止盈"},
+	{RuleProtect, "保护止盈"},
+}
+
+// 按规则名查找，用于命令行和控制接口
+func parseAutomationRule(name string) (AutomationRule, error) {
+	for _, r := range automationRules {
+		if string(r.Rule) == name {
+			return r.Rule, nil
+		}
+	}
+	return "", fmt.Errorf(T("未知的自动化规则: %s，可选 stop_loss/take_profit/protect"), name)
+}
+
+// 按交易对记录暂停的规则，没有记录的规则默认启用。界面和命令行的每个监控实例
+// 按自己的交易对检查规则
+type AutomationRules struct {
+	mu     sync.Mutex
+	paused map[string]map[AutomationRule]bool
+}
+
+func NewAutomationRules(paused map[string][]AutomationRule) *AutomationRules {
+	r := &AutomationRules{paused: make(map[string]map[AutomationRule]bool)}
+	for symbol, rules := range paused {
+		for _, rule := range rules {
+			r.SetEnabled(symbol, rule, false)
+		}
+	}
+	return r
+}
+
+func (r *AutomationRules) Enabled(symbol string, rule AutomationRule) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return !r.paused[symbol][rule]
+}
+
+func (r *AutomationRules) SetEnabled(symbol string, rule AutomationRule, enabled bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if enabled {
+		delete(r.paused[symbol], rule)
+		if len(r.paused[symbol]) == 0 {
+			delete(r.paused, symbol)
+		}
+		return
+	}
+	if r.paused[symbol] == nil {
+		r.paused[symbol] = make(map[AutomationRule]bool)
+	}
+	r.paused[symbol][rule] = true
+}
+
+// 暂停的规则，用于保存到配置文件
+func (r *AutomationRules) Paused() map[string][]AutomationRule {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	paused := make(map[string][]AutomationRule, len(r.paused))
+	for symbol, rules := range r.paused {
+		for _, rule := range automationRules {
+			if rules[rule.Rule] {
+				paused[symbol] = append(paused[symbol], rule.Rule)
+			}
+		}
+	}
+	return paused
+}
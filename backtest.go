@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// 回测中的一笔交易
+type BacktestTrade struct {
+	Side       futures.SideType
+	EntryTime  time.Time
+	EntryPrice float64
+	ExitTime   time.Time
+	ExitPrice  float64
+	Quantity   float64
+	PnL        float64 // 扣除手续费后的盈亏
+	Reason     string  // 开仓原因
+	ExitReason string
+}
+
+func (t *BacktestTrade) String() string {
+	return fmt.Sprintf(T("%s %-4s %.4f -> %s %.4f 数量 %.2f 盈亏 %+.2f (%s / %s)"),
+		t.EntryTime.Format("01-02 15:04"), t.Side, t.EntryPrice,
+		t.ExitTime.Format("01-02 15:04"), t.ExitPrice, t.Quantity, t.PnL, t.Reason, t.ExitReason)
+}
+
+// 回测结果
+type BacktestResult struct {
+	Strategy    string
+	Symbol      string
+	Interval    string
+	Start       time.Time
+	End         time.Time
+	Trades      []BacktestTrade
+	Rejected    int       // 被风控拒绝的信号数量
+	Equity      []float64 // 每笔交易平仓后的累计盈亏
+	NetPnL      float64
+	MaxDrawdown float64
+}
+
+// 胜率(%)
+func (r *BacktestResult) WinRate() float64 {
+	if len(r.Trades) == 0 {
+		return 0
+	}
+	wins := 0
+	for _, t := range r.Trades {
+		if t.PnL > 0 {
+			wins++
+		}
+	}
+	return float64(wins) / float64(len(r.Trades)) * 100
+}
+
+// 盈亏比：总盈利 / 总亏损
+func (r *BacktestResult) ProfitFactor() float64 {
+	var profit, loss float64
+	for _, t := range r.Trades {
+		if t.PnL > 0 {
+			profit += t.PnL
+		} else {
+			loss -= t.PnL
+		}
+	}
+	if loss == 0 {
+		return math.Inf(1)
+	}
+	return profit / loss
+}
+
+func (r *BacktestResult) String() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s %s %s  %s ~ %s\n", r.Strategy, r.Symbol, r.Interval,
+		r.Start.Format("2006-01-02 15:04"), r.End.Format("2006-01-02 15:04")))
+	b.WriteString(fmt.Sprintf(T("交易次数: %d (风控拒绝 %d)\n"), len(r.Trades), r.Rejected))
+	b.WriteString(fmt.Sprintf(T("胜率: %.1f%%\n"), r.WinRate()))
+	b.WriteString(fmt.Sprintf(T("盈亏比: %.2f\n"), r.ProfitFactor()))
+	b.WriteString(fmt.Sprintf(T("净盈亏: %+.2f\n"), r.NetPnL))
+	b.WriteString(fmt.Sprintf(T("最大回撤: %.2f\n"), r.MaxDrawdown))
+	return b.String()
+}
+
+// 回测执行层，按信号价格模拟开仓
+type backtestExecutor struct {
+	position *BacktestTrade
+}
+
+func (e *backtestExecutor) Name() string { return "backtest" }
+
+func (e *backtestExecutor) Execute(signal *Signal) error {
+	e.position = &BacktestTrade{
+		Side:       signal.Side,
+		EntryTime:  signal.Time,
+		EntryPrice: signal.Price,
+		Quantity:   signal.Quantity,
+		Reason:     signal.Reason,
+	}
+	return nil
+}
+
+// 带保护止盈的策略，最高盈利(U)达到arm后，收盘时盈利回撤到最高盈利的keep比例以下按收盘价平仓
+type protectiveExit interface {
+	ProtectExit() (arm, keep float64)
+}
+
+// 用历史K线回测策略。信号和实盘一样经过风控层，止损止盈按K线最高最低价判断，
+// 同一根K线同时触及止损和止盈时按止损计算。fee为单边手续费率，如0.0005
+func runBacktest(strategy Strategy, klines []Kline, risk []RiskRule, fee float64) *BacktestResult {
+	result := &BacktestResult{
+		Strategy: strategy.Name(),
+		Symbol:   strategy.Symbol(),
+		Interval: strategy.Interval(),
+	}
+	if len(klines) == 0 {
+		return result
+	}
+	result.Start = klines[0].Time
+	result.End = klines[len(klines)-1].Time
+
+	executor := &backtestExecutor{}
+	pipeline := NewPipeline(nil, []Strategy{strategy}, risk, executor)
+	pipeline.Logf = func(string, ...interface{}) {}
+
+	closePosition := func(k Kline, price float64, reason string) {
+		t := executor.position
+		t.ExitTime = k.Time
+		t.ExitPrice = price
+		t.ExitReason = reason
+		t.PnL = (t.ExitPrice - t.EntryPrice) * t.Quantity
+		if t.Side == futures.SideTypeSell {
+			t.PnL = -t.PnL
+		}
+		t.PnL -= (t.EntryPrice + t.ExitPrice) * t.Quantity * fee
+		result.Trades = append(result.Trades, *t)
+		executor.position = nil
+	}
+
+	arm, keep := 0.0, 0.0
+	if p, ok := strategy.(protectiveExit); ok {
+		arm, keep = p.ProtectExit()
+	}
+
+	var stopLoss, takeProfit, maxProfit float64
+	for i := range klines {
+		k := klines[i]
+
+		// 先检查持仓的止损止盈
+		if p := executor.position; p != nil {
+			long := p.Side == futures.SideTypeBuy
+			switch {
+			case long && k.Low <= stopLoss, !long && k.High >= stopLoss:
+				closePosition(k, stopLoss, T("止损"))
+			case takeProfit > 0 && (long && k.High >= takeProfit || !long && k.Low <= takeProfit):
+				closePosition(k, takeProfit, T("止盈"))
+			}
+		}
+
+		// 再检查保护止盈，最高盈利按K线最高最低价计算
+		if p := executor.position; p != nil && arm > 0 {
+			best, profit := (k.High-p.EntryPrice)*p.Quantity, (k.Close-p.EntryPrice)*p.Quantity
+			if p.Side == futures.SideTypeSell {
+				best, profit = (p.EntryPrice-k.Low)*p.Quantity, -profit
+			}
+			maxProfit = math.Max(maxProfit, best)
+			if maxProfit >= arm && profit <= maxProfit*keep {
+				closePosition(k, k.Close, T("保护止盈"))
+			}
+		}
+
+		signal := strategy.Next(klines[:i+1])
+		if signal == nil {
+			continue
+		}
+
+		// 反向信号先平掉当前持仓，同向信号忽略
+		if p := executor.position; p != nil {
+			if p.Side == signal.Side {
+				continue
+			}
+			closePosition(k, k.Close, T("反向信号"))
+		}
+
+		if err := pipeline.Process(signal); err != nil {
+			result.Rejected++
+			continue
+		}
+		stopLoss, takeProfit, maxProfit = signal.StopLoss, signal.TakeProfit, 0
+	}
+
+	// 回测结束时按最后收盘价平仓
+	if executor.position != nil {
+		last := klines[len(klines)-1]
+		closePosition(last, last.Close, T("回测结束"))
+	}
+
+	var equity, peak float64
+	for _, t := range result.Trades {
+		equity += t.PnL
+		result.Equity = append(result.Equity, equity)
+		peak = math.Max(peak, equity)
+		result.MaxDrawdown = math.Max(result.MaxDrawdown, peak-equity)
+	}
+	result.NetPnL = equity
+	return result
+}
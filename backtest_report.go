@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"strings"
+)
+
+// 回测报告中资金曲线的尺寸
+const (
+	reportChartWidth  = 800
+	reportChartHeight = 240
+)
+
+var backtestReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"T":   T,
+	"num": func(format string, v float64) string { return fmt.Sprintf(format, v) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Result.Strategy}} {{.Result.Symbol}} {{.Result.Interval}}</title>
+<style>
+body { font-family: sans-serif; margin: 24px; color: #222; }
+table { border-collapse: collapse; margin-bottom: 24px; }
+th, td { padding: 4px 10px; border-bottom: 1px solid #ddd; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+.win { color: #1a7f37; }
+.loss { color: #cf222e; }
+svg { background: #fafafa; border: 1px solid #ddd; }
+</style>
+</head>
+<body>
+<h1>{{.Result.Strategy}} {{.Result.Symbol}} {{.Result.Interval}}</h1>
+<p>{{.Result.Start.Format "2006-01-02 15:04"}} ~ {{.Result.End.Format "2006-01-02 15:04"}}</p>
+
+<h2>{{T "统计"}}</h2>
+<table>
+<tr><td>{{T "交易次数"}}</td><td>{{len .Result.Trades}}</td></tr>
+<tr><td>{{T "风控拒绝"}}</td><td>{{.Result.Rejected}}</td></tr>
+<tr><td>{{T "胜率"}}</td><td>{{num "%.1f%%" .Result.WinRate}}</td></tr>
+<tr><td>{{T "盈亏比"}}</td><td>{{num "%.2f" .Result.ProfitFactor}}</td></tr>
+<tr><td>{{T "净盈亏"}}</td><td>{{num "%+.2f" .Result.NetPnL}}</td></tr>
+<tr><td>{{T "最大回撤"}}</td><td>{{num "%.2f" .Result.MaxDrawdown}}</td></tr>
+</table>
+
+<h2>{{T "资金曲线"}}</h2>
+<svg width="{{.Width}}" height="{{.Height}}" viewBox="0 0 {{.Width}} {{.Height}}">
+<line x1="0" y1="{{.ZeroY}}" x2="{{.Width}}" y2="{{.ZeroY}}" stroke="#bbb" stroke-dasharray="4"/>
+<polyline fill="none" stroke="#0969da" stroke-width="2" points="{{.Points}}"/>
+</svg>
+
+<h2>{{T "交易明细"}}</h2>
+<table>
+<tr><th>{{T "开仓时间"}}</th><th>{{T "方向"}}</th><th>{{T "开仓价"}}</th><th>{{T "平仓时间"}}</th><th>{{T "平仓价"}}</th><th>{{T "数量"}}</th><th>{{T "盈亏"}}</th><th>{{T "开仓原因"}}</th><th>{{T "平仓原因"}}</th></tr>
+{{range .Result.Trades}}<tr class="{{if gt .PnL 0.0}}win{{else}}loss{{end}}">
+<td>{{.EntryTime.Format "2006-01-02 15:04"}}</td><td>{{.Side}}</td><td>{{num "%.4f" .EntryPrice}}</td>
+<td>{{.ExitTime.Format "2006-01-02 15:04"}}</td><td>{{num "%.4f" .ExitPrice}}</td><td>{{num "%.4f" .Quantity}}</td>
+<td>{{num "%+.2f" .PnL}}</td><td>{{.Reason}}</td><td>{{.ExitReason}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// 写入HTML回测报告，包含统计、资金曲线和交易明细，资金曲线用内联SVG绘制，不依赖外部脚本
+func writeBacktestReport(w io.Writer, result *BacktestResult) error {
+	data := struct {
+		Result        *BacktestResult
+		Width, Height int
+		ZeroY         float64
+		Points        string
+	}{Result: result, Width: reportChartWidth, Height: reportChartHeight}
+
+	// 曲线从0开始，纵轴范围包含0
+	equity := append([]float64{0}, result.Equity...)
+	low, high := 0.0, 0.0
+	for _, v := range equity {
+		low, high = math.Min(low, v), math.Max(high, v)
+	}
+	if high == low {
+		high = low + 1
+	}
+	const pad = 10.0
+	y := func(v float64) float64 {
+		return pad + (high-v)/(high-low)*(reportChartHeight-2*pad)
+	}
+	points := make([]string, len(equity))
+	for i, v := range equity {
+		x := float64(reportChartWidth) / 2
+		if len(equity) > 1 {
+			x = float64(i) / float64(len(equity)-1) * reportChartWidth
+		}
+		points[i] = fmt.Sprintf("%.1f,%.1f", x, y(v))
+	}
+	data.ZeroY = y(0)
+	data.Points = strings.Join(points, " ")
+
+	if err := backtestReportTemplate.Execute(w, data); err != nil {
+		return fmt.Errorf(T("生成回测报告失败: %v"), err)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"math"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 简单柱状图，正值用上涨颜色、负值用下跌颜色
+type BarChart struct {
+	widget.BaseWidget
+
+	mu     sync.Mutex
+	title  string
+	labels []string
+	values []float64
+	format func(float64) string
+}
+
+func NewBarChart(format func(float64) string) *BarChart {
+	c := &BarChart{format: format}
+	c.ExtendBaseWidget(c)
+	return c
+}
+
+// 更新图表数据，需要在UI线程中调用
+func (c *BarChart) SetData(title string, labels []string, values []float64) {
+	c.mu.Lock()
+	c.title = title
+	c.labels = labels
+	c.values = values
+	c.mu.Unlock()
+	c.Refresh()
+}
+
+func (c *BarChart) CreateRenderer() fyne.WidgetRenderer {
+	return &barChartRenderer{chart: c}
+}
+
+type barChartRenderer struct {
+	chart   *BarChart
+	objects []fyne.CanvasObject
+}
+
+func (r *barChartRenderer) Layout(size fyne.Size) {
+	r.build(size)
+}
+
+func (r *barChartRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(300, 160)
+}
+
+func (r *barChartRenderer) Refresh() {
+	r.build(r.chart.Size())
+	canvas.Refresh(r.chart)
+}
+
+func (r *barChartRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+func (r *barChartRenderer) Destroy() {}
+
+func (r *barChartRenderer) build(size fyne.Size) {
+	c := r.chart
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fg := theme.Color(theme.ColorNameForeground)
+	grid := theme.Color(theme.ColorNameSeparator)
+	textSize := theme.CaptionTextSize()
+
+	title := canvas.NewText(c.title, fg)
+	title.TextStyle = fyne.TextStyle{Bold: true}
+	title.Move(fyne.NewPos(chartPadLeft, 2))
+	objects := []fyne.CanvasObject{title}
+
+	if len(c.values) == 0 || size.Width <= chartPadLeft+chartPadRight || size.Height <= chartPadTop+chartPadBottom {
+		empty := canvas.NewText(T("暂无数据"), fg)
+		empty.Move(fyne.NewPos(size.Width/2-20, size.Height/2))
+		r.objects = append(objects, empty)
+		return
+	}
+
+	// 纵轴包含0，柱子从0画起
+	low, high := 0.0, 0.0
+	for _, v := range c.values {
+		low = math.Min(low, v)
+		high = math.Max(high, v)
+	}
+	s := chartScale{
+		left:   chartPadLeft,
+		top:    chartPadTop,
+		width:  size.Width - chartPadLeft - chartPadRight,
+		height: size.Height - chartPadTop - chartPadBottom,
+		count:  len(c.values),
+		min:    low,
+		max:    high,
+	}
+
+	for _, v := range []float64{high, 0, low} {
+		y := s.Y(v)
+		line := canvas.NewLine(grid)
+		line.Position1 = fyne.NewPos(s.left, y)
+		line.Position2 = fyne.NewPos(s.left+s.width, y)
+		label := canvas.NewText(c.format(v), fg)
+		label.TextSize = textSize
+		label.Move(fyne.NewPos(s.left+s.width+4, y-textSize/2-2))
+		objects = append(objects, line, label)
+	}
+
+	up := theme.Color(colorNameCandleUp)
+	down := theme.Color(colorNameCandleDown)
+	for i, v := range c.values {
+		fill := up
+		if v < 0 {
+			fill = down
+		}
+		objects = append(objects, paneBar(s, i, v, fill))
+	}
+
+	// 底部最多显示6个标签
+	step := (len(c.labels) + 5) / 6
+	for i := 0; i < len(c.labels); i += step {
+		label := canvas.NewText(c.labels[i], fg)
+		label.TextSize = textSize
+		x := s.X(i) - float32(len(label.Text))*textSize/4
+		label.Move(fyne.NewPos(x, s.top+s.height+2))
+		objects = append(objects, label)
+	}
+
+	r.objects = objects
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/adshao/go-binance/v2"
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// 期现基差
+type Basis struct {
+	Symbol     string
+	Spot       float64 // 现货价格
+	Futures    float64 // 合约标记价格
+	Basis      float64 // 合约 - 现货
+	BasisPct   float64 // 基差占现货价格的百分比
+	Annualized float64 // 年化基差(%)
+	Time       time.Time
+}
+
+// 获取现货和永续合约价格并计算基差。永续合约的溢价每个资金费周期(8小时)回归一次，
+// 年化基差按每天3个周期计算
+func fetchBasis(spot *binance.Client, client *futures.Client, symbol string) (*Basis, error) {
+	prices, err := spot.NewListPricesService().Symbol(symbol).Do(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf(T("获取现货价格失败: %v"), err)
+	}
+	if len(prices) == 0 {
+		return nil, fmt.Errorf(T("未找到%s的现货价格"), symbol)
+	}
+
+	premiums, err := client.NewPremiumIndexService().Symbol(symbol).Do(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf(T("获取合约价格失败: %v"), err)
+	}
+	if len(premiums) == 0 {
+		return nil, fmt.Errorf(T("未找到%s的合约价格"), symbol)
+	}
+
+	b := &Basis{Symbol: symbol, Time: time.Now()}
+	b.Spot, _ = strconv.ParseFloat(prices[0].Price, 64)
+	b.Futures, _ = strconv.ParseFloat(premiums[0].MarkPrice, 64)
+	if b.Spot == 0 {
+		return nil, fmt.Errorf(T("%s的现货价格无效"), symbol)
+	}
+
+	b.Basis = b.Futures - b.Spot
+	b.BasisPct = b.Basis / b.Spot * 100
+	b.Annualized = b.BasisPct * 3 * 365
+
+	return b, nil
+}
+
+func (b *Basis) String() string {
+	return fmt.Sprintf(T("现货: %.4f  合约: %.4f  基差: %+.4f (%+.4f%%)  年化: %+.2f%%"),
+		b.Spot, b.Futures, b.Basis, b.BasisPct, b.Annualized)
+}
+
+// 生成分析面板中的基差部分
+func (b *Basis) Analysis() string {
+	text := fmt.Sprintf(T("期现基差: %+.4f (%+.4f%%)\n年化基差: %+.2f%%\n"), b.Basis, b.BasisPct, b.Annualized)
+	if b.Basis > 0 {
+		text += T("- 合约升水，多头愿意支付溢价\n")
+	} else {
+		text += T("- 合约贴水，空头愿意支付溢价\n")
+	}
+	return text
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/dialog"
+)
+
+// 平仓截图的默认保存目录
+const defaultSnapshotDir = "snapshots"
+
+// 持仓刷新后才能发现平仓，截图时间会晚于平仓成交时间。
+// 相差不超过这个范围的截图关联到该笔交易
+const snapshotMatchWindow = 2 * time.Minute
+
+// 截取主窗口中的K线图，包括价格线等叠加内容，需要在UI线程中调用
+func (ui *TraderUI) captureChart() (image.Image, error) {
+	c := ui.window.Canvas()
+	img := c.Capture()
+	if img == nil || c.Size().Width <= 0 {
+		return nil, errors.New(T("截图失败"))
+	}
+
+	// 截图按像素计算，画布坐标需要乘以缩放比例
+	scale := float32(img.Bounds().Dx()) / c.Size().Width
+	pos := ui.app.Driver().AbsolutePositionForObject(ui.klineChart)
+	size := ui.klineChart.Size()
+	rect := image.Rect(
+		int(pos.X*scale), int(pos.Y*scale),
+		int((pos.X+size.Width)*scale), int((pos.Y+size.Height)*scale),
+	).Intersect(img.Bounds())
+	sub, ok := img.(interface {
+		SubImage(image.Rectangle) image.Image
+	})
+	if !ok || rect.Empty() {
+		return img, nil
+	}
+	return sub.SubImage(rect), nil
+}
+
+// 把K线图导出为PNG，文件名默认为交易对、周期和时间
+func (ui *TraderUI) exportChartSnapshot() {
+	img, err := ui.captureChart()
+	if err != nil {
+		dialog.ShowError(err, ui.window)
+		return
+	}
+
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, ui.window)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+		if err := png.Encode(writer, img); err != nil {
+			dialog.ShowError(fmt.Errorf(T("保存截图失败: %v"), err), ui.window)
+			return
+		}
+		ui.notify(NoticeInfo, T("已导出K线图: %s"), writer.URI().Name())
+	}, ui.window)
+	save.SetFileName(fmt.Sprintf("%s_%s_%s.png", ui.symbol, ui.interval, time.Now().Format("20060102_150405")))
+	save.Show()
+}
+
+// 保存平仓时的K线图截图并记录到交易数据库，需要在UI线程中、清除价格线之前调用
+func (ui *TraderUI) saveCloseSnapshot(symbol string) {
+	img, err := ui.captureChart()
+	if err != nil {
+		ui.notify(NoticeWarning, "%v", err)
+		return
+	}
+	dir := ui.config.Chart.SnapshotDir
+	if dir == "" {
+		dir = profilePath(ui.profile, defaultSnapshotDir)
+	}
+	now := time.Now()
+	path := filepath.Join(dir, fmt.Sprintf("%s_%s.png", symbol, now.Format("20060102_150405")))
+
+	go func() {
+		if err := writePNG(path, img); err != nil {
+			ui.notify(NoticeError, "%v", err)
+			return
+		}
+		if err := ui.tradeDB.AddSnapshot(ChartSnapshot{Symbol: symbol, Time: now, Path: path}); err != nil {
+			ui.notify(NoticeError, "%v", err)
+			return
+		}
+		ui.notify(NoticeInfo, T("已保存平仓截图: %s"), path)
+	}()
+}
+
+func writePNG(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf(T("保存截图失败: %v"), err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf(T("保存截图失败: %v"), err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		return fmt.Errorf(T("保存截图失败: %v"), err)
+	}
+	return nil
+}
+
+// 显示交易关联的平仓截图
+func (ui *TraderUI) showTradeSnapshot(trade ClosedTrade) {
+	if trade.Snapshot == "" {
+		return
+	}
+	if _, err := os.Stat(trade.Snapshot); err != nil {
+		dialog.ShowError(fmt.Errorf(T("截图文件不存在: %s"), trade.Snapshot), ui.window)
+		return
+	}
+	w := ui.app.NewWindow(fmt.Sprintf(T("%s 平仓截图 %s"), trade.Symbol, trade.ExitTime.Format("2006-01-02 15:04")))
+	img := canvas.NewImageFromFile(trade.Snapshot)
+	img.FillMode = canvas.ImageFillContain
+	w.SetContent(img)
+	w.Resize(ui.scaled(900, 600))
+	w.Show()
+}
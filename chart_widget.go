@@ -0,0 +1,822 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 绘图区域四周留给标题和坐标轴的空间
+const (
+	chartPadTop    = 24
+	chartPadBottom = 20
+	chartPadLeft   = 4
+	chartPadRight  = 70
+
+	// 每个副图占绘图区域的比例，以及副图之间的间隔
+	subPaneRatio = 0.18
+	chartPaneGap = 6
+)
+
+// 拖动价格线时，鼠标与线的距离在这个范围内才算选中
+const levelDragSlop = 6
+
+// 图表上带标签的水平价格线
+type ChartLevel struct {
+	Label     string
+	Price     float64
+	Color     color.Color
+	Draggable bool // 是否可以拖动到新价格
+}
+
+// 持仓相关的价格线，价格为0的不显示
+func positionLevels(entry, takeProfit, stopLoss, liquidation float64) []ChartLevel {
+	var levels []ChartLevel
+	add := func(label string, price float64, c color.Color, draggable bool) {
+		if price > 0 {
+			levels = append(levels, ChartLevel{Label: label, Price: price, Color: c, Draggable: draggable})
+		}
+	}
+	add(T("入场"), entry, color.NRGBA{R: 52, G: 152, B: 219, A: 255}, false)
+	add(T("止盈"), takeProfit, color.NRGBA{R: 39, G: 174, B: 96, A: 255}, true)
+	add(T("止损"), stopLoss, color.NRGBA{R: 231, G: 76, B: 60, A: 255}, true)
+	add(T("强平"), liquidation, color.NRGBA{R: 142, G: 68, B: 173, A: 255}, false)
+	return levels
+}
+
+// K线图控件，直接用画布对象绘制K线，不再生成临时图片
+type CandleChart struct {
+	widget.BaseWidget
+
+	mu       sync.Mutex
+	title    string
+	interval string
+	klines   []Kline // 原始K线，副图指标按原始K线计算
+	display  []Kline // 绘制的K线，Heikin-Ashi模式下为转换后的K线
+	mode     string
+	showRSI  bool
+	showMACD bool
+	profile  *VolumeProfile
+	levels   []ChartLevel
+	scale    chartScale // 最近一次绘制使用的坐标换算
+	bottom   float32    // 最下方副图的底边
+	minSize  fyne.Size
+
+	hovering bool
+	hover    fyne.Position
+	renderer *candleChartRenderer
+
+	// 正在拖动的价格线，dragLevel为-1表示没有拖动
+	dragLevel int
+	dragPrice float64
+
+	// 右键点击绘图区域时回调，price为点击位置对应的价格
+	OnSecondaryTapped func(price float64, pos fyne.Position)
+	// 拖动价格线时显示在线旁的提示文字，为nil时只显示价格
+	LevelDragInfo func(level ChartLevel, price float64) string
+	// 拖动价格线松开后回调，price为松开位置对应的价格
+	OnLevelDragged func(level ChartLevel, price float64)
+}
+
+func NewCandleChart() *CandleChart {
+	c := &CandleChart{mode: chartModeCandle, minSize: fyne.NewSize(300, 180), dragLevel: -1}
+	c.ExtendBaseWidget(c)
+	return c
+}
+
+// 更新图表数据，需要在UI线程中调用
+func (c *CandleChart) SetData(title, interval string, klines []Kline, mode string, profile *VolumeProfile) {
+	c.mu.Lock()
+	c.title = title
+	c.interval = interval
+	c.klines = klines
+	c.display = klines
+	if mode == chartModeHeikinAshi {
+		c.display = heikinAshi(klines)
+	}
+	c.mode = mode
+	c.profile = profile
+	c.mu.Unlock()
+	c.Refresh()
+}
+
+// 设置是否显示RSI和MACD副图，需要在UI线程中调用
+func (c *CandleChart) SetIndicators(rsi, macd bool) {
+	c.mu.Lock()
+	c.showRSI = rsi
+	c.showMACD = macd
+	c.mu.Unlock()
+	c.Refresh()
+}
+
+// 设置水平价格线，需要在UI线程中调用
+func (c *CandleChart) SetLevels(levels []ChartLevel) {
+	c.mu.Lock()
+	c.levels = levels
+	// 刷新后价格线可能变化，取消正在进行的拖动
+	c.dragLevel = -1
+	c.mu.Unlock()
+	c.Refresh()
+}
+
+func (c *CandleChart) SetMinSize(size fyne.Size) {
+	c.minSize = size
+	c.Refresh()
+}
+
+func (c *CandleChart) CreateRenderer() fyne.WidgetRenderer {
+	c.renderer = &candleChartRenderer{chart: c}
+	return c.renderer
+}
+
+func (c *CandleChart) TappedSecondary(e *fyne.PointEvent) {
+	c.mu.Lock()
+	s := c.scale
+	c.mu.Unlock()
+	if c.OnSecondaryTapped == nil || !s.Contains(e.Position) {
+		return
+	}
+	c.OnSecondaryTapped(s.Price(e.Position.Y), e.Position)
+}
+
+func (c *CandleChart) MouseIn(e *desktop.MouseEvent) {
+	c.MouseMoved(e)
+}
+
+// 鼠标移动时只重绘十字线
+func (c *CandleChart) MouseMoved(e *desktop.MouseEvent) {
+	c.mu.Lock()
+	c.hovering = true
+	c.hover = e.Position
+	c.mu.Unlock()
+	c.refreshCrosshair()
+}
+
+func (c *CandleChart) MouseOut() {
+	c.mu.Lock()
+	c.hovering = false
+	c.mu.Unlock()
+	c.refreshCrosshair()
+}
+
+func (c *CandleChart) refreshCrosshair() {
+	if c.renderer == nil {
+		return
+	}
+	c.renderer.buildCrosshair()
+	c.renderer.buildDrag()
+	canvas.Refresh(c)
+}
+
+// 鼠标在可拖动的价格线附近时显示上下调整的光标
+func (c *CandleChart) Cursor() desktop.Cursor {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.dragLevel >= 0 || (c.hovering && c.levelAtLocked(c.hover.Y) >= 0) {
+		return desktop.VResizeCursor
+	}
+	return desktop.DefaultCursor
+}
+
+// Y坐标附近可拖动的价格线序号，没有时返回-1
+func (c *CandleChart) levelAtLocked(y float32) int {
+	s := c.scale
+	best, bestDist := -1, float32(levelDragSlop)
+	for i, level := range c.levels {
+		if !level.Draggable || level.Price > s.max || level.Price < s.min {
+			continue
+		}
+		dist := float32(math.Abs(float64(s.Y(level.Price) - y)))
+		if dist <= bestDist {
+			best, bestDist = i, dist
+		}
+	}
+	return best
+}
+
+// 按下位置在可拖动的价格线附近时开始拖动，新价格限制在价格区域内
+func (c *CandleChart) Dragged(e *fyne.DragEvent) {
+	c.mu.Lock()
+	s := c.scale
+	if c.dragLevel < 0 {
+		c.dragLevel = c.levelAtLocked(e.Position.Y - e.Dragged.DY)
+		if c.dragLevel < 0 {
+			c.mu.Unlock()
+			return
+		}
+	}
+	y := float32(math.Max(float64(s.top), math.Min(float64(s.top+s.height), float64(e.Position.Y))))
+	c.dragPrice = s.Price(y)
+	c.mu.Unlock()
+	c.refreshCrosshair()
+}
+
+func (c *CandleChart) DragEnd() {
+	c.mu.Lock()
+	if c.dragLevel < 0 {
+		c.mu.Unlock()
+		return
+	}
+	level := c.levels[c.dragLevel]
+	price := c.dragPrice
+	c.dragLevel = -1
+	c.mu.Unlock()
+	c.refreshCrosshair()
+
+	if c.OnLevelDragged != nil && price != level.Price {
+		c.OnLevelDragged(level, price)
+	}
+}
+
+// 价格、K线序号和控件坐标之间的换算
+type chartScale struct {
+	left, top, width, height float32
+	count                    int
+	min, max                 float64
+}
+
+// 与s共用X轴的副图坐标换算
+func (s chartScale) Pane(top, height float32, min, max float64) chartScale {
+	s.top, s.height, s.min, s.max = top, height, min, max
+	return s
+}
+
+// 每根K线占用的宽度
+func (s chartScale) Slot() float32 {
+	if s.count == 0 {
+		return 0
+	}
+	return s.width / float32(s.count)
+}
+
+// 第i根K线中心的X坐标
+func (s chartScale) X(i int) float32 {
+	return s.left + s.Slot()*(float32(i)+0.5)
+}
+
+func (s chartScale) Y(price float64) float32 {
+	if s.max <= s.min {
+		return s.top + s.height/2
+	}
+	return s.top + float32((s.max-price)/(s.max-s.min))*s.height
+}
+
+// Y坐标对应的价格
+func (s chartScale) Price(y float32) float64 {
+	return s.max - float64((y-s.top)/s.height)*(s.max-s.min)
+}
+
+// X坐标对应的K线序号，不在范围内时返回-1
+func (s chartScale) Index(x float32) int {
+	if s.count == 0 || x < s.left || x >= s.left+s.width {
+		return -1
+	}
+	return int((x - s.left) / s.Slot())
+}
+
+func (s chartScale) Contains(pos fyne.Position) bool {
+	return pos.X >= s.left && pos.X <= s.left+s.width && pos.Y >= s.top && pos.Y <= s.top+s.height
+}
+
+type candleChartRenderer struct {
+	chart     *CandleChart
+	objects   []fyne.CanvasObject
+	crosshair []fyne.CanvasObject
+	drag      []fyne.CanvasObject
+}
+
+func (r *candleChartRenderer) Layout(size fyne.Size) {
+	r.build(size)
+	r.buildCrosshair()
+	r.buildDrag()
+}
+
+func (r *candleChartRenderer) MinSize() fyne.Size {
+	return r.chart.minSize
+}
+
+func (r *candleChartRenderer) Refresh() {
+	r.build(r.chart.Size())
+	r.buildCrosshair()
+	r.buildDrag()
+	canvas.Refresh(r.chart)
+}
+
+func (r *candleChartRenderer) Objects() []fyne.CanvasObject {
+	objects := make([]fyne.CanvasObject, 0, len(r.objects)+len(r.crosshair)+len(r.drag))
+	objects = append(objects, r.objects...)
+	objects = append(objects, r.crosshair...)
+	return append(objects, r.drag...)
+}
+
+func (r *candleChartRenderer) Destroy() {}
+
+// 重新生成所有画布对象
+func (r *candleChartRenderer) build(size fyne.Size) {
+	c := r.chart
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fg := theme.Color(theme.ColorNameForeground)
+	grid := theme.Color(colorNameChartGrid)
+	textSize := theme.CaptionTextSize()
+
+	background := canvas.NewRectangle(theme.Color(colorNameChartBackground))
+	background.Resize(size)
+	objects := []fyne.CanvasObject{background}
+
+	title := canvas.NewText(c.title, fg)
+	title.TextStyle = fyne.TextStyle{Bold: true}
+	title.Move(fyne.NewPos(chartPadLeft, 2))
+	objects = append(objects, title)
+
+	if len(c.display) == 0 || size.Width <= chartPadLeft+chartPadRight || size.Height <= chartPadTop+chartPadBottom {
+		empty := canvas.NewText(T("暂无数据"), fg)
+		empty.Move(fyne.NewPos(size.Width/2-20, size.Height/2))
+		r.objects = append(objects, empty)
+		c.scale = chartScale{}
+		return
+	}
+
+	klines := c.display
+
+	// 计算价格范围，上下各留1%
+	minPrice, maxPrice := klines[0].Low, klines[0].High
+	for _, k := range klines {
+		if k.Low < minPrice {
+			minPrice = k.Low
+		}
+		if k.High > maxPrice {
+			maxPrice = k.High
+		}
+	}
+	padding := (maxPrice - minPrice) * 0.01
+
+	// 价格区域下方依次是成交量、RSI和MACD副图，共用X轴
+	panes := 1
+	if c.showRSI {
+		panes++
+	}
+	if c.showMACD {
+		panes++
+	}
+	plotHeight := size.Height - chartPadTop - chartPadBottom
+	paneHeight := plotHeight * subPaneRatio
+	s := chartScale{
+		left:   chartPadLeft,
+		top:    chartPadTop,
+		width:  size.Width - chartPadLeft - chartPadRight,
+		height: plotHeight - float32(panes)*(paneHeight+chartPaneGap),
+		count:  len(klines),
+		min:    minPrice - padding,
+		max:    maxPrice + padding,
+	}
+	c.scale = s
+	c.bottom = chartPadTop + plotHeight
+
+	// 价格网格和右侧价格刻度
+	for i := 0; i <= 4; i++ {
+		price := s.min + (s.max-s.min)*float64(i)/4
+		y := s.Y(price)
+		line := canvas.NewLine(grid)
+		line.Position1 = fyne.NewPos(s.left, y)
+		line.Position2 = fyne.NewPos(s.left+s.width, y)
+		objects = append(objects, line)
+
+		label := canvas.NewText(formatChartPrice(price), fg)
+		label.TextSize = textSize
+		label.Move(fyne.NewPos(s.left+s.width+4, y-textSize/2-2))
+		objects = append(objects, label)
+	}
+
+	// 底部时间刻度，4小时及以上周期显示日期
+	timeFormat := "15:04"
+	if intervalDuration(c.interval) >= 4*time.Hour {
+		timeFormat = "01-02"
+	}
+	for i := 0; i < 5; i++ {
+		idx := i * (len(klines) - 1) / 4
+		label := canvas.NewText(klines[idx].Time.Format(timeFormat), fg)
+		label.TextSize = textSize
+		x := s.X(idx) - float32(len(label.Text))*textSize/4
+		label.Move(fyne.NewPos(x, c.bottom+2))
+		objects = append(objects, label)
+	}
+
+	// 成交量分布画在K线下层
+	objects = append(objects, volumeProfileObjects(c.profile, s, 0.25)...)
+
+	switch c.mode {
+	case chartModeLine:
+		objects = append(objects, lineObjects(klines, s, false)...)
+	case chartModeArea:
+		objects = append(objects, lineObjects(klines, s, true)...)
+	default:
+		objects = append(objects, candleObjects(klines, s)...)
+	}
+
+	objects = append(objects, levelObjects(c.levels, s)...)
+
+	// 副图
+	top := s.top + s.height + chartPaneGap
+	objects = append(objects, volumeObjects(klines, s.Pane(top, paneHeight, 0, 0), fg, grid)...)
+	if c.showRSI {
+		top += paneHeight + chartPaneGap
+		objects = append(objects, rsiObjects(c.klines, s.Pane(top, paneHeight, 0, 100), fg, grid)...)
+	}
+	if c.showMACD {
+		top += paneHeight + chartPaneGap
+		objects = append(objects, macdObjects(c.klines, s.Pane(top, paneHeight, 0, 0), fg, grid)...)
+	}
+
+	r.objects = objects
+}
+
+// 十字线：竖线对齐到K线中心，横线跟随鼠标，右侧刻度显示鼠标处价格，
+// 左上角显示该K线的时间和OHLCV
+func (r *candleChartRenderer) buildCrosshair() {
+	c := r.chart
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := c.scale
+	if !c.hovering || c.hover.Y < s.top || c.hover.Y > c.bottom {
+		r.crosshair = nil
+		return
+	}
+	idx := s.Index(c.hover.X)
+	if idx < 0 || idx >= len(c.display) {
+		r.crosshair = nil
+		return
+	}
+	k := c.display[idx]
+
+	fg := theme.Color(theme.ColorNameForeground)
+	bg := theme.Color(theme.ColorNameBackground)
+	lineColor := theme.Color(theme.ColorNamePlaceHolder)
+	textSize := theme.CaptionTextSize()
+	x := s.X(idx)
+	y := c.hover.Y
+
+	vertical := canvas.NewLine(lineColor)
+	vertical.Position1 = fyne.NewPos(x, s.top)
+	vertical.Position2 = fyne.NewPos(x, c.bottom)
+	objects := []fyne.CanvasObject{vertical}
+
+	// 鼠标在价格区域内时显示横线和价格刻度上的当前价格
+	if s.Contains(c.hover) {
+		horizontal := canvas.NewLine(lineColor)
+		horizontal.Position1 = fyne.NewPos(s.left, y)
+		horizontal.Position2 = fyne.NewPos(s.left+s.width, y)
+
+		priceText := canvas.NewText(formatChartPrice(s.Price(y)), bg)
+		priceText.TextSize = textSize
+		priceSize := priceText.MinSize()
+		priceBox := canvas.NewRectangle(fg)
+		priceBox.Move(fyne.NewPos(s.left+s.width+2, y-priceSize.Height/2))
+		priceBox.Resize(fyne.NewSize(priceSize.Width+4, priceSize.Height))
+		priceText.Move(fyne.NewPos(s.left+s.width+4, y-priceSize.Height/2))
+		objects = append(objects, horizontal, priceBox, priceText)
+	}
+
+	info := canvas.NewText(fmt.Sprintf(T("%s  开 %s  高 %s  低 %s  收 %s  量 %.0f"),
+		k.Time.Format("2006-01-02 15:04"), formatChartPrice(k.Open), formatChartPrice(k.High),
+		formatChartPrice(k.Low), formatChartPrice(k.Close), k.Volume), fg)
+	info.TextSize = textSize
+	infoSize := info.MinSize()
+	infoBox := canvas.NewRectangle(bg)
+	infoBox.StrokeColor = lineColor
+	infoBox.StrokeWidth = 1
+	infoBox.Move(fyne.NewPos(s.left+2, s.top+2))
+	infoBox.Resize(fyne.NewSize(infoSize.Width+8, infoSize.Height+4))
+	info.Move(fyne.NewPos(s.left+6, s.top+4))
+
+	r.crosshair = append(objects, infoBox, info)
+}
+
+// 拖动中的价格线：在新价格处画虚线，左侧显示新价格和LevelDragInfo提供的提示
+func (r *candleChartRenderer) buildDrag() {
+	c := r.chart
+	c.mu.Lock()
+	if c.dragLevel < 0 || c.dragLevel >= len(c.levels) {
+		c.mu.Unlock()
+		r.drag = nil
+		return
+	}
+	level := c.levels[c.dragLevel]
+	price := c.dragPrice
+	s := c.scale
+	info := c.LevelDragInfo
+	c.mu.Unlock()
+
+	text := fmt.Sprintf("%s → %s", level.Label, formatChartPrice(price))
+	if info != nil {
+		if extra := info(level, price); extra != "" {
+			text += "  " + extra
+		}
+	}
+
+	y := s.Y(price)
+	var objects []fyne.CanvasObject
+	for x := s.left; x < s.left+s.width; x += 8 {
+		dash := canvas.NewLine(level.Color)
+		dash.StrokeWidth = 2
+		dash.Position1 = fyne.NewPos(x, y)
+		dash.Position2 = fyne.NewPos(float32(math.Min(float64(x+4), float64(s.left+s.width))), y)
+		objects = append(objects, dash)
+	}
+
+	t := canvas.NewText(text, theme.Color(theme.ColorNameBackground))
+	t.TextSize = theme.CaptionTextSize()
+	size := t.MinSize()
+	// 提示放在线的上方，靠近顶部时放到下方
+	top := y - size.Height - 4
+	if top < s.top {
+		top = y + 4
+	}
+	box := canvas.NewRectangle(level.Color)
+	box.Move(fyne.NewPos(s.left+2, top))
+	box.Resize(fyne.NewSize(size.Width+8, size.Height))
+	t.Move(fyne.NewPos(s.left+6, top))
+	r.drag = append(objects, box, t)
+}
+
+// 水平价格线，标签画在右侧价格刻度上。超出价格范围的只在上下边缘显示标签
+func levelObjects(levels []ChartLevel, s chartScale) []fyne.CanvasObject {
+	textSize := theme.CaptionTextSize()
+	label := theme.Color(theme.ColorNameBackground)
+
+	var objects []fyne.CanvasObject
+	for _, level := range levels {
+		text := fmt.Sprintf("%s %s", level.Label, formatChartPrice(level.Price))
+		y := s.Y(level.Price)
+		switch {
+		case level.Price > s.max:
+			y = s.top
+			text += " ↑"
+		case level.Price < s.min:
+			y = s.top + s.height
+			text += " ↓"
+		default:
+			line := canvas.NewLine(level.Color)
+			line.StrokeWidth = 1
+			line.Position1 = fyne.NewPos(s.left, y)
+			line.Position2 = fyne.NewPos(s.left+s.width, y)
+			objects = append(objects, line)
+		}
+
+		t := canvas.NewText(text, label)
+		t.TextSize = textSize
+		size := t.MinSize()
+		x := s.left + s.width - size.Width - 4
+		box := canvas.NewRectangle(level.Color)
+		box.Move(fyne.NewPos(x-2, y-size.Height/2))
+		box.Resize(fyne.NewSize(size.Width+4, size.Height))
+		t.Move(fyne.NewPos(x, y-size.Height/2))
+		objects = append(objects, box, t)
+	}
+	return objects
+}
+
+// 副图的上边线和右侧标签
+func paneHeader(s chartScale, text string, fg, grid color.Color) []fyne.CanvasObject {
+	separator := canvas.NewLine(grid)
+	separator.Position1 = fyne.NewPos(s.left, s.top)
+	separator.Position2 = fyne.NewPos(s.left+s.width, s.top)
+	label := canvas.NewText(text, fg)
+	label.TextSize = theme.CaptionTextSize()
+	label.Move(fyne.NewPos(s.left+s.width+4, s.top))
+	return []fyne.CanvasObject{separator, label}
+}
+
+// 副图中的柱子，从value=0的位置画到value
+func paneBar(s chartScale, i int, value float64, fill color.Color) fyne.CanvasObject {
+	width := s.Slot() * 0.8
+	y0, y1 := s.Y(0), s.Y(value)
+	if y0 > y1 {
+		y0, y1 = y1, y0
+	}
+	bar := canvas.NewRectangle(fill)
+	bar.Move(fyne.NewPos(s.X(i)-width/2, y0))
+	bar.Resize(fyne.NewSize(width, y1-y0))
+	return bar
+}
+
+// 副图中的折线，从start开始绘制
+func seriesObjects(values []float64, start int, s chartScale, lineColor color.Color) []fyne.CanvasObject {
+	var objects []fyne.CanvasObject
+	for i := start + 1; i < len(values); i++ {
+		line := canvas.NewLine(lineColor)
+		line.StrokeWidth = 1
+		line.Position1 = fyne.NewPos(s.X(i-1), s.Y(values[i-1]))
+		line.Position2 = fyne.NewPos(s.X(i), s.Y(values[i]))
+		objects = append(objects, line)
+	}
+	return objects
+}
+
+// 成交量副图，阳线绿色、阴线红色，右侧标出最大成交量
+func volumeObjects(klines []Kline, s chartScale, fg, grid color.Color) []fyne.CanvasObject {
+	for _, k := range klines {
+		if k.Volume > s.max {
+			s.max = k.Volume
+		}
+	}
+
+	objects := paneHeader(s, fmt.Sprintf(T("成交量 %.0f"), s.max), fg, grid)
+	if s.max == 0 {
+		return objects
+	}
+
+	up := withAlpha(theme.Color(colorNameCandleUp), 160)
+	down := withAlpha(theme.Color(colorNameCandleDown), 160)
+	for i, k := range klines {
+		fill := up
+		if k.Close < k.Open {
+			fill = down
+		}
+		objects = append(objects, paneBar(s, i, k.Volume, fill))
+	}
+	return objects
+}
+
+// RSI(14)副图，带30和70两条参考线
+func rsiObjects(klines []Kline, s chartScale, fg, grid color.Color) []fyne.CanvasObject {
+	const period = 14
+	rsi := calculateRSISeries(klines, period)
+	text := "RSI(14)"
+	if len(rsi) > period {
+		text = fmt.Sprintf("RSI %.1f", rsi[len(rsi)-1])
+	}
+	objects := paneHeader(s, text, fg, grid)
+
+	for _, level := range []float64{30, 70} {
+		band := canvas.NewLine(color.NRGBA{R: 149, G: 165, B: 166, A: 180})
+		band.Position1 = fyne.NewPos(s.left, s.Y(level))
+		band.Position2 = fyne.NewPos(s.left+s.width, s.Y(level))
+		objects = append(objects, band)
+	}
+	if len(rsi) > period {
+		objects = append(objects, seriesObjects(rsi, period, s, color.NRGBA{R: 155, G: 89, B: 182, A: 255})...)
+	}
+	return objects
+}
+
+// MACD(12,26,9)副图：柱状图加MACD线和信号线，纵轴以0为中心对称
+func macdObjects(klines []Kline, s chartScale, fg, grid color.Color) []fyne.CanvasObject {
+	const fast, slow, signal = 12, 26, 9
+	macd, signalLine, hist := calculateMACD(klines, fast, slow, signal)
+	start := slow + signal - 2
+	if len(klines) <= start {
+		return paneHeader(s, "MACD", fg, grid)
+	}
+
+	var limit float64
+	for i := start; i < len(klines); i++ {
+		limit = math.Max(limit, math.Max(math.Abs(macd[i]), math.Abs(signalLine[i])))
+		limit = math.Max(limit, math.Abs(hist[i]))
+	}
+	if limit == 0 {
+		limit = 1
+	}
+	s.min, s.max = -limit, limit
+
+	objects := paneHeader(s, fmt.Sprintf("MACD %s", formatChartPrice(hist[len(hist)-1])), fg, grid)
+	zero := canvas.NewLine(grid)
+	zero.Position1 = fyne.NewPos(s.left, s.Y(0))
+	zero.Position2 = fyne.NewPos(s.left+s.width, s.Y(0))
+	objects = append(objects, zero)
+
+	up := withAlpha(theme.Color(colorNameCandleUp), 160)
+	down := withAlpha(theme.Color(colorNameCandleDown), 160)
+	for i := start; i < len(klines); i++ {
+		fill := up
+		if hist[i] < 0 {
+			fill = down
+		}
+		objects = append(objects, paneBar(s, i, hist[i], fill))
+	}
+	objects = append(objects, seriesObjects(macd, start, s, color.NRGBA{R: 52, G: 152, B: 219, A: 255})...)
+	objects = append(objects, seriesObjects(signalLine, start, s, color.NRGBA{R: 230, G: 126, B: 34, A: 255})...)
+	return objects
+}
+
+// 蜡烛图，阳线和阴线使用主题的K线颜色和影线宽度
+func candleObjects(klines []Kline, s chartScale) []fyne.CanvasObject {
+	up := theme.Color(colorNameCandleUp)
+	down := theme.Color(colorNameCandleDown)
+	wickWidth := theme.Size(sizeNameCandleWick)
+	if wickWidth <= 0 {
+		wickWidth = 1
+	}
+
+	var objects []fyne.CanvasObject
+	bodyWidth := s.Slot() * 0.8
+	for i, k := range klines {
+		x := s.X(i)
+		fill := up
+		if k.Close < k.Open {
+			fill = down
+		}
+
+		// 影线
+		wick := canvas.NewLine(fill)
+		wick.StrokeWidth = wickWidth
+		wick.Position1 = fyne.NewPos(x, s.Y(k.High))
+		wick.Position2 = fyne.NewPos(x, s.Y(k.Low))
+		objects = append(objects, wick)
+
+		// 蜡烛体
+		top, bottom := s.Y(k.Open), s.Y(k.Close)
+		if top > bottom {
+			top, bottom = bottom, top
+		}
+		if bottom-top < 1 {
+			bottom = top + 1
+		}
+		body := canvas.NewRectangle(fill)
+		body.Move(fyne.NewPos(x-bodyWidth/2, top))
+		body.Resize(fyne.NewSize(bodyWidth, bottom-top))
+		objects = append(objects, body)
+	}
+	return objects
+}
+
+// 折线图和面积图，面积图用每根K线宽度的矩形填充到底部
+func lineObjects(klines []Kline, s chartScale, fill bool) []fyne.CanvasObject {
+	lineColor := color.NRGBA{R: 70, G: 130, B: 180, A: 255}
+	fillColor := color.NRGBA{R: 70, G: 130, B: 180, A: 80}
+	bottom := s.top + s.height
+
+	var objects []fyne.CanvasObject
+	if fill {
+		slot := s.Slot()
+		for i, k := range klines {
+			y := s.Y(k.Close)
+			area := canvas.NewRectangle(fillColor)
+			area.Move(fyne.NewPos(s.X(i)-slot/2, y))
+			area.Resize(fyne.NewSize(slot, bottom-y))
+			objects = append(objects, area)
+		}
+	}
+	for i := 1; i < len(klines); i++ {
+		line := canvas.NewLine(lineColor)
+		line.StrokeWidth = 1.5
+		line.Position1 = fyne.NewPos(s.X(i-1), s.Y(klines[i-1].Close))
+		line.Position2 = fyne.NewPos(s.X(i), s.Y(klines[i].Close))
+		objects = append(objects, line)
+	}
+	return objects
+}
+
+// 成交量分布：从右侧向左的横向柱子，最长的柱子占图表宽度的width比例
+func volumeProfileObjects(vp *VolumeProfile, s chartScale, width float32) []fyne.CanvasObject {
+	if vp == nil || vp.Bins[vp.POC] == 0 {
+		return nil
+	}
+	maxVol := vp.Bins[vp.POC]
+	right := s.left + s.width
+
+	var objects []fyne.CanvasObject
+	for i, v := range vp.Bins {
+		// POC高亮，价值区内颜色加深
+		var fill color.Color
+		switch {
+		case i == vp.POC:
+			fill = color.NRGBA{R: 230, G: 126, B: 34, A: 140}
+		case i >= vp.VALow && i <= vp.VAHigh:
+			fill = color.NRGBA{R: 70, G: 130, B: 180, A: 100}
+		default:
+			fill = color.NRGBA{R: 70, G: 130, B: 180, A: 50}
+		}
+
+		y0 := s.Y(vp.Low + float64(i+1)*vp.BinSize)
+		y1 := s.Y(vp.Low + float64(i)*vp.BinSize)
+		w := s.width * width * float32(v/maxVol)
+		bar := canvas.NewRectangle(fill)
+		bar.Move(fyne.NewPos(right-w, y0))
+		bar.Resize(fyne.NewSize(w, y1-y0))
+		objects = append(objects, bar)
+	}
+	return objects
+}
+
+// 价格刻度的格式，价格越小保留的小数越多
+func formatChartPrice(price float64) string {
+	switch {
+	case price >= 1000:
+		return fmt.Sprintf("%.1f", price)
+	case price >= 1:
+		return fmt.Sprintf("%.2f", price)
+	default:
+		return fmt.Sprintf("%.5f", price)
+	}
+}
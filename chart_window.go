@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 独立的K线图窗口，可以同时查看不同交易对或周期。
+// K线和主窗口一样从共享的K线缓存获取
+type ChartWindow struct {
+	ui     *TraderUI
+	window fyne.Window
+	chart  *CandleChart
+
+	mu       sync.Mutex
+	symbol   string
+	interval string
+	stopC    chan struct{}
+}
+
+func (ui *TraderUI) showChartWindow() {
+	cw := &ChartWindow{
+		ui:       ui,
+		window:   ui.app.NewWindow(T("K线图")),
+		chart:    NewCandleChart(),
+		symbol:   ui.symbol,
+		interval: ui.interval,
+		stopC:    make(chan struct{}),
+	}
+	cw.chart.SetIndicators(ui.config.Chart.RSI, ui.config.Chart.MACD)
+	cw.chart.SetMinSize(ui.scaled(ui.config.Display.ChartWidth, ui.config.Display.ChartHeight))
+
+	symbolSelect := widget.NewSelectEntry(ui.config.Watchlist)
+	symbolSelect.SetText(cw.symbol)
+	symbolSelect.OnChanged = func(symbol string) {
+		for _, s := range ui.config.Watchlist {
+			if s == symbol {
+				cw.setSymbol(symbol)
+			}
+		}
+	}
+	symbolSelect.OnSubmitted = cw.setSymbol
+
+	intervalSelect := widget.NewSelect(klineIntervals, nil)
+	intervalSelect.SetSelected(cw.interval)
+	intervalSelect.OnChanged = func(interval string) {
+		cw.mu.Lock()
+		cw.interval = interval
+		cw.mu.Unlock()
+		go cw.refresh()
+	}
+
+	cw.window.SetContent(container.NewBorder(
+		container.NewGridWithColumns(2, symbolSelect, intervalSelect),
+		nil, nil, nil,
+		cw.chart,
+	))
+	cw.window.SetOnClosed(func() {
+		close(cw.stopC)
+	})
+	cw.window.Resize(ui.scaled(700, 450))
+	cw.window.Show()
+
+	go cw.run()
+}
+
+func (cw *ChartWindow) setSymbol(symbol string) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if symbol == "" {
+		return
+	}
+	cw.mu.Lock()
+	cw.symbol = symbol
+	cw.mu.Unlock()
+	go cw.refresh()
+}
+
+// 每5秒刷新一次，窗口关闭后停止
+func (cw *ChartWindow) run() {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	cw.refresh()
+	for {
+		select {
+		case <-cw.stopC:
+			return
+		case <-ticker.C:
+			cw.refresh()
+		}
+	}
+}
+
+func (cw *ChartWindow) refresh() {
+	cw.mu.Lock()
+	symbol, interval := cw.symbol, cw.interval
+	cw.mu.Unlock()
+
+	klines, err := cw.ui.klineCache.Get(symbol, interval, cw.ui.limit)
+	if err != nil {
+		fmt.Printf(T("更新K线失败: %v\n"), err)
+		return
+	}
+
+	// 获取期间已经切换了交易对或周期，丢弃结果
+	cw.mu.Lock()
+	changed := symbol != cw.symbol || interval != cw.interval
+	cw.mu.Unlock()
+	if changed {
+		return
+	}
+
+	title := fmt.Sprintf(T("%s %s K线图"), symbol, interval)
+	profile := calculateVolumeProfile(klines, 24)
+	mode := cw.ui.chartMode
+	fyne.Do(func() {
+		cw.window.SetTitle(title)
+		cw.chart.SetData(title, interval, klines, mode, profile)
+	})
+}
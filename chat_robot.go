@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// 企业微信群机器人设置，key为群机器人webhook地址中的key参数
+type WeComConfig struct {
+	Key    string   `json:"key"`
+	Events []string `json:"events,omitempty"` // 推送的事件类型，为空时推送所有事件
+}
+
+// 钉钉群机器人设置。token为webhook地址中的access_token，安全设置选择"加签"时填写secret
+type DingTalkConfig struct {
+	Token  string   `json:"token"`
+	Secret string   `json:"secret,omitempty"`
+	Events []string `json:"events,omitempty"` // 推送的事件类型，为空时推送所有事件
+}
+
+// 企业微信和钉钉群机器人，消息格式相同，都以文本消息发送
+type chatRobot struct {
+	name   string
+	url    func() string
+	client *http.Client
+}
+
+func NewWeCom(config WeComConfig) (Notifier, error) {
+	if config.Key == "" {
+		return nil, errors.New(T("企业微信机器人需要设置key"))
+	}
+	return &chatRobot{
+		name: "wecom",
+		url: func() string {
+			return "https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=" + url.QueryEscape(config.Key)
+		},
+		client: &http.Client{Timeout: 15 * time.Second},
+	}, nil
+}
+
+func NewDingTalk(config DingTalkConfig) (Notifier, error) {
+	if config.Token == "" {
+		return nil, errors.New(T("钉钉机器人需要设置token"))
+	}
+	return &chatRobot{
+		name: "dingtalk",
+		url: func() string {
+			u := "https://oapi.dingtalk.com/robot/send?access_token=" + url.QueryEscape(config.Token)
+			if config.Secret == "" {
+				return u
+			}
+			// 加签: 毫秒时间戳和secret用HMAC-SHA256签名，一小时内有效
+			timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+			mac := hmac.New(sha256.New, []byte(config.Secret))
+			mac.Write([]byte(timestamp + "\n" + config.Secret))
+			sign := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+			return u + "&timestamp=" + timestamp + "&sign=" + url.QueryEscape(sign)
+		},
+		client: &http.Client{Timeout: 15 * time.Second},
+	}, nil
+}
+
+func (r *chatRobot) Name() string { return r.name }
+
+func (r *chatRobot) Notify(event NotifyEvent) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"msgtype": "text",
+		"text":    map[string]string{"content": event.Text()},
+	})
+	if err != nil {
+		return fmt.Errorf(T("序列化请求失败: %v"), err)
+	}
+	resp, err := r.client.Post(r.url(), "application/json", bytes.NewReader(body))
+	if err != nil {
+		// 错误信息中的URL包含密钥，不能打印
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf(T("请求%s失败: %v"), r.name, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf(T("读取%s响应失败: %v"), r.name, err)
+	}
+	// 两个接口出错时HTTP状态码也是200，错误在errcode中
+	var result struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf(T("解析%s响应失败: %v"), r.name, err)
+	}
+	if resp.StatusCode != http.StatusOK || result.ErrCode != 0 {
+		return fmt.Errorf(T("%s返回错误 %d: %s"), r.name, result.ErrCode, result.ErrMsg)
+	}
+	return nil
+}
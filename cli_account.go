@@ -0,0 +1,466 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/adshao/go-binance/v2"
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// json输出的账户余额
+type BalanceJSON struct {
+	WalletBalance     float64        `json:"wallet_balance"`
+	MarginBalance     float64        `json:"margin_balance"`
+	AvailableBalance  float64        `json:"available_balance"`
+	UnrealizedProfit  float64        `json:"unrealized_pnl"`
+	InitialMargin     float64        `json:"initial_margin"`
+	MaintenanceMargin float64        `json:"maintenance_margin"`
+	PositionNotional  float64        `json:"position_notional"`
+	Leverage          float64        `json:"leverage"`
+	Assets            []AssetBalance `json:"assets"`
+}
+
+// 显示合约账户的余额、保证金和整体杠杆，以及各资产的余额
+func (t *TraderCLI) balance(args []string) error {
+	fs := flag.NewFlagSet("balance", flag.ContinueOnError)
+	output := outputFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+
+	account, err := fetchAccountSummary(t.client)
+	if err != nil {
+		return err
+	}
+	if *output == outputJSON {
+		assets := account.Assets
+		if assets == nil {
+			assets = []AssetBalance{}
+		}
+		return writeJSON(BalanceJSON{
+			WalletBalance:     account.WalletBalance,
+			MarginBalance:     account.MarginBalance,
+			AvailableBalance:  account.AvailableBalance,
+			UnrealizedProfit:  account.UnrealizedProfit,
+			InitialMargin:     account.InitialMargin,
+			MaintenanceMargin: account.MaintenanceMargin,
+			PositionNotional:  account.PositionNotional,
+			Leverage:          account.Leverage(),
+			Assets:            assets,
+		})
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, T("钱包余额\t%.2f\n"), account.WalletBalance)
+	fmt.Fprintf(w, T("保证金余额\t%.2f\n"), account.MarginBalance)
+	fmt.Fprintf(w, T("可用保证金\t%.2f\n"), account.AvailableBalance)
+	fmt.Fprintf(w, T("未实现盈亏\t%+.2f\n"), account.UnrealizedProfit)
+	fmt.Fprintf(w, T("持仓名义价值\t%.2f\n"), account.PositionNotional)
+	fmt.Fprintf(w, T("账户杠杆\t%.2fx\n"), account.Leverage())
+	fmt.Fprintf(w, T("保证金使用率\t%.2f%%\n"), account.MarginUsage())
+	fmt.Fprintf(w, T("维持保证金率\t%.2f%%\n"), account.MaintenanceRatio())
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if len(account.Assets) == 0 {
+		return nil
+	}
+
+	fmt.Println()
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, T("资产\t钱包余额\t保证金余额\t可用余额\t未实现盈亏"))
+	for _, a := range account.Assets {
+		fmt.Fprintf(w, "%s\t%.4f\t%.4f\t%.4f\t%+.4f\n",
+			a.Asset, a.WalletBalance, a.MarginBalance, a.AvailableBalance, a.UnrealizedProfit)
+	}
+	return w.Flush()
+}
+
+// json输出的资金费
+type FundingJSON struct {
+	Symbol          string          `json:"symbol"`
+	FundingRate     float64         `json:"funding_rate"`
+	NextFundingTime time.Time       `json:"next_funding_time"`
+	MarkPrice       float64         `json:"mark_price"`
+	Payments        []FundingRecord `json:"payments"`
+	Total           float64         `json:"total"` // 正数为收取，负数为支付
+}
+
+// 一笔资金费收付
+type FundingRecord struct {
+	Time   time.Time `json:"time"`
+	Income float64   `json:"income"`
+	Asset  string    `json:"asset"`
+}
+
+// 资金费率和资金费收付记录: funding [SYMBOL] [-days 7] [-from 2024-01-01 -to 2024-02-01]
+func (t *TraderCLI) funding(args []string) error {
+	fs := flag.NewFlagSet("funding", flag.ContinueOnError)
+	symbol := fs.String("symbol", t.protectConfig().Symbol, T("交易对"))
+	dates := dateRangeFlags(fs, 7, T("显示最近多少天的资金费"))
+	output := outputFlag(fs)
+	if err := parseWithSymbol(fs, args, symbol); err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+	since, until, err := dates.parse()
+	if err != nil {
+		return err
+	}
+	if until.IsZero() {
+		until = time.Now()
+	}
+
+	sym := strings.ToUpper(*symbol)
+	premiums, err := t.client.NewPremiumIndexService().Symbol(sym).Do(context.Background())
+	if err != nil {
+		return fmt.Errorf(T("获取资金费率失败: %v"), err)
+	}
+	if len(premiums) == 0 {
+		return fmt.Errorf(T("未找到交易对: %s"), sym)
+	}
+	incomes, err := fetchIncomeRange(t.client, sym, IncomeFunding, since, until)
+	if err != nil {
+		return err
+	}
+
+	result := FundingJSON{Symbol: sym, Payments: []FundingRecord{}}
+	result.FundingRate, _ = strconv.ParseFloat(premiums[0].LastFundingRate, 64)
+	result.MarkPrice, _ = strconv.ParseFloat(premiums[0].MarkPrice, 64)
+	result.NextFundingTime = time.UnixMilli(premiums[0].NextFundingTime)
+	for _, in := range incomes {
+		income, _ := strconv.ParseFloat(in.Income, 64)
+		result.Payments = append(result.Payments, FundingRecord{Time: time.UnixMilli(in.Time), Income: income, Asset: in.Asset})
+		result.Total += income
+	}
+	if *output == outputJSON {
+		return writeJSON(result)
+	}
+
+	fmt.Printf(T("%s 当前资金费率 %+.4f%%，下次结算 %s (%s后)，标记价 %.4f\n"), sym, result.FundingRate*100,
+		result.NextFundingTime.Format("01-02 15:04"), time.Until(result.NextFundingTime).Round(time.Minute), result.MarkPrice)
+	if len(result.Payments) == 0 {
+		fmt.Println(T("该时间段内没有资金费记录"))
+		return nil
+	}
+
+	fmt.Println()
+	var received, paid float64
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, T("时间\t资金费\t资产"))
+	for _, p := range result.Payments {
+		fmt.Fprintf(w, "%s\t%+.4f\t%s\n", p.Time.Format("01-02 15:04"), p.Income, p.Asset)
+		if p.Income > 0 {
+			received += p.Income
+		} else {
+			paid += p.Income
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf(T("共%d笔，收取 %.4f，支付 %.4f，净额 %+.4f\n"), len(result.Payments), received, -paid, result.Total)
+	return nil
+}
+
+// 按天和类型汇总的资金流水
+type IncomeGroup struct {
+	Date   string  `json:"date"` // 本地日期 YYYY-MM-DD
+	Type   string  `json:"type"`
+	Asset  string  `json:"asset"`
+	Income float64 `json:"income"`
+	Count  int     `json:"count"`
+}
+
+// json输出的资金流水汇总，totals按"类型 资产"汇总整个时间段
+type IncomeJSON struct {
+	Groups []IncomeGroup      `json:"groups"`
+	Totals map[string]float64 `json:"totals"`
+}
+
+// 汇总资金流水，结果按日期、类型和资产排序
+func groupIncome(incomes []*futures.IncomeHistory) []IncomeGroup {
+	index := make(map[string]int)
+	var groups []IncomeGroup
+	for _, in := range incomes {
+		date := time.UnixMilli(in.Time).Format(time.DateOnly)
+		key := date + " " + in.IncomeType + " " + in.Asset
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, IncomeGroup{Date: date, Type: in.IncomeType, Asset: in.Asset})
+		}
+		v, _ := strconv.ParseFloat(in.Income, 64)
+		groups[i].Income += v
+		groups[i].Count++
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		a, b := groups[i], groups[j]
+		if a.Date != b.Date {
+			return a.Date < b.Date
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.Asset < b.Asset
+	})
+	return groups
+}
+
+// 按天和类型汇总资金流水: income [-type REALIZED_PNL] [-symbol SOLUSDC] [-days 30]
+func (t *TraderCLI) income(args []string) error {
+	fs := flag.NewFlagSet("income", flag.ContinueOnError)
+	incomeType := fs.String("type", "", T("流水类型，如REALIZED_PNL、COMMISSION、FUNDING_FEE，为空时显示全部"))
+	symbol := fs.String("symbol", "", T("交易对，为空时显示全部"))
+	dates := dateRangeFlags(fs, 30, T("显示最近多少天的资金流水"))
+	output := outputFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+	since, until, err := dates.parse()
+	if err != nil {
+		return err
+	}
+	if until.IsZero() {
+		until = time.Now()
+	}
+
+	incomes, err := fetchIncomeRange(t.client, strings.ToUpper(*symbol), strings.ToUpper(*incomeType), since, until)
+	if err != nil {
+		return err
+	}
+	result := IncomeJSON{Groups: groupIncome(incomes), Totals: make(map[string]float64)}
+	if result.Groups == nil {
+		result.Groups = []IncomeGroup{}
+	}
+	for _, g := range result.Groups {
+		result.Totals[g.Type+" "+g.Asset] += g.Income
+	}
+	if *output == outputJSON {
+		return writeJSON(result)
+	}
+	if len(result.Groups) == 0 {
+		fmt.Println(T("该时间段内没有资金流水"))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, T("日期\t类型\t金额\t资产\t笔数"))
+	for _, g := range result.Groups {
+		fmt.Fprintf(w, "%s\t%s\t%+.4f\t%s\t%d\n", g.Date, g.Type, g.Income, g.Asset, g.Count)
+	}
+	fmt.Fprintln(w)
+	keys := make([]string, 0, len(result.Totals))
+	for key := range result.Totals {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		incomeType, asset, _ := strings.Cut(key, " ")
+		fmt.Fprintf(w, T("合计\t%s\t%+.4f\t%s\t\n"), incomeType, result.Totals[key], asset)
+	}
+	return w.Flush()
+}
+
+// 交易对的持仓风险，没有持仓时也会返回杠杆和保证金模式
+func (t *TraderCLI) positionRisk(symbol string) (*futures.PositionRisk, error) {
+	positions, err := t.client.NewGetPositionRiskService().Symbol(symbol).Do(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf(T("获取持仓信息失败: %v"), err)
+	}
+	if len(positions) == 0 {
+		return nil, fmt.Errorf(T("未找到交易对: %s"), symbol)
+	}
+	return positions[0], nil
+}
+
+// 有持仓时修改杠杆或保证金模式前需要确认，-y跳过确认
+func confirmWithPosition(p *futures.PositionRisk, yes bool, action string) bool {
+	amt, _ := strconv.ParseFloat(p.PositionAmt, 64)
+	if amt == 0 || yes {
+		return true
+	}
+	return confirm(fmt.Sprintf(T("%s 有%s %s，确定%s吗？"), p.Symbol, positionDirection(amt), p.PositionAmt, action))
+}
+
+// 解析 COMMAND [SYMBOL] [VALUE] 形式的参数，没有VALUE时只查看当前设置
+func parseSymbolValue(fs *flag.FlagSet, args []string, symbol *string) (string, error) {
+	positional, err := parsePositional(fs, args)
+	if err != nil {
+		return "", err
+	}
+	if len(positional) > 2 {
+		return "", fmt.Errorf(T("多余的参数: %s"), strings.Join(positional[2:], " "))
+	}
+	if len(positional) > 0 {
+		*symbol = positional[0]
+	}
+	if len(positional) > 1 {
+		return positional[1], nil
+	}
+	return "", nil
+}
+
+// 查看或设置杠杆: leverage SYMBOL [N] [-y]
+func (t *TraderCLI) leverage(args []string) error {
+	fs := flag.NewFlagSet("leverage", flag.ContinueOnError)
+	symbol := fs.String("symbol", t.protectConfig().Symbol, T("交易对"))
+	yes := fs.Bool("y", false, T("有持仓时不再确认"))
+	value, err := parseSymbolValue(fs, args, symbol)
+	if err != nil {
+		return err
+	}
+	sym := strings.ToUpper(*symbol)
+
+	p, err := t.positionRisk(sym)
+	if err != nil {
+		return err
+	}
+	if value == "" {
+		fmt.Printf(T("%s 杠杆 %sx，保证金模式 %s\n"), sym, p.Leverage, p.MarginType)
+		return nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 || n > 125 {
+		return fmt.Errorf(T("杠杆必须是1到125之间的整数: %s"), value)
+	}
+	if !confirmWithPosition(p, *yes, fmt.Sprintf(T("把杠杆从%sx改为%dx"), p.Leverage, n)) {
+		fmt.Println(T("已取消"))
+		return nil
+	}
+	if err := t.requirePIN(T("修改杠杆")); err != nil {
+		return err
+	}
+	res, err := t.client.NewChangeLeverageService().Symbol(sym).Leverage(n).Do(context.Background())
+	if err != nil {
+		return err
+	}
+	fmt.Printf(T("%s 杠杆已设置为 %dx，最大名义价值 %s\n"), sym, res.Leverage, res.MaxNotionalValue)
+	return nil
+}
+
+// 查看或设置保证金模式: margin-type SYMBOL [cross|isolated] [-y]
+func (t *TraderCLI) marginType(args []string) error {
+	fs := flag.NewFlagSet("margin-type", flag.ContinueOnError)
+	symbol := fs.String("symbol", t.protectConfig().Symbol, T("交易对"))
+	yes := fs.Bool("y", false, T("有持仓时不再确认"))
+	value, err := parseSymbolValue(fs, args, symbol)
+	if err != nil {
+		return err
+	}
+	sym := strings.ToUpper(*symbol)
+
+	p, err := t.positionRisk(sym)
+	if err != nil {
+		return err
+	}
+	if value == "" {
+		fmt.Printf(T("%s 保证金模式 %s\n"), sym, p.MarginType)
+		return nil
+	}
+
+	var mode futures.MarginType
+	switch strings.ToLower(value) {
+	case "cross", "crossed":
+		mode = futures.MarginTypeCrossed
+	case "isolated":
+		mode = futures.MarginTypeIsolated
+	default:
+		return fmt.Errorf(T("保证金模式只能是cross或isolated: %s"), value)
+	}
+	// 持仓风险接口返回小写的cross或isolated
+	current := futures.MarginTypeCrossed
+	if strings.EqualFold(p.MarginType, string(futures.MarginTypeIsolated)) {
+		current = futures.MarginTypeIsolated
+	}
+	if current == mode {
+		fmt.Printf(T("%s 已经是%s模式\n"), sym, p.MarginType)
+		return nil
+	}
+	if !confirmWithPosition(p, *yes, fmt.Sprintf(T("把保证金模式从%s改为%s"), p.MarginType, strings.ToLower(string(mode)))) {
+		fmt.Println(T("已取消"))
+		return nil
+	}
+	if err := t.requirePIN(T("修改保证金模式")); err != nil {
+		return err
+	}
+	if err := t.client.NewChangeMarginTypeService().Symbol(sym).MarginType(mode).Do(context.Background()); err != nil {
+		return err
+	}
+	fmt.Printf(T("%s 保证金模式已设置为 %s\n"), sym, strings.ToLower(string(mode)))
+	return nil
+}
+
+// 万向划转的账户名称，划转类型为 来源_目标，如MAIN_UMFUTURE
+var transferWallets = map[string]string{
+	"spot":    "MAIN",
+	"futures": "UMFUTURE",
+	"coin":    "CMFUTURE",
+	"margin":  "MARGIN",
+	"funding": "FUNDING",
+}
+
+// 在现货和合约等账户之间划转: transfer -from spot -to futures -asset USDC -amount 500，
+// 不指定资产时划转主交易对的保证金资产
+func (t *TraderCLI) transfer(args []string) error {
+	fs := flag.NewFlagSet("transfer", flag.ContinueOnError)
+	from := fs.String("from", "spot", T("转出账户: spot/futures/coin/margin/funding"))
+	to := fs.String("to", "futures", T("转入账户: spot/futures/coin/margin/funding"))
+	asset := fs.String("asset", "", T("划转的资产，默认为主交易对的保证金资产"))
+	amount := fs.Float64("amount", 0, T("划转数量"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	fromWallet, ok := transferWallets[strings.ToLower(*from)]
+	if !ok {
+		return fmt.Errorf(T("未知的账户: %s"), *from)
+	}
+	toWallet, ok := transferWallets[strings.ToLower(*to)]
+	if !ok {
+		return fmt.Errorf(T("未知的账户: %s"), *to)
+	}
+	if fromWallet == toWallet {
+		return errors.New(T("转出和转入账户不能相同"))
+	}
+	if *amount <= 0 {
+		return errors.New(T("请用-amount指定划转数量"))
+	}
+	if *asset == "" {
+		filters, err := NewSymbolFilterCache(t.client).Get(t.protectConfig().Symbol)
+		if err != nil {
+			return err
+		}
+		*asset = filters.MarginAsset
+	}
+
+	res, err := t.spotClient.NewUserUniversalTransferService().
+		Type(binance.UserUniversalTransferType(fromWallet + "_" + toWallet)).
+		Asset(strings.ToUpper(*asset)).
+		Amount(*amount).
+		Do(context.Background())
+	if err != nil {
+		return err
+	}
+	fmt.Printf(T("已从%s划转 %s %s 到%s，划转ID: %d\n"), *from, formatFloat(*amount), strings.ToUpper(*asset), *to, res.ID)
+	return nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 管理提醒，和界面共用账户目录下的alerts.json，运行中的监控程序和界面会自动读取修改:
+//
+//	alert add [-type price|rsi|pnl|drawdown|protect] [-repeat] [-channels a,b] SYMBOL CONDITION [VALUE]
+//	alert list [-symbol SYMBOL] [-active] [-output json]
+//	alert remove ID... | alert remove -all [-symbol SYMBOL] [-y]
+func (t *TraderCLI) alert(args []string) error {
+	if len(args) == 0 {
+		return errors.New(T("用法: alert add|list|remove"))
+	}
+
+	switch args[0] {
+	case "add":
+		return t.alertAdd(args[1:])
+	case "list":
+		return t.alertList(args[1:])
+	case "remove":
+		return t.alertRemove(args[1:])
+	default:
+		return fmt.Errorf(T("未知的提醒命令: %s"), args[0])
+	}
+}
+
+func (t *TraderCLI) alertAdd(args []string) error {
+	fs := flag.NewFlagSet("alert add", flag.ContinueOnError)
+	alertType := fs.String("type", string(AlertPrice), T("提醒类型: price/rsi/pnl/drawdown/protect"))
+	repeat := fs.Bool("repeat", false, T("条件解除后重新生效"))
+	channels := fs.String("channels", "", T("通知渠道，逗号分隔，为空时发送到所有渠道"))
+	rest, err := parsePositional(fs, args)
+	if err != nil {
+		return err
+	}
+
+	alert := Alert{Type: AlertType(*alertType), Repeat: *repeat}
+	if *channels != "" {
+		alert.Channels = strings.Split(*channels, ",")
+	}
+
+	// 保护止盈事件提醒没有阈值
+	if alert.Type == AlertProtect {
+		if len(rest) != 2 {
+			return errors.New(T("用法: alert add -type protect SYMBOL armed|fired"))
+		}
+	} else {
+		if len(rest) != 3 {
+			return errors.New(T("用法: alert add [-type TYPE] SYMBOL above|below|cross VALUE"))
+		}
+		value, err := strconv.ParseFloat(rest[2], 64)
+		if err != nil {
+			return fmt.Errorf(T("阈值格式错误: %v"), err)
+		}
+		alert.Value = value
+	}
+	alert.Symbol = strings.ToUpper(rest[0])
+	alert.Condition = AlertCondition(rest[1])
+
+	a, err := t.alerts.Add(alert)
+	if err != nil {
+		return err
+	}
+	fmt.Printf(T("已添加提醒 %s\n"), a)
+	return nil
+}
+
+func (t *TraderCLI) alertList(args []string) error {
+	fs := flag.NewFlagSet("alert list", flag.ContinueOnError)
+	symbol := fs.String("symbol", "", T("只显示该交易对的提醒"))
+	active := fs.Bool("active", false, T("只显示等待触发的提醒"))
+	output := outputFlag(fs)
+	if err := parseWithSymbol(fs, args, symbol); err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+
+	sym := strings.ToUpper(*symbol)
+	alerts := []*Alert{}
+	for _, a := range t.alerts.List() {
+		if sym != "" && a.Symbol != sym {
+			continue
+		}
+		if *active && a.Triggered && !a.Repeat {
+			continue
+		}
+		alerts = append(alerts, a)
+	}
+
+	if *output == "json" {
+		return writeJSON(alerts)
+	}
+	if len(alerts) == 0 {
+		fmt.Println(T("没有提醒"))
+	}
+	for _, a := range alerts {
+		fmt.Println(a)
+	}
+	return nil
+}
+
+func (t *TraderCLI) alertRemove(args []string) error {
+	fs := flag.NewFlagSet("alert remove", flag.ContinueOnError)
+	all := fs.Bool("all", false, T("删除所有提醒，可以用-symbol只删除一个交易对的"))
+	symbol := fs.String("symbol", "", T("和-all一起使用，只删除该交易对的提醒"))
+	yes := fs.Bool("y", false, T("不确认直接执行"))
+	ids, err := parsePositional(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if *all {
+		if len(ids) > 0 {
+			return errors.New(T("-all不能和提醒ID一起使用"))
+		}
+		sym := strings.ToUpper(*symbol)
+		question := T("删除所有提醒，确定吗？")
+		if sym != "" {
+			question = fmt.Sprintf(T("删除%s的所有提醒，确定吗？"), sym)
+		}
+		if !*yes && !confirm(question) {
+			fmt.Println(T("已取消"))
+			return nil
+		}
+		n, err := t.alerts.Clear(sym)
+		if err != nil {
+			return err
+		}
+		fmt.Printf(T("已删除%d个提醒\n"), n)
+		return nil
+	}
+
+	if len(ids) == 0 {
+		return errors.New(T("用法: alert remove ID... 或 alert remove -all [-symbol SYMBOL]"))
+	}
+	// 先检查所有ID，格式错误时一个都不删除
+	parsed := make([]int64, len(ids))
+	for i, s := range ids {
+		id, err := strconv.ParseInt(strings.TrimPrefix(s, "#"), 10, 64)
+		if err != nil {
+			return fmt.Errorf(T("提醒ID格式错误: %v"), err)
+		}
+		parsed[i] = id
+	}
+	for _, id := range parsed {
+		if err := t.alerts.Remove(id); err != nil {
+			return err
+		}
+		fmt.Printf(T("已删除提醒 #%d\n"), id)
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// 命令行的子命令: protect [--profile NAME] COMMAND [FLAGS]，每个命令有自己的参数，
+// 用 protect COMMAND -h 查看
+type cliCommand struct {
+	Name  string
+	Usage string // 命令说明
+	Fail  string // 失败时的错误格式
+	Run   func(t *TraderCLI, args []string) error
+}
+
+var cliCommands []cliCommand
+
+// 在init中赋值，交互模式的命令会引用这个表
+func init() {
+	cliCommands = []cliCommand{
+		{"run", "运行止盈止损和保护止盈监控", "交易系统运行失败: %v", (*TraderCLI).runCommand},
+		{"status", "查询运行中的监控程序的状态", "查询状态失败: %v", (*TraderCLI).status},
+		{"metrics", "显示运行中的监控程序的内部计数器: 请求、错误、重连、下单和缓存命中率", "查询计数器失败: %v", (*TraderCLI).metrics},
+		{"killswitch", "紧急停止: 平掉所有持仓、撤销所有订单并暂停自动化", "紧急停止失败: %v", (*TraderCLI).killswitch},
+		{"automation", "暂停或恢复交易对的自动止损、自动止盈或保护止盈", "修改自动化规则失败: %v", (*TraderCLI).automationCommand},
+		{"shell", "交互模式，在后台运行监控", "交互模式失败: %v", (*TraderCLI).shell},
+		{"tui", "终端全屏界面", "终端界面失败: %v", (*TraderCLI).tui},
+		{"watch", "原地刷新持仓、挂单和盈亏", "监视失败: %v", (*TraderCLI).watch},
+		{"buy", "买入开多", "下单失败: %v", (*TraderCLI).buy},
+		{"sell", "卖出开空", "下单失败: %v", (*TraderCLI).sell},
+		{"close", "市价平仓，-pct部分平仓并调整止盈止损单", "平仓失败: %v", (*TraderCLI).closeCommand},
+		{"positions", "显示持仓", "获取持仓信息失败: %v", (*TraderCLI).listPositions},
+		{"orders", "显示挂单或订单历史", "获取订单失败: %v", (*TraderCLI).orders},
+		{"cancel", "撤单", "撤单失败: %v", (*TraderCLI).cancel},
+		{"balance", "显示账户余额、保证金和杠杆", "获取账户信息失败: %v", (*TraderCLI).balance},
+		{"funding", "显示资金费率和资金费收付记录", "获取资金费失败: %v", (*TraderCLI).funding},
+		{"income", "按天和类型汇总盈亏、手续费等资金流水", "获取资金流水失败: %v", (*TraderCLI).income},
+		{"leverage", "查看或设置交易对的杠杆", "设置杠杆失败: %v", (*TraderCLI).leverage},
+		{"margin-type", "查看或设置交易对的保证金模式(cross/isolated)", "设置保证金模式失败: %v", (*TraderCLI).marginType},
+		{"transfer", "在现货和合约账户之间划转资产", "划转失败: %v", (*TraderCLI).transfer},
+		{"trades", "显示最近的成交记录", "获取成交记录失败: %v", (*TraderCLI).trades},
+		{"report", "交易日报或周报: 盈亏、手续费、平仓交易、保护止盈次数和最大回撤", "生成报告失败: %v", (*TraderCLI).report},
+		{"activity", "查询自动化决策的活动记录", "查询活动记录失败: %v", (*TraderCLI).activityLog},
+		{"alert", "管理提醒", "管理提醒失败: %v", (*TraderCLI).alert},
+		{"screener", "市场筛选", "市场筛选失败: %v", (*TraderCLI).screener},
+		{"movers", "涨跌榜", "获取涨跌榜失败: %v", (*TraderCLI).movers},
+		{"basis", "期现基差", "获取期现基差失败: %v", (*TraderCLI).basis},
+		{"backtest", "用历史K线回测策略并生成HTML报告", "回测失败: %v", (*TraderCLI).backtest},
+		{"optimize", "并行回测参数范围内的所有组合并排名", "参数优化失败: %v", (*TraderCLI).optimize},
+		{"simulate", "在历史价格上模拟当前止盈止损设置的结果", "模拟失败: %v", (*TraderCLI).simulate},
+		{"doctor", "检查API密钥、权限、时钟和交易设置", "诊断失败: %v", (*TraderCLI).doctor},
+		{"download", "下载历史K线和资金费率供回测使用，可断点续传", "下载失败: %v", (*TraderCLI).download},
+		{"completion", "生成bash/zsh/fish补全脚本", "生成补全脚本失败: %v", (*TraderCLI).completion},
+		{"version", "显示版本、提交和构建信息", "获取版本失败: %v", (*TraderCLI).version},
+		{"export", "导出K线、成交记录或资金流水到CSV，-from/-to按日期导出对账数据", "导出失败: %v", (*TraderCLI).export},
+	}
+}
+
+// 解析参数，位置参数和-flag可以交替出现，返回位置参数
+func parsePositional(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// 解析参数，交易对可以写在参数前面: close SOLUSDC -pct 50，此时覆盖-symbol
+func parseWithSymbol(fs *flag.FlagSet, args []string, symbol *string) error {
+	positional, err := parsePositional(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 1 {
+		return fmt.Errorf(T("多余的参数: %s"), strings.Join(positional[1:], " "))
+	}
+	if len(positional) == 1 {
+		*symbol = positional[0]
+	}
+	return nil
+}
+
+// 标准输入共用一个带缓冲的reader，交互模式和确认提示各自创建会互相吞掉已缓冲的输入
+var stdin = bufio.NewReader(os.Stdin)
+
+// 设置了PIN时在终端输入PIN，输入不回显，输错时返回错误
+func (t *TraderCLI) requirePIN(action string) error {
+	if !t.pin.Enabled() {
+		return nil
+	}
+	fmt.Printf(T("%s需要PIN: "), action)
+	if restore, err := disableEcho(); err == nil {
+		defer restore()
+	}
+	line, _ := stdin.ReadString('\n')
+	fmt.Println()
+	if !t.pin.Check(strings.TrimSpace(line)) {
+		return errors.New(T("PIN错误"))
+	}
+	return nil
+}
+
+// 在终端询问是否继续，输入y或yes时返回true
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	line, _ := stdin.ReadString('\n')
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes"
+}
+
+func findCLICommand(name string) *cliCommand {
+	for i := range cliCommands {
+		if cliCommands[i].Name == name {
+			return &cliCommands[i]
+		}
+	}
+	return nil
+}
+
+func printCLIUsage() {
+	fmt.Fprintln(os.Stderr, T("用法: protect [--config PATH] [--profile NAME] [--quiet|--verbose] [--log-format text|json] COMMAND [FLAGS]"))
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, T("命令:"))
+	w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
+	for _, c := range cliCommands {
+		fmt.Fprintf(w, "  %s\t%s\n", c.Name, T(c.Usage))
+	}
+	w.Flush()
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, T("不指定命令时运行run，用 protect COMMAND -h 查看命令的参数"))
+	fmt.Fprintln(os.Stderr, T("配置优先级: 命令行参数 > 环境变量 > 配置文件(./config.json或~/.config/protect/config.json)"))
+}
+
+// 运行监控循环，参数覆盖配置中的止盈止损设置。--symbol可以指定多次，同时管理多个交易对，
+// 第一个是主交易对。在配置的strategies中有设置的交易对使用自己的参数，其他使用这里的参数
+func (t *TraderCLI) runCommand(args []string) error {
+	config := t.protectConfig()
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	var symbols stringList
+	fs.Var(&symbols, "symbol", T("自动管理的交易对，可以指定多次，默认为配置中的protect和strategies"))
+	fs.Float64Var(&config.StopLoss, "sl-distance", config.StopLoss, T("止损价与入场价的距离"))
+	fs.Float64Var(&config.TakeProfit, "tp-distance", config.TakeProfit, T("止盈价与入场价的距离"))
+	fs.Float64Var(&config.ArmProfit, "protect-trigger", config.ArmProfit, T("最高盈利达到该值(U)后启动保护止盈"))
+	drawdown := fs.Float64("protect-drawdown", (1-config.KeepRatio)*100, T("盈利从最高点回撤该百分比时保护止盈平仓"))
+	fs.DurationVar(&t.pollInterval, "poll-interval", t.pollInterval, T("轮询持仓的间隔"))
+	fs.StringVar(&t.controlAddr, "control", t.controlAddr, T("控制接口的监听地址，off表示不开启"))
+	fs.StringVar(&t.strategyFile, "strategy", "", T("同时运行的策略文件(.yaml)或流水线配置(.json)"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if len(symbols) > 0 {
+		config.Symbol = symbols[0]
+	}
+	config.Symbol = strings.ToUpper(config.Symbol)
+	config.KeepRatio = 1 - *drawdown/100
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	if t.pollInterval <= 0 {
+		return errors.New(T("轮询间隔必须大于0"))
+	}
+	t.setProtectConfig(config)
+	// 指定了--symbol时只管理指定的交易对，否则同时管理strategies中的交易对
+	var extra []string
+	if len(symbols) > 0 {
+		extra = append([]string{}, symbols[1:]...)
+	}
+	if err := t.setExtraSymbols(extra, config); err != nil {
+		return err
+	}
+	for _, e := range t.engines {
+		c := e.config()
+		log.Printf(T("止盈止损参数: %s 止损距离 %g 止盈距离 %g 保护止盈启动 %g 回撤 %.0f%%"),
+			c.Symbol, c.StopLoss, c.TakeProfit, c.ArmProfit, (1-c.KeepRatio)*100)
+	}
+	log.Printf(T("轮询间隔 %s"), t.pollInterval)
+	return t.run()
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// 位置参数是交易对的命令，补全时提示交易对
+var symbolCommands = []string{"close", "funding", "leverage", "margin-type", "download", "alert", "simulate"}
+
+// 不需要API密钥的命令，没有配置密钥时也能运行
+var offlineCLICommands = map[string]bool{"completion": true, "version": true}
+
+// shell补全脚本。命令列表在生成时写入脚本，交易对和账户名在补全时调用
+// protect completion symbols/profiles 获取，修改配置后不需要重新生成
+var completionScripts = map[string]*template.Template{
+	"bash": template.Must(template.New("bash").Parse(`# protect bash completion: source <(protect completion bash)
+_protect() {
+    local cur prev cmd i
+    cur="${COMP_WORDS[COMP_CWORD]}"
+    prev="${COMP_WORDS[COMP_CWORD-1]}"
+    case "$prev" in
+        --profile) COMPREPLY=($(compgen -W "$(protect completion profiles 2>/dev/null)" -- "$cur")); return ;;
+        --config) COMPREPLY=($(compgen -f -- "$cur")); return ;;
+        -symbol|--symbol) COMPREPLY=($(compgen -W "$(protect completion symbols 2>/dev/null)" -- "$cur")); return ;;
+    esac
+    for ((i = 1; i < COMP_CWORD; i++)); do
+        case "${COMP_WORDS[i]}" in
+            --config|--profile) ((i++)) ;;
+            -*) ;;
+            *) cmd="${COMP_WORDS[i]}"; break ;;
+        esac
+    done
+    if [[ -z "$cmd" ]]; then
+        COMPREPLY=($(compgen -W "--config --profile{{range .Commands}} {{.Name}}{{end}}" -- "$cur"))
+        return
+    fi
+    case "$cmd" in
+        alert) if ((i + 1 == COMP_CWORD)); then COMPREPLY=($(compgen -W "add list remove" -- "$cur")); return; fi ;;
+        completion) COMPREPLY=($(compgen -W "bash zsh fish" -- "$cur")); return ;;
+    esac
+    case " {{.SymbolCommands}} " in
+        *" $cmd "*) [[ "$cur" != -* ]] && COMPREPLY=($(compgen -W "$(protect completion symbols 2>/dev/null)" -- "$cur")) ;;
+    esac
+}
+complete -F _protect protect
+`)),
+	"zsh": template.Must(template.New("zsh").Parse(`#compdef protect
+# protect zsh completion: source <(protect completion zsh)
+_protect() {
+    local -a commands
+    commands=({{range .Commands}}
+        {{.ZshItem}}{{end}}
+    )
+    local cmd i
+    case "${words[CURRENT-1]}" in
+        --profile) compadd -- ${(f)"$(protect completion profiles 2>/dev/null)"}; return ;;
+        --config) _files; return ;;
+        -symbol|--symbol) compadd -- ${(f)"$(protect completion symbols 2>/dev/null)"}; return ;;
+    esac
+    for ((i = 2; i < CURRENT; i++)); do
+        case "${words[i]}" in
+            --config|--profile) ((i++)) ;;
+            -*) ;;
+            *) cmd="${words[i]}"; break ;;
+        esac
+    done
+    if [[ -z "$cmd" ]]; then
+        _describe 'command' commands
+        compadd -- --config --profile
+        return
+    fi
+    case "$cmd" in
+        alert) if ((i + 1 == CURRENT)); then compadd add list remove; return; fi ;;
+        completion) compadd bash zsh fish; return ;;
+    esac
+    if [[ " {{.SymbolCommands}} " == *" $cmd "* && "${words[CURRENT]}" != -* ]]; then
+        compadd -- ${(f)"$(protect completion symbols 2>/dev/null)"}
+    fi
+}
+compdef _protect protect
+`)),
+	"fish": template.Must(template.New("fish").Parse(`# protect fish completion: protect completion fish > ~/.config/fish/completions/protect.fish
+function __protect_command
+    set -l words (commandline -opc)
+    set -e words[1]
+    while set -q words[1]
+        switch $words[1]
+            case --config --profile
+                set -e words[1]
+            case '-*'
+            case '*'
+                echo $words[1]
+                return 0
+        end
+        set -e words[1]
+    end
+    return 1
+end
+
+function __protect_using
+    set -l cmd (__protect_command); or return 1
+    contains -- $cmd $argv
+end
+
+complete -c protect -f
+complete -c protect -n 'not __protect_command >/dev/null' -l config -r -F
+complete -c protect -n 'not __protect_command >/dev/null' -l profile -x -a '(protect completion profiles 2>/dev/null)'
+{{range .Commands}}complete -c protect -n 'not __protect_command >/dev/null' -a {{.Name}} -d {{.FishUsage}}
+{{end}}complete -c protect -n '__protect_using {{.SymbolCommands}}' -a '(protect completion symbols 2>/dev/null)'
+complete -c protect -n '__protect_using alert' -a 'add list remove'
+complete -c protect -n '__protect_using completion' -a 'bash zsh fish'
+complete -c protect -o symbol -x -a '(protect completion symbols 2>/dev/null)'
+`)),
+}
+
+// 补全脚本中的命令和说明
+type completionCommand struct {
+	Name, Usage string
+}
+
+// zsh _describe的 'name:说明'，说明中的冒号需要转义
+func (c completionCommand) ZshItem() string {
+	return shellQuote(c.Name + ":" + strings.ReplaceAll(c.Usage, ":", `\:`))
+}
+
+// fish的单引号字符串中用反斜杠转义单引号
+func (c completionCommand) FishUsage() string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(c.Usage) + "'"
+}
+
+// bash和zsh的单引号字符串，内部的单引号先结束引号再转义
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
+
+// 生成shell补全脚本: completion bash|zsh|fish。
+// completion symbols/profiles 输出补全用的交易对和账户名，每行一个
+func (t *TraderCLI) completion(args []string) error {
+	if len(args) != 1 {
+		return errors.New(T("用法: completion bash|zsh|fish"))
+	}
+
+	switch args[0] {
+	case "symbols":
+		for _, s := range t.completionSymbols() {
+			fmt.Println(s)
+		}
+		return nil
+	case "profiles":
+		config, err := loadAppConfig(configPath())
+		if err != nil {
+			return err
+		}
+		for _, name := range config.Names() {
+			fmt.Println(name)
+		}
+		return nil
+	}
+
+	script, ok := completionScripts[args[0]]
+	if !ok {
+		return fmt.Errorf(T("不支持的shell: %s"), args[0])
+	}
+	data := struct {
+		Commands       []completionCommand
+		SymbolCommands string
+	}{SymbolCommands: strings.Join(symbolCommands, " ")}
+	for _, c := range cliCommands {
+		data.Commands = append(data.Commands, completionCommand{c.Name, T(c.Usage)})
+	}
+	return script.Execute(os.Stdout, data)
+}
+
+// 补全用的交易对: 管理的交易对、提醒中的交易对和已下载历史数据的交易对
+func (t *TraderCLI) completionSymbols() []string {
+	seen := make(map[string]bool)
+	for _, s := range t.managedSymbols() {
+		seen[s] = true
+	}
+	for _, a := range t.alerts.List() {
+		seen[a.Symbol] = true
+	}
+	files, _ := filepath.Glob(filepath.Join(historyDir, "*_*.json"))
+	for _, f := range files {
+		name := filepath.Base(f)
+		seen[name[:strings.Index(name, "_")]] = true
+	}
+
+	symbols := make([]string, 0, len(seen))
+	for s := range seen {
+		if s != "" {
+			symbols = append(symbols, s)
+		}
+	}
+	sort.Strings(symbols)
+	return symbols
+}
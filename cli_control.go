@@ -0,0 +1,669 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// 控制接口默认只监听本机
+const defaultControlAddr = "127.0.0.1:7878"
+
+// 运行中的监控程序把控制接口的地址和令牌写在账户目录的这个文件里，status等命令从这里读取
+const controlFile = "control.json"
+
+// 超过这么久没有成功获取持仓时认为与交易所的连接断开
+const controlStaleAfter = time.Minute
+
+// 控制接口的连接信息，令牌每次启动随机生成，文件只有当前用户可读
+type controlInfo struct {
+	Addr    string    `json:"addr"`
+	Token   string    `json:"token"`
+	PID     int       `json:"pid"`
+	Started time.Time `json:"started"`
+}
+
+// 监控循环的运行状态，监控循环写入，控制接口读取
+type engineState struct {
+	mu        sync.Mutex
+	started   time.Time
+	lastPoll  time.Time // 最后一次成功获取持仓的时间
+	lastError string
+	positions []PositionJSON
+	maxProfit map[string]float64 // 有持仓的交易对的最高盈利
+	paused    bool               // 紧急停止后暂停自动化
+	pipeline  *Pipeline          // 策略流水线，没有配置时为nil
+
+	// 监控循环每次检查止盈止损时持有，紧急停止等这一轮结束后再撤单平仓
+	cycle sync.Mutex
+}
+
+func (s *engineState) isPaused() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.paused
+}
+
+// 暂停或恢复止盈止损监控和策略流水线
+func (s *engineState) setPaused(paused bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paused = paused
+	if s.pipeline != nil {
+		s.pipeline.SetPaused(paused)
+	}
+}
+
+// 获取持仓成功，只保留非零持仓
+func (s *engineState) polled(positions []*futures.PositionRisk) {
+	var open []PositionJSON
+	for _, p := range positions {
+		if amt, _ := strconv.ParseFloat(p.PositionAmt, 64); amt != 0 {
+			open = append(open, newPositionJSON(p))
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastPoll = time.Now()
+	s.lastError = ""
+	s.positions = open
+}
+
+// 获取持仓失败，返回是否是上次成功后的第一次失败
+func (s *engineState) failed(err error) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	first := s.lastError == ""
+	s.lastError = err.Error()
+	return first
+}
+
+// 记录交易对的最高盈利，为0时表示没有持仓
+func (s *engineState) setMaxProfit(symbol string, maxProfit float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if maxProfit == 0 {
+		delete(s.maxProfit, symbol)
+		return
+	}
+	if s.maxProfit == nil {
+		s.maxProfit = make(map[string]float64)
+	}
+	s.maxProfit[symbol] = maxProfit
+}
+
+// 一个交易对的保护止盈状态
+type ProtectionJSON struct {
+	Symbol    string  `json:"symbol"`
+	MaxProfit float64 `json:"max_profit"`
+	ArmProfit float64 `json:"arm_profit"`
+	KeepRatio float64 `json:"keep_ratio"` // 价格异动后可能收紧
+	Armed     bool    `json:"armed"`      // 最高盈利已达到启动盈利
+}
+
+// status返回的监控程序状态
+type ControlStatus struct {
+	Profile     string                      `json:"profile"`
+	PID         int                         `json:"pid"`
+	Started     time.Time                   `json:"started"`
+	Uptime      float64                     `json:"uptime_seconds"`
+	Connected   bool                        `json:"connected"`
+	Paused      bool                        `json:"paused"`
+	PausedRules map[string][]AutomationRule `json:"paused_rules,omitempty"` // 按交易对暂停的自动化规则
+	Build       BuildInfo                   `json:"build"`
+	LastPoll    time.Time                   `json:"last_poll"`
+	LastError   string                      `json:"last_error,omitempty"`
+	Protect     ProtectConfig               `json:"protect"` // 主交易对的参数
+	Symbols     []ProtectConfig             `json:"symbols"` // 管理的所有交易对的参数，主交易对排在第一个
+	Positions   []PositionJSON              `json:"positions"`
+	Protections []ProtectionJSON            `json:"protections"`
+	Activity    []Activity                  `json:"activity"`
+}
+
+// 当前状态，activity为返回的最近活动记录数量
+func (t *TraderCLI) controlStatus(activity int) (*ControlStatus, error) {
+	protect := t.protectConfig()
+	s := &t.engine
+	s.mu.Lock()
+	status := &ControlStatus{
+		Profile:     t.profile,
+		PID:         os.Getpid(),
+		Started:     s.started,
+		Uptime:      time.Since(s.started).Seconds(),
+		Connected:   s.lastError == "" && !s.lastPoll.IsZero() && time.Since(s.lastPoll) < controlStaleAfter,
+		Paused:      s.paused,
+		Build:       currentBuildInfo(),
+		LastPoll:    s.lastPoll,
+		LastError:   s.lastError,
+		Protect:     protect,
+		Positions:   append([]PositionJSON{}, s.positions...),
+		PausedRules: t.automation.Paused(),
+	}
+	for _, e := range t.engines {
+		status.Symbols = append(status.Symbols, e.config())
+	}
+	for symbol, maxProfit := range s.maxProfit {
+		e := t.engineFor(symbol)
+		if e == nil {
+			continue
+		}
+		config := e.config()
+		status.Protections = append(status.Protections, ProtectionJSON{
+			Symbol:    symbol,
+			MaxProfit: maxProfit,
+			ArmProfit: config.ArmProfit,
+			KeepRatio: e.spikes.ProtectRatio(config.KeepRatio),
+			Armed:     maxProfit >= config.ArmProfit,
+		})
+	}
+	s.mu.Unlock()
+
+	var err error
+	if status.Activity, err = t.activity.Query(ActivityQuery{Limit: activity}); err != nil {
+		return nil, fmt.Errorf(T("查询活动记录失败: %v"), err)
+	}
+	return status, nil
+}
+
+// 启动控制接口，地址为off时不启动。接口需要令牌，避免本机其他用户或网页发来的请求
+func (t *TraderCLI) startControl() error {
+	t.engine.mu.Lock()
+	t.engine.started = time.Now()
+	t.engine.mu.Unlock()
+	if t.controlAddr == "off" {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", t.controlAddr)
+	if err != nil {
+		return err
+	}
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		listener.Close()
+		return err
+	}
+	info := controlInfo{
+		Addr:    listener.Addr().String(),
+		Token:   hex.EncodeToString(token),
+		PID:     os.Getpid(),
+		Started: t.engine.started,
+	}
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		listener.Close()
+		return err
+	}
+	if err := os.WriteFile(profilePath(t.profile, controlFile), data, 0600); err != nil {
+		listener.Close()
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		activity, _ := strconv.Atoi(r.URL.Query().Get("activity"))
+		status, err := t.controlStatus(activity)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(status)
+	})
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(t.controlMetrics())
+	})
+	mux.HandleFunc("POST /killswitch", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(t.flattenAll())
+	})
+	mux.HandleFunc("POST /resume", func(w http.ResponseWriter, r *http.Request) {
+		t.engine.setPaused(false)
+		log.Print(T("已通过控制接口恢复自动化"))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]bool{"paused": false})
+	})
+	mux.HandleFunc("POST /automation", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		rule, err := parseAutomationRule(query.Get("rule"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		symbol := query.Get("symbol")
+		if t.engineFor(symbol) == nil {
+			http.Error(w, fmt.Sprintf(T("监控程序没有管理%s"), symbol), http.StatusBadRequest)
+			return
+		}
+		enabled := query.Get("enabled") == "true"
+		t.automation.SetEnabled(symbol, rule, enabled)
+		if enabled {
+			log.Printf(T("已通过控制接口恢复%s的%s"), symbol, rule)
+		} else {
+			log.Printf(T("已通过控制接口暂停%s的%s"), symbol, rule)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(t.automation.Paused())
+	})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+info.Token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+	go func() {
+		if err := http.Serve(listener, handler); err != nil {
+			warnf(T("控制接口停止: %v"), err)
+		}
+	}()
+	log.Printf(T("控制接口监听 %s"), info.Addr)
+	return nil
+}
+
+// 请求运行中的监控程序的控制接口，结果解析到v
+func (t *TraderCLI) controlRequest(method, path string, timeout time.Duration, v interface{}) error {
+	data, err := os.ReadFile(profilePath(t.profile, controlFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return errors.New(T("没有找到运行中的监控程序，请先运行 protect run"))
+	}
+	if err != nil {
+		return fmt.Errorf(T("读取控制接口信息失败: %v"), err)
+	}
+	var info controlInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return fmt.Errorf(T("读取控制接口信息失败: %v"), err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, "http://"+info.Addr+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+info.Token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf(T("连接监控程序失败(PID %d): %v"), info.PID, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf(T("连接监控程序失败(PID %d): %v"), info.PID, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf(T("监控程序返回错误: %s"), string(body))
+	}
+	return json.Unmarshal(body, v)
+}
+
+// 查询运行中的监控程序: status [-activity N] [-output json]。
+// 连接断开时返回错误，退出码非0，可以在cron中检查
+func (t *TraderCLI) status(args []string) error {
+	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	activity := fs.Int("activity", 10, T("显示最近几条活动记录"))
+	timeout := fs.Duration("timeout", 5*time.Second, T("连接监控程序的超时时间"))
+	output := outputFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+
+	var status ControlStatus
+	if err := t.controlRequest(http.MethodGet, "/status?activity="+strconv.Itoa(*activity), *timeout, &status); err != nil {
+		return err
+	}
+	if *output == "json" {
+		if err := writeJSON(status); err != nil {
+			return err
+		}
+	} else {
+		printControlStatus(&status)
+	}
+	if !status.Connected {
+		return errors.New(T("监控程序与交易所的连接异常"))
+	}
+	return nil
+}
+
+func printControlStatus(s *ControlStatus) {
+	uptime := time.Duration(s.Uptime * float64(time.Second)).Round(time.Second)
+	fmt.Printf(T("监控程序 PID %d，已运行 %s\n"), s.PID, uptime)
+	fmt.Println(s.Build)
+	if s.Connected {
+		fmt.Printf(T("连接正常，最后更新 %s\n"), s.LastPoll.Format("15:04:05"))
+	} else if s.LastPoll.IsZero() {
+		fmt.Printf(T("连接异常，还没有获取到持仓: %s\n"), s.LastError)
+	} else {
+		fmt.Printf(T("连接异常，最后更新 %s: %s\n"), s.LastPoll.Format("2006-01-02 15:04:05"), s.LastError)
+	}
+	if s.Paused {
+		fmt.Println(T("自动化已暂停，用 killswitch -resume 恢复"))
+	}
+	printPausedRules(s.PausedRules)
+	for _, p := range s.Symbols {
+		fmt.Printf(T("止盈止损参数: %s 止损距离 %g 止盈距离 %g 保护止盈启动 %g 回撤 %.0f%%\n"),
+			p.Symbol, p.StopLoss, p.TakeProfit, p.ArmProfit, (1-p.KeepRatio)*100)
+	}
+
+	fmt.Println()
+	if len(s.Positions) == 0 {
+		fmt.Println(T("没有持仓"))
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, T("交易对\t方向\t数量\t入场价\t标记价\t未实现盈亏"))
+		for _, p := range s.Positions {
+			fmt.Fprintf(w, "%s\t%s\t%.4f\t%.4f\t%.4f\t%+.2f\n",
+				p.Symbol, p.Side, p.Quantity, p.EntryPrice, p.MarkPrice, p.UnrealizedPnL)
+		}
+		w.Flush()
+	}
+
+	if len(s.Protections) > 0 {
+		fmt.Println()
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, T("交易对\t最高盈利\t启动盈利\t保留比例\t保护止盈"))
+		for _, p := range s.Protections {
+			state := T("未启动")
+			if p.Armed {
+				state = T("已启动")
+			}
+			fmt.Fprintf(w, "%s\t%.2f\t%.2f\t%.0f%%\t%s\n", p.Symbol, p.MaxProfit, p.ArmProfit, p.KeepRatio*100, state)
+		}
+		w.Flush()
+	}
+
+	if len(s.Activity) > 0 {
+		fmt.Println()
+		fmt.Println(T("最近活动:"))
+		for _, a := range s.Activity {
+			fmt.Println(a)
+		}
+	}
+}
+
+// 紧急停止的结果，ok为false时有订单没有撤销或持仓没有平掉
+type KillswitchResult struct {
+	OK        bool              `json:"ok"`
+	Paused    bool              `json:"paused"`
+	Errors    []string          `json:"errors,omitempty"` // 获取订单或持仓失败
+	Cancelled []KillswitchOrder `json:"cancelled"`
+	Closed    []KillswitchClose `json:"closed"`
+}
+
+// 一个交易对撤销的订单
+type KillswitchOrder struct {
+	Symbol string `json:"symbol"`
+	Orders int    `json:"orders"`
+	Error  string `json:"error,omitempty"`
+}
+
+// 一个平掉的持仓
+type KillswitchClose struct {
+	Symbol   string  `json:"symbol"`
+	Side     string  `json:"side"` // long或short
+	Quantity float64 `json:"qty"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// 紧急停止: 暂停自动化，以只减仓的市价单平掉所有持仓，再撤销所有交易对的挂单。
+// 先平仓，平仓失败的交易对保留挂单，止损单仍然保护着持仓
+func (t *TraderCLI) flattenAll() *KillswitchResult {
+	t.engine.setPaused(true)
+	t.engine.cycle.Lock()
+	defer t.engine.cycle.Unlock()
+	warnf(T("紧急停止: 平掉所有持仓并撤销所有订单"))
+
+	ctx := context.Background()
+	result := &KillswitchResult{OK: true, Paused: true, Cancelled: []KillswitchOrder{}, Closed: []KillswitchClose{}}
+	fail := func(format string, err error) {
+		result.OK = false
+		result.Errors = append(result.Errors, fmt.Sprintf(T(format), err))
+	}
+
+	positions, err := t.client.NewGetPositionRiskService().Do(ctx)
+	if err != nil {
+		fail("获取持仓信息失败: %v", err)
+	}
+	unclosed := make(map[string]bool)
+	for _, p := range positions {
+		amt, _ := strconv.ParseFloat(p.PositionAmt, 64)
+		if amt == 0 {
+			continue
+		}
+		c := KillswitchClose{Symbol: p.Symbol, Side: "long", Quantity: math.Abs(amt)}
+		side := futures.SideTypeSell
+		if amt < 0 {
+			c.Side, side = "short", futures.SideTypeBuy
+		}
+		order := t.client.NewCreateOrderService().
+			Symbol(p.Symbol).
+			NewClientOrderID(newClientOrderID(tagKill)).
+			Side(side).
+			Type(futures.OrderTypeMarket).
+			Quantity(strings.TrimPrefix(p.PositionAmt, "-"))
+		// 双向持仓模式下按持仓方向平仓，不能指定只减仓
+		if p.PositionSide != "" && p.PositionSide != string(futures.PositionSideTypeBoth) {
+			order.PositionSide(futures.PositionSideType(p.PositionSide))
+		} else {
+			order.PositionSide(futures.PositionSideTypeBoth).ReduceOnly(true)
+		}
+		_, err := order.Do(ctx)
+
+		markPrice, _ := strconv.ParseFloat(p.MarkPrice, 64)
+		activity := Activity{
+			Symbol:   p.Symbol,
+			Action:   ActivityKill,
+			Price:    markPrice,
+			Quantity: c.Quantity,
+			Reason:   fmt.Sprintf(T("紧急停止，市价平掉%s %.4f"), positionDirection(amt), c.Quantity),
+		}
+		if err != nil {
+			c.Error = err.Error()
+			activity.Error = err.Error()
+			result.OK = false
+			warnf(T("%s 平仓失败: %v"), p.Symbol, err)
+			unclosed[p.Symbol] = true
+		}
+		t.activity.Record(activity)
+		result.Closed = append(result.Closed, c)
+	}
+
+	orders, err := t.client.NewListOpenOrdersService().Do(ctx)
+	if err != nil {
+		fail("获取订单失败: %v", err)
+		return result
+	}
+	var symbols []string
+	counts := make(map[string]int)
+	for _, o := range orders {
+		if counts[o.Symbol] == 0 {
+			symbols = append(symbols, o.Symbol)
+		}
+		counts[o.Symbol]++
+	}
+	for _, symbol := range symbols {
+		c := KillswitchOrder{Symbol: symbol, Orders: counts[symbol]}
+		if unclosed[symbol] {
+			c.Error = T("持仓没有平掉，保留挂单")
+			result.Cancelled = append(result.Cancelled, c)
+			continue
+		}
+		if err := t.client.NewCancelAllOpenOrdersService().Symbol(symbol).Do(ctx); err != nil {
+			c.Error = err.Error()
+			result.OK = false
+			warnf(T("撤销%s的订单失败: %v"), symbol, err)
+		}
+		result.Cancelled = append(result.Cancelled, c)
+	}
+	return result
+}
+
+// 紧急停止: killswitch [-y] [-output json]，通过控制接口让运行中的监控程序平掉所有持仓、
+// 撤销所有订单并暂停自动化。killswitch -resume 恢复自动化。有失败时退出码非0
+func (t *TraderCLI) killswitch(args []string) error {
+	fs := flag.NewFlagSet("killswitch", flag.ContinueOnError)
+	yes := fs.Bool("y", false, T("不确认直接执行"))
+	resume := fs.Bool("resume", false, T("恢复自动化"))
+	timeout := fs.Duration("timeout", 30*time.Second, T("等待监控程序执行完成的超时时间"))
+	output := outputFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+
+	if *resume {
+		var res map[string]bool
+		if err := t.controlRequest(http.MethodPost, "/resume", *timeout, &res); err != nil {
+			return err
+		}
+		if *output == "json" {
+			return writeJSON(res)
+		}
+		fmt.Println(T("已恢复自动化"))
+		return nil
+	}
+
+	if !*yes && !confirm(T("市价平掉所有持仓、撤销所有订单并暂停自动化，确定吗？")) {
+		fmt.Println(T("已取消"))
+		return nil
+	}
+	var result KillswitchResult
+	if err := t.controlRequest(http.MethodPost, "/killswitch", *timeout, &result); err != nil {
+		return err
+	}
+	if *output == "json" {
+		if err := writeJSON(result); err != nil {
+			return err
+		}
+	} else {
+		for _, c := range result.Cancelled {
+			if c.Error != "" {
+				fmt.Printf(T("%s 撤销%d个订单失败: %s\n"), c.Symbol, c.Orders, c.Error)
+			} else {
+				fmt.Printf(T("%s 已撤销%d个订单\n"), c.Symbol, c.Orders)
+			}
+		}
+		for _, c := range result.Closed {
+			if c.Error != "" {
+				fmt.Printf(T("%s %s %.4f 平仓失败: %s\n"), c.Symbol, c.Side, c.Quantity, c.Error)
+			} else {
+				fmt.Printf(T("%s %s %.4f 已市价平仓\n"), c.Symbol, c.Side, c.Quantity)
+			}
+		}
+		for _, e := range result.Errors {
+			fmt.Println(e)
+		}
+		fmt.Println(T("自动化已暂停，用 killswitch -resume 恢复"))
+	}
+	if !result.OK {
+		return errors.New(T("紧急停止没有全部完成"))
+	}
+	return nil
+}
+
+func printPausedRules(paused map[string][]AutomationRule) {
+	symbols := make([]string, 0, len(paused))
+	for symbol := range paused {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	for _, symbol := range symbols {
+		fmt.Printf(T("%s 已暂停: %s\n"), symbol, joinRules(paused[symbol]))
+	}
+}
+
+func joinRules(rules []AutomationRule) string {
+	names := make([]string, len(rules))
+	for i, r := range rules {
+		names[i] = string(r)
+	}
+	return strings.Join(names, ", ")
+}
+
+// 暂停或恢复一个交易对的单条自动化规则: automation pause|resume RULE [-symbol SOLUSDC]，
+// 规则为stop_loss/take_profit/protect。通过控制接口修改运行中的监控程序，不带参数时显示暂停的规则。
+// 重启后恢复为配置文件中的paused_rules
+func (t *TraderCLI) automationCommand(args []string) error {
+	fs := flag.NewFlagSet("automation", flag.ContinueOnError)
+	symbol := fs.String("symbol", t.protectConfig().Symbol, T("交易对"))
+	timeout := fs.Duration("timeout", 5*time.Second, T("连接监控程序的超时时间"))
+	output := outputFlag(fs)
+	positional, err := parsePositional(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+
+	var paused map[string][]AutomationRule
+	switch {
+	case len(positional) == 0:
+		var status ControlStatus
+		if err := t.controlRequest(http.MethodGet, "/status", *timeout, &status); err != nil {
+			return err
+		}
+		paused = status.PausedRules
+	case len(positional) == 2 && (positional[0] == "pause" || positional[0] == "resume"):
+		rule, err := parseAutomationRule(positional[1])
+		if err != nil {
+			return err
+		}
+		enabled := positional[0] == "resume"
+		// 与界面一致，关闭自动止损需要输入PIN
+		if !enabled && rule == RuleStopLoss {
+			if err := t.requirePIN(T("关闭自动止损")); err != nil {
+				return err
+			}
+		}
+		query := url.Values{
+			"symbol":  {strings.ToUpper(*symbol)},
+			"rule":    {string(rule)},
+			"enabled": {strconv.FormatBool(enabled)},
+		}
+		if err := t.controlRequest(http.MethodPost, "/automation?"+query.Encode(), *timeout, &paused); err != nil {
+			return err
+		}
+	default:
+		return errors.New(T("用法: automation [pause|resume stop_loss|take_profit|protect] [-symbol SOLUSDC]"))
+	}
+
+	if *output == "json" {
+		if paused == nil {
+			paused = map[string][]AutomationRule{}
+		}
+		return writeJSON(paused)
+	}
+	if len(paused) == 0 {
+		fmt.Println(T("所有自动化规则都在运行"))
+		return nil
+	}
+	printPausedRules(paused)
+	return nil
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// 诊断结果
+const (
+	checkPass = "pass"
+	checkWarn = "warn"
+	checkFail = "fail"
+)
+
+var checkLabels = map[string]string{
+	checkPass: "通过",
+	checkWarn: "警告",
+	checkFail: "失败",
+}
+
+// 一项诊断检查
+type doctorCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // pass/warn/fail
+	Detail string `json:"detail"`
+}
+
+// 诊断报告，某一项失败时后面依赖它的检查可能无法进行
+type doctorReport struct {
+	Checks []doctorCheck `json:"checks"`
+}
+
+func (r *doctorReport) add(name, status, format string, args ...interface{}) {
+	r.Checks = append(r.Checks, doctorCheck{Name: name, Status: status, Detail: fmt.Sprintf(format, args...)})
+}
+
+// 失败的检查数量
+func (r *doctorReport) failures() int {
+	n := 0
+	for _, c := range r.Checks {
+		if c.Status == checkFail {
+			n++
+		}
+	}
+	return n
+}
+
+// 检查API密钥、权限、IP限制、时钟偏差、持仓模式、杠杆和交易规则，
+// 在用真实资金运行监控前确认环境没有问题
+func (t *TraderCLI) doctor(args []string) error {
+	protect := t.protectConfig()
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	symbol := fs.String("symbol", protect.Symbol, T("检查的交易对"))
+	maxLeverage := fs.Int("max-leverage", 20, T("杠杆超过该值时警告"))
+	output := outputFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+	protect.Symbol = strings.ToUpper(*symbol)
+
+	report := t.runDoctor(protect, *maxLeverage)
+	if *output == outputJSON {
+		if err := writeJSON(report); err != nil {
+			return err
+		}
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, c := range report.Checks {
+			fmt.Fprintf(w, "[%s]\t%s\t%s\n", T(checkLabels[c.Status]), T(c.Name), c.Detail)
+		}
+		w.Flush()
+	}
+	if n := report.failures(); n > 0 {
+		return fmt.Errorf(T("%d项检查未通过"), n)
+	}
+	return nil
+}
+
+func (t *TraderCLI) runDoctor(protect ProtectConfig, maxLeverage int) *doctorReport {
+	report := &doctorReport{}
+	ctx := context.Background()
+
+	// 密钥无效时其他需要签名的接口都会失败，不再继续
+	account, err := t.client.NewGetAccountService().Do(ctx)
+	if err != nil {
+		report.add("API密钥", checkFail, T("API密钥验证失败: %v"), err)
+		return report
+	}
+	if account.CanTrade {
+		report.add("API密钥", checkPass, T("期货账户可交易"))
+	} else {
+		report.add("API密钥", checkFail, T("期货账户当前不可交易"))
+	}
+
+	// 密钥权限接口在现货API上，部分子账户查询不到
+	permission, err := t.spotClient.NewGetAPIKeyPermission().Do(ctx)
+	if err != nil {
+		report.add("期货权限", checkWarn, T("无法查询密钥权限: %v"), err)
+		report.add("IP限制", checkWarn, T("无法查询密钥权限: %v"), err)
+	} else {
+		if permission.EnableFutures {
+			report.add("期货权限", checkPass, T("已开通期货交易权限"))
+		} else {
+			report.add("期货权限", checkFail, T("API密钥未开通期货交易权限"))
+		}
+		switch {
+		case !permission.IPRestrict:
+			report.add("IP限制", checkWarn, T("密钥没有限制IP，泄露后可以在任何地方使用"))
+		case permission.EnableWithdrawals:
+			report.add("IP限制", checkWarn, T("已限制IP，但密钥开通了提现权限"))
+		default:
+			report.add("IP限制", checkPass, T("已限制IP，未开通提现权限"))
+		}
+	}
+
+	// 请求时间戳比服务器快1秒以上或超过recvWindow时交易所会拒绝请求
+	before := time.Now()
+	serverTime, err := t.client.NewServerTimeService().Do(ctx)
+	after := time.Now()
+	if err != nil {
+		report.add("时钟偏差", checkFail, T("获取服务器时间失败: %v"), err)
+	} else {
+		local := before.Add(after.Sub(before) / 2)
+		skew := time.UnixMilli(serverTime).Sub(local)
+		status := checkPass
+		if math.Abs(float64(skew.Milliseconds())) > 1000 {
+			status = checkFail
+		} else if math.Abs(float64(skew.Milliseconds())) > 500 {
+			status = checkWarn
+		}
+		report.add("时钟偏差", status, T("本机时间与服务器相差%dms，请求往返%dms"),
+			-skew.Milliseconds(), after.Sub(before).Milliseconds())
+	}
+
+	// 程序下单都使用单向持仓(BOTH)，双向持仓模式下会被拒绝
+	mode, err := t.client.NewGetPositionModeService().Do(ctx)
+	switch {
+	case err != nil:
+		report.add("持仓模式", checkFail, T("获取持仓模式失败: %v"), err)
+	case mode.DualSidePosition:
+		report.add("持仓模式", checkFail, T("当前为双向持仓，请在交易所切换为单向持仓"))
+	default:
+		report.add("持仓模式", checkPass, T("单向持仓"))
+	}
+
+	// 没有持仓时持仓风险接口也会返回交易对的杠杆设置
+	positions, err := t.client.NewGetPositionRiskService().Symbol(protect.Symbol).Do(ctx)
+	switch {
+	case err != nil:
+		report.add("杠杆", checkFail, T("获取%s的杠杆失败: %v"), protect.Symbol, err)
+	case len(positions) == 0:
+		report.add("杠杆", checkFail, T("未找到交易对: %s"), protect.Symbol)
+	default:
+		leverage, _ := strconv.Atoi(positions[0].Leverage)
+		status := checkPass
+		if leverage > maxLeverage {
+			status = checkWarn
+		}
+		report.add("杠杆", status, T("%s %dx，保证金模式 %s"), protect.Symbol, leverage, positions[0].MarginType)
+	}
+
+	// 止盈止损价按配置的精度取整，精度比交易所粗没有问题，比交易所细时下单会被拒绝
+	filters, err := NewSymbolFilterCache(t.client).Get(protect.Symbol)
+	if err != nil {
+		report.add("交易规则", checkFail, "%v", err)
+		return report
+	}
+	detail := fmt.Sprintf(T("%s 价格精度 %s，数量精度 %s，最小数量 %s"), protect.Symbol,
+		formatFloat(filters.TickSize), formatFloat(filters.StepSize), formatFloat(filters.MinQty))
+	if !isMultipleOf(protect.TickSize, filters.TickSize) {
+		report.add("交易规则", checkFail, T("%s；配置的价格精度%s不是交易所精度的整数倍"), detail, formatFloat(protect.TickSize))
+	} else {
+		report.add("交易规则", checkPass, "%s", detail)
+	}
+	return report
+}
+
+// v是否是step的整数倍，允许浮点误差
+func isMultipleOf(v, step float64) bool {
+	if step <= 0 {
+		return true
+	}
+	n := v / step
+	return n >= 1-1e-9 && math.Abs(n-math.Round(n)) < 1e-6
+}
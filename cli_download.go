@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// 下载历史K线和资金费率到本地: download SOLUSDC 5m -from 2023-01-01 [-to 2024-01-01]，
+// 回测指定-from时读取这些数据。中断后重新运行会从上次保存的位置继续
+func (t *TraderCLI) download(args []string) error {
+	fs := flag.NewFlagSet("download", flag.ContinueOnError)
+	symbol := fs.String("symbol", t.protectConfig().Symbol, T("交易对"))
+	interval := fs.String("interval", "5m", T("K线周期"))
+	from := fs.String("from", "", T("起始日期(YYYY-MM-DD)"))
+	to := fs.String("to", "", T("结束日期(YYYY-MM-DD，不含当天)，默认到现在"))
+	funding := fs.Bool("funding", true, T("同时下载资金费率"))
+	dir := fs.String("dir", historyDir, T("历史数据目录"))
+	positional, err := parsePositional(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 2 {
+		return fmt.Errorf(T("多余的参数: %s"), strings.Join(positional[2:], " "))
+	}
+	if len(positional) > 0 {
+		*symbol = positional[0]
+	}
+	if len(positional) > 1 {
+		*interval = positional[1]
+	}
+
+	if *from == "" {
+		return errors.New(T("请用-from指定起始日期"))
+	}
+	start, err := time.ParseInLocation(time.DateOnly, *from, time.Local)
+	if err != nil {
+		return fmt.Errorf(T("日期格式错误: %s"), *from)
+	}
+	end := time.Now()
+	if *to != "" {
+		if end, err = time.ParseInLocation(time.DateOnly, *to, time.Local); err != nil {
+			return fmt.Errorf(T("日期格式错误: %s"), *to)
+		}
+	}
+	if !end.After(start) {
+		return errors.New(T("结束日期必须晚于起始日期"))
+	}
+
+	// 根据交易所返回的已用权重限速，避免批量下载触发封禁
+	store := NewHistoryStore(t.client, *dir, t.monitor)
+	store.Logf = log.Printf
+
+	sym := strings.ToUpper(*symbol)
+	n, err := store.DownloadKlines(sym, *interval, start, end)
+	if err != nil {
+		return err
+	}
+	fmt.Printf(T("%s %s 新下载%d根K线，保存在 %s\n"), sym, *interval, n, *dir)
+
+	if *funding {
+		n, err := store.DownloadFunding(sym, start, end)
+		if err != nil {
+			return err
+		}
+		fmt.Printf(T("%s 新下载%d条资金费率\n"), sym, n)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+// 一个交易对的监控实例。run可以同时管理多个交易对，所有实例共用客户端、持仓查询、
+// 提醒和活动记录，止盈止损参数和价格异动检测各自独立
+type symbolEngine struct {
+	protect atomic.Pointer[ProtectConfig]
+	spikes  *SpikeDetector
+}
+
+func newSymbolEngine(protect ProtectConfig, spike SpikeConfig) *symbolEngine {
+	e := &symbolEngine{spikes: NewSpikeDetector(spike)}
+	e.setConfig(protect)
+	e.spikes.OnSpike = func(s *Spike) {
+		config := e.config()
+		log.Printf(T("价格异动提醒: %s %s"), config.Symbol, s)
+		if spike.TightenStops {
+			log.Printf(T("已收紧保护止盈，回撤到最高盈利的%.0f%%时平仓"), e.spikes.ProtectRatio(config.KeepRatio)*100)
+		}
+	}
+	return e
+}
+
+func (e *symbolEngine) config() ProtectConfig {
+	return *e.protect.Load()
+}
+
+// 修改止盈止损参数，没有设置的参数使用默认值。交易对变化时清除价格异动的历史和收紧状态
+func (e *symbolEngine) setConfig(config ProtectConfig) {
+	config.applyDefaults()
+	if old := e.protect.Swap(&config); old != nil && old.Symbol != config.Symbol {
+		e.spikes.Reset()
+	}
+}
+
+// strategies中交易对没有填写的参数沿用protect的设置
+func (c ProtectConfig) inherit(base ProtectConfig) ProtectConfig {
+	if c.TakeProfit <= 0 {
+		c.TakeProfit = base.TakeProfit
+	}
+	if c.StopLoss <= 0 {
+		c.StopLoss = base.StopLoss
+	}
+	if c.TickSize <= 0 {
+		c.TickSize = base.TickSize
+	}
+	if c.ArmProfit <= 0 {
+		c.ArmProfit = base.ArmProfit
+	}
+	if c.KeepRatio <= 0 {
+		c.KeepRatio = base.KeepRatio
+	}
+	return c
+}
+
+// 设置主交易对以外同时管理的交易对。在strategies中有设置的交易对使用自己的参数，
+// 其他交易对使用base的参数。symbols为nil时管理strategies中的所有交易对
+func (t *TraderCLI) setExtraSymbols(symbols []string, base ProtectConfig) error {
+	own := make(map[string]ProtectConfig)
+	for _, c := range t.strategies {
+		own[strings.ToUpper(c.Symbol)] = c.inherit(base)
+	}
+	if symbols == nil {
+		for _, c := range t.strategies {
+			symbols = append(symbols, c.Symbol)
+		}
+	}
+
+	primary := t.protectConfig().Symbol
+	seen := map[string]bool{primary: true}
+	engines := []*symbolEngine{t.engines[0]}
+	for _, symbol := range symbols {
+		symbol = strings.ToUpper(symbol)
+		if seen[symbol] {
+			continue
+		}
+		seen[symbol] = true
+		config, ok := own[symbol]
+		if !ok {
+			config = base
+		}
+		config.Symbol = symbol
+		if err := config.Validate(); err != nil {
+			return fmt.Errorf(T("%s的止盈止损设置无效: %v"), symbol, err)
+		}
+		engines = append(engines, newSymbolEngine(config, t.spikeConfig))
+	}
+	t.engines = engines
+	return nil
+}
+
+// 交易对的监控实例，没有管理该交易对时返回nil
+func (t *TraderCLI) engineFor(symbol string) *symbolEngine {
+	for _, e := range t.engines {
+		if e.config().Symbol == symbol {
+			return e
+		}
+	}
+	return nil
+}
+
+// 管理的所有交易对，主交易对排在第一个
+func (t *TraderCLI) managedSymbols() []string {
+	symbols := make([]string, len(t.engines))
+	for i, e := range t.engines {
+		symbols[i] = e.config().Symbol
+	}
+	return symbols
+}
+
+// 可以指定多次的参数，如 --symbol SOLUSDC --symbol ETHUSDC，也可以用逗号分隔
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(s string) error {
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// 日志的输出，可以在运行时切换
+type logOutput struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (o *logOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.w.Write(p)
+}
+
+func (o *logOutput) Get() io.Writer {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.w
+}
+
+// 修改日志输出，交互模式和终端界面用它隐藏日志，不要直接调用log.SetOutput
+func (o *logOutput) Set(w io.Writer) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.w = w
+}
+
+var cliLogOutput = &logOutput{w: os.Stderr}
+
+// 从命令行参数中取出--quiet、--verbose和--log-format并设置日志，返回其余参数。
+// 日志分为三级，log.Printf输出为info:
+//
+//	debug 每次轮询核对持仓和订单的过程，--verbose时显示
+//	info  下单、撤单、保护止盈等自动化操作和提醒，默认显示
+//	warn  请求失败等错误，--quiet时只显示这一级
+//
+// --log-format json 时每行输出一个JSON对象，便于日志采集
+func parseLogFlags(args []string) ([]string, error) {
+	quiet, args := extractBoolFlag(args, "quiet")
+	verbose, args := extractBoolFlag(args, "verbose")
+	format, args := extractFlag(args, "log-format")
+
+	level := slog.LevelInfo
+	switch {
+	case quiet && verbose:
+		return nil, errors.New(T("--quiet和--verbose不能同时使用"))
+	case quiet:
+		level = slog.LevelWarn
+	case verbose:
+		level = slog.LevelDebug
+	}
+
+	var handler slog.Handler
+	switch format {
+	case "", "text":
+		handler = &textLogHandler{level: level}
+	case "json":
+		handler = slog.NewJSONHandler(cliLogOutput, &slog.HandlerOptions{Level: level})
+	default:
+		return nil, fmt.Errorf(T("不支持的日志格式: %s，可选text/json"), format)
+	}
+	// log包的输出也经过handler，级别为info
+	slog.SetDefault(slog.New(handler))
+	return args, nil
+}
+
+// 默认的文本日志，格式和log包相同，非info级别的日志加上级别
+type textLogHandler struct {
+	level slog.Level
+	attrs []slog.Attr
+}
+
+func (h *textLogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *textLogHandler) Handle(_ context.Context, r slog.Record) error {
+	var sb strings.Builder
+	sb.WriteString(r.Time.Format("2006/01/02 15:04:05 "))
+	if r.Level != slog.LevelInfo {
+		sb.WriteString(r.Level.String() + " ")
+	}
+	sb.WriteString(r.Message)
+	for _, a := range h.attrs {
+		fmt.Fprintf(&sb, " %s=%v", a.Key, a.Value)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		fmt.Fprintf(&sb, " %s=%v", a.Key, a.Value)
+		return true
+	})
+	sb.WriteByte('\n')
+	_, err := io.WriteString(cliLogOutput, sb.String())
+	return err
+}
+
+func (h *textLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &textLogHandler{level: h.level, attrs: append(append([]slog.Attr{}, h.attrs...), attrs...)}
+}
+
+func (h *textLogHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+// 按级别格式化输出，级别未开启时不格式化
+func logf(level slog.Level, format string, args ...interface{}) {
+	ctx := context.Background()
+	if slog.Default().Enabled(ctx, level) {
+		slog.Log(ctx, level, fmt.Sprintf(format, args...))
+	}
+}
+
+func debugf(format string, args ...interface{}) { logf(slog.LevelDebug, format, args...) }
+func warnf(format string, args ...interface{})  { logf(slog.LevelWarn, format, args...) }
+
+// 输出错误并退出。log.Fatalf的输出是info级别，--quiet时看不到
+func fatalf(format string, args ...interface{}) {
+	slog.Error(fmt.Sprintf(format, args...))
+	os.Exit(1)
+}
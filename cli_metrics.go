@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// metrics返回的内部计数器，进程启动后累计
+type MetricsJSON struct {
+	Started       time.Time          `json:"started"`
+	Uptime        float64            `json:"uptime_seconds"`
+	Counters      map[string]int64   `json:"counters"`
+	CacheHitRates map[string]float64 `json:"cache_hit_rates"` // 百分比，键为缓存名
+	LatencyMs     float64            `json:"latency_ms"`      // 最近一次API请求的延迟
+	UsedWeight    int                `json:"used_weight"`     // 当前一分钟内已用的请求权重
+}
+
+func (t *TraderCLI) controlMetrics() *MetricsJSON {
+	t.engine.mu.Lock()
+	started := t.engine.started
+	t.engine.mu.Unlock()
+	counters := metrics.Snapshot()
+	latency, weight := t.monitor.Stats()
+	return &MetricsJSON{
+		Started:       started,
+		Uptime:        time.Since(started).Seconds(),
+		Counters:      counters,
+		CacheHitRates: cacheHitRates(counters),
+		LatencyMs:     float64(latency.Microseconds()) / 1000,
+		UsedWeight:    weight,
+	}
+}
+
+// 显示运行中的监控程序的内部计数器: metrics [-timeout 5s] [-output json]。
+// 通过控制接口查询，不需要开启其他监控服务
+func (t *TraderCLI) metrics(args []string) error {
+	fs := flag.NewFlagSet("metrics", flag.ContinueOnError)
+	timeout := fs.Duration("timeout", 5*time.Second, T("连接监控程序的超时时间"))
+	output := outputFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+
+	var m MetricsJSON
+	if err := t.controlRequest(http.MethodGet, "/metrics", *timeout, &m); err != nil {
+		return err
+	}
+	if *output == "json" {
+		return writeJSON(m)
+	}
+
+	uptime := time.Duration(m.Uptime * float64(time.Second)).Round(time.Second)
+	fmt.Printf(T("监控程序已运行 %s，最近请求延迟 %.0fms，已用权重 %d/%d\n"), uptime, m.LatencyMs, m.UsedWeight, weightLimit)
+	fmt.Println()
+	if len(m.Counters) == 0 {
+		fmt.Println(T("还没有计数"))
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, T("计数器\t数量"))
+	for _, name := range sortedMetricNames(m.Counters) {
+		fmt.Fprintf(w, "%s\t%d\n", name, m.Counters[name])
+	}
+	w.Flush()
+
+	if len(m.CacheHitRates) > 0 {
+		fmt.Println()
+		caches := make([]string, 0, len(m.CacheHitRates))
+		for name := range m.CacheHitRates {
+			caches = append(caches, name)
+		}
+		sort.Strings(caches)
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, T("缓存\t命中率"))
+		for _, name := range caches {
+			fmt.Fprintf(w, "%s\t%.1f%%\n", name, m.CacheHitRates[name])
+		}
+		w.Flush()
+	}
+	return nil
+}
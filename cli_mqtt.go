@@ -0,0 +1,99 @@
+package main
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// MQTT价格主题的消息
+type MQTTPrice struct {
+	Symbol string    `json:"symbol"`
+	Price  float64   `json:"price"`
+	Time   time.Time `json:"time"`
+}
+
+// MQTT持仓主题的消息，只包含非零持仓
+type MQTTPositions struct {
+	Time      time.Time      `json:"time"`
+	Positions []PositionJSON `json:"positions"`
+}
+
+// 设置了mqtt时把管理的交易对的标记价格、每次轮询的持仓快照和推送事件发布到broker
+func (t *TraderCLI) startMQTT() error {
+	if t.mqttConfig == nil {
+		return nil
+	}
+	config := *t.mqttConfig
+	if config.ClientID == "" {
+		config.ClientID = "protect"
+		if t.profile != "" {
+			config.ClientID += "-" + t.profile
+		}
+	}
+	m, err := NewMQTT(config)
+	if err != nil {
+		return err
+	}
+	if err := t.notify.Add(m, config.Events); err != nil {
+		return err
+	}
+	t.mqtt = m
+	for _, symbol := range t.managedSymbols() {
+		t.publishPrices(symbol)
+	}
+	log.Printf(T("MQTT发布到 %s"), config.Broker)
+	return nil
+}
+
+// 订阅标记价格流(每3秒一次)，发布到价格主题，断开后自动重连
+func (t *TraderCLI) publishPrices(symbol string) {
+	topic := strings.ReplaceAll(t.mqtt.config.Topics.Price, "{symbol}", symbol)
+	handler := func(event *futures.WsMarkPriceEvent) {
+		price, err := strconv.ParseFloat(event.MarkPrice, 64)
+		if err != nil {
+			return
+		}
+		// broker不可用时每次推送都会失败，只在调试日志中打印
+		if err := t.mqtt.PublishJSON(topic, MQTTPrice{Symbol: symbol, Price: price, Time: time.UnixMilli(event.Time)}, true); err != nil {
+			debugf("%v", err)
+		}
+	}
+	go func() {
+		for {
+			doneC, _, err := futures.WsMarkPriceServe(symbol, handler, func(err error) {
+				debugf(T("标记价格数据流错误: %v"), err)
+			})
+			if err != nil {
+				warnf(T("订阅标记价格失败: %v"), err)
+				time.Sleep(5 * time.Second)
+				continue
+			}
+			<-doneC
+			metrics.Inc(metricWSReconnects)
+			time.Sleep(time.Second)
+		}
+	}()
+}
+
+// 发布持仓快照，在后台发送，不阻塞监控循环。上一次快照还没发完时丢弃这一次
+func (t *TraderCLI) publishPositions(positions []*futures.PositionRisk) {
+	if t.mqtt == nil || !t.mqttBusy.CompareAndSwap(false, true) {
+		return
+	}
+	snapshot := MQTTPositions{Time: time.Now(), Positions: []PositionJSON{}}
+	for _, p := range positions {
+		if amt, _ := strconv.ParseFloat(p.PositionAmt, 64); amt != 0 {
+			snapshot.Positions = append(snapshot.Positions, newPositionJSON(p))
+		}
+	}
+	go func() {
+		defer t.mqttBusy.Store(false)
+		if err := t.mqtt.PublishJSON(t.mqtt.config.Topics.Positions, snapshot, true); err != nil {
+			debugf("%v", err)
+		}
+	}()
+}
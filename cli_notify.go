@@ -0,0 +1,214 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 按配置启动推送通知: 订阅账户数据流推送成交，活动记录和提醒转发到各通知渠道，设置了
+// daily_summary时每天发送前一天的交易日报。Telegram启用了命令时接收手机发来的命令
+func (t *TraderCLI) startNotify() error {
+	config := t.notifyConfig
+	// 邮件主要用来发送日报，没有设置时间时使用默认时间
+	if config.Email != nil && config.DailySummary == "" {
+		config.DailySummary = defaultSummaryTime
+	}
+	var summaryAt time.Duration
+	if config.DailySummary != "" {
+		var err error
+		if summaryAt, err = parseDailyTime(config.DailySummary); err != nil {
+			return fmt.Errorf(T("每日汇总时间设置无效: %v"), err)
+		}
+	}
+
+	var telegram *Telegram
+	if config.Telegram != nil {
+		var err error
+		if telegram, err = NewTelegram(*config.Telegram); err != nil {
+			return err
+		}
+		if err := t.notify.Add(telegram, config.Telegram.Events); err != nil {
+			return err
+		}
+		t.registerTelegramCommands(telegram)
+	}
+	if config.Discord != nil {
+		discord, err := NewDiscord(*config.Discord)
+		if err != nil {
+			return err
+		}
+		if err := t.notify.Add(discord, config.Discord.Events); err != nil {
+			return err
+		}
+	}
+	for i, c := range config.Webhooks {
+		if c.Name == "" && i > 0 {
+			c.Name = fmt.Sprintf("webhook%d", i+1)
+		}
+		webhook, err := NewWebhook(c)
+		if err != nil {
+			return err
+		}
+		if err := t.notify.Add(webhook, c.Events); err != nil {
+			return err
+		}
+	}
+	if config.WeCom != nil {
+		wecom, err := NewWeCom(*config.WeCom)
+		if err != nil {
+			return err
+		}
+		if err := t.notify.Add(wecom, config.WeCom.Events); err != nil {
+			return err
+		}
+	}
+	if config.DingTalk != nil {
+		dingtalk, err := NewDingTalk(*config.DingTalk)
+		if err != nil {
+			return err
+		}
+		if err := t.notify.Add(dingtalk, config.DingTalk.Events); err != nil {
+			return err
+		}
+	}
+	if config.Pushover != nil {
+		pushover, err := NewPushover(*config.Pushover)
+		if err != nil {
+			return err
+		}
+		if err := t.notify.AddFiltered(pushover, config.Pushover.Events, pushover.config.MinSeverity); err != nil {
+			return err
+		}
+	}
+	if config.Bark != nil {
+		bark, err := NewBark(*config.Bark)
+		if err != nil {
+			return err
+		}
+		if err := t.notify.AddFiltered(bark, config.Bark.Events, bark.config.MinSeverity); err != nil {
+			return err
+		}
+	}
+	if config.Email != nil {
+		email, err := NewEmail(*config.Email)
+		if err != nil {
+			return err
+		}
+		if err := t.notify.Add(email, email.Events()); err != nil {
+			return err
+		}
+	}
+	if len(t.notify.Names()) == 0 {
+		return nil
+	}
+
+	t.notify.RegisterAlertChannels(t.alerts)
+	t.activity.OnRecord = func(a Activity) {
+		t.notify.Send(activityEvent(a))
+	}
+	stream := NewUserStream(t.client)
+	stream.OnFill = func(f Fill) {
+		t.notify.Send(fillEvent(f))
+	}
+	stream.Start()
+	if config.DailySummary != "" {
+		go t.sendDailySummaries(summaryAt)
+	}
+	if telegram != nil {
+		telegram.Start()
+	}
+	log.Printf(T("推送通知: %s"), strings.Join(t.notify.Names(), ", "))
+	return nil
+}
+
+// 每天at时刻发送前一天的交易日报
+func (t *TraderCLI) sendDailySummaries(at time.Duration) {
+	for {
+		next := nextDailyTime(time.Now(), at)
+		time.Sleep(time.Until(next))
+
+		end := periodStart(next, statsDay)
+		report, err := buildPeriodReport(t.client, t.activity, statsDay, end.AddDate(0, 0, -1), end)
+		if err != nil {
+			warnf(T("生成交易日报失败: %v"), err)
+			continue
+		}
+		title, body, _ := strings.Cut(strings.TrimSpace(report.String()), "\n")
+		t.notify.Send(NotifyEvent{
+			Type:    EventSummary,
+			Title:   title,
+			Message: body,
+			Fields: map[string]float64{
+				"realized": report.Realized,
+				"fees":     report.Fees,
+				"funding":  report.Funding,
+				"net":      report.Net(),
+				"trades":   float64(len(report.Trades)),
+			},
+		})
+	}
+}
+
+// Telegram命令: /positions 显示持仓，/close SYMBOL|all 市价平仓，/pause 暂停自动化，/resume 恢复
+func (t *TraderCLI) registerTelegramCommands(b *Telegram) {
+	b.Handle("positions", func(args []string) (string, error) {
+		positions, err := t.openPositions("")
+		if err != nil {
+			return "", err
+		}
+		if len(positions) == 0 {
+			return T("无持仓"), nil
+		}
+		var lines []string
+		for _, p := range positions {
+			amt, _ := strconv.ParseFloat(p.PositionAmt, 64)
+			pnl, _ := strconv.ParseFloat(p.UnRealizedProfit, 64)
+			lines = append(lines, fmt.Sprintf(T("%s %s %.4f @ %s 标记价 %s 未实现盈亏 %+.2f"),
+				p.Symbol, positionDirection(amt), math.Abs(amt), p.EntryPrice, p.MarkPrice, pnl))
+		}
+		return strings.Join(lines, "\n"), nil
+	})
+
+	b.Handle("close", func(args []string) (string, error) {
+		if len(args) != 1 {
+			return "", errors.New(T("用法: /close SYMBOL 或 /close all"))
+		}
+		filter := strings.ToUpper(args[0])
+		if filter == "ALL" {
+			filter = ""
+		}
+		positions, err := t.openPositions(filter)
+		if err != nil {
+			return "", err
+		}
+		if len(positions) == 0 {
+			return T("无持仓"), nil
+		}
+		var lines []string
+		for _, p := range positions {
+			if err := t.closePosition(p); err != nil {
+				lines = append(lines, err.Error())
+				continue
+			}
+			lines = append(lines, fmt.Sprintf(T("已市价平仓: %s %s"), p.Symbol, p.PositionAmt))
+		}
+		return strings.Join(lines, "\n"), nil
+	})
+
+	b.Handle("pause", func(args []string) (string, error) {
+		t.engine.setPaused(true)
+		log.Print(T("已通过Telegram暂停自动化"))
+		return T("自动化已暂停，止盈止损和策略不再执行，发送 /resume 恢复"), nil
+	})
+
+	b.Handle("resume", func(args []string) (string, error) {
+		t.engine.setPaused(false)
+		log.Print(T("已通过Telegram恢复自动化"))
+		return T("自动化已恢复"), nil
+	})
+}
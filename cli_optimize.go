@@ -0,0 +1,260 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+// 参数组合数量上限，避免范围写错时跑很久
+const maxOptimizeRuns = 10000
+
+// 一个参数的取值范围，from到to(含)，步长step
+type paramRange struct {
+	Name           string
+	From, To, Step float64
+}
+
+func (r paramRange) values() []float64 {
+	var values []float64
+	// 按步数计算而不是累加，避免浮点误差多出或少掉最后一个值
+	n := int(math.Floor((r.To-r.From)/r.Step+1e-9)) + 1
+	for i := 0; i < n; i++ {
+		values = append(values, r.From+float64(i)*r.Step)
+	}
+	return values
+}
+
+// 解析参数范围，如 fast=5:20:5,slow=20:60:10，单个值表示固定不变，省略步长时为1
+func parseParamRanges(s string) ([]paramRange, error) {
+	var ranges []paramRange
+	for _, kv := range strings.Split(s, ",") {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf(T("参数范围格式错误: %s"), kv)
+		}
+		bounds := strings.Split(parts[1], ":")
+		if len(bounds) > 3 {
+			return nil, fmt.Errorf(T("参数范围格式错误: %s"), kv)
+		}
+		nums := make([]float64, len(bounds))
+		for i, b := range bounds {
+			v, err := strconv.ParseFloat(strings.TrimSpace(b), 64)
+			if err != nil {
+				return nil, fmt.Errorf(T("参数范围格式错误: %s"), kv)
+			}
+			nums[i] = v
+		}
+		r := paramRange{Name: strings.TrimSpace(parts[0]), From: nums[0], To: nums[0], Step: 1}
+		if len(nums) > 1 {
+			r.To = nums[1]
+		}
+		if len(nums) > 2 {
+			r.Step = nums[2]
+		}
+		if r.Step <= 0 || r.To < r.From {
+			return nil, fmt.Errorf(T("参数范围格式错误: %s"), kv)
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+// 所有参数组合，base中的固定参数加在每个组合里
+func paramGrid(base map[string]float64, ranges []paramRange) []map[string]float64 {
+	grid := []map[string]float64{base}
+	for _, r := range ranges {
+		var next []map[string]float64
+		for _, params := range grid {
+			for _, v := range r.values() {
+				p := make(map[string]float64, len(params)+1)
+				for k, pv := range params {
+					p[k] = pv
+				}
+				p[r.Name] = v
+				next = append(next, p)
+			}
+		}
+		grid = next
+	}
+	return grid
+}
+
+// 一组参数的回测结果
+type OptimizeResult struct {
+	Params       map[string]float64 `json:"params"`
+	Trades       int                `json:"trades"`
+	WinRate      float64            `json:"win_rate"`
+	ProfitFactor float64            `json:"-"`
+	NetPnL       float64            `json:"net_pnl"`
+	MaxDrawdown  float64            `json:"max_drawdown"`
+	// 没有亏损时盈亏比为无穷大，json中为null
+	ProfitFactorJSON *float64 `json:"profit_factor"`
+
+	err error // 参数组合无效，如fast>=slow
+}
+
+// 参数按名称排序后的文本，如 fast=9 slow=21
+func (r *OptimizeResult) paramString() string {
+	names := make([]string, 0, len(r.Params))
+	for name := range r.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + "=" + strconv.FormatFloat(r.Params[name], 'f', -1, 64)
+	}
+	return strings.Join(parts, " ")
+}
+
+// 排序方式，返回a是否排在b前面
+var optimizeSorts = map[string]func(a, b *OptimizeResult) bool{
+	"pnl":      func(a, b *OptimizeResult) bool { return a.NetPnL > b.NetPnL },
+	"drawdown": func(a, b *OptimizeResult) bool { return a.MaxDrawdown < b.MaxDrawdown },
+	"pf":       func(a, b *OptimizeResult) bool { return a.ProfitFactor > b.ProfitFactor },
+	"winrate":  func(a, b *OptimizeResult) bool { return a.WinRate > b.WinRate },
+}
+
+// 参数优化: optimize -strategy ema_cross -ranges fast=5:20:1,slow=20:60:5，
+// 用同一段K线回测所有参数组合，多个goroutine并行，按净盈亏等排序输出
+func (t *TraderCLI) optimize(args []string) error {
+	fs := flag.NewFlagSet("optimize", flag.ContinueOnError)
+	var config StrategyConfig
+	fs.StringVar(&config.Name, "strategy", "ema_cross", T("策略: ")+strings.Join(StrategyNames(), "/"))
+	fs.StringVar(&config.Symbol, "symbol", "SOLUSDC", T("交易对"))
+	fs.StringVar(&config.Interval, "interval", "15m", T("K线周期"))
+	rangesFlag := fs.String("ranges", "", T("参数范围，如 fast=5:20:1,slow=20:60:5 (起始:结束:步长)"))
+	params := fs.String("params", "", T("固定的策略参数，如 stop=2"))
+	limit := fs.Int("limit", 1000, T("回测使用的K线数量，指定-from时忽略"))
+	from := fs.String("from", "", T("起始日期(YYYY-MM-DD)，指定后忽略-limit"))
+	to := fs.String("to", "", T("结束日期(YYYY-MM-DD，不含当天)，默认到现在"))
+	riskPerTrade := fs.Float64("risk", 10, T("每笔交易止损时亏损的金额(USDC)"))
+	fee := fs.Float64("fee", 0.05, T("单边手续费率(%)"))
+	workers := fs.Int("workers", runtime.NumCPU(), T("并行回测的数量"))
+	sortBy := fs.String("sort", "pnl", T("排序: pnl/drawdown/pf/winrate"))
+	minTrades := fs.Int("min-trades", 1, T("交易次数少于此数的参数组合不列出"))
+	top := fs.Int("top", 20, T("列出前几名，0表示全部"))
+	output := outputFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+	less, ok := optimizeSorts[*sortBy]
+	if !ok {
+		return fmt.Errorf(T("不支持的排序方式: %s"), *sortBy)
+	}
+	if *rangesFlag == "" {
+		return errors.New(T("请用-ranges指定参数范围"))
+	}
+
+	config.Symbol = strings.ToUpper(config.Symbol)
+	base, err := parseStrategyParams(*params)
+	if err != nil {
+		return err
+	}
+	ranges, err := parseParamRanges(*rangesFlag)
+	if err != nil {
+		return err
+	}
+	grid := paramGrid(base, ranges)
+	if len(grid) > maxOptimizeRuns {
+		return fmt.Errorf(T("参数组合太多(%d)，最多%d个，请缩小范围或加大步长"), len(grid), maxOptimizeRuns)
+	}
+	klines, err := t.backtestKlines(config.Symbol, config.Interval, *limit, *from, *to)
+	if err != nil {
+		return err
+	}
+	if *output != "json" {
+		fmt.Printf(T("%s %s %s 共%d根K线，回测%d组参数\n"), config.Name, config.Symbol, config.Interval, len(klines), len(grid))
+	}
+
+	// 每组参数各自创建策略和风控，K线只读，可以共享
+	jobs := make(chan map[string]float64)
+	results := make(chan *OptimizeResult)
+	var wg sync.WaitGroup
+	for i := 0; i < max(*workers, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range jobs {
+				c := config
+				c.Params = p
+				strategy, err := NewStrategy(c)
+				if err != nil {
+					results <- &OptimizeResult{Params: p, err: err}
+					continue
+				}
+				r := runBacktest(strategy, klines, []RiskRule{&PositionSizer{RiskPerTrade: *riskPerTrade}}, *fee/100)
+				result := &OptimizeResult{
+					Params:       p,
+					Trades:       len(r.Trades),
+					WinRate:      r.WinRate(),
+					ProfitFactor: r.ProfitFactor(),
+					NetPnL:       r.NetPnL,
+					MaxDrawdown:  r.MaxDrawdown,
+				}
+				if !math.IsInf(result.ProfitFactor, 0) {
+					result.ProfitFactorJSON = &result.ProfitFactor
+				}
+				results <- result
+			}
+		}()
+	}
+	go func() {
+		for _, p := range grid {
+			jobs <- p
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	// 无效的参数组合跳过，全部无效时返回第一个错误
+	var ranked []*OptimizeResult
+	var invalid error
+	valid := 0
+	for r := range results {
+		if r.err != nil {
+			if invalid == nil {
+				invalid = r.err
+			}
+			continue
+		}
+		if valid++; r.Trades >= *minTrades {
+			ranked = append(ranked, r)
+		}
+	}
+	if valid == 0 && invalid != nil {
+		return invalid
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return less(ranked[i], ranked[j]) })
+	if *top > 0 && len(ranked) > *top {
+		ranked = ranked[:*top]
+	}
+
+	if *output == "json" {
+		return writeJSON(ranked)
+	}
+	if len(ranked) == 0 {
+		fmt.Println(T("没有符合条件的参数组合"))
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, T("排名\t参数\t交易次数\t胜率\t盈亏比\t净盈亏\t最大回撤"))
+	for i, r := range ranked {
+		fmt.Fprintf(w, "%d\t%s\t%d\t%.1f%%\t%.2f\t%+.2f\t%.2f\n",
+			i+1, r.paramString(), r.Trades, r.WinRate, r.ProfitFactor, r.NetPnL, r.MaxDrawdown)
+	}
+	return w.Flush()
+}
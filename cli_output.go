@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// 查询命令的输出格式，json输出的字段名保持稳定，供jq和其他脚本使用
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// 给命令加上-output参数
+func outputFlag(fs *flag.FlagSet) *string {
+	return fs.String("output", outputText, T("输出格式: text/json"))
+}
+
+func checkOutput(format string) error {
+	if format != outputText && format != outputJSON {
+		return fmt.Errorf(T("未知的输出格式: %s"), format)
+	}
+	return nil
+}
+
+// 按日期查询的参数: -from/-to指定日期范围，否则查询最近-days天
+type dateRange struct {
+	days     *int
+	from, to *string
+}
+
+// 给命令加上-days、-from和-to参数，daysUsage是-days的说明
+func dateRangeFlags(fs *flag.FlagSet, days int, daysUsage string) *dateRange {
+	return &dateRange{
+		days: fs.Int("days", days, daysUsage),
+		from: fs.String("from", "", T("起始日期(YYYY-MM-DD)，指定后忽略-days")),
+		to:   fs.String("to", "", T("结束日期(YYYY-MM-DD，不含当天)，默认到现在")),
+	}
+}
+
+// 是否指定了-from或-to
+func (r *dateRange) explicit() bool {
+	return *r.from != "" || *r.to != ""
+}
+
+// 查询的起止时间，没有指定-to时until为零
+func (r *dateRange) parse() (since, until time.Time, err error) {
+	since = time.Now().AddDate(0, 0, -*r.days)
+	if *r.from != "" {
+		if since, err = time.ParseInLocation(time.DateOnly, *r.from, time.Local); err != nil {
+			return since, until, fmt.Errorf(T("日期格式错误: %s"), *r.from)
+		}
+	}
+	if *r.to != "" {
+		if until, err = time.ParseInLocation(time.DateOnly, *r.to, time.Local); err != nil {
+			return since, until, fmt.Errorf(T("日期格式错误: %s"), *r.to)
+		}
+		if !until.After(since) {
+			return since, until, errors.New(T("结束日期必须晚于起始日期"))
+		}
+	}
+	return since, until, nil
+}
+
+// 以缩进的JSON写到标准输出
+func writeJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// json输出的持仓
+type PositionJSON struct {
+	Symbol           string  `json:"symbol"`
+	Side             string  `json:"side"` // long或short
+	Quantity         float64 `json:"qty"`
+	EntryPrice       float64 `json:"entry_price"`
+	MarkPrice        float64 `json:"mark_price"`
+	UnrealizedPnL    float64 `json:"unrealized_pnl"`
+	LiquidationPrice float64 `json:"liquidation_price"`
+	Leverage         int     `json:"leverage"`
+	MarginType       string  `json:"margin_type"`
+}
+
+func newPositionJSON(p *futures.PositionRisk) PositionJSON {
+	amt, _ := strconv.ParseFloat(p.PositionAmt, 64)
+	side := "long"
+	if amt < 0 {
+		side, amt = "short", -amt
+	}
+	entry, _ := strconv.ParseFloat(p.EntryPrice, 64)
+	mark, _ := strconv.ParseFloat(p.MarkPrice, 64)
+	pnl, _ := strconv.ParseFloat(p.UnRealizedProfit, 64)
+	liquidation, _ := strconv.ParseFloat(p.LiquidationPrice, 64)
+	leverage, _ := strconv.Atoi(p.Leverage)
+	return PositionJSON{
+		Symbol:           p.Symbol,
+		Side:             side,
+		Quantity:         amt,
+		EntryPrice:       entry,
+		MarkPrice:        mark,
+		UnrealizedPnL:    pnl,
+		LiquidationPrice: liquidation,
+		Leverage:         leverage,
+		MarginType:       p.MarginType,
+	}
+}
+
+// json输出的订单，止损单的触发价在stop_price中
+type OrderJSON struct {
+	OrderID       int64     `json:"order_id"`
+	ClientOrderID string    `json:"client_order_id"`
+	Tag           string    `json:"tag"` // 程序下单时的标签，外部订单为空
+	Symbol        string    `json:"symbol"`
+	Side          string    `json:"side"`
+	Type          string    `json:"type"`
+	Status        string    `json:"status"`
+	Price         float64   `json:"price"`
+	StopPrice     float64   `json:"stop_price"`
+	Quantity      float64   `json:"qty"`
+	ExecutedQty   float64   `json:"executed_qty"`
+	AvgPrice      float64   `json:"avg_price"`
+	ReduceOnly    bool      `json:"reduce_only"`
+	Time          time.Time `json:"time"`
+}
+
+func newOrderJSON(o *futures.Order) OrderJSON {
+	price, _ := strconv.ParseFloat(o.Price, 64)
+	stopPrice, _ := strconv.ParseFloat(o.StopPrice, 64)
+	qty, _ := strconv.ParseFloat(o.OrigQuantity, 64)
+	executed, _ := strconv.ParseFloat(o.ExecutedQuantity, 64)
+	avgPrice, _ := strconv.ParseFloat(o.AvgPrice, 64)
+	return OrderJSON{
+		OrderID:       o.OrderID,
+		ClientOrderID: o.ClientOrderID,
+		Tag:           orderTag(o.ClientOrderID),
+		Symbol:        o.Symbol,
+		Side:          string(o.Side),
+		Type:          string(o.Type),
+		Status:        string(o.Status),
+		Price:         price,
+		StopPrice:     stopPrice,
+		Quantity:      qty,
+		ExecutedQty:   executed,
+		AvgPrice:      avgPrice,
+		ReduceOnly:    o.ReduceOnly,
+		Time:          time.UnixMilli(o.Time),
+	}
+}
+
+func positionsJSON(positions []*futures.PositionRisk) []PositionJSON {
+	result := make([]PositionJSON, 0, len(positions))
+	for _, p := range positions {
+		result = append(result, newPositionJSON(p))
+	}
+	return result
+}
+
+func ordersJSON(orders []*futures.Order) []OrderJSON {
+	result := make([]OrderJSON, 0, len(orders))
+	for _, o := range orders {
+		result = append(result, newOrderJSON(o))
+	}
+	return result
+}
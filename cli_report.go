@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+)
+
+// json输出的交易报告
+type ReportJSON struct {
+	Period        string            `json:"period"`
+	Start         time.Time         `json:"start"`
+	End           time.Time         `json:"end"`
+	RealizedPnL   float64           `json:"realized_pnl"`
+	Fees          float64           `json:"fees"`
+	Funding       float64           `json:"funding"`
+	NetPnL        float64           `json:"net_pnl"`
+	MaxDrawdown   float64           `json:"max_drawdown"`
+	Fills         int               `json:"fills"`
+	Interventions int               `json:"protective_closes"`
+	Actions       map[string]int    `json:"actions"`
+	Trades        []ReportTradeJSON `json:"trades"`
+}
+
+type ReportTradeJSON struct {
+	Symbol     string    `json:"symbol"`
+	Side       string    `json:"side"` // long或short
+	EntryTime  time.Time `json:"entry_time"`
+	ExitTime   time.Time `json:"exit_time"`
+	EntryPrice float64   `json:"entry_price"`
+	ExitPrice  float64   `json:"exit_price"`
+	Quantity   float64   `json:"qty"`
+	PnL        float64   `json:"pnl"`
+	Fees       float64   `json:"fees"`
+}
+
+func newReportJSON(r *PeriodReport) ReportJSON {
+	out := ReportJSON{
+		Period:        r.Period,
+		Start:         r.Start,
+		End:           r.End,
+		RealizedPnL:   r.Realized,
+		Fees:          r.Fees,
+		Funding:       r.Funding,
+		NetPnL:        r.Net(),
+		MaxDrawdown:   r.MaxDrawdown,
+		Fills:         r.Fills,
+		Interventions: r.Interventions(),
+		Actions:       r.Actions,
+		Trades:        []ReportTradeJSON{},
+	}
+	for _, t := range r.Trades {
+		side := "long"
+		if !t.Long {
+			side = "short"
+		}
+		out.Trades = append(out.Trades, ReportTradeJSON{
+			Symbol:     t.Symbol,
+			Side:       side,
+			EntryTime:  t.EntryTime,
+			ExitTime:   t.ExitTime,
+			EntryPrice: t.EntryPrice,
+			ExitPrice:  t.ExitPrice,
+			Quantity:   t.Size,
+			PnL:        t.PnL,
+			Fees:       t.Fees,
+		})
+	}
+	return out
+}
+
+// 交易报告: report [-daily|-weekly] [-date YYYY-MM-DD|yesterday] [-output json]，
+// 汇总当天(或当周)的盈亏、手续费、资金费、平仓交易、保护止盈次数和最大回撤
+func (t *TraderCLI) report(args []string) error {
+	fs := flag.NewFlagSet("report", flag.ContinueOnError)
+	fs.Bool("daily", true, T("日报，默认"))
+	weekly := fs.Bool("weekly", false, T("周报，从周一开始"))
+	date := fs.String("date", "", T("报告的日期(YYYY-MM-DD)，yesterday表示昨天，默认今天"))
+	output := outputFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return errors.New(T("用法: report [-daily|-weekly] [-date YYYY-MM-DD]"))
+	}
+
+	day := time.Now()
+	switch *date {
+	case "":
+	case "yesterday":
+		day = day.AddDate(0, 0, -1)
+	default:
+		var err error
+		if day, err = time.ParseInLocation(time.DateOnly, *date, time.Local); err != nil {
+			return fmt.Errorf(T("日期格式错误: %s"), *date)
+		}
+	}
+
+	period := statsDay
+	if *weekly {
+		period = statsWeek
+	}
+	start := periodStart(day, period)
+	end := start.AddDate(0, 0, 1)
+	if period == statsWeek {
+		end = start.AddDate(0, 0, 7)
+	}
+	now := time.Now()
+	if start.After(now) {
+		return errors.New(T("报告的日期不能晚于今天"))
+	}
+	if end.After(now) {
+		end = now
+	}
+
+	report, err := buildPeriodReport(t.client, t.activity, period, start, end)
+	if err != nil {
+		return err
+	}
+	if *output == "json" {
+		return writeJSON(newReportJSON(report))
+	}
+	fmt.Print(report)
+	return nil
+}
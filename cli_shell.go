@@ -0,0 +1,335 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// 交互模式的命令历史文件和保留条数
+const (
+	shellHistoryFile = "shell_history"
+	shellHistoryMax  = 1000
+)
+
+// 交互模式中可以修改的止盈止损参数
+var shellSettings = []struct {
+	Key   string
+	Label string
+}{
+	{"symbol", "自动管理的交易对"},
+	{"tp", "止盈距离"},
+	{"sl", "止损距离"},
+	{"tick", "价格精度"},
+	{"arm", "保护止盈启动盈利(U)"},
+	{"keep", "保留盈利比例"},
+}
+
+// 只有交互模式才有的命令
+var shellBuiltins = []struct {
+	Name  string
+	Usage string
+}{
+	{"set", "修改止盈止损参数: set KEY VALUE"},
+	{"show", "显示当前的止盈止损参数"},
+	{"log", "显示或隐藏监控日志: log on|off"},
+	{"history", "显示命令历史，!N 重新执行第N条，!! 重新执行上一条"},
+	{"help", "显示命令列表"},
+	{"exit", "退出"},
+}
+
+// 可以开关的日志输出，交互模式下默认隐藏监控日志，避免打断输入
+type switchWriter struct {
+	w  io.Writer
+	on atomic.Bool
+}
+
+func (s *switchWriter) Write(p []byte) (int, error) {
+	if !s.on.Load() {
+		return len(p), nil
+	}
+	return s.w.Write(p)
+}
+
+// 交互模式: 在后台运行监控，在提示符下查询持仓、下单和修改止盈止损参数。
+// 命令可以只输入能唯一确定的前缀，以?结尾时列出可以补全的命令或参数
+func (t *TraderCLI) shell(args []string) error {
+	fs := flag.NewFlagSet("shell", flag.ContinueOnError)
+	engine := fs.Bool("engine", true, T("在后台运行止盈止损监控"))
+	showLog := fs.Bool("log", false, T("显示监控日志"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	logWriter := &switchWriter{w: cliLogOutput.Get()}
+	logWriter.on.Store(*showLog)
+	cliLogOutput.Set(logWriter)
+
+	if *engine {
+		go func() {
+			if err := t.run(); err != nil {
+				fmt.Printf(T("交易系统运行失败: %v")+"\n", err)
+			}
+		}()
+		fmt.Println(T("止盈止损监控已在后台运行，输入 log on 查看日志"))
+	}
+	fmt.Println(T("输入 help 查看命令，exit 退出"))
+
+	historyPath := profilePath(t.profile, shellHistoryFile)
+	history := loadShellHistory(historyPath)
+
+	for {
+		fmt.Print("protect> ")
+		line, err := stdin.ReadString('\n')
+		if err != nil && line == "" {
+			fmt.Println()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		// 历史命令
+		if strings.HasPrefix(line, "!") {
+			recalled, err := recallHistory(history, line)
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			line = recalled
+			fmt.Println(line)
+		}
+
+		if strings.HasSuffix(line, "?") {
+			fmt.Println(strings.Join(shellCompletions(strings.TrimSuffix(line, "?")), "  "))
+			continue
+		}
+
+		history = append(history, line)
+		appendShellHistory(historyPath, line)
+
+		fields := strings.Fields(line)
+		name, err := resolveShellCommand(fields[0])
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		switch name {
+		case "exit":
+			return nil
+		case "help":
+			printShellHelp()
+		case "history":
+			start := len(history) - 50
+			if start < 0 {
+				start = 0
+			}
+			for i := start; i < len(history); i++ {
+				fmt.Printf("%5d  %s\n", i+1, history[i])
+			}
+		case "log":
+			if len(fields) > 1 {
+				logWriter.on.Store(fields[1] == "on")
+			}
+			if logWriter.on.Load() {
+				fmt.Println(T("监控日志: 显示"))
+			} else {
+				fmt.Println(T("监控日志: 隐藏"))
+			}
+		case "show":
+			t.printProtectConfig()
+		case "set":
+			if err := t.shellSet(fields[1:]); err != nil {
+				fmt.Println(err)
+				continue
+			}
+			t.printProtectConfig()
+		default:
+			cmd := findCLICommand(name)
+			if err := cmd.Run(t, fields[1:]); err != nil && !errors.Is(err, flag.ErrHelp) {
+				fmt.Printf(T(cmd.Fail)+"\n", err)
+			}
+		}
+	}
+}
+
+// 交互模式中可用的命令名，run和shell本身除外
+func shellCommandNames() []string {
+	var names []string
+	for _, b := range shellBuiltins {
+		names = append(names, b.Name)
+	}
+	for _, c := range cliCommands {
+		if c.Name != "run" && c.Name != "shell" {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
+
+// 按前缀找到命令，前缀对应多个命令时报错
+func resolveShellCommand(prefix string) (string, error) {
+	if prefix == "quit" {
+		return "exit", nil
+	}
+	var matches []string
+	for _, name := range shellCommandNames() {
+		if name == prefix {
+			return name, nil
+		}
+		if strings.HasPrefix(name, prefix) {
+			matches = append(matches, name)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf(T("未知命令: %s，输入 help 查看命令"), prefix)
+	case 1:
+		return matches[0], nil
+	}
+	return "", fmt.Errorf(T("命令不明确: %s 可以是 %s"), prefix, strings.Join(matches, ", "))
+}
+
+// 列出可以补全的命令，set和log后面补全参数
+func shellCompletions(line string) []string {
+	fields := strings.Fields(line)
+	prefix := ""
+	if len(fields) > 0 && !strings.HasSuffix(line, " ") {
+		prefix = fields[len(fields)-1]
+		fields = fields[:len(fields)-1]
+	}
+
+	var candidates []string
+	if len(fields) == 0 {
+		candidates = shellCommandNames()
+	} else {
+		name, err := resolveShellCommand(fields[0])
+		if err != nil {
+			return []string{err.Error()}
+		}
+		switch {
+		case name == "set" && len(fields) == 1:
+			for _, s := range shellSettings {
+				candidates = append(candidates, s.Key)
+			}
+		case name == "log":
+			candidates = []string{"on", "off"}
+		}
+	}
+
+	var matches []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, prefix) {
+			matches = append(matches, c)
+		}
+	}
+	return matches
+}
+
+// set KEY VALUE，修改后的参数要通过检查才生效，下一次监控循环开始使用
+func (t *TraderCLI) shellSet(args []string) error {
+	if len(args) != 2 {
+		return errors.New(T("用法: set KEY VALUE，KEY为 symbol/tp/sl/tick/arm/keep"))
+	}
+	config := t.protectConfig()
+	key, value := args[0], args[1]
+	if key == "symbol" {
+		config.Symbol = strings.ToUpper(value)
+	} else {
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf(T("数值格式错误: %s"), value)
+		}
+		switch key {
+		case "tp":
+			config.TakeProfit = v
+		case "sl":
+			config.StopLoss = v
+		case "tick":
+			config.TickSize = v
+		case "arm":
+			config.ArmProfit = v
+		case "keep":
+			config.KeepRatio = v
+		default:
+			return fmt.Errorf(T("未知参数: %s"), key)
+		}
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	t.setProtectConfig(config)
+	return nil
+}
+
+func (t *TraderCLI) printProtectConfig() {
+	config := t.protectConfig()
+	values := map[string]string{
+		"symbol": config.Symbol,
+		"tp":     strconv.FormatFloat(config.TakeProfit, 'f', -1, 64),
+		"sl":     strconv.FormatFloat(config.StopLoss, 'f', -1, 64),
+		"tick":   strconv.FormatFloat(config.TickSize, 'f', -1, 64),
+		"arm":    strconv.FormatFloat(config.ArmProfit, 'f', -1, 64),
+		"keep":   strconv.FormatFloat(config.KeepRatio, 'f', -1, 64),
+	}
+	for _, s := range shellSettings {
+		fmt.Printf("  %-6s %-10s %s\n", s.Key, values[s.Key], T(s.Label))
+	}
+}
+
+func printShellHelp() {
+	for _, b := range shellBuiltins {
+		fmt.Printf("  %-10s %s\n", b.Name, T(b.Usage))
+	}
+	for _, c := range cliCommands {
+		if c.Name != "run" && c.Name != "shell" {
+			fmt.Printf("  %-10s %s\n", c.Name, T(c.Usage))
+		}
+	}
+	fmt.Println(T("命令可以只输入前缀，以?结尾列出补全，COMMAND -h 查看命令的参数"))
+}
+
+// !! 为上一条命令，!N 为第N条
+func recallHistory(history []string, line string) (string, error) {
+	if len(history) == 0 {
+		return "", errors.New(T("没有命令历史"))
+	}
+	if line == "!!" {
+		return history[len(history)-1], nil
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(line, "!"))
+	if err != nil || n < 1 || n > len(history) {
+		return "", fmt.Errorf(T("没有第%s条命令"), strings.TrimPrefix(line, "!"))
+	}
+	return history[n-1], nil
+}
+
+func loadShellHistory(path string) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) > shellHistoryMax {
+		lines = lines[len(lines)-shellHistoryMax:]
+	}
+	return lines
+}
+
+func appendShellHistory(path, line string) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintln(f, line)
+}
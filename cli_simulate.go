@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// json输出的模拟结果
+type SimulationJSON struct {
+	Symbol     string     `json:"symbol"`
+	Side       string     `json:"side"` // long或short
+	Quantity   float64    `json:"qty"`
+	EntryTime  time.Time  `json:"entry_time"`
+	EntryPrice float64    `json:"entry_price"`
+	StopLoss   float64    `json:"stop_loss"`
+	TakeProfit float64    `json:"take_profit"`
+	ArmProfit  float64    `json:"arm_profit"`
+	KeepRatio  float64    `json:"keep_ratio"`
+	ArmedTime  *time.Time `json:"armed_time"` // 保护止盈未启动时为null
+	ArmedPrice float64    `json:"armed_price,omitempty"`
+	MaxProfit  float64    `json:"max_profit"`
+	MaxLoss    float64    `json:"max_loss"`
+	ExitTime   time.Time  `json:"exit_time"`
+	ExitPrice  float64    `json:"exit_price"`
+	ExitReason string     `json:"exit_reason"` // stop_loss/take_profit/protect/end
+	PnL        float64    `json:"pnl"`
+	Fees       float64    `json:"fees"`
+	NetPnL     float64    `json:"net_pnl"`
+}
+
+func newSimulationJSON(s *ProtectSimulation) SimulationJSON {
+	out := SimulationJSON{
+		Symbol:     s.Protect.Symbol,
+		Side:       "long",
+		Quantity:   s.Quantity,
+		EntryTime:  s.EntryTime,
+		EntryPrice: s.EntryPrice,
+		StopLoss:   s.StopLoss,
+		TakeProfit: s.TakeProfit,
+		ArmProfit:  s.Protect.ArmProfit,
+		KeepRatio:  s.Protect.KeepRatio,
+		ArmedPrice: s.ArmedPrice,
+		MaxProfit:  s.MaxProfit,
+		MaxLoss:    s.MaxLoss,
+		ExitTime:   s.ExitTime,
+		ExitPrice:  s.ExitPrice,
+		ExitReason: s.ExitReason,
+		PnL:        s.PnL,
+		Fees:       s.Fees,
+		NetPnL:     s.Net(),
+	}
+	if !s.Long {
+		out.Side = "short"
+	}
+	if !s.ArmedTime.IsZero() {
+		out.ArmedTime = &s.ArmedTime
+	}
+	return out
+}
+
+// 模拟止盈止损: simulate SOLUSDC -qty 10 [-entry 135.2] [-short] -from 2024-01-02 [-to 2024-01-03] [-interval 1m]
+// 或 simulate SOLUSDC -qty 10 -file prices.csv，按当前的止盈止损和保护止盈设置在历史价格上
+// 走一遍，显示保护止盈何时启动、何时以什么价格平仓和盈亏。-sl/-tp/-arm/-keep可以试不同的设置
+func (t *TraderCLI) simulate(args []string) error {
+	protect := t.protectConfig()
+	fs := flag.NewFlagSet("simulate", flag.ContinueOnError)
+	symbol := fs.String("symbol", protect.Symbol, T("交易对"))
+	entry := fs.Float64("entry", 0, T("入场价，默认为价格数据的第一个开盘价"))
+	qty := fs.Float64("qty", 0, T("持仓数量"))
+	short := fs.Bool("short", false, T("模拟空仓，默认多仓"))
+	interval := fs.String("interval", "1m", T("K线周期，越短越接近实盘"))
+	from := fs.String("from", "", T("入场日期(YYYY-MM-DD)，从这天开始的历史数据"))
+	to := fs.String("to", "", T("结束日期(YYYY-MM-DD，不含当天)，默认到现在"))
+	file := fs.String("file", "", T("价格数据CSV，export导出的K线或time,price格式的记录"))
+	fs.Float64Var(&protect.StopLoss, "sl", protect.StopLoss, T("止损距离"))
+	fs.Float64Var(&protect.TakeProfit, "tp", protect.TakeProfit, T("止盈距离"))
+	fs.Float64Var(&protect.ArmProfit, "arm", protect.ArmProfit, T("启动保护止盈的盈利(U)"))
+	fs.Float64Var(&protect.KeepRatio, "keep", protect.KeepRatio, T("保留的最高盈利比例"))
+	fee := fs.Float64("fee", 0.05, T("单边手续费率(%)"))
+	output := outputFlag(fs)
+	if err := parseWithSymbol(fs, args, symbol); err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+	if *qty <= 0 {
+		return errors.New(T("请用-qty指定持仓数量"))
+	}
+	if (*from == "") == (*file == "") {
+		return errors.New(T("请用-from指定历史数据的日期，或用-file指定价格数据"))
+	}
+	protect.Symbol = strings.ToUpper(*symbol)
+	if err := protect.Validate(); err != nil {
+		return err
+	}
+
+	var klines []Kline
+	if *file != "" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return fmt.Errorf(T("打开价格数据失败: %v"), err)
+		}
+		defer f.Close()
+		if klines, err = readPricePath(f); err != nil {
+			return err
+		}
+	} else {
+		var err error
+		if klines, err = t.backtestKlines(protect.Symbol, *interval, 0, *from, *to); err != nil {
+			return err
+		}
+	}
+
+	sim, err := simulateProtect(protect, !*short, *entry, *qty, klines, *fee/100)
+	if err != nil {
+		return err
+	}
+	if *output == "json" {
+		return writeJSON(newSimulationJSON(sim))
+	}
+	fmt.Print(sim)
+	return nil
+}
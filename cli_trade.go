@@ -0,0 +1,434 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// 买入开多: buy -qty 1.5 [-price 135.2] [-sl 133]
+func (t *TraderCLI) buy(args []string) error {
+	return t.placeOrder(futures.SideTypeBuy, args)
+}
+
+// 卖出开空: sell -qty 1.5 [-price 135.2] [-sl 137]
+func (t *TraderCLI) sell(args []string) error {
+	return t.placeOrder(futures.SideTypeSell, args)
+}
+
+// 下单，指定价格时下限价单，否则下市价单；指定止损价格时同时创建止损单
+func (t *TraderCLI) placeOrder(side futures.SideType, args []string) error {
+	fs := flag.NewFlagSet(strings.ToLower(string(side)), flag.ContinueOnError)
+	symbol := fs.String("symbol", "SOLUSDC", T("交易对"))
+	qty := fs.Float64("qty", 0, T("下单数量"))
+	price := fs.Float64("price", 0, T("限价单价格，为0时下市价单"))
+	stopLoss := fs.Float64("sl", 0, T("止损价格，为0时不设置"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *qty <= 0 {
+		return errors.New(T("请用-qty指定下单数量"))
+	}
+	sym := strings.ToUpper(*symbol)
+	filters, err := NewSymbolFilterCache(t.client).Get(sym)
+	if err != nil {
+		return err
+	}
+	quantity := filters.FloorQuantity(*qty)
+	if quantity <= 0 || quantity < filters.MinQty {
+		return fmt.Errorf(T("下单数量不能小于%s"), filters.FormatQuantity(filters.MinQty))
+	}
+
+	service := t.client.NewCreateOrderService().
+		Symbol(sym).
+		NewClientOrderID(newClientOrderID(tagManual)).
+		Side(side).
+		PositionSide("BOTH").
+		Quantity(filters.FormatQuantity(quantity))
+	if *price > 0 {
+		service = service.Type(futures.OrderTypeLimit).
+			TimeInForce(futures.TimeInForceTypeGTC).
+			Price(filters.FormatPrice(*price))
+	} else {
+		service = service.Type(futures.OrderTypeMarket)
+	}
+	order, err := service.Do(context.Background())
+	if err != nil {
+		return err
+	}
+	fmt.Printf(T("已下单: %s %s %s，订单ID: %d\n"), sym, side, filters.FormatQuantity(quantity), order.OrderID)
+
+	if *stopLoss > 0 {
+		stopSide := futures.SideTypeSell
+		if side == futures.SideTypeSell {
+			stopSide = futures.SideTypeBuy
+		}
+		_, err := t.client.NewCreateOrderService().
+			Symbol(sym).
+			NewClientOrderID(newClientOrderID(tagStopLoss)).
+			Side(stopSide).
+			PositionSide("BOTH").
+			Type(futures.OrderTypeStopMarket).
+			StopPrice(filters.FormatPrice(*stopLoss)).
+			Quantity(filters.FormatQuantity(quantity)).
+			ReduceOnly(true).
+			Do(context.Background())
+		if err != nil {
+			return fmt.Errorf(T("主订单已成功，但止损单创建失败: %v"), err)
+		}
+		fmt.Printf(T("已设置止损单，价格: %s\n"), filters.FormatPrice(*stopLoss))
+	}
+	return nil
+}
+
+// 有持仓的交易对，symbol为空时返回全部
+func (t *TraderCLI) openPositions(symbol string) ([]*futures.PositionRisk, error) {
+	service := t.client.NewGetPositionRiskService()
+	if symbol != "" {
+		service = service.Symbol(symbol)
+	}
+	positions, err := service.Do(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf(T("获取持仓信息失败: %v"), err)
+	}
+	var open []*futures.PositionRisk
+	for _, p := range positions {
+		if amt, _ := strconv.ParseFloat(p.PositionAmt, 64); amt != 0 {
+			open = append(open, p)
+		}
+	}
+	return open, nil
+}
+
+// 显示持仓
+func (t *TraderCLI) listPositions(args []string) error {
+	fs := flag.NewFlagSet("positions", flag.ContinueOnError)
+	symbol := fs.String("symbol", "", T("交易对，为空时显示全部"))
+	output := outputFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+
+	positions, err := t.openPositions(strings.ToUpper(*symbol))
+	if err != nil {
+		return err
+	}
+	if *output == outputJSON {
+		return writeJSON(positionsJSON(positions))
+	}
+	if len(positions) == 0 {
+		fmt.Println(T("无持仓"))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, T("交易对\t方向\t数量\t入场价\t标记价\t未实现盈亏\t强平价\t杠杆"))
+	for _, p := range positions {
+		amt, _ := strconv.ParseFloat(p.PositionAmt, 64)
+		fmt.Fprintf(w, "%s\t%s\t%.4f\t%s\t%s\t%s\t%s\t%sx\n",
+			p.Symbol, positionDirection(amt), math.Abs(amt), p.EntryPrice, p.MarkPrice,
+			p.UnRealizedProfit, p.LiquidationPrice, p.Leverage)
+	}
+	return w.Flush()
+}
+
+// 市价平仓: close [SYMBOL] [-pct 50] [-all]，只减仓。全部平仓后取消该交易对的止盈止损单，
+// 部分平仓后把止盈止损单的数量调整为剩余持仓
+func (t *TraderCLI) closeCommand(args []string) error {
+	fs := flag.NewFlagSet("close", flag.ContinueOnError)
+	symbol := fs.String("symbol", "SOLUSDC", T("交易对"))
+	all := fs.Bool("all", false, T("平掉所有持仓"))
+	pct := fs.Float64("pct", 100, T("平仓比例(%)"))
+	if err := parseWithSymbol(fs, args, symbol); err != nil {
+		return err
+	}
+	if *pct <= 0 || *pct > 100 {
+		return errors.New(T("平仓比例必须大于0且不超过100"))
+	}
+
+	filter := strings.ToUpper(*symbol)
+	if *all {
+		filter = ""
+	}
+	positions, err := t.openPositions(filter)
+	if err != nil {
+		return err
+	}
+	if len(positions) == 0 {
+		fmt.Println(T("无持仓"))
+		return nil
+	}
+
+	var failed []string
+	for _, p := range positions {
+		closePos := t.closePosition
+		if *pct < 100 {
+			closePos = func(p *futures.PositionRisk) error { return t.closePartial(p, *pct) }
+		}
+		if err := closePos(p); err != nil {
+			warnf("%v", err)
+			failed = append(failed, p.Symbol)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf(T("以下交易对平仓失败: %s"), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func (t *TraderCLI) closePosition(position *futures.PositionRisk) error {
+	amt, _ := strconv.ParseFloat(position.PositionAmt, 64)
+	side := futures.SideTypeSell
+	if amt < 0 {
+		side = futures.SideTypeBuy
+	}
+
+	_, err := t.client.NewCreateOrderService().
+		Symbol(position.Symbol).
+		NewClientOrderID(newClientOrderID(tagClose)).
+		Side(side).
+		PositionSide("BOTH").
+		Type(futures.OrderTypeMarket).
+		Quantity(fmt.Sprintf("%.4f", math.Abs(amt))).
+		ReduceOnly(true).
+		Do(context.Background())
+	if err != nil {
+		return fmt.Errorf(T("%s 平仓失败: %v"), position.Symbol, err)
+	}
+	fmt.Printf(T("已市价平仓: %s %.4f\n"), position.Symbol, amt)
+
+	orders, err := t.client.NewListOpenOrdersService().Symbol(position.Symbol).Do(context.Background())
+	if err != nil {
+		return fmt.Errorf(T("获取订单失败: %v"), err)
+	}
+	for _, o := range orders {
+		if !isProtectiveOrder(o) {
+			continue
+		}
+		if _, err := t.client.NewCancelOrderService().Symbol(position.Symbol).OrderID(o.OrderID).Do(context.Background()); err != nil {
+			return fmt.Errorf(T("取消订单失败 [OrderID: %d]: %v"), o.OrderID, err)
+		}
+	}
+	return nil
+}
+
+// 按比例市价平掉部分持仓，数量按交易对的数量精度向下取整。
+// 剩余持仓的止盈止损单数量超过剩余数量时，先按剩余数量下新单，成功后再撤旧单
+func (t *TraderCLI) closePartial(position *futures.PositionRisk, pct float64) error {
+	symbol := position.Symbol
+	amt, _ := strconv.ParseFloat(position.PositionAmt, 64)
+	filters, err := NewSymbolFilterCache(t.client).Get(symbol)
+	if err != nil {
+		return err
+	}
+	quantity := filters.FloorQuantity(math.Abs(amt) * pct / 100)
+	if quantity <= 0 || quantity < filters.MinQty {
+		return fmt.Errorf(T("%s 平仓数量不能小于%s"), symbol, filters.FormatQuantity(filters.MinQty))
+	}
+	remaining := filters.FloorQuantity(math.Abs(amt) - quantity)
+	if remaining <= 0 {
+		return t.closePosition(position)
+	}
+
+	side := futures.SideTypeSell
+	if amt < 0 {
+		side = futures.SideTypeBuy
+	}
+	_, err = t.client.NewCreateOrderService().
+		Symbol(symbol).
+		NewClientOrderID(newClientOrderID(tagClose)).
+		Side(side).
+		PositionSide("BOTH").
+		Type(futures.OrderTypeMarket).
+		Quantity(filters.FormatQuantity(quantity)).
+		ReduceOnly(true).
+		Do(context.Background())
+	if err != nil {
+		return fmt.Errorf(T("%s 平仓失败: %v"), symbol, err)
+	}
+	fmt.Printf(T("已市价平仓: %s %s (%.0f%%)，剩余 %s\n"), symbol,
+		filters.FormatQuantity(quantity), pct, filters.FormatQuantity(remaining))
+
+	orders, err := t.client.NewListOpenOrdersService().Symbol(symbol).Do(context.Background())
+	if err != nil {
+		return fmt.Errorf(T("获取订单失败: %v"), err)
+	}
+	for _, o := range orders {
+		// 全部平仓的条件单没有数量，会随持仓自动调整
+		if !isProtectiveOrder(o) || o.ClosePosition {
+			continue
+		}
+		if qty, _ := strconv.ParseFloat(o.OrigQuantity, 64); qty <= remaining {
+			continue
+		}
+		// 跟踪止损单的回调比例和激活价无法从挂单中完整还原，只提示手动调整
+		if !resizableOrderTypes[o.Type] {
+			warnf(T("%s单类型为%s，无法调整数量，请手动处理 [OrderID: %d]"), orderTagLabel(o.ClientOrderID), o.Type, o.OrderID)
+			continue
+		}
+		if err := t.resizeOrder(o, filters, remaining); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 可以按新数量重新下单的订单类型
+var resizableOrderTypes = map[futures.OrderType]bool{
+	futures.OrderTypeLimit:            true,
+	futures.OrderTypeStop:             true,
+	futures.OrderTypeStopMarket:       true,
+	futures.OrderTypeTakeProfit:       true,
+	futures.OrderTypeTakeProfitMarket: true,
+}
+
+// 按新数量重新下止盈止损单，价格、触发价、触发价类型和标签不变，成功后撤销旧单
+func (t *TraderCLI) resizeOrder(o *futures.Order, filters SymbolFilters, quantity float64) error {
+	tag := orderTag(o.ClientOrderID)
+	if tag == "" {
+		tag = tagManual
+	}
+	service := t.client.NewCreateOrderService().
+		Symbol(o.Symbol).
+		NewClientOrderID(newClientOrderID(tag)).
+		Side(o.Side).
+		PositionSide("BOTH").
+		Type(futures.OrderType(o.Type)).
+		Quantity(filters.FormatQuantity(quantity)).
+		ReduceOnly(true)
+	if price, _ := strconv.ParseFloat(o.Price, 64); price > 0 {
+		service = service.Price(o.Price).TimeInForce(o.TimeInForce)
+	}
+	if stopPrice, _ := strconv.ParseFloat(o.StopPrice, 64); stopPrice > 0 {
+		service = service.StopPrice(o.StopPrice).WorkingType(o.WorkingType).PriceProtect(o.PriceProtect)
+	}
+	if _, err := service.Do(context.Background()); err != nil {
+		return fmt.Errorf(T("调整订单数量失败 [OrderID: %d]: %v"), o.OrderID, err)
+	}
+	if _, err := t.client.NewCancelOrderService().Symbol(o.Symbol).OrderID(o.OrderID).Do(context.Background()); err != nil {
+		return fmt.Errorf(T("新订单已创建，但取消旧订单失败 [OrderID: %d]: %v"), o.OrderID, err)
+	}
+	fmt.Printf(T("已调整%s单数量: %s -> %s\n"), orderTagLabel(o.ClientOrderID), o.OrigQuantity, filters.FormatQuantity(quantity))
+	return nil
+}
+
+// 显示挂单
+func (t *TraderCLI) openOrders(symbol, output string) error {
+	service := t.client.NewListOpenOrdersService()
+	if symbol != "" {
+		service = service.Symbol(symbol)
+	}
+	orders, err := service.Do(context.Background())
+	if err != nil {
+		return fmt.Errorf(T("获取订单失败: %v"), err)
+	}
+	if output == outputJSON {
+		return writeJSON(ordersJSON(orders))
+	}
+	if len(orders) == 0 {
+		fmt.Println(T("没有订单"))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, T("订单ID\t交易对\t方向\t类型\t价格\t数量\t来源"))
+	for _, o := range orders {
+		price := o.Price
+		if o.Type == futures.OrderTypeStopMarket || o.Type == futures.OrderTypeTakeProfitMarket {
+			price = o.StopPrice
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			o.OrderID, o.Symbol, o.Side, o.Type, price, o.OrigQuantity, orderTagLabel(o.ClientOrderID))
+	}
+	return w.Flush()
+}
+
+// 撤单: cancel [-symbol SOLUSDC] ID... 或 cancel [-symbol SOLUSDC] -all
+func (t *TraderCLI) cancel(args []string) error {
+	fs := flag.NewFlagSet("cancel", flag.ContinueOnError)
+	symbol := fs.String("symbol", "SOLUSDC", T("交易对"))
+	all := fs.Bool("all", false, T("撤销该交易对的所有挂单"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	sym := strings.ToUpper(*symbol)
+	if *all {
+		if err := t.client.NewCancelAllOpenOrdersService().Symbol(sym).Do(context.Background()); err != nil {
+			return err
+		}
+		fmt.Printf(T("已撤销%s的所有挂单\n"), sym)
+		return nil
+	}
+
+	if fs.NArg() == 0 {
+		return errors.New(T("用法: cancel [-symbol SYMBOL] ID... 或 cancel [-symbol SYMBOL] -all"))
+	}
+	for _, arg := range fs.Args() {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return fmt.Errorf(T("订单ID格式错误: %s"), arg)
+		}
+		if _, err := t.client.NewCancelOrderService().Symbol(sym).OrderID(id).Do(context.Background()); err != nil {
+			return fmt.Errorf(T("取消订单失败 [OrderID: %d]: %v"), id, err)
+		}
+		fmt.Printf(T("已取消订单 [OrderID: %d]\n"), id)
+	}
+	return nil
+}
+
+// 显示最近的成交记录
+func (t *TraderCLI) trades(args []string) error {
+	fs := flag.NewFlagSet("trades", flag.ContinueOnError)
+	symbol := fs.String("symbol", "SOLUSDC", T("交易对"))
+	days := fs.Int("days", 7, T("显示最近多少天的成交记录"))
+	n := fs.Int("n", 50, T("最多显示多少条"))
+	output := outputFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+
+	trades, err := fetchAccountTrades(t.client, strings.ToUpper(*symbol), time.Now().AddDate(0, 0, -*days))
+	if err != nil {
+		return err
+	}
+	if *n > 0 && len(trades) > *n {
+		trades = trades[len(trades)-*n:]
+	}
+	fills := make([]FillRecord, 0, len(trades))
+	for _, trade := range trades {
+		fills = append(fills, newFillRecord(trade))
+	}
+	if *output == outputJSON {
+		return writeJSON(fills)
+	}
+	if len(fills) == 0 {
+		fmt.Println(T("没有成交记录"))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, T("时间\t交易对\t方向\t价格\t数量\t已实现盈亏\t手续费"))
+	for _, f := range fills {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%.4f\t%.4f\t%.4f\t%.4f %s\n",
+			f.Time.Format("01-02 15:04:05"), f.Symbol, f.Side, f.Price, f.Quantity,
+			f.RealizedPnL, f.Commission, f.CommissionAsset)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,472 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// 终端界面的刷新间隔和K线数量
+const (
+	tuiRefresh = 2 * time.Second
+	tuiKlines  = 200
+)
+
+// 终端界面可以切换的K线周期
+var tuiIntervals = []string{"1m", "5m", "15m", "1h", "4h", "1d"}
+
+// ANSI控制序列
+const (
+	ansiAltScreen  = "\x1b[?1049h"
+	ansiMainScreen = "\x1b[?1049l"
+	ansiHideCursor = "\x1b[?25l"
+	ansiShowCursor = "\x1b[?25h"
+	ansiHome       = "\x1b[H"
+	ansiClearLine  = "\x1b[K"
+	ansiClearDown  = "\x1b[J"
+	ansiReset      = "\x1b[0m"
+	ansiBold       = "\x1b[1m"
+	ansiDim        = "\x1b[2m"
+	ansiReverse    = "\x1b[7m"
+	ansiRed        = "\x1b[31m"
+	ansiGreen      = "\x1b[32m"
+	ansiYellow     = "\x1b[33m"
+)
+
+func defaultTerminalSize() (cols, rows int) {
+	cols, _ = strconv.Atoi(os.Getenv("COLUMNS"))
+	rows, _ = strconv.Atoi(os.Getenv("LINES"))
+	if cols <= 0 {
+		cols = 120
+	}
+	if rows <= 0 {
+		rows = 40
+	}
+	return cols, rows
+}
+
+// 按盈亏着色
+func pnlColor(pnl float64) string {
+	switch {
+	case pnl > 0:
+		return ansiGreen
+	case pnl < 0:
+		return ansiRed
+	}
+	return ""
+}
+
+// 截断到width个字符，宽字符按一个字符计算
+func truncateLine(line string, width int) string {
+	runes := []rune(line)
+	if len(runes) > width {
+		return string(runes[:width])
+	}
+	return line
+}
+
+// 用tabwriter对齐，返回每一行
+func tabulate(header string, rows []string) []string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, header)
+	for _, r := range rows {
+		fmt.Fprintln(w, r)
+	}
+	w.Flush()
+	return strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+}
+
+// 持仓表格的行，第一行是表头。同时返回每行的盈亏用于着色
+func positionLines(positions []*futures.PositionRisk) ([]string, []float64) {
+	var rows []string
+	pnls := []float64{0}
+	for _, p := range positions {
+		amt, _ := strconv.ParseFloat(p.PositionAmt, 64)
+		pnl, _ := strconv.ParseFloat(p.UnRealizedProfit, 64)
+		rows = append(rows, fmt.Sprintf("%s\t%s\t%.4f\t%s\t%s\t%.2f\t%s\t%sx",
+			p.Symbol, positionDirection(amt), math.Abs(amt), p.EntryPrice, p.MarkPrice,
+			pnl, p.LiquidationPrice, p.Leverage))
+		pnls = append(pnls, pnl)
+	}
+	return tabulate(T("交易对\t方向\t数量\t入场价\t标记价\t未实现盈亏\t强平价\t杠杆"), rows), pnls
+}
+
+// 挂单表格的行，第一行是表头
+func orderLines(orders []*futures.Order) []string {
+	var rows []string
+	for _, o := range orders {
+		price := o.Price
+		if o.Type == futures.OrderTypeStopMarket || o.Type == futures.OrderTypeTakeProfitMarket {
+			price = o.StopPrice
+		}
+		rows = append(rows, fmt.Sprintf("%d\t%s\t%s\t%s\t%s\t%s\t%s",
+			o.OrderID, o.Symbol, o.Side, o.Type, price, o.OrigQuantity, orderTagLabel(o.ClientOrderID)))
+	}
+	return tabulate(T("订单ID\t交易对\t方向\t类型\t价格\t数量\t来源"), rows)
+}
+
+// 用字符画K线: 实体为█，影线为│，涨绿跌红。右侧标出最高价和最低价
+func renderCandles(klines []Kline, width, height int) []string {
+	const labelWidth = 12
+	width -= labelWidth
+	if width < 10 || height < 3 || len(klines) == 0 {
+		return nil
+	}
+	if len(klines) > width {
+		klines = klines[len(klines)-width:]
+	}
+
+	high, low := klines[0].High, klines[0].Low
+	for _, k := range klines {
+		high = math.Max(high, k.High)
+		low = math.Min(low, k.Low)
+	}
+	span := high - low
+	if span <= 0 {
+		span = 1
+	}
+	// 价格对应的行号，第0行是最高价
+	row := func(price float64) int {
+		return int(math.Round((high - price) / span * float64(height-1)))
+	}
+
+	lines := make([]string, height)
+	for y := 0; y < height; y++ {
+		var sb strings.Builder
+		color := ""
+		for _, k := range klines {
+			top, bottom := row(math.Max(k.Open, k.Close)), row(math.Min(k.Open, k.Close))
+			ch := " "
+			switch {
+			case y >= top && y <= bottom:
+				ch = "█"
+			case y >= row(k.High) && y <= row(k.Low):
+				ch = "│"
+			}
+			c := ansiGreen
+			if k.Close < k.Open {
+				c = ansiRed
+			}
+			if ch != " " && c != color {
+				sb.WriteString(c)
+				color = c
+			}
+			sb.WriteString(ch)
+		}
+		sb.WriteString(ansiReset)
+		switch y {
+		case 0:
+			fmt.Fprintf(&sb, " %.4f", high)
+		case height - 1:
+			fmt.Fprintf(&sb, " %.4f", low)
+		}
+		lines[y] = sb.String()
+	}
+	return lines
+}
+
+// 终端界面的一次数据
+type tuiData struct {
+	Symbol    string
+	Interval  string
+	Price     float64
+	Change    float64 // 相对第一根K线开盘价的涨跌幅(%)
+	Klines    []Kline
+	Positions []*futures.PositionRisk
+	Orders    []*futures.Order
+	Activity  []Activity
+	Err       error
+	Updated   time.Time
+}
+
+func (t *TraderCLI) fetchDashboard(symbol, interval string) tuiData {
+	d := tuiData{Symbol: symbol, Interval: interval, Updated: time.Now()}
+	klines, err := fetchKlines(t.client, symbol, interval, tuiKlines)
+	if err != nil {
+		d.Err = err
+		return d
+	}
+	d.Klines = klines
+	if len(klines) > 0 {
+		d.Price = klines[len(klines)-1].Close
+		if open := klines[0].Open; open > 0 {
+			d.Change = (d.Price - open) / open * 100
+		}
+	}
+	if d.Positions, err = t.openPositions(""); err != nil {
+		d.Err = err
+		return d
+	}
+	if d.Orders, err = t.client.NewListOpenOrdersService().Do(context.Background()); err != nil {
+		d.Err = fmt.Errorf(T("获取订单失败: %v"), err)
+		return d
+	}
+	d.Activity, err = t.activity.Query(ActivityQuery{Limit: 100})
+	if err != nil {
+		d.Err = err
+	}
+	return d
+}
+
+// 终端界面的面板
+const (
+	tuiPanePositions = iota
+	tuiPaneOrders
+	tuiPaneActivity
+	tuiPaneCount
+)
+
+var tuiPaneTitles = [tuiPaneCount]string{"持仓", "订单", "活动记录"}
+
+type dashboard struct {
+	symbol   string
+	interval string
+	focus    int
+	scroll   [tuiPaneCount]int
+	data     tuiData
+	loading  bool
+}
+
+// 面板的内容，第一行是表头(活动记录没有表头)
+func (d *dashboard) paneLines(pane int) (lines []string, colors []string) {
+	switch pane {
+	case tuiPanePositions:
+		if len(d.data.Positions) == 0 {
+			return []string{T("无持仓")}, nil
+		}
+		lines, pnls := positionLines(d.data.Positions)
+		for _, pnl := range pnls {
+			colors = append(colors, pnlColor(pnl))
+		}
+		colors[0] = ansiDim
+		return lines, colors
+	case tuiPaneOrders:
+		if len(d.data.Orders) == 0 {
+			return []string{T("没有订单")}, nil
+		}
+		lines := orderLines(d.data.Orders)
+		colors = make([]string, len(lines))
+		colors[0] = ansiDim
+		return lines, colors
+	}
+	if len(d.data.Activity) == 0 {
+		return []string{T("没有活动记录")}, nil
+	}
+	// 最新的在最上面
+	for i := len(d.data.Activity) - 1; i >= 0; i-- {
+		a := d.data.Activity[i]
+		lines = append(lines, a.String())
+		switch {
+		case a.Error != "":
+			colors = append(colors, ansiRed)
+		case a.Action == ActivityProtect:
+			colors = append(colors, ansiYellow)
+		default:
+			colors = append(colors, "")
+		}
+	}
+	return lines, colors
+}
+
+func (d *dashboard) render(cols, rows int) string {
+	var out []string
+	add := func(line, color string) {
+		line = truncateLine(line, cols)
+		if color != "" {
+			line = color + line + ansiReset
+		}
+		out = append(out, line)
+	}
+
+	// 标题: 交易对、价格、涨跌幅和更新时间
+	header := fmt.Sprintf(" %s  %.4f  %+.2f%%  %s  %s", d.symbol, d.data.Price, d.data.Change, d.interval,
+		d.data.Updated.Format("15:04:05"))
+	if d.loading {
+		header += "  " + T("刷新中...")
+	}
+	add(header, ansiBold+pnlColor(d.data.Change))
+	if d.data.Err != nil {
+		add(" "+d.data.Err.Error(), ansiRed)
+	}
+
+	// K线占三分之一的高度，三个面板平分剩下的高度
+	footer := 1
+	candleHeight := rows / 3
+	out = append(out, renderCandles(d.data.Klines, cols, candleHeight)...)
+	paneHeight := (rows - len(out) - footer) / tuiPaneCount
+	for pane := 0; pane < tuiPaneCount; pane++ {
+		lines, colors := d.paneLines(pane)
+		title := fmt.Sprintf(" %s (%d) ", T(tuiPaneTitles[pane]), len(lines))
+		if pane == d.focus {
+			add(title+strings.Repeat("─", max(0, cols-len([]rune(title)))), ansiReverse)
+		} else {
+			add(title+strings.Repeat("─", max(0, cols-len([]rune(title)))), ansiDim)
+		}
+
+		visible := paneHeight - 1
+		if d.scroll[pane] > len(lines)-visible {
+			d.scroll[pane] = max(0, len(lines)-visible)
+		}
+		for i := 0; i < visible; i++ {
+			idx := d.scroll[pane] + i
+			if idx >= len(lines) {
+				add("", "")
+				continue
+			}
+			color := ""
+			if idx < len(colors) {
+				color = colors[idx]
+			}
+			add(lines[idx], color)
+		}
+	}
+	add(T(" Tab 切换面板  ↑↓/jk 滚动  ←→/hl 切换周期  r 刷新  q 退出"), ansiDim)
+
+	var sb strings.Builder
+	sb.WriteString(ansiHome)
+	for i, line := range out {
+		if i >= rows {
+			break
+		}
+		sb.WriteString(line)
+		sb.WriteString(ansiClearLine)
+		if i < rows-1 {
+			sb.WriteString("\r\n")
+		}
+	}
+	sb.WriteString(ansiClearDown)
+	return sb.String()
+}
+
+// 处理按键，返回是否退出和是否需要立即刷新
+func (d *dashboard) handleKey(key string) (quit, refresh bool) {
+	switch key {
+	case "q", "\x03":
+		return true, false
+	case "\t":
+		d.focus = (d.focus + 1) % tuiPaneCount
+	case "j", "\x1b[B":
+		d.scroll[d.focus]++
+	case "k", "\x1b[A":
+		if d.scroll[d.focus] > 0 {
+			d.scroll[d.focus]--
+		}
+	case "h", "l", "\x1b[D", "\x1b[C":
+		step := 1
+		if key == "h" || key == "\x1b[D" {
+			step = -1
+		}
+		for i, interval := range tuiIntervals {
+			if interval == d.interval {
+				d.interval = tuiIntervals[(i+step+len(tuiIntervals))%len(tuiIntervals)]
+				return false, true
+			}
+		}
+		d.interval = tuiIntervals[0]
+		return false, true
+	case "r":
+		return false, true
+	}
+	return false, false
+}
+
+// 读取按键，方向键是三个字节的转义序列
+func readKeys(r io.Reader, keys chan<- string) {
+	buf := make([]byte, 16)
+	for {
+		n, err := r.Read(buf)
+		if err != nil {
+			close(keys)
+			return
+		}
+		input := string(buf[:n])
+		for len(input) > 0 {
+			if strings.HasPrefix(input, "\x1b[") && len(input) >= 3 {
+				keys <- input[:3]
+				input = input[3:]
+				continue
+			}
+			keys <- input[:1]
+			input = input[1:]
+		}
+	}
+}
+
+// 终端全屏界面: 价格和K线、持仓、挂单和活动记录，可以在SSH上使用
+func (t *TraderCLI) tui(args []string) error {
+	fs := flag.NewFlagSet("tui", flag.ContinueOnError)
+	symbol := fs.String("symbol", t.protectConfig().Symbol, T("交易对"))
+	interval := fs.String("interval", "15m", T("K线周期"))
+	engine := fs.Bool("engine", false, T("在后台运行止盈止损监控"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	restore, err := enableRawTerminal()
+	if err != nil {
+		return err
+	}
+	defer restore()
+	fmt.Print(ansiAltScreen + ansiHideCursor)
+	defer fmt.Print(ansiShowCursor + ansiMainScreen)
+
+	// 日志会打乱界面，自动化的决策在活动记录面板中显示
+	cliLogOutput.Set(io.Discard)
+	if *engine {
+		go t.run()
+	}
+
+	d := &dashboard{symbol: strings.ToUpper(*symbol), interval: *interval, loading: true}
+	dataC := make(chan tuiData, 1)
+	fetch := func() {
+		d.loading = true
+		symbol, interval := d.symbol, d.interval
+		go func() { dataC <- t.fetchDashboard(symbol, interval) }()
+	}
+	keys := make(chan string)
+	go readKeys(os.Stdin, keys)
+
+	ticker := time.NewTicker(tuiRefresh)
+	defer ticker.Stop()
+	fetch()
+	for {
+		cols, rows := terminalSize()
+		fmt.Print(d.render(cols, rows))
+
+		select {
+		case key, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			quit, refresh := d.handleKey(key)
+			if quit {
+				return nil
+			}
+			if refresh {
+				fetch()
+			}
+		case data := <-dataC:
+			// 丢弃切换周期之前发出的请求，等待新的结果
+			if data.Symbol != d.symbol || data.Interval != d.interval {
+				continue
+			}
+			d.data = data
+			d.loading = false
+		case <-ticker.C:
+			if !d.loading {
+				fetch()
+			}
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+)
+
+// 显示版本: version [-output json]，也可以用 protect --version
+func (t *TraderCLI) version(args []string) error {
+	fs := flag.NewFlagSet("version", flag.ContinueOnError)
+	output := outputFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+
+	info := currentBuildInfo()
+	if *output == "json" {
+		return writeJSON(info)
+	}
+	fmt.Println(info)
+	return nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// 在终端中原地刷新持仓、挂单和盈亏表格，Ctrl+C退出
+func (t *TraderCLI) watch(args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	seconds := fs.Int("n", 2, T("刷新间隔(秒)"))
+	symbol := fs.String("symbol", "", T("交易对，为空时显示全部"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *seconds < 1 {
+		*seconds = 1
+	}
+	filter := strings.ToUpper(*symbol)
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+
+	fmt.Print("\x1b[2J" + ansiHideCursor)
+	defer fmt.Print(ansiShowCursor)
+
+	ticker := time.NewTicker(time.Duration(*seconds) * time.Second)
+	defer ticker.Stop()
+	for {
+		cols, _ := terminalSize()
+		fmt.Print(t.renderWatch(filter, *seconds, cols))
+		select {
+		case <-interrupt:
+			fmt.Println()
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// 一屏内容: 标题、账户、持仓和挂单。获取失败时在标题下显示错误，保留其他部分
+func (t *TraderCLI) renderWatch(symbol string, seconds, cols int) string {
+	var sb strings.Builder
+	line := func(text, color string) {
+		text = truncateLine(text, cols)
+		if color != "" {
+			text = color + text + ansiReset
+		}
+		sb.WriteString(text + ansiClearLine + "\n")
+	}
+
+	sb.WriteString(ansiHome)
+	line(fmt.Sprintf(T("protect watch  每%d秒刷新  %s  Ctrl+C 退出"), seconds, time.Now().Format("15:04:05")), ansiBold)
+	line("", "")
+
+	if account, err := fetchAccountSummary(t.client); err != nil {
+		line(err.Error(), ansiRed)
+	} else {
+		line(fmt.Sprintf(T("钱包余额 %.2f  可用 %.2f  保证金使用率 %.2f%%  维持保证金率 %.2f%%"),
+			account.WalletBalance, account.AvailableBalance, account.MarginUsage(), account.MaintenanceRatio()), "")
+	}
+
+	positions, err := t.openPositions(symbol)
+	if err != nil {
+		line(err.Error(), ansiRed)
+	}
+	var total float64
+	for _, p := range positions {
+		total += newPositionJSON(p).UnrealizedPnL
+	}
+	line(fmt.Sprintf(T("未实现盈亏 %+.2f"), total), ansiBold+pnlColor(total))
+	line("", "")
+
+	line(fmt.Sprintf(" %s (%d) ", T("持仓"), len(positions)), ansiReverse)
+	if len(positions) == 0 {
+		line(T("无持仓"), ansiDim)
+	} else {
+		lines, pnls := positionLines(positions)
+		for i, l := range lines {
+			color := pnlColor(pnls[i])
+			if i == 0 {
+				color = ansiDim
+			}
+			line(l, color)
+		}
+	}
+	line("", "")
+
+	service := t.client.NewListOpenOrdersService()
+	if symbol != "" {
+		service = service.Symbol(symbol)
+	}
+	orders, err := service.Do(context.Background())
+	if err != nil {
+		line(fmt.Sprintf(T("获取订单失败: %v"), err), ansiRed)
+	}
+	line(fmt.Sprintf(" %s (%d) ", T("订单"), len(orders)), ansiReverse)
+	if len(orders) == 0 {
+		line(T("没有订单"), ansiDim)
+	} else {
+		for i, l := range orderLines(orders) {
+			color := ""
+			if i == 0 {
+				color = ansiDim
+			}
+			line(l, color)
+		}
+	}
+
+	sb.WriteString(ansiClearDown)
+	return sb.String()
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// 每次同步最多计算多少笔交易的最大浮盈浮亏，避免第一次同步时请求过多
+const maxExcursionsPerSync = 50
+
+// 一笔完整的交易：从开仓到仓位归零，反手时拆成两笔
+type ClosedTrade struct {
+	ID         string // 交易对和开仓成交ID
+	Symbol     string
+	Long       bool
+	EntryTime  time.Time
+	ExitTime   time.Time
+	EntryPrice float64 // 开仓成交均价
+	ExitPrice  float64 // 平仓成交均价
+	Size       float64 // 最大持仓数量
+	PnL        float64 // 已实现盈亏
+	Fees       float64 // 手续费
+	Excursion  *Excursion
+	Snapshot   string     // 平仓截图的路径，没有时为空
+	Note       *TradeNote // 交易笔记，没有时为nil
+}
+
+func (t ClosedTrade) Direction() string {
+	if t.Long {
+		return T("多")
+	}
+	return T("空")
+}
+
+// 扣除手续费后的净盈亏
+func (t ClosedTrade) Net() float64 {
+	return t.PnL - t.Fees
+}
+
+// 交易列表中显示的一行
+func (t ClosedTrade) String() string {
+	excursion := "-"
+	if t.Excursion != nil {
+		excursion = fmt.Sprintf("+%.2f%%/-%.2f%%", t.Excursion.Favorable, t.Excursion.Adverse)
+	}
+	return fmt.Sprintf(T("%s %s %s %.4f 开仓 %s @%.4f 平仓 %s @%.4f 盈亏 %+.2f 手续费 %.2f 最大浮盈/浮亏 %s"),
+		t.ExitTime.Format("01-02"), t.Symbol, t.Direction(), t.Size,
+		t.EntryTime.Format("01-02 15:04"), t.EntryPrice,
+		t.ExitTime.Format("01-02 15:04"), t.ExitPrice,
+		t.PnL, t.Fees, excursion)
+}
+
+// 交易汇总：笔数、胜率和净盈亏
+func closedTradeSummary(trades []ClosedTrade) string {
+	if len(trades) == 0 {
+		return T("没有符合条件的交易")
+	}
+	var wins int
+	var pnl, fees float64
+	for _, t := range trades {
+		if t.Net() > 0 {
+			wins++
+		}
+		pnl += t.PnL
+		fees += t.Fees
+	}
+	return fmt.Sprintf(T("共%d笔 胜率 %.1f%% 盈亏 %+.2f 手续费 %.2f 净盈亏 %+.2f"),
+		len(trades), float64(wins)/float64(len(trades))*100, pnl, fees, pnl-fees)
+}
+
+// 按成交记录还原交易，只支持单向持仓模式。同步范围之前开的仓无法还原，
+// 其平仓成交会被忽略
+func buildClosedTrades(fills []FillRecord) []ClosedTrade {
+	type openTrade struct {
+		trade     ClosedTrade
+		position  float64
+		entryQty  float64
+		entryCost float64
+		exitQty   float64
+		exitCost  float64
+	}
+
+	var trades []ClosedTrade
+	open := make(map[string]*openTrade)
+	for _, f := range fills {
+		qty := f.SignedQuantity()
+		st := open[f.Symbol]
+		if st == nil && f.RealizedPnL != 0 {
+			continue
+		}
+
+		for math.Abs(qty) > 1e-12 {
+			if st == nil {
+				st = &openTrade{trade: ClosedTrade{
+					ID:        fmt.Sprintf("%s-%d", f.Symbol, f.ID),
+					Symbol:    f.Symbol,
+					Long:      qty > 0,
+					EntryTime: f.Time,
+				}}
+				open[f.Symbol] = st
+			}
+			share := math.Abs(qty) / f.Quantity
+
+			// 开仓或加仓
+			if st.position == 0 || (st.position > 0) == (qty > 0) {
+				st.entryQty += math.Abs(qty)
+				st.entryCost += math.Abs(qty) * f.Price
+				st.position += qty
+				st.trade.Size = math.Max(st.trade.Size, math.Abs(st.position))
+				st.trade.Fees += f.Commission * share
+				break
+			}
+
+			// 减仓或平仓，超出持仓的部分在下一轮作为反手开仓
+			closeQty := math.Min(math.Abs(qty), math.Abs(st.position))
+			share = closeQty / f.Quantity
+			st.exitQty += closeQty
+			st.exitCost += closeQty * f.Price
+			st.trade.PnL += f.RealizedPnL * share
+			st.trade.Fees += f.Commission * share
+			if qty > 0 {
+				st.position += closeQty
+				qty -= closeQty
+			} else {
+				st.position -= closeQty
+				qty += closeQty
+			}
+
+			if math.Abs(st.position) < 1e-9 {
+				t := st.trade
+				t.ExitTime = f.Time
+				t.EntryPrice = st.entryCost / st.entryQty
+				t.ExitPrice = st.exitCost / st.exitQty
+				trades = append(trades, t)
+				delete(open, f.Symbol)
+				st = nil
+			}
+		}
+	}
+	return trades
+}
+
+// 按交易对和平仓时间筛选已平仓的交易，symbol为空时不限交易对，按平仓时间排序
+func (db *TradeDB) ClosedTrades(symbol string, since, until time.Time) []ClosedTrade {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var result []ClosedTrade
+	for _, t := range buildClosedTrades(db.data.Fills) {
+		if symbol != "" && !strings.EqualFold(t.Symbol, symbol) {
+			continue
+		}
+		if t.ExitTime.Before(since) || (!until.IsZero() && t.ExitTime.After(until)) {
+			continue
+		}
+		if e, ok := db.data.Excursions[t.ID]; ok {
+			t.Excursion = &e
+		}
+		t.Snapshot = db.snapshotLocked(t)
+		if i := db.noteLocked(t); i >= 0 {
+			note := db.data.Notes[i]
+			t.Note = &note
+		}
+		result = append(result, t)
+	}
+	return result
+}
+
+// 与交易平仓时间最接近的同交易对截图
+func (db *TradeDB) snapshotLocked(t ClosedTrade) string {
+	var path string
+	best := snapshotMatchWindow
+	for _, s := range db.data.Snapshots {
+		if s.Symbol != t.Symbol {
+			continue
+		}
+		d := s.Time.Sub(t.ExitTime)
+		if d < 0 {
+			d = -d
+		}
+		if d <= best {
+			path, best = s.Path, d
+		}
+	}
+	return path
+}
+
+// 计算还没有最大浮盈浮亏的交易，用持仓期间的K线最高价和最低价
+func (db *TradeDB) updateExcursions() error {
+	db.mu.Lock()
+	var pending []ClosedTrade
+	for _, t := range buildClosedTrades(db.data.Fills) {
+		if _, ok := db.data.Excursions[t.ID]; !ok {
+			pending = append(pending, t)
+		}
+	}
+	db.mu.Unlock()
+
+	// 优先计算最近的交易
+	if len(pending) > maxExcursionsPerSync {
+		pending = pending[len(pending)-maxExcursionsPerSync:]
+	}
+	excursions := make(map[string]Excursion)
+	for _, t := range pending {
+		e, err := db.fetchExcursion(t)
+		if err != nil {
+			return err
+		}
+		excursions[t.ID] = e
+	}
+	if len(excursions) == 0 {
+		return nil
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.data.Excursions == nil {
+		db.data.Excursions = make(map[string]Excursion)
+	}
+	for id, e := range excursions {
+		db.data.Excursions[id] = e
+	}
+	return db.saveLocked()
+}
+
+// 选择K线数量不超过一次请求上限的最小周期
+func excursionInterval(d time.Duration) string {
+	for _, interval := range []string{"1m", "5m", "15m", "1h", "4h"} {
+		if d/intervalDuration(interval) < maxKlinesPerRequest {
+			return interval
+		}
+	}
+	return "1d"
+}
+
+func (db *TradeDB) fetchExcursion(t ClosedTrade) (Excursion, error) {
+	interval := excursionInterval(t.ExitTime.Sub(t.EntryTime))
+	start := t.EntryTime.Truncate(intervalDuration(interval))
+	klines, err := fetchKlinesRange(db.client, t.Symbol, interval, start, t.ExitTime, maxKlinesPerRequest)
+	if err != nil {
+		return Excursion{}, err
+	}
+
+	high, low := math.Max(t.EntryPrice, t.ExitPrice), math.Min(t.EntryPrice, t.ExitPrice)
+	for _, k := range klines {
+		high = math.Max(high, k.High)
+		low = math.Min(low, k.Low)
+	}
+	up := (high - t.EntryPrice) / t.EntryPrice * 100
+	down := (t.EntryPrice - low) / t.EntryPrice * 100
+	if t.Long {
+		return Excursion{Favorable: up, Adverse: down}, nil
+	}
+	return Excursion{Favorable: down, Adverse: up}, nil
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// 两个持仓之间的相关性警告
+type CorrelationWarning struct {
+	SymbolA string
+	SymbolB string
+	Corr    float64
+}
+
+func (w CorrelationWarning) String() string {
+	return fmt.Sprintf(T("相关性警告: %s / %s 相关系数 %.2f，实际上是同一方向的押注"), w.SymbolA, w.SymbolB, w.Corr)
+}
+
+// 持仓相关性监控，计算持仓交易对之间收益率的滚动相关系数
+type CorrelationMonitor struct {
+	client    *futures.Client
+	interval  string
+	limit     int
+	threshold float64
+
+	lastCheck time.Time
+	lastKey   string
+	warnings  []CorrelationWarning
+}
+
+func NewCorrelationMonitor(client *futures.Client, interval string, limit int, threshold float64) *CorrelationMonitor {
+	return &CorrelationMonitor{
+		client:    client,
+		interval:  interval,
+		limit:     limit,
+		threshold: threshold,
+	}
+}
+
+// 检查持仓之间的相关性，持仓不变时每5分钟重新计算一次
+func (m *CorrelationMonitor) Check(positions []*futures.PositionRisk) ([]CorrelationWarning, error) {
+	held := make(map[string]float64)
+	var symbols []string
+	for _, p := range positions {
+		amt, _ := strconv.ParseFloat(p.PositionAmt, 64)
+		if amt == 0 {
+			continue
+		}
+		if _, ok := held[p.Symbol]; !ok {
+			symbols = append(symbols, p.Symbol)
+		}
+		held[p.Symbol] += amt
+	}
+	sort.Strings(symbols)
+
+	if len(symbols) < 2 {
+		m.warnings = nil
+		return nil, nil
+	}
+
+	key := fmt.Sprint(held)
+	if key == m.lastKey && time.Since(m.lastCheck) < 5*time.Minute {
+		return m.warnings, nil
+	}
+
+	returns := make(map[string][]float64)
+	for _, symbol := range symbols {
+		klines, err := fetchKlines(m.client, symbol, m.interval, m.limit)
+		if err != nil {
+			return m.warnings, err
+		}
+		returns[symbol] = logReturns(klines)
+	}
+
+	var warnings []CorrelationWarning
+	for i := 0; i < len(symbols); i++ {
+		for j := i + 1; j < len(symbols); j++ {
+			a, b := symbols[i], symbols[j]
+			corr := pearson(returns[a], returns[b])
+
+			// 正相关且同向持仓，或负相关且反向持仓，风险会叠加
+			sameDirection := (held[a] > 0) == (held[b] > 0)
+			if (corr >= m.threshold && sameDirection) || (corr <= -m.threshold && !sameDirection) {
+				warnings = append(warnings, CorrelationWarning{SymbolA: a, SymbolB: b, Corr: corr})
+			}
+		}
+	}
+
+	m.warnings = warnings
+	m.lastKey = key
+	m.lastCheck = time.Now()
+	return warnings, nil
+}
+
+// 计算K线收盘价的对数收益率
+func logReturns(klines []Kline) []float64 {
+	var returns []float64
+	for i := 1; i < len(klines); i++ {
+		if klines[i-1].Close <= 0 || klines[i].Close <= 0 {
+			continue
+		}
+		returns = append(returns, math.Log(klines[i].Close/klines[i-1].Close))
+	}
+	return returns
+}
+
+// 计算皮尔逊相关系数，长度不同时使用两者末尾对齐的部分
+func pearson(a, b []float64) float64 {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	if n < 2 {
+		return 0
+	}
+	a = a[len(a)-n:]
+	b = b[len(b)-n:]
+
+	var meanA, meanB float64
+	for i := 0; i < n; i++ {
+		meanA += a[i]
+		meanB += b[i]
+	}
+	meanA /= float64(n)
+	meanB /= float64(n)
+
+	var cov, varA, varB float64
+	for i := 0; i < n; i++ {
+		da := a[i] - meanA
+		db := b[i] - meanB
+		cov += da * db
+		varA += da * da
+		varB += db * db
+	}
+	if varA == 0 || varB == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(varA*varB)
+}
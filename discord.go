@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Discord通知设置，在频道设置的"整合 → Webhook"中创建webhook
+type DiscordConfig struct {
+	WebhookURL string   `json:"webhook_url"`
+	Username   string   `json:"username,omitempty"` // 消息显示的发送者名称，为空时使用webhook的名称
+	Events     []string `json:"events,omitempty"`   // 推送的事件类型，为空时推送所有事件
+}
+
+// 各类事件的embed颜色
+var discordColors = map[string]int{
+	EventFill:    0x3498db,
+	EventOrder:   0x95a5a6,
+	EventProtect: 0xe67e22,
+	EventAlert:   0xf1c40f,
+	EventError:   0xe74c3c,
+	EventSummary: 0x2ecc71,
+}
+
+// 通过webhook推送embed消息到Discord频道
+type Discord struct {
+	config DiscordConfig
+	client *http.Client
+}
+
+func NewDiscord(config DiscordConfig) (*Discord, error) {
+	if config.WebhookURL == "" {
+		return nil, errors.New(T("Discord需要设置webhook_url"))
+	}
+	return &Discord{config: config, client: &http.Client{Timeout: 15 * time.Second}}, nil
+}
+
+func (d *Discord) Name() string { return "discord" }
+
+type discordField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+type discordEmbed struct {
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	Color       int            `json:"color"`
+	Fields      []discordField `json:"fields,omitempty"`
+	Timestamp   string         `json:"timestamp"`
+	Footer      struct {
+		Text string `json:"text"`
+	} `json:"footer"`
+}
+
+func (d *Discord) Notify(event NotifyEvent) error {
+	embed := discordEmbed{
+		Title:       event.Title,
+		Description: event.Message,
+		Color:       discordColors[event.Type],
+		Timestamp:   event.Time.UTC().Format(time.RFC3339),
+	}
+	embed.Footer.Text = event.Type
+	names := make([]string, 0, len(event.Fields))
+	for name := range event.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	// Discord限制每个embed最多25个字段
+	for _, name := range names[:min(len(names), 25)] {
+		embed.Fields = append(embed.Fields, discordField{Name: name, Value: fmt.Sprintf("%.6g", event.Fields[name]), Inline: true})
+	}
+
+	body, err := json.Marshal(struct {
+		Username string         `json:"username,omitempty"`
+		Embeds   []discordEmbed `json:"embeds"`
+	}{d.config.Username, []discordEmbed{embed}})
+	if err != nil {
+		return fmt.Errorf(T("序列化请求失败: %v"), err)
+	}
+	resp, err := d.client.Post(d.config.WebhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		// 错误信息中的webhook地址包含令牌，不能打印
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf(T("请求Discord失败: %v"), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf(T("Discord返回错误 %d: %s"), resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return nil
+}
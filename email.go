@@ -0,0 +1,115 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 邮件通知设置。默认只发送每日汇总，没有设置daily_summary时在每天00:05发送前一天的日报
+type EmailConfig struct {
+	Host     string   `json:"host"`           // SMTP服务器
+	Port     int      `json:"port,omitempty"` // 默认587(STARTTLS)，465使用TLS直连
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+	Events   []string `json:"events,omitempty"` // 发送的事件类型，默认只发送summary
+}
+
+// 每日汇总的默认发送时间
+const defaultSummaryTime = "00:05"
+
+// 通过SMTP发送邮件，标题为事件标题，正文为事件内容
+type Email struct {
+	config EmailConfig
+}
+
+func NewEmail(config EmailConfig) (*Email, error) {
+	if config.Host == "" || config.From == "" || len(config.To) == 0 {
+		return nil, errors.New(T("邮件需要设置host、from和to"))
+	}
+	if config.Port == 0 {
+		config.Port = 587
+	}
+	if len(config.Events) == 0 {
+		config.Events = []string{EventSummary}
+	}
+	return &Email{config: config}, nil
+}
+
+func (e *Email) Name() string { return "email" }
+
+// 订阅的事件类型
+func (e *Email) Events() []string { return e.config.Events }
+
+func (e *Email) Notify(event NotifyEvent) error {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", e.config.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(e.config.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", event.Title))
+	fmt.Fprintf(&msg, "Date: %s\r\n", event.Time.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(event.Message, "\n", "\r\n"))
+	msg.WriteString("\r\n")
+
+	if err := e.send([]byte(msg.String())); err != nil {
+		return fmt.Errorf(T("发送邮件失败: %v"), err)
+	}
+	return nil
+}
+
+func (e *Email) send(msg []byte) error {
+	addr := net.JoinHostPort(e.config.Host, strconv.Itoa(e.config.Port))
+	var auth smtp.Auth
+	if e.config.Username != "" {
+		auth = smtp.PlainAuth("", e.config.Username, e.config.Password, e.config.Host)
+	}
+	if e.config.Port != 465 {
+		// SendMail在服务器支持时使用STARTTLS
+		return smtp.SendMail(addr, auth, e.config.From, e.config.To, msg)
+	}
+
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 30 * time.Second}, "tcp", addr, &tls.Config{ServerName: e.config.Host})
+	if err != nil {
+		return err
+	}
+	c, err := smtp.NewClient(conn, e.config.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if auth != nil {
+		if err := c.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(e.config.From); err != nil {
+		return err
+	}
+	for _, to := range e.config.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
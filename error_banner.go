@@ -0,0 +1,82 @@
+package main
+
+import (
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 面板顶部的错误横幅：轮询或下单失败时显示错误详情和立即重试按钮，
+// 避免面板上的旧数据被当成最新数据。下一次成功后自动隐藏，
+// 关闭后同样的错误不再显示，直到成功一次或出现不同的错误
+type ErrorBanner struct {
+	retry func() error
+
+	message  *widget.Label
+	retryBtn *widget.Button
+	box      *fyne.Container
+
+	mu        sync.Mutex
+	current   string
+	dismissed string
+}
+
+func NewErrorBanner(retry func() error) *ErrorBanner {
+	b := &ErrorBanner{retry: retry}
+	b.message = widget.NewLabel("")
+	b.message.Importance = widget.DangerImportance
+	b.message.Wrapping = fyne.TextWrapWord
+	b.retryBtn = widget.NewButtonWithIcon(T("立即重试"), theme.ViewRefreshIcon(), b.retryNow)
+	closeBtn := widget.NewButtonWithIcon("", theme.CancelIcon(), b.dismiss)
+	closeBtn.Importance = widget.LowImportance
+	b.box = container.NewBorder(nil, nil,
+		widget.NewIcon(theme.ErrorIcon()),
+		container.NewHBox(b.retryBtn, closeBtn),
+		b.message,
+	)
+	b.box.Hide()
+	return b
+}
+
+func (b *ErrorBanner) Content() fyne.CanvasObject {
+	return b.box
+}
+
+// 报告一次轮询或请求的结果，err为nil时隐藏横幅。可以在任意goroutine中调用
+func (b *ErrorBanner) Report(err error) {
+	b.mu.Lock()
+	if err == nil {
+		b.current, b.dismissed = "", ""
+	} else {
+		b.current = err.Error()
+	}
+	text, visible := b.current, b.current != "" && b.current != b.dismissed
+	b.mu.Unlock()
+
+	fyne.Do(func() {
+		b.message.SetText(text)
+		if visible {
+			b.box.Show()
+		} else {
+			b.box.Hide()
+		}
+	})
+}
+
+func (b *ErrorBanner) dismiss() {
+	b.mu.Lock()
+	b.dismissed = b.current
+	b.mu.Unlock()
+	b.box.Hide()
+}
+
+func (b *ErrorBanner) retryNow() {
+	b.retryBtn.Disable()
+	go func() {
+		b.Report(b.retry())
+		fyne.Do(b.retryBtn.Enable)
+	}()
+}
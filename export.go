@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// 可导出的数据类型
+const (
+	ExportKlines = "klines" // K线
+	ExportTrades = "trades" // 成交记录
+	ExportIncome = "income" // 资金流水（已实现盈亏、资金费、手续费），用于还原持仓历史
+)
+
+var exportKinds = []string{ExportKlines, ExportTrades, ExportIncome}
+
+// 导出参数
+type ExportOptions struct {
+	Kind     string
+	Symbol   string    // 成交记录和资金流水为空时导出全部交易对
+	Interval string    // 仅K线
+	Limit    int       // 仅K线，导出最近多少根
+	Since    time.Time // 成交记录和资金流水的起始时间
+	Until    time.Time // 成交记录和资金流水的结束时间(不含)，为零时导出到现在
+}
+
+// 默认文件名，如 SOLUSDC_klines_1h_20240102.csv，指定了结束时间时
+// 用起止日期命名，如 ALL_income_20240101-20240201.csv
+func (o ExportOptions) DefaultPath() string {
+	symbol := o.Symbol
+	if symbol == "" {
+		symbol = "ALL"
+	}
+	name := symbol + "_" + o.Kind
+	if o.Kind == ExportKlines {
+		name += "_" + o.Interval
+	}
+	if !o.Until.IsZero() {
+		return name + "_" + o.Since.Format("20060102") + "-" + o.Until.Format("20060102") + ".csv"
+	}
+	return name + "_" + time.Now().Format("20060102") + ".csv"
+}
+
+// 成交记录和资金流水的结束时间
+func (o ExportOptions) until() time.Time {
+	if o.Until.IsZero() {
+		return time.Now()
+	}
+	return o.Until
+}
+
+// 按类型导出数据为CSV，返回导出的行数。K线优先使用本地缓存
+func runExport(client *futures.Client, cache *KlineCache, opts ExportOptions, w io.Writer) (int, error) {
+	var header []string
+	var rows [][]string
+	switch opts.Kind {
+	case ExportKlines:
+		klines, err := cache.Get(opts.Symbol, opts.Interval, opts.Limit)
+		if err != nil {
+			return 0, err
+		}
+		header, rows = klineRows(klines)
+	case ExportTrades:
+		trades, err := fetchAllAccountTrades(client, opts.Symbol, opts.Since, opts.until())
+		if err != nil {
+			return 0, err
+		}
+		header, rows = tradeRows(trades)
+	case ExportIncome:
+		incomes, err := fetchIncomeRange(client, opts.Symbol, "", opts.Since, opts.until())
+		if err != nil {
+			return 0, err
+		}
+		header, rows = incomeRows(incomes)
+	default:
+		return 0, fmt.Errorf(T("不支持的导出类型: %s"), opts.Kind)
+	}
+
+	if err := writeCSV(w, header, rows); err != nil {
+		return 0, err
+	}
+	return len(rows), nil
+}
+
+func writeCSV(out io.Writer, header []string, rows [][]string) error {
+	// 写入UTF-8 BOM，Excel打开时中文不会乱码
+	if _, err := io.WriteString(out, "\xef\xbb\xbf"); err != nil {
+		return fmt.Errorf(T("写入导出文件失败: %v"), err)
+	}
+
+	w := csv.NewWriter(out)
+	w.Write(header)
+	w.WriteAll(rows)
+	if err := w.Error(); err != nil {
+		return fmt.Errorf(T("写入导出文件失败: %v"), err)
+	}
+	return nil
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func formatMillis(ms int64) string {
+	return time.UnixMilli(ms).UTC().Format(time.RFC3339)
+}
+
+func klineRows(klines []Kline) ([]string, [][]string) {
+	header := []string{"time", "open", "high", "low", "close", "volume"}
+	rows := make([][]string, len(klines))
+	for i, k := range klines {
+		rows[i] = []string{
+			k.Time.UTC().Format(time.RFC3339),
+			formatFloat(k.Open),
+			formatFloat(k.High),
+			formatFloat(k.Low),
+			formatFloat(k.Close),
+			formatFloat(k.Volume),
+		}
+	}
+	return header, rows
+}
+
+func tradeRows(trades []*futures.AccountTrade) ([]string, [][]string) {
+	header := []string{"time", "symbol", "id", "order_id", "side", "position_side", "price", "qty", "quote_qty",
+		"realized_pnl", "commission", "commission_asset", "maker"}
+	rows := make([][]string, len(trades))
+	for i, t := range trades {
+		rows[i] = []string{
+			formatMillis(t.Time),
+			t.Symbol,
+			strconv.FormatInt(t.ID, 10),
+			strconv.FormatInt(t.OrderID, 10),
+			string(t.Side),
+			string(t.PositionSide),
+			t.Price,
+			t.Quantity,
+			t.QuoteQuantity,
+			t.RealizedPnl,
+			t.Commission,
+			t.CommissionAsset,
+			strconv.FormatBool(t.Maker),
+		}
+	}
+	return header, rows
+}
+
+func incomeRows(incomes []*futures.IncomeHistory) ([]string, [][]string) {
+	header := []string{"time", "symbol", "type", "income", "asset", "info", "tran_id", "trade_id"}
+	rows := make([][]string, len(incomes))
+	for i, in := range incomes {
+		rows[i] = []string{
+			formatMillis(in.Time),
+			in.Symbol,
+			in.IncomeType,
+			in.Income,
+			in.Asset,
+			in.Info,
+			strconv.FormatInt(in.TranID, 10),
+			in.TradeID,
+		}
+	}
+	return header, rows
+}
+
+// 获取从since开始的全部成交记录，接口每次最多查询7天
+func fetchAccountTrades(client *futures.Client, symbol string, since time.Time) ([]*futures.AccountTrade, error) {
+	return fetchTradeRange(client, symbol, since, time.Now())
+}
+
+// 获取[since, until)之间的成交记录
+func fetchTradeRange(client *futures.Client, symbol string, since, until time.Time) ([]*futures.AccountTrade, error) {
+	const window = 7 * 24 * time.Hour
+
+	var result []*futures.AccountTrade
+	for start := since; start.Before(until); {
+		end := start.Add(window - time.Millisecond)
+		if end.After(until) {
+			end = until.Add(-time.Millisecond)
+		}
+		trades, err := client.NewListAccountTradeService().
+			Symbol(symbol).
+			StartTime(start.UnixMilli()).
+			EndTime(end.UnixMilli()).
+			Limit(1000).
+			Do(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf(T("获取成交记录失败: %v"), err)
+		}
+		result = append(result, trades...)
+
+		// 一个窗口内超过1000笔成交时从最后一笔之后继续
+		if len(trades) == 1000 {
+			start = time.UnixMilli(trades[len(trades)-1].Time + 1)
+		} else {
+			start = end.Add(time.Millisecond)
+		}
+	}
+	return result, nil
+}
+
+// 成交记录接口必须指定交易对，symbol为空时从资金流水中找出有成交的交易对，逐个获取后按时间排序
+func fetchAllAccountTrades(client *futures.Client, symbol string, since, until time.Time) ([]*futures.AccountTrade, error) {
+	if symbol != "" {
+		return fetchTradeRange(client, symbol, since, until)
+	}
+	incomes, err := fetchIncomeRange(client, "", IncomeCommission, since, until)
+	if err != nil {
+		return nil, err
+	}
+	var result []*futures.AccountTrade
+	seen := make(map[string]bool)
+	for _, in := range incomes {
+		if in.Symbol == "" || seen[in.Symbol] {
+			continue
+		}
+		seen[in.Symbol] = true
+		trades, err := fetchTradeRange(client, in.Symbol, since, until)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, trades...)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Time < result[j].Time })
+	return result, nil
+}
+
+// 获取从since开始的全部资金流水，symbol为空时获取所有交易对
+func fetchIncomeHistory(client *futures.Client, symbol string, since time.Time) ([]*futures.IncomeHistory, error) {
+	return fetchIncomeRange(client, symbol, "", since, time.Now())
+}
+
+// 获取[since, until)之间的资金流水，incomeType为空时获取所有类型
+func fetchIncomeRange(client *futures.Client, symbol, incomeType string, since, until time.Time) ([]*futures.IncomeHistory, error) {
+	var result []*futures.IncomeHistory
+	start := since
+	for {
+		service := client.NewGetIncomeHistoryService().
+			StartTime(start.UnixMilli()).
+			EndTime(until.UnixMilli() - 1).
+			Limit(1000)
+		if symbol != "" {
+			service.Symbol(symbol)
+		}
+		if incomeType != "" {
+			service.IncomeType(incomeType)
+		}
+		incomes, err := service.Do(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf(T("获取资金流水失败: %v"), err)
+		}
+		result = append(result, incomes...)
+		if len(incomes) < 1000 {
+			return result, nil
+		}
+		start = time.UnixMilli(incomes[len(incomes)-1].Time + 1)
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// 资金费跟踪，按持仓记录实际支付/收取的资金费
+type FundingTracker struct {
+	client    *futures.Client
+	openTime  map[string]time.Time // 当前持仓的开仓时间
+	openAmt   map[string]float64   // 计算开仓时间时的持仓数量
+	funding   map[string]float64   // 当前持仓的累计资金费
+	lastFetch map[string]time.Time
+}
+
+func NewFundingTracker(client *futures.Client) *FundingTracker {
+	return &FundingTracker{
+		client:    client,
+		openTime:  make(map[string]time.Time),
+		openAmt:   make(map[string]float64),
+		funding:   make(map[string]float64),
+		lastFetch: make(map[string]time.Time),
+	}
+}
+
+// 更新持仓的累计资金费，资金费每8小时结算一次，所以每分钟查询一次即可
+func (f *FundingTracker) Update(symbol string, amt float64) (float64, error) {
+	if amt == 0 {
+		f.Reset(symbol)
+		return 0, nil
+	}
+
+	// 仓位数量变化（加仓/减仓/反手）时重新计算开仓时间
+	if _, ok := f.openTime[symbol]; !ok || math.Abs(f.openAmt[symbol]-amt) > 0.0001 {
+		openTime, err := f.findPositionOpenTime(symbol, amt)
+		if err != nil {
+			return f.funding[symbol], err
+		}
+		f.openTime[symbol] = openTime
+		f.openAmt[symbol] = amt
+		delete(f.lastFetch, symbol)
+	}
+
+	if last, ok := f.lastFetch[symbol]; ok && time.Since(last) < time.Minute {
+		return f.funding[symbol], nil
+	}
+
+	incomes, err := f.client.NewGetIncomeHistoryService().
+		Symbol(symbol).
+		IncomeType("FUNDING_FEE").
+		StartTime(f.openTime[symbol].UnixMilli()).
+		Limit(1000).
+		Do(context.Background())
+	if err != nil {
+		return f.funding[symbol], fmt.Errorf(T("获取资金费记录失败: %v"), err)
+	}
+
+	var total float64
+	for _, income := range incomes {
+		v, _ := strconv.ParseFloat(income.Income, 64)
+		total += v
+	}
+
+	f.funding[symbol] = total
+	f.lastFetch[symbol] = time.Now()
+	return total, nil
+}
+
+// 获取当前持仓的累计资金费（正数为收取，负数为支付）
+func (f *FundingTracker) Funding(symbol string) float64 {
+	return f.funding[symbol]
+}
+
+// 持仓平掉后清除记录
+func (f *FundingTracker) Reset(symbol string) {
+	delete(f.openTime, symbol)
+	delete(f.openAmt, symbol)
+	delete(f.funding, symbol)
+	delete(f.lastFetch, symbol)
+}
+
+// 从最近的成交记录往回推算，找到当前持仓从0开始建立的时间
+func (f *FundingTracker) findPositionOpenTime(symbol string, amt float64) (time.Time, error) {
+	trades, err := f.client.NewListAccountTradeService().
+		Symbol(symbol).
+		Limit(500).
+		Do(context.Background())
+	if err != nil {
+		return time.Time{}, fmt.Errorf(T("获取成交记录失败: %v"), err)
+	}
+	if len(trades) == 0 {
+		return time.Now(), nil
+	}
+
+	remaining := amt
+	for i := len(trades) - 1; i >= 0; i-- {
+		qty, _ := strconv.ParseFloat(trades[i].Quantity, 64)
+		if trades[i].Side == futures.SideTypeBuy {
+			remaining -= qty
+		} else {
+			remaining += qty
+		}
+		if math.Abs(remaining) <= 0.0001 {
+			return time.UnixMilli(trades[i].Time), nil
+		}
+	}
+
+	// 成交记录不够长，使用最早的一笔成交时间
+	return time.UnixMilli(trades[0].Time), nil
+}
@@ -7,6 +7,7 @@ toolchain go1.24.2
 require (
 	fyne.io/fyne/v2 v2.6.0
 	github.com/adshao/go-binance/v2 v2.8.2
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -54,5 +55,4 @@ require (
 	golang.org/x/sys v0.30.0 // indirect
 	golang.org/x/text v0.23.0 // indirect
 	gonum.org/v1/plot v0.16.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// 本地历史数据目录。download命令下载的K线和资金费率保存在这里供回测读取，
+// 与K线缓存分开，不会被缓存的数量上限截断
+const historyDir = "history"
+
+// 下载时每下载多少页保存一次，中断后从最后保存的位置继续
+const historySaveEvery = 20
+
+// 下载时已用权重超过上限的该比例后暂停到下一分钟
+const historyWeightRatio = 0.75
+
+// 历史资金费率
+type FundingRate struct {
+	Time time.Time `json:"time"`
+	Rate float64   `json:"rate"`
+}
+
+// 本地历史数据，按交易对和周期保存为JSON文件
+type HistoryStore struct {
+	client  *futures.Client
+	dir     string
+	monitor *APIMonitor // 为nil时不根据请求权重限速
+	Logf    func(format string, args ...interface{})
+}
+
+func NewHistoryStore(client *futures.Client, dir string, monitor *APIMonitor) *HistoryStore {
+	return &HistoryStore{client: client, dir: dir, monitor: monitor, Logf: func(string, ...interface{}) {}}
+}
+
+func (s *HistoryStore) path(symbol, name string) string {
+	return filepath.Join(s.dir, symbol+"_"+name+".json")
+}
+
+// 读取JSON文件，文件不存在时不是错误
+func (s *HistoryStore) load(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf(T("读取历史数据失败: %v"), err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf(T("解析历史数据失败: %v"), err)
+	}
+	return nil
+}
+
+func (s *HistoryStore) save(path string, v interface{}) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf(T("保存历史数据失败: %v"), err)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf(T("保存历史数据失败: %v"), err)
+	}
+	// 先写临时文件再改名，下载中断时不会留下半个文件
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf(T("保存历史数据失败: %v"), err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf(T("保存历史数据失败: %v"), err)
+	}
+	return nil
+}
+
+// 每次请求后检查请求权重，接近上限时等到下一分钟
+func (s *HistoryStore) throttle() {
+	if s.monitor == nil {
+		return
+	}
+	if wait := s.monitor.WaitForWeight(int(weightLimit * historyWeightRatio)); wait > 0 {
+		s.Logf(T("请求权重接近上限，暂停%s"), wait.Round(time.Second))
+	}
+}
+
+// 本地保存的[start, end)之间的K线
+func (s *HistoryStore) Klines(symbol, interval string, start, end time.Time) ([]Kline, error) {
+	var klines []Kline
+	if err := s.load(s.path(symbol, interval), &klines); err != nil {
+		return nil, err
+	}
+	from := sort.Search(len(klines), func(i int) bool { return !klines[i].Time.Before(start) })
+	to := sort.Search(len(klines), func(i int) bool { return !klines[i].Time.Before(end) })
+	return klines[from:to], nil
+}
+
+// 下载[start, end)之间本地还没有的K线。已有数据之前和之后缺少的部分分别补齐，
+// 中断后重新运行从上次保存的位置继续。返回新下载的K线数量
+func (s *HistoryStore) DownloadKlines(symbol, interval string, start, end time.Time) (int, error) {
+	step := intervalDuration(interval)
+	if step <= 0 {
+		return 0, fmt.Errorf(T("不支持的K线周期: %s"), interval)
+	}
+	// 只下载已经收盘的K线，未收盘的K线保存后不会再更新
+	if closed := time.Now().Truncate(step); end.After(closed) {
+		end = closed
+	}
+	path := s.path(symbol, interval)
+	var klines []Kline
+	if err := s.load(path, &klines); err != nil {
+		return 0, err
+	}
+
+	// 需要下载的区间: 已有数据之前的部分、中间的缺口和之后的部分。向前补下载中断时
+	// 已保存的部分和原有数据之间会留下缺口，交易所停机造成的缺口每次会重新查询一次
+	type span struct{ from, to time.Time }
+	var spans []span
+	next := start
+	for _, k := range klines {
+		if !k.Time.Before(end) {
+			break
+		}
+		if k.Time.After(next) {
+			spans = append(spans, span{next, k.Time})
+		}
+		if t := k.Time.Add(step); t.After(next) {
+			next = t
+		}
+	}
+	if next.Before(end) {
+		spans = append(spans, span{next, end})
+	}
+
+	total, pages := 0, 0
+	for _, sp := range spans {
+		for from := sp.from; from.Before(sp.to); {
+			page, err := fetchKlinesRange(s.client, symbol, interval, from, sp.to.Add(-time.Millisecond), maxKlinesPerRequest)
+			if err != nil {
+				// 保存已下载的部分，下次从这里继续
+				if total > 0 {
+					s.save(path, klines)
+				}
+				return total, err
+			}
+			s.throttle()
+			if len(page) == 0 {
+				break
+			}
+			n := len(klines)
+			klines = mergeKlines(klines, page)
+			total += len(klines) - n
+			from = page[len(page)-1].Time.Add(step)
+			s.Logf(T("%s %s 已下载%d根K线，到 %s"), symbol, interval, total, page[len(page)-1].Time.Format("2006-01-02 15:04"))
+
+			if pages++; pages%historySaveEvery == 0 {
+				if err := s.save(path, klines); err != nil {
+					return total, err
+				}
+			}
+		}
+	}
+	if total > 0 {
+		if err := s.save(path, klines); err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// 本地保存的[start, end)之间的资金费率
+func (s *HistoryStore) Funding(symbol string, start, end time.Time) ([]FundingRate, error) {
+	var rates []FundingRate
+	if err := s.load(s.path(symbol, "funding"), &rates); err != nil {
+		return nil, err
+	}
+	from := sort.Search(len(rates), func(i int) bool { return !rates[i].Time.Before(start) })
+	to := sort.Search(len(rates), func(i int) bool { return !rates[i].Time.Before(end) })
+	return rates[from:to], nil
+}
+
+// 下载[start, end)之间的资金费率，从本地最后一条之后继续。返回新下载的数量
+func (s *HistoryStore) DownloadFunding(symbol string, start, end time.Time) (int, error) {
+	path := s.path(symbol, "funding")
+	var rates []FundingRate
+	if err := s.load(path, &rates); err != nil {
+		return 0, err
+	}
+	// 资金费率数据量很小，本地数据没有覆盖起始时间时整段重新下载
+	from := start
+	if len(rates) > 0 && !rates[0].Time.After(start) {
+		from = rates[len(rates)-1].Time.Add(time.Millisecond)
+	}
+
+	byTime := make(map[int64]FundingRate, len(rates))
+	for _, r := range rates {
+		byTime[r.Time.UnixMilli()] = r
+	}
+	total := 0
+	for from.Before(end) {
+		page, err := s.client.NewFundingRateService().
+			Symbol(symbol).
+			StartTime(from.UnixMilli()).
+			EndTime(end.UnixMilli() - 1).
+			Limit(1000).
+			Do(context.Background())
+		if err != nil {
+			return total, fmt.Errorf(T("获取资金费率失败: %v"), err)
+		}
+		s.throttle()
+		for _, r := range page {
+			rate, _ := strconv.ParseFloat(r.FundingRate, 64)
+			byTime[r.FundingTime] = FundingRate{Time: time.UnixMilli(r.FundingTime), Rate: rate}
+		}
+		total += len(page)
+		if len(page) < 1000 {
+			break
+		}
+		from = time.UnixMilli(page[len(page)-1].FundingTime + 1)
+	}
+	if total == 0 {
+		return 0, nil
+	}
+
+	rates = rates[:0]
+	for _, r := range byTime {
+		rates = append(rates, r)
+	}
+	sort.Slice(rates, func(i, j int) bool { return rates[i].Time.Before(rates[j].Time) })
+	s.Logf(T("%s 已下载%d条资金费率"), symbol, total)
+	return total, s.save(path, rates)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// 界面和日志文字以中文为原文，其他语言的翻译放在locales目录下，
+// 按中文原文查找，没有翻译的文字显示中文
+//
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+var (
+	translationMu sync.RWMutex
+	translations  map[string]string
+)
+
+// 支持的语言和显示名称
+var languageOptions = []struct {
+	Code  string
+	Label string
+}{
+	{"zh", "中文"},
+	{"en", "English"},
+}
+
+// 设置语言，zh或空字符串使用中文原文。en_US.UTF-8这样的写法只看语言部分
+func SetLanguage(lang string) error {
+	lang = normalizeLanguage(lang)
+
+	var table map[string]string
+	if lang != "zh" {
+		data, err := localeFiles.ReadFile("locales/" + lang + ".json")
+		if err != nil {
+			return fmt.Errorf("不支持的语言: %s", lang)
+		}
+		if err := json.Unmarshal(data, &table); err != nil {
+			return fmt.Errorf("解析语言文件失败: %v", err)
+		}
+	}
+
+	translationMu.Lock()
+	translations = table
+	translationMu.Unlock()
+	return nil
+}
+
+func normalizeLanguage(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "_-."); i >= 0 {
+		lang = lang[:i]
+	}
+	if lang == "" {
+		return "zh"
+	}
+	return lang
+}
+
+// 翻译文字，text为中文原文，也可以是格式字符串
+func T(text string) string {
+	translationMu.RLock()
+	defer translationMu.RUnlock()
+	if translated, ok := translations[text]; ok && translated != "" {
+		return translated
+	}
+	return text
+}
@@ -0,0 +1,149 @@
+package main
+
+import "math"
+
+// 计算最近period根K线的RSI
+func calculateRSI(klines []Kline, period int) float64 {
+	if len(klines) < period+1 {
+		return 50 // 数据不足时返回中性值
+	}
+
+	var gains, losses float64
+	for i := len(klines) - period; i < len(klines); i++ {
+		change := klines[i].Close - klines[i-1].Close
+		if change > 0 {
+			gains += change
+		} else {
+			losses -= change
+		}
+	}
+
+	if losses == 0 {
+		return 100
+	}
+
+	rs := gains / losses
+	return 100 - (100 / (1 + rs))
+}
+
+// 计算最近period根K线的平均真实波幅(ATR)
+func calculateATR(klines []Kline, period int) float64 {
+	if len(klines) < period+1 {
+		return 0
+	}
+
+	var sum float64
+	for i := len(klines) - period; i < len(klines); i++ {
+		prevClose := klines[i-1].Close
+		tr := klines[i].High - klines[i].Low
+		tr = math.Max(tr, math.Abs(klines[i].High-prevClose))
+		tr = math.Max(tr, math.Abs(klines[i].Low-prevClose))
+		sum += tr
+	}
+	return sum / float64(period)
+}
+
+// 计算收盘价的EMA序列，前period-1个值为0
+func calculateEMA(klines []Kline, period int) []float64 {
+	ema := make([]float64, len(klines))
+	if period <= 0 || len(klines) < period {
+		return ema
+	}
+
+	// 用前period根的简单平均作为初始值
+	var sum float64
+	for i := 0; i < period; i++ {
+		sum += klines[i].Close
+	}
+	ema[period-1] = sum / float64(period)
+
+	k := 2 / float64(period+1)
+	for i := period; i < len(klines); i++ {
+		ema[i] = klines[i].Close*k + ema[i-1]*(1-k)
+	}
+	return ema
+}
+
+// 计算每根K线的RSI序列，前period个值为0。第一个值用前period个涨跌的简单平均，
+// 之后按Wilder平滑逐根递推
+func calculateRSISeries(klines []Kline, period int) []float64 {
+	rsi := make([]float64, len(klines))
+	if period <= 0 || len(klines) <= period {
+		return rsi
+	}
+
+	var avgGain, avgLoss float64
+	for i := 1; i < len(klines); i++ {
+		change := klines[i].Close - klines[i-1].Close
+		gain, loss := math.Max(change, 0), math.Max(-change, 0)
+		if i <= period {
+			avgGain += gain / float64(period)
+			avgLoss += loss / float64(period)
+			if i < period {
+				continue
+			}
+		} else {
+			avgGain = (avgGain*float64(period-1) + gain) / float64(period)
+			avgLoss = (avgLoss*float64(period-1) + loss) / float64(period)
+		}
+		if avgLoss == 0 {
+			rsi[i] = 100
+		} else {
+			rsi[i] = 100 - 100/(1+avgGain/avgLoss)
+		}
+	}
+	return rsi
+}
+
+// 计算MACD线、信号线和柱状图，前slow+signal-2个值为0
+func calculateMACD(klines []Kline, fast, slow, signal int) (macd, signalLine, hist []float64) {
+	macd = make([]float64, len(klines))
+	signalLine = make([]float64, len(klines))
+	hist = make([]float64, len(klines))
+	start := slow + signal - 2
+	if fast <= 0 || slow <= fast || signal <= 0 || len(klines) <= start {
+		return
+	}
+
+	fastEMA := calculateEMA(klines, fast)
+	slowEMA := calculateEMA(klines, slow)
+	for i := slow - 1; i < len(klines); i++ {
+		macd[i] = fastEMA[i] - slowEMA[i]
+	}
+
+	// 信号线是MACD的EMA，用前signal个MACD值的简单平均作为初始值
+	var sum float64
+	for i := slow - 1; i <= start; i++ {
+		sum += macd[i]
+	}
+	signalLine[start] = sum / float64(signal)
+	k := 2 / float64(signal+1)
+	for i := start + 1; i < len(klines); i++ {
+		signalLine[i] = macd[i]*k + signalLine[i-1]*(1-k)
+	}
+	for i := start; i < len(klines); i++ {
+		hist[i] = macd[i] - signalLine[i]
+	}
+	return
+}
+
+// 把K线转换为Heikin-Ashi（平均K线）
+func heikinAshi(klines []Kline) []Kline {
+	ha := make([]Kline, len(klines))
+	for i, k := range klines {
+		haClose := (k.Open + k.High + k.Low + k.Close) / 4
+		haOpen := (k.Open + k.Close) / 2
+		if i > 0 {
+			haOpen = (ha[i-1].Open + ha[i-1].Close) / 2
+		}
+		ha[i] = Kline{
+			Time:   k.Time,
+			Open:   haOpen,
+			High:   math.Max(k.High, math.Max(haOpen, haClose)),
+			Low:    math.Min(k.Low, math.Min(haOpen, haClose)),
+			Close:  haClose,
+			Volume: k.Volume,
+		}
+	}
+	return ha
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+// 交易笔记。已平仓交易的笔记按交易ID保存；持仓中的交易还没有完整的成交记录，
+// TradeID为空，按交易对和记录时间关联到之后平仓的交易
+type TradeNote struct {
+	TradeID string    `json:"trade_id,omitempty"`
+	Symbol  string    `json:"symbol"`
+	Time    time.Time `json:"time"`
+	Text    string    `json:"text"`
+	Tags    []string  `json:"tags,omitempty"`
+}
+
+func (n TradeNote) Empty() bool {
+	return strings.TrimSpace(n.Text) == "" && len(n.Tags) == 0
+}
+
+// 列表中显示的标签和笔记第一行
+func (n TradeNote) Summary() string {
+	var parts []string
+	for _, tag := range n.Tags {
+		parts = append(parts, "#"+tag)
+	}
+	if line, _, _ := strings.Cut(strings.TrimSpace(n.Text), "\n"); line != "" {
+		parts = append(parts, line)
+	}
+	return strings.Join(parts, " ")
+}
+
+func (n TradeNote) HasTag(tag string) bool {
+	for _, t := range n.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// 按逗号、空格或换行分隔的标签，去掉开头的#并去重
+func parseTags(text string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, tag := range strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == '，' || r == ' ' || r == '\n' || r == '\t'
+	}) {
+		tag = strings.TrimLeft(tag, "#")
+		key := strings.ToLower(tag)
+		if tag != "" && !seen[key] {
+			seen[key] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// 持仓中的交易的笔记，没有时返回TradeID为空的新笔记。
+// 交易对最近一笔平仓之前记录的笔记属于已平仓的交易
+func (db *TradeDB) OpenNote(symbol string) TradeNote {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if i := db.openNoteLocked(symbol); i >= 0 {
+		return db.data.Notes[i]
+	}
+	return TradeNote{Symbol: symbol}
+}
+
+func (db *TradeDB) openNoteLocked(symbol string) int {
+	var lastExit time.Time
+	for _, t := range buildClosedTrades(db.data.Fills) {
+		if t.Symbol == symbol && t.ExitTime.After(lastExit) {
+			lastExit = t.ExitTime
+		}
+	}
+	for i := len(db.data.Notes) - 1; i >= 0; i-- {
+		n := db.data.Notes[i]
+		if n.TradeID == "" && n.Symbol == symbol && n.Time.After(lastExit) {
+			return i
+		}
+	}
+	return -1
+}
+
+// 保存持仓中的交易的笔记，笔记和标签都为空时删除
+func (db *TradeDB) SaveOpenNote(symbol, text string, tags []string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	note := TradeNote{Symbol: symbol, Text: text, Tags: tags}
+	return db.saveNoteLocked(db.openNoteLocked(symbol), note)
+}
+
+// 保存已平仓交易的笔记，持仓期间记录的笔记改为按交易ID保存
+func (db *TradeDB) SaveTradeNote(t ClosedTrade, text string, tags []string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	note := TradeNote{TradeID: t.ID, Symbol: t.Symbol, Text: text, Tags: tags}
+	return db.saveNoteLocked(db.noteLocked(t), note)
+}
+
+// 替换第i条笔记，i为-1时新增
+func (db *TradeDB) saveNoteLocked(i int, note TradeNote) error {
+	switch {
+	case note.Empty() && i >= 0:
+		db.data.Notes = append(db.data.Notes[:i], db.data.Notes[i+1:]...)
+	case note.Empty():
+		return nil
+	case i >= 0:
+		note.Time = db.data.Notes[i].Time
+		db.data.Notes[i] = note
+	default:
+		note.Time = time.Now()
+		db.data.Notes = append(db.data.Notes, note)
+	}
+	return db.saveLocked()
+}
+
+// 交易的笔记序号：按交易ID保存的优先，否则使用持仓期间记录的笔记，没有时返回-1
+func (db *TradeDB) noteLocked(t ClosedTrade) int {
+	open := -1
+	for i, n := range db.data.Notes {
+		if n.TradeID == t.ID {
+			return i
+		}
+		if n.TradeID == "" && n.Symbol == t.Symbol && !n.Time.Before(t.EntryTime) && !n.Time.After(t.ExitTime) {
+			open = i
+		}
+	}
+	return open
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// 单次请求最多返回的K线数量，交易所的上限是1500
+const maxKlinesPerRequest = 1500
+
+// 本地K线缓存，按交易对+周期保存在内存和磁盘中。刷新时只获取缺少的尾部，
+// 断线后产生的缺口会自动补齐
+type KlineCache struct {
+	client  *futures.Client
+	dir     string // 磁盘缓存目录，为空时只缓存在内存中
+	maxSize int    // 每个序列最多保留的K线数量
+
+	mu     sync.Mutex
+	series map[string][]Kline
+	noData map[string]bool // 交易所本身没有数据的缺口，不再重复请求
+}
+
+func NewKlineCache(client *futures.Client, dir string, maxSize int) *KlineCache {
+	return &KlineCache{
+		client:  client,
+		dir:     dir,
+		maxSize: maxSize,
+		series:  make(map[string][]Kline),
+		noData:  make(map[string]bool),
+	}
+}
+
+func klineCacheKey(symbol, interval string) string {
+	return symbol + "_" + interval
+}
+
+// 只读取缓存，不发起请求，没有缓存时返回false
+func (c *KlineCache) Cached(symbol, interval string, limit int) ([]Kline, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	klines := c.loadLocked(symbol, interval)
+	if len(klines) == 0 {
+		return nil, false
+	}
+	return tail(klines, limit), true
+}
+
+// 获取最近limit根K线，优先使用缓存，只请求缺少的部分
+func (c *KlineCache) Get(symbol, interval string, limit int) ([]Kline, error) {
+	step := intervalDuration(interval)
+	if step <= 0 {
+		return nil, fmt.Errorf(T("不支持的K线周期: %s"), interval)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	klines := c.loadLocked(symbol, interval)
+	var lastBefore time.Time
+	if len(klines) > 0 {
+		lastBefore = klines[len(klines)-1].Time
+	}
+	countBefore := len(klines)
+
+	// 缓存为空或者已经过期太久，丢弃旧数据重新获取
+	miss := len(klines) == 0 || time.Since(klines[len(klines)-1].Time) > time.Duration(limit)*step
+	metrics.Cache(metricKlineCache, !miss)
+	if miss {
+		fresh, err := fetchKlines(c.client, symbol, interval, min(limit, maxKlinesPerRequest))
+		if err != nil {
+			return nil, err
+		}
+		klines = fresh
+		countBefore = 0
+	} else {
+		// 从最后一根K线开始获取，最后一根可能还未收盘需要更新
+		for {
+			last := klines[len(klines)-1].Time
+			fresh, err := fetchKlinesRange(c.client, symbol, interval, last, time.Time{}, maxKlinesPerRequest)
+			if err != nil {
+				return nil, err
+			}
+			klines = mergeKlines(klines, fresh)
+			if len(fresh) < maxKlinesPerRequest {
+				break
+			}
+		}
+	}
+
+	// 历史不够时向前补充，每次最多请求maxKlinesPerRequest根，交易所没有更早的数据时停止
+	for len(klines) > 0 && len(klines) < limit {
+		older, err := fetchKlinesRange(c.client, symbol, interval, time.Time{}, klines[0].Time.Add(-time.Millisecond), min(limit-len(klines), maxKlinesPerRequest))
+		if err != nil {
+			return nil, err
+		}
+		if len(older) == 0 {
+			break
+		}
+		klines = mergeKlines(klines, older)
+	}
+
+	// 补齐中间的缺口
+	klines, err := c.fillGaps(symbol, interval, klines, step)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.maxSize > 0 && len(klines) > c.maxSize {
+		klines = klines[len(klines)-c.maxSize:]
+	}
+	c.series[klineCacheKey(symbol, interval)] = klines
+
+	// 只有新增K线时才写磁盘，未收盘K线的更新不需要持久化
+	if len(klines) > 0 && (len(klines) != countBefore || !klines[len(klines)-1].Time.Equal(lastBefore)) {
+		if err := c.saveLocked(symbol, interval, klines); err != nil {
+			fmt.Printf(T("保存K线缓存失败: %v\n"), err)
+		}
+	}
+
+	return tail(klines, limit), nil
+}
+
+// 找出相邻K线时间间隔大于周期的位置并补齐
+func (c *KlineCache) fillGaps(symbol, interval string, klines []Kline, step time.Duration) ([]Kline, error) {
+	for i := 1; i < len(klines); i++ {
+		if klines[i].Time.Sub(klines[i-1].Time) <= step {
+			continue
+		}
+
+		start := klines[i-1].Time.Add(step)
+		end := klines[i].Time.Add(-time.Millisecond)
+		gapKey := fmt.Sprintf("%s_%s_%d", symbol, interval, start.Unix())
+		if c.noData[gapKey] {
+			continue
+		}
+
+		missing, err := fetchKlinesRange(c.client, symbol, interval, start, end, maxKlinesPerRequest)
+		if err != nil {
+			return klines, fmt.Errorf(T("补齐K线缺口失败: %v"), err)
+		}
+		if len(missing) == 0 {
+			// 交易所本身没有数据（如停盘）
+			c.noData[gapKey] = true
+			continue
+		}
+		klines = mergeKlines(klines, missing)
+	}
+	return klines, nil
+}
+
+// 合并两组K线，按时间排序，相同时间的以新数据为准
+func mergeKlines(old, fresh []Kline) []Kline {
+	byTime := make(map[int64]Kline, len(old)+len(fresh))
+	for _, k := range old {
+		byTime[k.Time.Unix()] = k
+	}
+	for _, k := range fresh {
+		byTime[k.Time.Unix()] = k
+	}
+
+	merged := make([]Kline, 0, len(byTime))
+	for _, k := range byTime {
+		merged = append(merged, k)
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Time.Before(merged[j].Time) })
+	return merged
+}
+
+func tail(klines []Kline, n int) []Kline {
+	if n <= 0 || len(klines) <= n {
+		return klines
+	}
+	return klines[len(klines)-n:]
+}
+
+// 从内存读取，内存中没有时从磁盘加载
+func (c *KlineCache) loadLocked(symbol, interval string) []Kline {
+	key := klineCacheKey(symbol, interval)
+	if klines, ok := c.series[key]; ok {
+		return klines
+	}
+	if c.dir == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(c.dir, key+".json"))
+	if err != nil {
+		return nil
+	}
+	var klines []Kline
+	if err := json.Unmarshal(data, &klines); err != nil {
+		fmt.Printf(T("解析K线缓存失败: %v\n"), err)
+		return nil
+	}
+	c.series[key] = klines
+	return klines
+}
+
+func (c *KlineCache) saveLocked(symbol, interval string, klines []Kline) error {
+	if c.dir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(klines)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(c.dir, klineCacheKey(symbol, interval)+".json"), data, 0644)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// 获取K线数据并转换为我们的格式
+func fetchKlines(client *futures.Client, symbol, interval string, limit int) ([]Kline, error) {
+	return fetchKlinesRange(client, symbol, interval, time.Time{}, time.Time{}, limit)
+}
+
+// 获取指定时间范围内的K线，start/end为零值时不限制
+func fetchKlinesRange(client *futures.Client, symbol, interval string, start, end time.Time, limit int) ([]Kline, error) {
+	service := client.NewKlinesService().
+		Symbol(symbol).
+		Interval(interval).
+		Limit(limit)
+	if !start.IsZero() {
+		service = service.StartTime(start.UnixMilli())
+	}
+	if !end.IsZero() {
+		service = service.EndTime(end.UnixMilli())
+	}
+
+	klines, err := service.Do(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf(T("获取K线数据失败: %v"), err)
+	}
+
+	result := make([]Kline, len(klines))
+	for i, k := range klines {
+		open, _ := strconv.ParseFloat(k.Open, 64)
+		high, _ := strconv.ParseFloat(k.High, 64)
+		low, _ := strconv.ParseFloat(k.Low, 64)
+		close, _ := strconv.ParseFloat(k.Close, 64)
+		volume, _ := strconv.ParseFloat(k.Volume, 64)
+		result[i] = Kline{
+			Time:   time.Unix(k.OpenTime/1000, 0),
+			Open:   open,
+			High:   high,
+			Low:    low,
+			Close:  close,
+			Volume: volume,
+		}
+	}
+	return result, nil
+}
+
+// K线周期对应的时长，如 1m/5m/1h/4h/1d/1w
+func intervalDuration(interval string) time.Duration {
+	if len(interval) < 2 {
+		return 0
+	}
+	n, err := strconv.Atoi(interval[:len(interval)-1])
+	if err != nil {
+		return 0
+	}
+	switch interval[len(interval)-1] {
+	case 'm':
+		return time.Duration(n) * time.Minute
+	case 'h':
+		return time.Duration(n) * time.Hour
+	case 'd':
+		return time.Duration(n) * 24 * time.Hour
+	case 'w':
+		return time.Duration(n) * 7 * 24 * time.Hour
+	}
+	return 0
+}
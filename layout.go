@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fyne.io/fyne/v2"
+)
+
+// 偏好设置使用的应用ID，Fyne按ID保存偏好设置
+const appID = "binance_demo.trader"
+
+// 窗口布局在偏好设置中的键
+const (
+	prefWindowWidth    = "window.width"
+	prefWindowHeight   = "window.height"
+	prefSplitOffset    = "layout.split_offset"
+	prefChartInterval  = "chart.interval"
+	prefPanelWatchlist = "panel.watchlist"
+	prefPanelAnalysis  = "panel.analysis"
+	prefPanelMovers    = "panel.movers"
+	prefPanelAccount   = "panel.account"
+)
+
+// 可以在视图菜单中隐藏的面板
+var layoutPanels = []struct {
+	Key   string
+	Label string
+}{
+	{prefPanelWatchlist, "自选"},
+	{prefPanelAnalysis, "技术分析"},
+	{prefPanelMovers, "涨跌榜"},
+	{prefPanelAccount, "账户"},
+}
+
+// 恢复上次关闭时的窗口大小、分栏位置和面板显示状态
+func (ui *TraderUI) restoreLayout() {
+	prefs := ui.app.Preferences()
+
+	width := float32(prefs.Float(prefWindowWidth))
+	height := float32(prefs.Float(prefWindowHeight))
+	if width > 0 && height > 0 {
+		ui.window.Resize(fyne.NewSize(width, height))
+	}
+	if offset := prefs.Float(prefSplitOffset); offset > 0 && offset < 1 {
+		ui.split.SetOffset(offset)
+	}
+	for key, panel := range ui.panels {
+		if !prefs.BoolWithFallback(key, true) {
+			panel.Hide()
+		}
+	}
+}
+
+// 保存窗口布局，在关闭窗口时调用
+func (ui *TraderUI) saveLayout() {
+	prefs := ui.app.Preferences()
+
+	size := ui.window.Canvas().Size()
+	prefs.SetFloat(prefWindowWidth, float64(size.Width))
+	prefs.SetFloat(prefWindowHeight, float64(size.Height))
+	prefs.SetFloat(prefSplitOffset, ui.split.Offset)
+	prefs.SetString(prefChartInterval, ui.interval)
+	for key, panel := range ui.panels {
+		prefs.SetBool(key, panel.Visible())
+	}
+}
+
+// 视图菜单，勾选显示或隐藏面板
+func (ui *TraderUI) viewMenu() *fyne.Menu {
+	var items []*fyne.MenuItem
+	for _, p := range layoutPanels {
+		panel := ui.panels[p.Key]
+		key := p.Key
+		item := fyne.NewMenuItem(T(p.Label), nil)
+		item.Checked = panel.Visible()
+		item.Action = func() {
+			if panel.Visible() {
+				panel.Hide()
+			} else {
+				panel.Show()
+			}
+			item.Checked = panel.Visible()
+			ui.app.Preferences().SetBool(key, item.Checked)
+			ui.window.MainMenu().Refresh()
+		}
+		items = append(items, item)
+	}
+	items = append(items,
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem(T("新建K线图窗口"), ui.showChartWindow),
+		fyne.NewMenuItem(T("迷你模式"), ui.showMiniWindow),
+	)
+	return fyne.NewMenu(T("视图"), items...)
+}
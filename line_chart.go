@@ -0,0 +1,155 @@
+package main
+
+import (
+	"image/color"
+	"math"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 折线图中的一条数据序列
+type LineSeries struct {
+	Label  string
+	Color  color.Color
+	Values []float64
+}
+
+// 按时间绘制的折线图，多条序列共用纵轴
+type LineChart struct {
+	widget.BaseWidget
+
+	mu     sync.Mutex
+	title  string
+	times  []time.Time
+	series []LineSeries
+	format func(float64) string
+}
+
+func NewLineChart(format func(float64) string) *LineChart {
+	c := &LineChart{format: format}
+	c.ExtendBaseWidget(c)
+	return c
+}
+
+// 更新图表数据，每条序列的长度与times相同，需要在UI线程中调用
+func (c *LineChart) SetData(title string, times []time.Time, series []LineSeries) {
+	c.mu.Lock()
+	c.title = title
+	c.times = times
+	c.series = series
+	c.mu.Unlock()
+	c.Refresh()
+}
+
+func (c *LineChart) CreateRenderer() fyne.WidgetRenderer {
+	return &lineChartRenderer{chart: c}
+}
+
+type lineChartRenderer struct {
+	chart   *LineChart
+	objects []fyne.CanvasObject
+}
+
+func (r *lineChartRenderer) Layout(size fyne.Size) {
+	r.build(size)
+}
+
+func (r *lineChartRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(300, 200)
+}
+
+func (r *lineChartRenderer) Refresh() {
+	r.build(r.chart.Size())
+	canvas.Refresh(r.chart)
+}
+
+func (r *lineChartRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+func (r *lineChartRenderer) Destroy() {}
+
+func (r *lineChartRenderer) build(size fyne.Size) {
+	c := r.chart
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fg := theme.Color(theme.ColorNameForeground)
+	grid := theme.Color(theme.ColorNameSeparator)
+	textSize := theme.CaptionTextSize()
+
+	title := canvas.NewText(c.title, fg)
+	title.TextStyle = fyne.TextStyle{Bold: true}
+	title.Move(fyne.NewPos(chartPadLeft, 2))
+	objects := []fyne.CanvasObject{title}
+
+	if len(c.times) < 2 || size.Width <= chartPadLeft+chartPadRight || size.Height <= chartPadTop+chartPadBottom {
+		empty := canvas.NewText(T("暂无数据"), fg)
+		empty.Move(fyne.NewPos(size.Width/2-20, size.Height/2))
+		r.objects = append(objects, empty)
+		return
+	}
+
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, series := range c.series {
+		for _, v := range series.Values {
+			low = math.Min(low, v)
+			high = math.Max(high, v)
+		}
+	}
+	padding := (high - low) * 0.05
+	s := chartScale{
+		left:   chartPadLeft,
+		top:    chartPadTop,
+		width:  size.Width - chartPadLeft - chartPadRight,
+		height: size.Height - chartPadTop - chartPadBottom,
+		count:  len(c.times),
+		min:    low - padding,
+		max:    high + padding,
+	}
+
+	for i := 0; i <= 4; i++ {
+		v := s.min + (s.max-s.min)*float64(i)/4
+		y := s.Y(v)
+		line := canvas.NewLine(grid)
+		line.Position1 = fyne.NewPos(s.left, y)
+		line.Position2 = fyne.NewPos(s.left+s.width, y)
+		label := canvas.NewText(c.format(v), fg)
+		label.TextSize = textSize
+		label.Move(fyne.NewPos(s.left+s.width+4, y-textSize/2-2))
+		objects = append(objects, line, label)
+	}
+
+	// 跨度超过两天时只显示日期
+	timeFormat := "01-02 15:04"
+	if c.times[len(c.times)-1].Sub(c.times[0]) > 48*time.Hour {
+		timeFormat = "01-02"
+	}
+	for i := 0; i < 5; i++ {
+		idx := i * (len(c.times) - 1) / 4
+		label := canvas.NewText(c.times[idx].Format(timeFormat), fg)
+		label.TextSize = textSize
+		x := s.X(idx) - float32(len(label.Text))*textSize/4
+		label.Move(fyne.NewPos(x, s.top+s.height+2))
+		objects = append(objects, label)
+	}
+
+	// 图例放在标题右侧
+	legendX := chartPadLeft + title.MinSize().Width + 12
+	for _, series := range c.series {
+		objects = append(objects, seriesObjects(series.Values, 0, s, series.Color)...)
+
+		legend := canvas.NewText(series.Label, series.Color)
+		legend.TextSize = textSize
+		legend.Move(fyne.NewPos(legendX, 4))
+		objects = append(objects, legend)
+		legendX += legend.MinSize().Width + 12
+	}
+
+	r.objects = objects
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// 单笔强平订单
+type liquidationEvent struct {
+	Time     time.Time
+	Side     futures.SideType // SELL为多头被强平，BUY为空头被强平
+	Notional float64
+}
+
+// 强平订单流监控，统计滚动窗口内的强平成交额
+type LiquidationMonitor struct {
+	symbol    string
+	window    time.Duration
+	threshold float64 // 窗口内强平名义价值超过该值时视为连环爆仓，0表示不提醒
+
+	mu        sync.Mutex
+	events    []liquidationEvent
+	lastAlert time.Time
+	stopC     chan struct{}
+	connected bool
+
+	// 连环爆仓回调，参数为窗口内多头和空头的强平名义价值
+	OnCascade func(longNotional, shortNotional float64)
+}
+
+func NewLiquidationMonitor(symbol string, window time.Duration, threshold float64) *LiquidationMonitor {
+	return &LiquidationMonitor{
+		symbol:    symbol,
+		window:    window,
+		threshold: threshold,
+	}
+}
+
+// 订阅forceOrder数据流，断开后自动重连
+func (m *LiquidationMonitor) Start() {
+	go func() {
+		for {
+			m.mu.Lock()
+			symbol := m.symbol
+			m.mu.Unlock()
+
+			doneC, stopC, err := futures.WsLiquidationOrderServe(symbol, m.handleEvent, func(err error) {
+				log.Printf(T("强平数据流错误: %v"), err)
+			})
+			if err != nil {
+				log.Printf(T("订阅强平数据流失败: %v"), err)
+				time.Sleep(5 * time.Second)
+				continue
+			}
+			m.mu.Lock()
+			m.stopC = stopC
+			m.connected = true
+			m.mu.Unlock()
+			<-doneC
+			m.mu.Lock()
+			m.connected = false
+			m.mu.Unlock()
+			metrics.Inc(metricWSReconnects)
+			time.Sleep(time.Second)
+		}
+	}()
+}
+
+// 切换监控的交易对，断开当前数据流后按新交易对重连
+func (m *LiquidationMonitor) SetSymbol(symbol string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if symbol == m.symbol {
+		return
+	}
+	m.symbol = symbol
+	m.events = nil
+	if m.stopC != nil {
+		close(m.stopC)
+		m.stopC = nil
+	}
+}
+
+// 修改连环爆仓提醒的阈值，0表示不提醒
+func (m *LiquidationMonitor) SetThreshold(threshold float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.threshold = threshold
+}
+
+// 数据流是否已连接
+func (m *LiquidationMonitor) Connected() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.connected
+}
+
+func (m *LiquidationMonitor) handleEvent(event *futures.WsLiquidationOrderEvent) {
+	order := event.LiquidationOrder
+	qty, _ := strconv.ParseFloat(order.AccumulatedFilledQty, 64)
+	price, _ := strconv.ParseFloat(order.AvgPrice, 64)
+
+	m.mu.Lock()
+	// 切换交易对后旧数据流可能还有残留的事件
+	if order.Symbol != m.symbol {
+		m.mu.Unlock()
+		return
+	}
+	m.events = append(m.events, liquidationEvent{
+		Time:     time.UnixMilli(order.TradeTime),
+		Side:     order.Side,
+		Notional: qty * price,
+	})
+	m.pruneLocked()
+	longNotional, shortNotional := m.sumLocked()
+
+	// 每个窗口最多提醒一次
+	cascade := m.threshold > 0 && longNotional+shortNotional >= m.threshold &&
+		time.Since(m.lastAlert) >= m.window
+	if cascade {
+		m.lastAlert = time.Now()
+	}
+	m.mu.Unlock()
+
+	if cascade && m.OnCascade != nil {
+		m.OnCascade(longNotional, shortNotional)
+	}
+}
+
+// 清除窗口之外的记录
+func (m *LiquidationMonitor) pruneLocked() {
+	cutoff := time.Now().Add(-m.window)
+	i := 0
+	for i < len(m.events) && m.events[i].Time.Before(cutoff) {
+		i++
+	}
+	m.events = m.events[i:]
+}
+
+func (m *LiquidationMonitor) sumLocked() (longNotional, shortNotional float64) {
+	for _, e := range m.events {
+		if e.Side == futures.SideTypeSell {
+			longNotional += e.Notional
+		} else {
+			shortNotional += e.Notional
+		}
+	}
+	return
+}
+
+// 窗口内多头和空头的强平名义价值
+func (m *LiquidationMonitor) Volume() (longNotional, shortNotional float64, count int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pruneLocked()
+	longNotional, shortNotional = m.sumLocked()
+	return longNotional, shortNotional, len(m.events)
+}
+
+// 生成分析面板中的强平部分
+func (m *LiquidationMonitor) Analysis() string {
+	longNotional, shortNotional, count := m.Volume()
+	return fmt.Sprintf(T("强平(%.0f分钟): %d笔, 多头 %.0f / 空头 %.0f\n"),
+		m.window.Minutes(), count, longNotional, shortNotional)
+}
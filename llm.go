@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LLM市场点评设置，使用OpenAI兼容的chat completions接口
+type LLMConfig struct {
+	Endpoint    string `json:"endpoint"`     // 如 https://api.openai.com/v1/chat/completions
+	Model       string `json:"model"`        // 模型名称
+	APIKey      string `json:"api_key"`      // LLM服务的密钥，与币安密钥无关
+	MinInterval int    `json:"min_interval"` // 两次请求的最短间隔(秒)，默认300
+	Language    string `json:"language"`     // 点评使用的语言，默认与界面语言相同
+}
+
+// 发送给LLM的指标快照，只包含行情和指标数据
+type llmSnapshot struct {
+	Symbol        string   `json:"symbol"`
+	Interval      string   `json:"interval"`
+	Price         float64  `json:"price"`
+	CandleChange  float64  `json:"candle_change_pct"`
+	RangeChange   float64  `json:"range_change_pct"`
+	RangeSpan     string   `json:"range_span"`
+	RSI14         float64  `json:"rsi14"`
+	ATR14         float64  `json:"atr14"`
+	POC           float64  `json:"volume_poc,omitempty"`
+	Vol1h         *float64 `json:"realized_vol_1h_pct,omitempty"`
+	Vol24h        *float64 `json:"realized_vol_24h_pct,omitempty"`
+	DayOpen       *float64 `json:"day_open,omitempty"`
+	DayHigh       *float64 `json:"day_high,omitempty"`
+	DayLow        *float64 `json:"day_low,omitempty"`
+	LongShort     *float64 `json:"long_short_account_ratio,omitempty"`
+	TakerBuySell  *float64 `json:"taker_buy_sell_ratio,omitempty"`
+	BasisAnnual   *float64 `json:"basis_annualized_pct,omitempty"`
+	LiqLong       *float64 `json:"liquidated_long_notional_5m,omitempty"`
+	LiqShort      *float64 `json:"liquidated_short_notional_5m,omitempty"`
+	LiqOrderCount *int     `json:"liquidation_orders_5m,omitempty"`
+}
+
+func newLLMSnapshot(input *AnalysisInput) *llmSnapshot {
+	klines := input.Klines
+	last := klines[len(klines)-1]
+	prev := klines[len(klines)-2]
+	first := klines[0]
+
+	s := &llmSnapshot{
+		Symbol:       input.Symbol,
+		Interval:     input.Interval,
+		Price:        last.Close,
+		CandleChange: (last.Close - prev.Close) / prev.Close * 100,
+		RangeChange:  (last.Close - first.Close) / first.Close * 100,
+		RangeSpan:    formatSpan(last.Time.Sub(first.Time)),
+		RSI14:        calculateRSI(klines, 14),
+		ATR14:        calculateATR(klines, 14),
+	}
+	if profile := calculateVolumeProfile(klines, 24); profile != nil {
+		s.POC = profile.POCPrice()
+	}
+	if v := input.Volatility; v != nil {
+		s.Vol1h, s.Vol24h = &v.Vol1h, &v.Vol24h
+	}
+	if d := input.Session; d != nil {
+		s.DayOpen, s.DayHigh, s.DayLow = &d.Open, &d.High, &d.Low
+	}
+	if m := input.Sentiment; m != nil {
+		s.LongShort, s.TakerBuySell = &m.LongShortRatio, &m.TakerBuySellRatio
+	}
+	if b := input.Basis; b != nil {
+		s.BasisAnnual = &b.Annualized
+	}
+	if l := input.Liquidations; l != nil {
+		longNotional, shortNotional, count := l.Volume()
+		s.LiqLong, s.LiqShort, s.LiqOrderCount = &longNotional, &shortNotional, &count
+	}
+	return s
+}
+
+// LLM市场点评分析器。请求在后台进行，分析面板显示最近一次的结果，
+// 两次请求之间至少间隔MinInterval秒
+type llmAnalysisProvider struct {
+	config LLMConfig
+	client *http.Client
+
+	mu          sync.Mutex
+	summary     string
+	summaryTime time.Time
+	lastRequest time.Time
+	lastErr     error
+	pending     bool
+}
+
+func NewLLMAnalysisProvider(config LLMConfig) AnalysisProvider {
+	if config.MinInterval <= 0 {
+		config.MinInterval = 300
+	}
+	// 默认使用界面语言
+	if config.Language == "" {
+		config.Language = T("中文")
+	}
+	return &llmAnalysisProvider{
+		config: config,
+		client: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+func (p *llmAnalysisProvider) Name() string { return "llm" }
+
+func (p *llmAnalysisProvider) Analyze(input *AnalysisInput) ([]AnalysisSection, error) {
+	if p.config.Endpoint == "" {
+		return nil, errors.New(T("未配置LLM接口地址"))
+	}
+	if len(input.Klines) < 2 {
+		return nil, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	due := time.Since(p.lastRequest) >= time.Duration(p.config.MinInterval)*time.Second
+	if due && !p.pending {
+		p.pending = true
+		p.lastRequest = time.Now()
+		go p.request(newLLMSnapshot(input))
+	}
+
+	var body string
+	switch {
+	case p.summary != "":
+		body = p.summary + "\n" + fmt.Sprintf(T("(%s生成)\n"), p.summaryTime.Format("15:04"))
+	case p.lastErr != nil:
+		body = fmt.Sprintf(T("生成点评失败: %v\n"), p.lastErr)
+	default:
+		body = T("正在生成点评...\n")
+	}
+	return []AnalysisSection{{Title: T("AI点评"), Body: body}}, nil
+}
+
+func (p *llmAnalysisProvider) request(snapshot *llmSnapshot) {
+	summary, err := p.complete(snapshot)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pending = false
+	p.lastErr = err
+	if err == nil {
+		p.summary = summary
+		p.summaryTime = time.Now()
+	}
+}
+
+type llmMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+func (p *llmAnalysisProvider) complete(snapshot *llmSnapshot) (string, error) {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return "", fmt.Errorf(T("序列化指标快照失败: %v"), err)
+	}
+
+	reqBody, err := json.Marshal(map[string]interface{}{
+		"model": p.config.Model,
+		"messages": []llmMessage{
+			{Role: "system", Content: fmt.Sprintf(T("你是加密货币合约交易助手。根据用户提供的指标快照，用%s写3到5句简短的市场点评，")+
+				T("说明趋势、动能、波动和需要注意的风险，不要给出具体的买卖建议。"), p.config.Language)},
+			{Role: "user", Content: string(data)},
+		},
+		"max_tokens": 300,
+	})
+	if err != nil {
+		return "", fmt.Errorf(T("序列化请求失败: %v"), err)
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, p.config.Endpoint, bytes.NewReader(reqBody))
+	if err != nil {
+		return "", fmt.Errorf(T("创建请求失败: %v"), err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf(T("请求LLM失败: %v"), err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf(T("读取LLM响应失败: %v"), err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf(T("LLM返回错误 %d: %s"), resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	var result struct {
+		Choices []struct {
+			Message llmMessage `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf(T("解析LLM响应失败: %v"), err)
+	}
+	if len(result.Choices) == 0 {
+		return "", errors.New(T("LLM没有返回内容"))
+	}
+	return strings.TrimSpace(result.Choices[0].Message.Content), nil
+}
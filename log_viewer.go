@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	maxLogEntries  = 5000        // 保留的日志条数
+	logViewRefresh = time.Second // 日志窗口的刷新间隔
+)
+
+// 没有明确级别的输出按关键字判断级别
+var (
+	logErrorWords   = []string{"失败", "错误", "error", "Error", "failed", "Failed"}
+	logWarningWords = []string{"警告", "注意", "断开", "warning", "Warning"}
+)
+
+// 一条日志，级别与通知相同
+type LogEntry struct {
+	Time  time.Time
+	Level NoticeLevel
+	Text  string
+}
+
+func (e LogEntry) String() string {
+	return e.Time.Format("01-02 15:04:05") + " " + e.Text
+}
+
+func logLevelOf(text string) NoticeLevel {
+	for _, w := range logErrorWords {
+		if strings.Contains(text, w) {
+			return NoticeError
+		}
+	}
+	for _, w := range logWarningWords {
+		if strings.Contains(text, w) {
+			return NoticeWarning
+		}
+	}
+	return NoticeInfo
+}
+
+// 程序的日志：接管标准输出和log包的输出，每行原样写到控制台并保存在内存中，
+// 供界面上的日志窗口查看
+type LogBuffer struct {
+	console io.Writer
+
+	mu      sync.Mutex
+	entries []LogEntry
+}
+
+// 把标准输出和log包的输出重定向到LogBuffer，失败时保持原来的输出
+func CaptureOutput() *LogBuffer {
+	b := &LogBuffer{console: os.Stdout}
+	r, w, err := os.Pipe()
+	if err != nil {
+		fmt.Printf(T("接管程序输出失败: %v\n"), err)
+		return b
+	}
+	os.Stdout = w
+	log.SetOutput(w)
+
+	go func() {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := scanner.Text()
+			fmt.Fprintln(b.console, line)
+			if strings.TrimSpace(line) != "" {
+				b.add(LogEntry{Time: time.Now(), Level: logLevelOf(line), Text: line})
+			}
+		}
+	}()
+	return b
+}
+
+// 写入已知级别的日志
+func (b *LogBuffer) Print(level NoticeLevel, text string) {
+	fmt.Fprintln(b.console, text)
+	b.add(LogEntry{Time: time.Now(), Level: level, Text: text})
+}
+
+func (b *LogBuffer) add(e LogEntry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries = append(b.entries, e)
+	if len(b.entries) > maxLogEntries {
+		b.entries = append([]LogEntry(nil), b.entries[len(b.entries)-maxLogEntries:]...)
+	}
+}
+
+// 不低于minLevel且包含query(不区分大小写)的日志，按时间排序
+func (b *LogBuffer) Entries(minLevel NoticeLevel, query string) []LogEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	query = strings.ToLower(query)
+	var result []LogEntry
+	for _, e := range b.entries {
+		if e.Level < minLevel {
+			continue
+		}
+		if query != "" && !strings.Contains(strings.ToLower(e.Text), query) {
+			continue
+		}
+		result = append(result, e)
+	}
+	return result
+}
+
+func (b *LogBuffer) Clear() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries = nil
+}
+
+// 日志窗口的级别筛选
+var logLevelOptions = []struct {
+	Level NoticeLevel
+	Label string
+}{
+	{NoticeInfo, "全部"},
+	{NoticeWarning, "警告和错误"},
+	{NoticeError, "只看错误"},
+}
+
+// 日志窗口：按级别筛选、搜索，打开期间每秒刷新，勾选自动滚动时停在最新一条
+func (ui *TraderUI) showLogs() {
+	w := ui.app.NewWindow(T("日志"))
+
+	var entries []LogEntry
+	list := widget.NewList(
+		func() int { return len(entries) },
+		func() fyne.CanvasObject {
+			return widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			label := o.(*widget.Label)
+			label.Importance = Notice{Level: entries[id].Level}.importance()
+			label.SetText(entries[id].String())
+		},
+	)
+
+	minLevel := NoticeInfo
+	var levelLabels []string
+	for _, o := range logLevelOptions {
+		levelLabels = append(levelLabels, T(o.Label))
+	}
+	levelSelect := widget.NewSelect(levelLabels, nil)
+	searchEntry := widget.NewEntry()
+	searchEntry.SetPlaceHolder(T("搜索"))
+	autoScroll := widget.NewCheck(T("自动滚动"), nil)
+	autoScroll.SetChecked(true)
+	count := widget.NewLabel("")
+
+	refresh := func() {
+		entries = ui.logs.Entries(minLevel, strings.TrimSpace(searchEntry.Text))
+		count.SetText(fmt.Sprintf(T("%d条"), len(entries)))
+		list.Refresh()
+		if autoScroll.Checked {
+			list.ScrollToBottom()
+		}
+	}
+	levelSelect.OnChanged = func(label string) {
+		for _, o := range logLevelOptions {
+			if T(o.Label) == label {
+				minLevel = o.Level
+			}
+		}
+		refresh()
+	}
+	searchEntry.OnChanged = func(string) { refresh() }
+	levelSelect.SetSelected(levelLabels[0])
+
+	clearBtn := widget.NewButton(T("清空"), func() {
+		ui.logs.Clear()
+		refresh()
+	})
+
+	stopC := make(chan struct{})
+	w.SetOnClosed(func() { close(stopC) })
+	go func() {
+		ticker := time.NewTicker(logViewRefresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopC:
+				return
+			case <-ticker.C:
+				fyne.Do(refresh)
+			}
+		}
+	}()
+
+	w.SetContent(container.NewBorder(
+		container.NewBorder(nil, nil,
+			levelSelect,
+			container.NewHBox(autoScroll, clearBtn, count),
+			searchEntry,
+		),
+		nil, nil, nil,
+		list,
+	))
+	w.Resize(ui.scaled(800, 500))
+	w.Show()
+}
@@ -1,6 +1,16 @@
 package main
 
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2/app"
+)
+
 func main() {
-	ui := NewTraderUI()
+	ui, err := NewTraderUI(app.NewWithID(appID))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 	ui.Show()
 }
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 内部计数器的名称。按类型细分的计数器在后面加上.类型，如 orders.stop_loss
+const (
+	metricAPIRequests   = "api.requests"   // 经过APIMonitor的请求
+	metricAPIErrors     = "api.errors"     // 网络错误或HTTP状态码>=400
+	metricWSReconnects  = "ws.reconnects"  // 数据流断开后重连
+	metricOrders        = "orders"         // 提交的订单，按clientOrderId的标签细分
+	metricKlineCache    = "kline_cache"    // K线缓存，.hits为增量更新，.misses为全部重新获取
+	metricFilterCache   = "filter_cache"   // 交易规则缓存
+	metricPositionCache = "position_cache" // 监控循环的持仓缓存
+)
+
+// 进程内的计数器，只增不减。监控程序通过控制接口提供，protect metrics显示
+type Metrics struct {
+	mu       sync.Mutex
+	counters map[string]int64
+}
+
+var metrics = &Metrics{counters: make(map[string]int64)}
+
+func (m *Metrics) Add(name string, delta int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counters[name] += delta
+}
+
+func (m *Metrics) Inc(name string) {
+	m.Add(name, 1)
+}
+
+// 记录一次缓存命中或未命中，计数器为name.hits和name.misses
+func (m *Metrics) Cache(name string, hit bool) {
+	if hit {
+		m.Inc(name + ".hits")
+	} else {
+		m.Inc(name + ".misses")
+	}
+}
+
+// 当前所有计数器的副本
+func (m *Metrics) Snapshot() map[string]int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	counters := make(map[string]int64, len(m.counters))
+	for k, v := range m.counters {
+		counters[k] = v
+	}
+	return counters
+}
+
+// 按名称排序的计数器名
+func sortedMetricNames(counters map[string]int64) []string {
+	names := make([]string, 0, len(counters))
+	for name := range counters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// 按计数器计算各个缓存的命中率(%)，键为缓存名
+func cacheHitRates(counters map[string]int64) map[string]float64 {
+	rates := make(map[string]float64)
+	for name := range counters {
+		cache, ok := strings.CutSuffix(name, ".hits")
+		if !ok {
+			cache, ok = strings.CutSuffix(name, ".misses")
+		}
+		if !ok {
+			continue
+		}
+		hits, misses := counters[cache+".hits"], counters[cache+".misses"]
+		if hits+misses > 0 {
+			rates[cache] = float64(hits) / float64(hits+misses) * 100
+		}
+	}
+	return rates
+}
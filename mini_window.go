@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+const miniRefresh = time.Second
+
+// 当前平台无法置顶窗口时返回的错误
+func errUnsupportedTopmost() error {
+	return errors.New(T("当前平台不支持窗口置顶"))
+}
+
+// 迷你模式：主窗口隐藏，只显示价格、持仓盈亏和平仓按钮的小窗口，尽量保持在其他窗口之上。
+// 关闭迷你窗口或点击展开时恢复主窗口
+type MiniWindow struct {
+	ui       *TraderUI
+	window   fyne.Window
+	price    *widget.Label
+	position *widget.Label
+	total    *widget.Label
+	status   *widget.Label
+	stopC    chan struct{}
+}
+
+func (ui *TraderUI) showMiniWindow() {
+	m := &MiniWindow{
+		ui:       ui,
+		window:   ui.app.NewWindow(T("迷你模式")),
+		price:    widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true, Bold: true}),
+		position: widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true}),
+		total:    widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true}),
+		status:   widget.NewLabel(""),
+		stopC:    make(chan struct{}),
+	}
+
+	// 平仓确认对话框显示在主窗口上，先恢复主窗口
+	closeBtn := widget.NewButton(T("平仓"), func() {
+		symbol := ui.symbol
+		m.restore()
+		ui.confirmClosePosition(symbol)
+	})
+	closeBtn.Importance = widget.DangerImportance
+	flattenBtn := widget.NewButton(T("全部平仓"), func() {
+		m.restore()
+		ui.confirmFlattenAll()
+	})
+	flattenBtn.Importance = widget.DangerImportance
+	expandBtn := widget.NewButton(T("展开"), m.restore)
+
+	m.window.SetContent(container.NewVBox(
+		m.price,
+		m.position,
+		m.total,
+		m.status,
+		container.NewGridWithColumns(3, closeBtn, flattenBtn, expandBtn),
+	))
+	m.window.SetFixedSize(true)
+	m.window.SetOnClosed(func() {
+		close(m.stopC)
+		ui.window.Show()
+	})
+	m.window.Resize(ui.scaled(320, 160))
+	m.refresh()
+	m.window.Show()
+	if err := setAlwaysOnTop(m.window); err != nil {
+		fmt.Printf(T("迷你窗口置顶失败: %v\n"), err)
+	}
+	ui.window.Hide()
+
+	go m.run()
+}
+
+// 关闭迷你窗口并显示主窗口
+func (m *MiniWindow) restore() {
+	m.window.Close()
+	m.ui.window.RequestFocus()
+}
+
+func (m *MiniWindow) run() {
+	ticker := time.NewTicker(miniRefresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stopC:
+			return
+		case <-ticker.C:
+			fyne.Do(m.refresh)
+		}
+	}
+}
+
+// 按主界面最近一次刷新的价格和持仓更新显示，需要在UI线程中调用
+func (m *MiniWindow) refresh() {
+	ui := m.ui
+	symbol := ui.symbol
+	m.price.SetText(fmt.Sprintf("%s  %s", symbol, formatChartPrice(ui.currentPrice)))
+
+	var total float64
+	positionText := T("无持仓")
+	m.position.Importance = widget.MediumImportance
+	for _, row := range ui.lastPositions {
+		total += row.PnL
+		if row.Symbol != symbol {
+			continue
+		}
+		side := T("多")
+		if !row.Long {
+			side = T("空")
+		}
+		positionText = fmt.Sprintf(T("%s %s  盈亏 %+.2f (%+.2f%%)"), side, formatFloat(row.Size), row.PnL, row.ROE)
+		m.position.Importance = pnlImportance(row.PnL)
+	}
+	m.position.SetText(positionText)
+
+	m.total.Importance = pnlImportance(total)
+	m.total.SetText(fmt.Sprintf(T("%d个持仓 总盈亏 %+.2f"), len(ui.lastPositions), total))
+
+	if ui.paused.Load() {
+		setStatus(m.status, T("自动化: 已暂停"), widget.WarningImportance)
+	} else {
+		setStatus(m.status, T("自动化: 运行中"), widget.SuccessImportance)
+	}
+}
+
+func pnlImportance(pnl float64) widget.Importance {
+	switch {
+	case pnl > 0:
+		return widget.SuccessImportance
+	case pnl < 0:
+		return widget.DangerImportance
+	}
+	return widget.MediumImportance
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Pushover推送设置，token为应用的API令牌，user为用户或群组的key
+type PushoverConfig struct {
+	Token       string   `json:"token"`
+	User        string   `json:"user"`
+	Device      string   `json:"device,omitempty"`       // 只推送到该设备，为空时推送到所有设备
+	MinSeverity string   `json:"min_severity,omitempty"` // 只推送不低于该严重程度的事件，默认critical
+	Events      []string `json:"events,omitempty"`       // 推送的事件类型，为空时不限
+}
+
+// Bark推送设置，key为Bark App中显示的设备key，自建服务时修改server
+type BarkConfig struct {
+	Key         string   `json:"key"`
+	Server      string   `json:"server,omitempty"`       // 默认https://api.day.app
+	MinSeverity string   `json:"min_severity,omitempty"` // 只推送不低于该严重程度的事件，默认critical
+	Events      []string `json:"events,omitempty"`       // 推送的事件类型，为空时不限
+}
+
+// 手机推送渠道默认只推送严重事件
+func mobileMinSeverity(severity string) string {
+	if severity == "" {
+		return SeverityCritical
+	}
+	return severity
+}
+
+type Pushover struct {
+	config PushoverConfig
+	client *http.Client
+}
+
+func NewPushover(config PushoverConfig) (*Pushover, error) {
+	if config.Token == "" || config.User == "" {
+		return nil, errors.New(T("Pushover需要设置token和user"))
+	}
+	config.MinSeverity = mobileMinSeverity(config.MinSeverity)
+	return &Pushover{config: config, client: &http.Client{Timeout: 15 * time.Second}}, nil
+}
+
+func (p *Pushover) Name() string { return "pushover" }
+
+// Pushover的优先级: critical为高优先级(绕过免打扰)，info为低优先级(不响铃)
+var pushoverPriority = map[string]string{
+	SeverityInfo:     "-1",
+	SeverityWarning:  "0",
+	SeverityCritical: "1",
+}
+
+func (p *Pushover) Notify(event NotifyEvent) error {
+	priority := pushoverPriority[event.Severity]
+	if priority == "" {
+		priority = pushoverPriority[SeverityInfo]
+	}
+	form := url.Values{
+		"token":     {p.config.Token},
+		"user":      {p.config.User},
+		"title":     {event.Title},
+		"message":   {event.Message},
+		"priority":  {priority},
+		"timestamp": {strconv.FormatInt(event.Time.Unix(), 10)},
+	}
+	if event.Message == "" {
+		form.Set("message", event.Title)
+	}
+	if p.config.Device != "" {
+		form.Set("device", p.config.Device)
+	}
+	resp, err := p.client.PostForm("https://api.pushover.net/1/messages.json", form)
+	if err != nil {
+		return fmt.Errorf(T("请求%s失败: %v"), p.Name(), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf(T("%s返回错误 %d: %s"), p.Name(), resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return nil
+}
+
+type Bark struct {
+	config BarkConfig
+	client *http.Client
+}
+
+func NewBark(config BarkConfig) (*Bark, error) {
+	if config.Key == "" {
+		return nil, errors.New(T("Bark需要设置key"))
+	}
+	if config.Server == "" {
+		config.Server = "https://api.day.app"
+	}
+	config.MinSeverity = mobileMinSeverity(config.MinSeverity)
+	return &Bark{config: config, client: &http.Client{Timeout: 15 * time.Second}}, nil
+}
+
+func (b *Bark) Name() string { return "bark" }
+
+// Bark的通知级别: critical为重要警告(静音时也响铃)，warning为时效性通知
+var barkLevel = map[string]string{
+	SeverityInfo:     "active",
+	SeverityWarning:  "timeSensitive",
+	SeverityCritical: "critical",
+}
+
+func (b *Bark) Notify(event NotifyEvent) error {
+	level := barkLevel[event.Severity]
+	if level == "" {
+		level = barkLevel[SeverityInfo]
+	}
+	body, err := json.Marshal(map[string]string{
+		"device_key": b.config.Key,
+		"title":      event.Title,
+		"body":       event.Message,
+		"level":      level,
+		"group":      "protect",
+	})
+	if err != nil {
+		return fmt.Errorf(T("序列化请求失败: %v"), err)
+	}
+	resp, err := b.client.Post(strings.TrimSuffix(b.config.Server, "/")+"/push", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf(T("请求%s失败: %v"), b.Name(), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf(T("%s返回错误 %d: %s"), b.Name(), resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return nil
+}
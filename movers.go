@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// 24h行情统计
+type TickerStat struct {
+	Symbol      string
+	LastPrice   float64
+	Change      float64 // 24h涨跌幅(%)
+	QuoteVolume float64 // 24h成交额
+}
+
+// 涨幅榜、跌幅榜和成交额榜
+type TopMovers struct {
+	Gainers []TickerStat
+	Losers  []TickerStat
+	Volume  []TickerStat
+}
+
+// 从24h行情中统计USDT/USDC永续合约的涨跌幅和成交额排行
+func fetchTopMovers(client *futures.Client, n int) (*TopMovers, error) {
+	stats, err := client.NewListPriceChangeStatsService().Do(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf(T("获取24h行情失败: %v"), err)
+	}
+
+	var tickers []TickerStat
+	for _, s := range stats {
+		// 交割合约的交易对带有日期后缀，如BTCUSDT_250328
+		if strings.Contains(s.Symbol, "_") {
+			continue
+		}
+		if !strings.HasSuffix(s.Symbol, "USDT") && !strings.HasSuffix(s.Symbol, "USDC") {
+			continue
+		}
+		t := TickerStat{Symbol: s.Symbol}
+		t.LastPrice, _ = strconv.ParseFloat(s.LastPrice, 64)
+		t.Change, _ = strconv.ParseFloat(s.PriceChangePercent, 64)
+		t.QuoteVolume, _ = strconv.ParseFloat(s.QuoteVolume, 64)
+		tickers = append(tickers, t)
+	}
+
+	movers := &TopMovers{}
+
+	sort.Slice(tickers, func(i, j int) bool { return tickers[i].Change > tickers[j].Change })
+	movers.Gainers = topN(tickers, n)
+
+	sort.Slice(tickers, func(i, j int) bool { return tickers[i].Change < tickers[j].Change })
+	movers.Losers = topN(tickers, n)
+
+	sort.Slice(tickers, func(i, j int) bool { return tickers[i].QuoteVolume > tickers[j].QuoteVolume })
+	movers.Volume = topN(tickers, n)
+
+	return movers, nil
+}
+
+func topN(tickers []TickerStat, n int) []TickerStat {
+	if len(tickers) < n {
+		n = len(tickers)
+	}
+	result := make([]TickerStat, n)
+	copy(result, tickers[:n])
+	return result
+}
+
+func (m *TopMovers) String() string {
+	var sb strings.Builder
+
+	sb.WriteString(T("涨幅榜:\n"))
+	for _, t := range m.Gainers {
+		sb.WriteString(fmt.Sprintf("  %-14s %12.4f %+7.2f%%\n", t.Symbol, t.LastPrice, t.Change))
+	}
+	sb.WriteString(T("跌幅榜:\n"))
+	for _, t := range m.Losers {
+		sb.WriteString(fmt.Sprintf("  %-14s %12.4f %+7.2f%%\n", t.Symbol, t.LastPrice, t.Change))
+	}
+	sb.WriteString(T("成交额榜:\n"))
+	for _, t := range m.Volume {
+		sb.WriteString(fmt.Sprintf("  %-14s %12.4f %10.2fM\n", t.Symbol, t.LastPrice, t.QuoteVolume/1e6))
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MQTT发布设置，把价格、持仓快照和推送事件发布到broker，供家庭自动化面板等订阅
+type MQTTConfig struct {
+	Broker   string `json:"broker"`              // tcp://host:1883，TLS使用ssl://host:8883
+	ClientID string `json:"client_id,omitempty"` // 默认protect-账户名
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	// 主题，{symbol}替换为交易对，{type}替换为事件类型，为空时使用默认主题
+	Topics struct {
+		Price     string `json:"price"`     // 标记价格，默认protect/price/{symbol}
+		Positions string `json:"positions"` // 持仓快照(保留消息)，默认protect/positions
+		Events    string `json:"events"`    // 推送事件，默认protect/events/{type}
+	} `json:"topics"`
+	Events []string `json:"events,omitempty"` // 发布的事件类型，为空时发布所有事件
+}
+
+// 没有设置主题时使用默认主题
+func (c *MQTTConfig) applyDefaults() {
+	if c.Topics.Price == "" {
+		c.Topics.Price = "protect/price/{symbol}"
+	}
+	if c.Topics.Positions == "" {
+		c.Topics.Positions = "protect/positions"
+	}
+	if c.Topics.Events == "" {
+		c.Topics.Events = "protect/events/{type}"
+	}
+}
+
+// 只发布QoS 0消息的MQTT 3.1.1客户端。连接在第一次发布时在后台建立，断开后按退避时间重连，
+// 没有连接时直接丢弃消息，发布不会等待连接
+type MQTT struct {
+	config MQTTConfig
+	addr   string
+	useTLS bool
+
+	mu         sync.Mutex
+	conn       net.Conn
+	connecting bool
+	retryAt    time.Time     // 连接失败后到这个时间之前不再重连
+	backoff    time.Duration // 下次连接失败后的等待时间，每次失败翻倍
+}
+
+const (
+	mqttKeepAlive  = 60 * time.Second
+	mqttMinBackoff = time.Second
+	mqttMaxBackoff = time.Minute
+)
+
+func NewMQTT(config MQTTConfig) (*MQTT, error) {
+	u, err := url.Parse(config.Broker)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf(T("MQTT broker地址格式错误: %s"), config.Broker)
+	}
+	m := &MQTT{config: config, addr: u.Host}
+	switch u.Scheme {
+	case "tcp", "mqtt":
+	case "ssl", "tls", "mqtts":
+		m.useTLS = true
+	default:
+		return nil, fmt.Errorf(T("MQTT broker地址格式错误: %s"), config.Broker)
+	}
+	if u.Port() == "" {
+		port := "1883"
+		if m.useTLS {
+			port = "8883"
+		}
+		m.addr = net.JoinHostPort(u.Hostname(), port)
+	}
+	m.config.applyDefaults()
+	return m, nil
+}
+
+func (m *MQTT) Name() string { return "mqtt" }
+
+// 把推送事件发布到事件主题
+func (m *MQTT) Notify(event NotifyEvent) error {
+	topic := strings.ReplaceAll(m.config.Topics.Events, "{type}", event.Type)
+	return m.PublishJSON(topic, event, false)
+}
+
+// 以JSON发布，retain为true时broker保留最后一条消息，新订阅者马上能收到
+func (m *MQTT) PublishJSON(topic string, v interface{}, retain bool) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf(T("序列化请求失败: %v"), err)
+	}
+	return m.Publish(topic, payload, retain)
+}
+
+func (m *MQTT) Publish(topic string, payload []byte, retain bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.conn == nil {
+		m.reconnectLocked()
+		return errors.New(T("MQTT未连接，丢弃消息"))
+	}
+
+	header := byte(0x30)
+	if retain {
+		header |= 0x01
+	}
+	body := append(mqttString(topic), payload...)
+	if err := m.writeLocked(header, body); err != nil {
+		m.closeLocked()
+		return fmt.Errorf(T("发布MQTT消息失败: %v"), err)
+	}
+	return nil
+}
+
+// 没有正在进行的连接且退避时间已过时在后台连接
+func (m *MQTT) reconnectLocked() {
+	if m.connecting || time.Now().Before(m.retryAt) {
+		return
+	}
+	m.connecting = true
+	go func() {
+		conn, err := m.dial()
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.connecting = false
+		if err != nil {
+			m.backoff = min(max(m.backoff*2, mqttMinBackoff), mqttMaxBackoff)
+			m.retryAt = time.Now().Add(m.backoff)
+			debugf(T("连接MQTT broker失败，%v后重试: %v"), m.backoff, err)
+			return
+		}
+		m.conn, m.backoff = conn, 0
+		go m.keepAlive(conn)
+	}()
+}
+
+// 建立连接并完成CONNECT握手，不持有锁
+func (m *MQTT) dial() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	var conn net.Conn
+	var err error
+	if m.useTLS {
+		host, _, _ := net.SplitHostPort(m.addr)
+		conn, err = tls.DialWithDialer(dialer, "tcp", m.addr, &tls.Config{ServerName: host})
+	} else {
+		conn, err = dialer.Dial("tcp", m.addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// CONNECT: 协议名、级别4、clean session，以及用户名和密码
+	flags := byte(0x02)
+	payload := mqttString(m.config.ClientID)
+	if m.config.Username != "" {
+		flags |= 0x80
+		payload = append(payload, mqttString(m.config.Username)...)
+		if m.config.Password != "" {
+			flags |= 0x40
+			payload = append(payload, mqttString(m.config.Password)...)
+		}
+	}
+	body := append(mqttString("MQTT"), 4, flags, byte(mqttKeepAlive/time.Second>>8), byte(mqttKeepAlive/time.Second))
+	body = append(body, payload...)
+	if err := writePacket(conn, 0x10, body); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	// CONNACK: 0x20 0x02 标志 返回码
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	ack := make([]byte, 4)
+	if _, err := io.ReadFull(conn, ack); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetReadDeadline(time.Time{})
+	if ack[0] != 0x20 || ack[3] != 0 {
+		conn.Close()
+		return nil, fmt.Errorf(T("broker拒绝连接，返回码 %d"), ack[3])
+	}
+	return conn, nil
+}
+
+// 定时发送PINGREQ，并读取broker发来的数据，连接断开时清除
+func (m *MQTT) keepAlive(conn net.Conn) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r := bufio.NewReader(conn)
+		for {
+			if _, err := r.ReadByte(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(mqttKeepAlive / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.mu.Lock()
+			if m.conn != conn {
+				m.mu.Unlock()
+				return
+			}
+			if err := m.writeLocked(0xC0, nil); err != nil {
+				m.closeLocked()
+			}
+			m.mu.Unlock()
+		case <-done:
+			m.mu.Lock()
+			if m.conn == conn {
+				m.closeLocked()
+			}
+			m.mu.Unlock()
+			return
+		}
+	}
+}
+
+func (m *MQTT) writeLocked(header byte, body []byte) error {
+	return writePacket(m.conn, header, body)
+}
+
+func writePacket(conn net.Conn, header byte, body []byte) error {
+	packet := append([]byte{header}, mqttLength(len(body))...)
+	packet = append(packet, body...)
+	conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	_, err := conn.Write(packet)
+	return err
+}
+
+func (m *MQTT) closeLocked() {
+	if m.conn != nil {
+		m.conn.Close()
+		m.conn = nil
+	}
+}
+
+// MQTT的字符串: 两字节长度加UTF-8内容
+func mqttString(s string) []byte {
+	return append([]byte{byte(len(s) >> 8), byte(len(s))}, s...)
+}
+
+// 剩余长度的变长编码，每字节7位
+func mqttLength(n int) []byte {
+	var b []byte
+	for {
+		digit := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			digit |= 0x80
+		}
+		b = append(b, digit)
+		if n == 0 {
+			return b
+		}
+	}
+}
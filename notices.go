@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 通知级别
+type NoticeLevel int
+
+const (
+	NoticeInfo NoticeLevel = iota
+	NoticeWarning
+	NoticeError
+)
+
+const (
+	maxNotices    = 200             // 保留的通知记录数量
+	maxToasts     = 3               // 同时显示的提示数量
+	toastDuration = 6 * time.Second // 提示显示时长
+	noticeDedup   = time.Minute     // 相同通知不重复提示的时间
+)
+
+// 自动下单、保护止盈和策略执行等操作的通知
+type Notice struct {
+	Time  time.Time
+	Level NoticeLevel
+	Text  string
+}
+
+func (n Notice) String() string {
+	return n.Time.Format("01-02 15:04:05") + " " + n.Text
+}
+
+func (n Notice) importance() widget.Importance {
+	switch n.Level {
+	case NoticeWarning:
+		return widget.WarningImportance
+	case NoticeError:
+		return widget.DangerImportance
+	}
+	return widget.MediumImportance
+}
+
+// 通知记录和窗口右下角的提示
+type Notices struct {
+	mu      sync.Mutex
+	history []Notice
+	recent  map[string]time.Time // 最近提示过的通知内容和时间，用于去重
+	toasts  *fyne.Container
+}
+
+func NewNotices() *Notices {
+	return &Notices{recent: make(map[string]time.Time), toasts: container.NewVBox()}
+}
+
+// 覆盖在content上的提示层，提示不接收点击，不影响下面的控件
+func (n *Notices) Overlay(content fyne.CanvasObject) fyne.CanvasObject {
+	return container.NewStack(content, container.NewVBox(
+		layout.NewSpacer(),
+		container.NewHBox(layout.NewSpacer(), n.toasts),
+	))
+}
+
+// 历史通知，最新的在前
+func (n *Notices) History() []Notice {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	history := make([]Notice, len(n.history))
+	for i, notice := range n.history {
+		history[len(n.history)-1-i] = notice
+	}
+	return history
+}
+
+// 相同的通知一分钟内不重复提示，避免每次刷新都报同一个错误。几个错误交替出现时
+// 也能去重，过期的记录在添加通知时清除
+func (n *Notices) add(notice Notice) {
+	n.mu.Lock()
+	for text, t := range n.recent {
+		if notice.Time.Sub(t) >= noticeDedup {
+			delete(n.recent, text)
+		}
+	}
+	if _, ok := n.recent[notice.Text]; ok {
+		n.mu.Unlock()
+		return
+	}
+	n.recent[notice.Text] = notice.Time
+	n.history = append(n.history, notice)
+	if len(n.history) > maxNotices {
+		n.history = n.history[len(n.history)-maxNotices:]
+	}
+	n.mu.Unlock()
+
+	fyne.Do(func() {
+		label := widget.NewLabel(notice.String())
+		label.Importance = notice.importance()
+		toast := widget.NewCard("", "", label)
+		n.toasts.Add(toast)
+		if len(n.toasts.Objects) > maxToasts {
+			n.toasts.Remove(n.toasts.Objects[0])
+		}
+		time.AfterFunc(toastDuration, func() {
+			fyne.Do(func() {
+				n.toasts.Remove(toast)
+			})
+		})
+	})
+}
+
+// 记录通知并在界面上提示，同时输出到标准输出
+func (ui *TraderUI) notify(level NoticeLevel, format string, args ...interface{}) {
+	text := fmt.Sprintf(format, args...)
+	ui.logs.Print(level, text)
+	ui.notices.add(Notice{Time: time.Now(), Level: level, Text: text})
+}
+
+// 通知记录窗口
+func (ui *TraderUI) showNotices() {
+	w := ui.app.NewWindow(T("通知记录"))
+
+	var history []Notice
+	list := widget.NewList(
+		func() int { return len(history) },
+		func() fyne.CanvasObject {
+			return widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			label := o.(*widget.Label)
+			label.Importance = history[id].importance()
+			label.SetText(history[id].String())
+		},
+	)
+	refresh := func() {
+		history = ui.notices.History()
+		list.Refresh()
+	}
+	refresh()
+
+	w.SetContent(container.NewBorder(
+		container.NewHBox(widget.NewButton(T("刷新"), refresh)),
+		nil, nil, nil,
+		list,
+	))
+	w.Resize(ui.scaled(640, 400))
+	w.Show()
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// 推送事件的类型，各通知渠道可以在配置中用events只订阅其中几种
+const (
+	EventFill    = "fill"    // 订单成交
+	EventOrder   = "order"   // 自动设置或撤销止盈止损单
+	EventProtect = "protect" // 止盈止损单触发、保护止盈平仓和紧急停止
+	EventAlert   = "alert"   // 提醒触发
+	EventError   = "error"   // 获取持仓失败、自动化执行失败
+	EventSummary = "summary" // 每日交易汇总
+)
+
+var notifyEvents = []string{EventFill, EventOrder, EventProtect, EventAlert, EventError, EventSummary}
+
+// 事件的严重程度，手机推送渠道默认只推送critical: 止损触发、连环爆仓和与交易所的连接异常
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+var notifySeverities = []string{SeverityInfo, SeverityWarning, SeverityCritical}
+
+// 严重程度的级别，空字符串视为info
+func severityLevel(severity string) int {
+	return max(slices.Index(notifySeverities, severity), 0)
+}
+
+// 检查配置中的事件类型
+func checkNotifyEvents(events []string) error {
+	for _, event := range events {
+		if !slices.Contains(notifyEvents, event) {
+			return fmt.Errorf(T("未知的事件类型: %s，可选 %s"), event, strings.Join(notifyEvents, "/"))
+		}
+	}
+	return nil
+}
+
+// 推送给通知渠道的事件
+type NotifyEvent struct {
+	Type     string             `json:"type"`
+	Time     time.Time          `json:"time"`
+	Symbol   string             `json:"symbol,omitempty"`
+	Severity string             `json:"severity"` // info/warning/critical，为空时为info
+	Title    string             `json:"title"`
+	Message  string             `json:"message"`
+	Fields   map[string]float64 `json:"fields,omitempty"` // 价格、数量、盈亏等数据
+}
+
+// 纯文本的消息，标题和内容各占一行
+func (e NotifyEvent) Text() string {
+	if e.Message == "" {
+		return e.Title
+	}
+	return e.Title + "\n" + e.Message
+}
+
+// 通知渠道
+type Notifier interface {
+	Name() string
+	Notify(event NotifyEvent) error
+}
+
+// 推送通知的设置，只有命令行的监控程序发送
+type NotifyConfig struct {
+	// 每天发送前一天交易日报的时间，如08:00，为空时不发送
+	DailySummary string          `json:"daily_summary,omitempty"`
+	Telegram     *TelegramConfig `json:"telegram,omitempty"`
+	Discord      *DiscordConfig  `json:"discord,omitempty"`
+	Webhooks     []WebhookConfig `json:"webhooks,omitempty"`
+	Email        *EmailConfig    `json:"email,omitempty"`
+	WeCom        *WeComConfig    `json:"wecom,omitempty"`
+	DingTalk     *DingTalkConfig `json:"dingtalk,omitempty"`
+	Pushover     *PushoverConfig `json:"pushover,omitempty"`
+	Bark         *BarkConfig     `json:"bark,omitempty"`
+}
+
+type notifyTarget struct {
+	notifier    Notifier
+	events      []string // 为空时订阅所有事件
+	minSeverity string   // 只推送不低于该严重程度的事件
+}
+
+func (t notifyTarget) wants(event NotifyEvent) bool {
+	return (len(t.events) == 0 || slices.Contains(t.events, event.Type)) &&
+		severityLevel(event.Severity) >= severityLevel(t.minSeverity)
+}
+
+// 把事件分发给所有订阅了该事件的通知渠道。发送在后台进行，失败只打印警告
+type NotifyHub struct {
+	targets []notifyTarget
+}
+
+// 添加通知渠道，events为订阅的事件类型，为空时订阅所有事件
+func (h *NotifyHub) Add(n Notifier, events []string) error {
+	return h.AddFiltered(n, events, "")
+}
+
+// 添加只推送不低于minSeverity的事件的通知渠道
+func (h *NotifyHub) AddFiltered(n Notifier, events []string, minSeverity string) error {
+	if err := checkNotifyEvents(events); err != nil {
+		return fmt.Errorf("%s: %v", n.Name(), err)
+	}
+	if minSeverity != "" && !slices.Contains(notifySeverities, minSeverity) {
+		return fmt.Errorf(T("%s: 未知的严重程度: %s，可选 %s"), n.Name(), minSeverity, strings.Join(notifySeverities, "/"))
+	}
+	if slices.Contains(h.Names(), n.Name()) {
+		return fmt.Errorf(T("通知渠道名称重复: %s"), n.Name())
+	}
+	h.targets = append(h.targets, notifyTarget{notifier: n, events: events, minSeverity: minSeverity})
+	return nil
+}
+
+// 已添加的通知渠道名称
+func (h *NotifyHub) Names() []string {
+	names := make([]string, len(h.targets))
+	for i, t := range h.targets {
+		names[i] = t.notifier.Name()
+	}
+	return names
+}
+
+func (h *NotifyHub) Send(event NotifyEvent) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	for _, t := range h.targets {
+		if t.wants(event) {
+			go h.deliver(t.notifier, event)
+		}
+	}
+}
+
+func (h *NotifyHub) deliver(n Notifier, event NotifyEvent) {
+	if err := n.Notify(event); err != nil {
+		warnf(T("%s通知发送失败: %v"), n.Name(), err)
+	}
+}
+
+// 把每个通知渠道注册为提醒的通知方式，提醒的channels可以指定发送到哪些渠道。
+// 提醒明确指定的渠道不按事件类型和严重程度过滤，手机推送默认只推送critical也能收到
+func (h *NotifyHub) RegisterAlertChannels(alerts *AlertManager) {
+	for _, t := range h.targets {
+		alerts.RegisterChannel(t.notifier.Name(), func(alert *Alert, value float64) {
+			event := NotifyEvent{
+				Type:     EventAlert,
+				Time:     time.Now(),
+				Symbol:   alert.Symbol,
+				Severity: SeverityInfo,
+				Title:    fmt.Sprintf(T("%s提醒"), alert.Type.Label()),
+				Message:  alert.Message(value),
+				Fields:   map[string]float64{"value": value},
+			}
+			if slices.Contains(alert.Channels, t.notifier.Name()) || t.wants(event) {
+				go h.deliver(t.notifier, event)
+			}
+		})
+	}
+}
+
+// 把活动记录转成推送事件: 止盈止损单的设置和撤销、保护止盈平仓和紧急停止，执行失败时为错误事件
+func activityEvent(a Activity) NotifyEvent {
+	event := NotifyEvent{
+		Type:     EventOrder,
+		Time:     a.Time,
+		Symbol:   a.Symbol,
+		Severity: SeverityInfo,
+		Title:    fmt.Sprintf("%s %s", a.Symbol, activityLabel(a.Action)),
+		Message:  a.Reason,
+		Fields:   map[string]float64{},
+	}
+	switch a.Action {
+	case ActivityProtect:
+		event.Type, event.Severity = EventProtect, SeverityWarning
+	case ActivityKill:
+		event.Type, event.Severity = EventProtect, SeverityCritical
+	}
+	if a.Error != "" {
+		event.Type, event.Severity = EventError, SeverityWarning
+		event.Title += T(" 失败")
+		event.Message = strings.TrimSpace(a.Reason + "\n" + a.Error)
+	}
+	if a.Price > 0 {
+		event.Fields["price"] = a.Price
+	}
+	if a.Quantity > 0 {
+		event.Fields["quantity"] = a.Quantity
+	}
+	for k, v := range a.Inputs {
+		event.Fields[k] = v
+	}
+	return event
+}
+
+// 成交推送事件，止盈止损单成交时为止盈止损触发事件
+func fillEvent(f Fill) NotifyEvent {
+	event := NotifyEvent{
+		Type:     EventFill,
+		Time:     f.Time,
+		Symbol:   f.Symbol,
+		Severity: SeverityInfo,
+		Title:    fmt.Sprintf(T("%s %s单成交"), f.Symbol, orderTagLabel(f.ClientOrderID)),
+		Fields:   map[string]float64{"price": f.Price, "quantity": f.Quantity},
+	}
+	switch tag := orderTag(f.ClientOrderID); tag {
+	case tagTakeProfit, tagStopLoss:
+		event.Type = EventProtect
+		event.Title = fmt.Sprintf(T("%s %s触发"), f.Symbol, orderTagLabel(f.ClientOrderID))
+		if tag == tagStopLoss {
+			event.Severity = SeverityCritical
+		}
+	}
+	side := T("买入")
+	if f.Side == futures.SideTypeSell {
+		side = T("卖出")
+	}
+	event.Message = fmt.Sprintf("%s %.4f @ %.4f", side, f.Quantity, f.Price)
+	if f.RealizedPnL != 0 {
+		event.Message += fmt.Sprintf(T("，已实现盈亏 %+.2f"), f.RealizedPnL)
+		event.Fields["realized_pnl"] = f.RealizedPnL
+	}
+	return event
+}
+
+// 解析每天的时间，如08:00，返回当天零点起的时长
+func parseDailyTime(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf(T("时间格式错误，应为HH:MM: %s"), s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// 下一次到达每天at时刻的时间
+func nextDailyTime(now time.Time, at time.Duration) time.Time {
+	next := periodStart(now, statsDay).Add(at)
+	if !next.After(now) {
+		next = periodStart(now.AddDate(0, 0, 1), statsDay).Add(at)
+	}
+	return next
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+const maxBufferedEvents = 1000
+
+// 盘口的一档
+type BookLevel struct {
+	Price    float64
+	Quantity float64
+}
+
+// 本地维护的深度，按交易所文档用REST快照加增量深度流同步：
+// 先缓存增量事件，取得快照后丢弃快照之前的事件，之后每个事件的pu必须等于上一个事件的u，
+// 否则重新同步
+type OrderBook struct {
+	client *futures.Client
+
+	mu           sync.Mutex
+	symbol       string
+	bids         map[float64]float64
+	asks         map[float64]float64
+	lastUpdateID int64
+	synced       bool
+	syncing      bool
+	buffer       []*futures.WsDepthEvent
+	stopC        chan struct{}
+}
+
+func NewOrderBook(client *futures.Client, symbol string) *OrderBook {
+	return &OrderBook{client: client, symbol: symbol}
+}
+
+// 订阅增量深度流，断开后自动重连并重新同步
+func (b *OrderBook) Start() {
+	go func() {
+		for {
+			b.mu.Lock()
+			symbol := b.symbol
+			b.resetLocked()
+			b.mu.Unlock()
+			if symbol == "" {
+				return
+			}
+
+			doneC, stopC, err := futures.WsDiffDepthServe(symbol, b.handleEvent, func(err error) {
+				log.Printf(T("深度数据流错误: %v"), err)
+			})
+			if err != nil {
+				log.Printf(T("订阅深度数据流失败: %v"), err)
+				time.Sleep(5 * time.Second)
+				continue
+			}
+			// 连接期间切换了交易对或已经停止时，SetSymbol和Stop还拿不到stopC，在这里断开
+			b.mu.Lock()
+			if b.symbol != symbol {
+				b.mu.Unlock()
+				close(stopC)
+				<-doneC
+				continue
+			}
+			b.stopC = stopC
+			b.mu.Unlock()
+			<-doneC
+			metrics.Inc(metricWSReconnects)
+			time.Sleep(time.Second)
+		}
+	}()
+}
+
+// 停止数据流，关闭价格阶梯窗口时调用
+func (b *OrderBook) Stop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.symbol = ""
+	if b.stopC != nil {
+		close(b.stopC)
+		b.stopC = nil
+	}
+}
+
+// 切换交易对，断开当前数据流后按新交易对重连
+func (b *OrderBook) SetSymbol(symbol string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if symbol == b.symbol {
+		return
+	}
+	b.symbol = symbol
+	b.resetLocked()
+	if b.stopC != nil {
+		close(b.stopC)
+		b.stopC = nil
+	}
+}
+
+func (b *OrderBook) Symbol() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.symbol
+}
+
+func (b *OrderBook) resetLocked() {
+	b.bids = make(map[float64]float64)
+	b.asks = make(map[float64]float64)
+	b.lastUpdateID = 0
+	b.synced = false
+	b.buffer = nil
+}
+
+// 获取快照并应用缓存的增量事件
+func (b *OrderBook) sync(symbol string) {
+	snapshot, err := b.client.NewDepthService().Symbol(symbol).Limit(1000).Do(context.Background())
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.syncing = false
+	if err != nil {
+		log.Printf(T("获取深度快照失败: %v"), err)
+		return
+	}
+	if symbol != b.symbol {
+		return
+	}
+	buffer := b.buffer
+	b.resetLocked()
+	for _, bid := range snapshot.Bids {
+		setLevel(b.bids, bid.Price, bid.Quantity)
+	}
+	for _, ask := range snapshot.Asks {
+		setLevel(b.asks, ask.Price, ask.Quantity)
+	}
+	b.lastUpdateID = snapshot.LastUpdateID
+	b.synced = true
+
+	first := true
+	for _, event := range buffer {
+		if event.LastUpdateID < b.lastUpdateID {
+			continue
+		}
+		if first && event.FirstUpdateID > b.lastUpdateID {
+			// 快照和增量之间有缺口，下一个事件到来时重新同步
+			b.synced = false
+			return
+		}
+		first = false
+		b.applyLocked(event)
+	}
+}
+
+func (b *OrderBook) handleEvent(event *futures.WsDepthEvent) {
+	b.mu.Lock()
+	if event.Symbol != b.symbol {
+		b.mu.Unlock()
+		return
+	}
+	if b.synced && event.PrevLastUpdateID != b.lastUpdateID {
+		// 丢失了事件，重新获取快照
+		b.synced = false
+		b.buffer = nil
+	}
+	if b.synced {
+		b.applyLocked(event)
+		b.mu.Unlock()
+		return
+	}
+
+	// 未同步时缓存事件，快照获取失败时最多保留最近的maxBufferedEvents个
+	b.buffer = append(b.buffer, event)
+	if len(b.buffer) > maxBufferedEvents {
+		b.buffer = b.buffer[len(b.buffer)-maxBufferedEvents:]
+	}
+	syncing := b.syncing
+	b.syncing = true
+	symbol := b.symbol
+	b.mu.Unlock()
+	if !syncing {
+		go b.sync(symbol)
+	}
+}
+
+func (b *OrderBook) applyLocked(event *futures.WsDepthEvent) {
+	for _, bid := range event.Bids {
+		setLevel(b.bids, bid.Price, bid.Quantity)
+	}
+	for _, ask := range event.Asks {
+		setLevel(b.asks, ask.Price, ask.Quantity)
+	}
+	b.lastUpdateID = event.LastUpdateID
+}
+
+func setLevel(levels map[float64]float64, price, quantity string) {
+	p, _ := strconv.ParseFloat(price, 64)
+	q, _ := strconv.ParseFloat(quantity, 64)
+	if q == 0 {
+		delete(levels, p)
+	} else {
+		levels[p] = q
+	}
+}
+
+// 最优的n档买卖盘，买盘从高到低、卖盘从低到高。未同步时返回false
+func (b *OrderBook) Levels(n int) (bids, asks []BookLevel, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.synced {
+		return nil, nil, false
+	}
+
+	for p, q := range b.bids {
+		bids = append(bids, BookLevel{Price: p, Quantity: q})
+	}
+	for p, q := range b.asks {
+		asks = append(asks, BookLevel{Price: p, Quantity: q})
+	}
+	sort.Slice(bids, func(i, j int) bool { return bids[i].Price > bids[j].Price })
+	sort.Slice(asks, func(i, j int) bool { return asks[i].Price < asks[j].Price })
+	if len(bids) > n {
+		bids = bids[:n]
+	}
+	if len(asks) > n {
+		asks = asks[:n]
+	}
+	return bids, asks, true
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 下单前确认的设置
+type OrderConfirmConfig struct {
+	Enabled     bool    `json:"enabled"`      // 下单前显示确认对话框
+	MinNotional float64 `json:"min_notional"` // 名义价值低于该值(USDC)的订单不再确认，0表示全部确认
+}
+
+// 是否需要确认该名义价值的订单
+func (c OrderConfirmConfig) Required(notional float64) bool {
+	return c.Enabled && notional >= c.MinNotional
+}
+
+// 确认对话框中的订单摘要
+type OrderSummary struct {
+	Symbol   string
+	Long     bool
+	Price    float64
+	Quantity float64
+	Leverage float64 // 获取失败时为0
+	StopLoss float64 // 没有止损时为0
+	Warning  string  // 显示在摘要最前面的警告，如限价单会立即成交
+}
+
+func (s OrderSummary) Notional() float64 {
+	return s.Price * s.Quantity
+}
+
+func (s OrderSummary) String() string {
+	direction := T("买入做多")
+	if !s.Long {
+		direction = T("卖出做空")
+	}
+	var lines []string
+	if s.Warning != "" {
+		lines = append(lines, "⚠ "+s.Warning, "")
+	}
+	lines = append(lines,
+		fmt.Sprintf(T("交易对: %s"), s.Symbol),
+		fmt.Sprintf(T("方向: %s"), direction),
+		fmt.Sprintf(T("价格: %s  数量: %s"), formatChartPrice(s.Price), formatFloat(s.Quantity)),
+		fmt.Sprintf(T("名义价值: %.2f"), s.Notional()),
+	)
+	if s.Leverage > 0 {
+		lines = append(lines, fmt.Sprintf(T("保证金: %.2f (%.0fx)"), s.Notional()/s.Leverage, s.Leverage))
+	}
+	if s.StopLoss > 0 {
+		loss := math.Abs(s.Price-s.StopLoss) * s.Quantity
+		lines = append(lines, fmt.Sprintf(T("止损: %s  预计亏损: %.2f"), formatChartPrice(s.StopLoss), loss))
+	} else {
+		lines = append(lines, T("止损: 未设置"))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// 交易对的杠杆倍数，获取失败时返回0。会请求交易所，不要在界面线程调用
+func (ui *TraderUI) symbolLeverage(symbol string) float64 {
+	positions, err := ui.client.NewGetPositionRiskService().Symbol(symbol).Do(context.Background())
+	if err != nil || len(positions) == 0 {
+		return 0
+	}
+	leverage, _ := strconv.ParseFloat(positions[0].Leverage, 64)
+	return leverage
+}
+
+// 在主窗口显示订单摘要，确认后调用onConfirm。勾选不再确认时把阈值保存到配置文件
+func (ui *TraderUI) confirmOrder(summary OrderSummary, onConfirm func()) {
+	ui.confirmOrderIn(ui.window, summary, onConfirm)
+}
+
+// 在指定窗口显示订单确认对话框
+func (ui *TraderUI) confirmOrderIn(parent fyne.Window, summary OrderSummary, onConfirm func()) {
+	skipCheck := widget.NewCheck(T("名义价值低于以下金额时不再确认"), nil)
+	thresholdEntry := widget.NewEntry()
+	thresholdEntry.SetText(strconv.FormatFloat(math.Ceil(summary.Notional()), 'f', -1, 64))
+
+	content := container.NewVBox(
+		widget.NewLabelWithStyle(summary.String(), fyne.TextAlignLeading, fyne.TextStyle{Monospace: true}),
+		skipCheck,
+		thresholdEntry,
+	)
+	dialog.ShowCustomConfirm(T("确认下单"), T("下单"), T("取消"), content, func(ok bool) {
+		if !ok {
+			return
+		}
+		if skipCheck.Checked {
+			if threshold, err := strconv.ParseFloat(thresholdEntry.Text, 64); err == nil && threshold > 0 {
+				ui.config.OrderConfirm.MinNotional = threshold
+				if err := ui.saveConfig(); err != nil {
+					ui.notify(NoticeError, "%v", err)
+				}
+			}
+		}
+		onConfirm()
+	}, parent)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// 订单历史接口每次最多查询7天、返回1000个订单，交易所只保留最近90天的订单
+const (
+	orderHistoryWindow    = 7 * 24 * time.Hour
+	orderHistoryRetention = 90 * 24 * time.Hour
+	maxOrdersPerRequest   = 1000
+)
+
+// 一页订单历史，按时间从新到旧排序，同一毫秒的按订单号从大到小
+type OrderPage struct {
+	Orders []*futures.Order
+	Next   time.Time // 下一页的before参数，没有更多订单时为零值
+	NextID int64     // 下一页的beforeID参数，即这一页最后一个订单的订单号
+}
+
+// 获取before之前最近的limit个订单。beforeID不为0时包括before这一毫秒中订单号
+// 小于beforeID的订单，避免同一毫秒的多个订单被分在两页时漏掉。按7天的窗口向前查找，
+// 窗口内订单超过一次请求的上限时继续向后翻页，保证不漏掉最近的订单
+func fetchOrderHistory(client *futures.Client, symbol string, before time.Time, beforeID int64, limit int) (*OrderPage, error) {
+	if before.IsZero() {
+		before = time.Now()
+	}
+	oldest := time.Now().Add(-orderHistoryRetention)
+
+	// 每页的边界包括上一页最后一个订单的那一毫秒，按订单号去重
+	end := before.UnixMilli()
+	if beforeID == 0 {
+		end--
+	}
+	seen := make(map[int64]bool)
+	var orders []*futures.Order
+	for len(orders) < limit && time.UnixMilli(end).After(oldest) {
+		start := time.UnixMilli(end).Add(-orderHistoryWindow).UnixMilli()
+		for from := start; ; {
+			page, err := client.NewListOrdersService().
+				Symbol(symbol).
+				StartTime(from).
+				EndTime(end).
+				Limit(maxOrdersPerRequest).
+				Do(context.Background())
+			if err != nil {
+				return nil, fmt.Errorf(T("获取订单历史失败: %v"), err)
+			}
+			for _, o := range page {
+				if seen[o.OrderID] || (beforeID != 0 && o.Time == before.UnixMilli() && o.OrderID >= beforeID) {
+					continue
+				}
+				seen[o.OrderID] = true
+				orders = append(orders, o)
+			}
+			if len(page) < maxOrdersPerRequest {
+				break
+			}
+			// 从这一页最后一毫秒继续，同一毫秒的订单已经去重；
+			// 一毫秒内的订单超过一页时只能跳到下一毫秒
+			last := page[len(page)-1].Time
+			if last == from {
+				last++
+			}
+			from = last
+		}
+		end = start - 1
+	}
+
+	sort.Slice(orders, func(i, j int) bool {
+		if orders[i].Time != orders[j].Time {
+			return orders[i].Time > orders[j].Time
+		}
+		return orders[i].OrderID > orders[j].OrderID
+	})
+	result := &OrderPage{Orders: orders}
+	if len(orders) > limit {
+		result.Orders = orders[:limit]
+	}
+	if len(result.Orders) > 0 && (len(orders) > limit || time.UnixMilli(end).After(oldest)) {
+		last := result.Orders[len(result.Orders)-1]
+		result.Next = time.UnixMilli(last.Time)
+		result.NextID = last.OrderID
+	}
+	return result, nil
+}
+
+// 订单历史中显示的一行：时间、标签、方向、类型、状态、成交数量和成交均价
+func formatHistoryOrder(o *futures.Order) string {
+	price := o.Price
+	if o.Type == futures.OrderTypeStopMarket || o.Type == futures.OrderTypeTakeProfitMarket {
+		price = o.StopPrice
+	}
+	fill := "-"
+	if executed, _ := strconv.ParseFloat(o.ExecutedQuantity, 64); executed > 0 {
+		fill = fmt.Sprintf("%s@%s", o.ExecutedQuantity, o.AvgPrice)
+	}
+	return fmt.Sprintf("%s %-8s %-4s %-18s %-16s %s@%s %s %s",
+		time.UnixMilli(o.Time).Format("01-02 15:04:05"), orderTagLabel(o.ClientOrderID),
+		o.Side, o.Type, o.Status, o.OrigQuantity, price, T("成交"), fill)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// 程序下的订单在clientOrderId中带上前缀和标签，订单历史中据此区分
+// 手动下单、自动止盈止损和策略订单。没有前缀的是在其他地方下的订单
+const clientOrderPrefix = "bd"
+
+const (
+	tagManual     = "manual"   // 界面手动下单
+	tagTakeProfit = "tp"       // 止盈单
+	tagStopLoss   = "sl"       // 止损单
+	tagProtect    = "protect"  // 保护止盈平仓
+	tagStrategy   = "strategy" // 策略流水线
+	tagClose      = "close"    // 手动平仓
+	tagKill       = "kill"     // 紧急停止时平仓
+)
+
+var orderTagLabels = map[string]string{
+	tagManual:     "手动",
+	tagTakeProfit: "止盈",
+	tagStopLoss:   "止损",
+	tagProtect:    "保护止盈",
+	tagStrategy:   "策略",
+	tagClose:      "平仓",
+	tagKill:       "紧急平仓",
+}
+
+// 生成带标签的clientOrderId，交易所限制最长36个字符
+func newClientOrderID(tag string) string {
+	metrics.Inc(metricOrders + "." + tag)
+	return fmt.Sprintf("%s_%s_%d", clientOrderPrefix, tag, time.Now().UnixNano())
+}
+
+// 从clientOrderId解析标签，不是程序下的订单返回空字符串
+func orderTag(clientOrderID string) string {
+	parts := strings.Split(clientOrderID, "_")
+	if len(parts) != 3 || parts[0] != clientOrderPrefix {
+		return ""
+	}
+	return parts[1]
+}
+
+// 是否是持仓的止盈止损单：程序设置的止盈止损和保护单，或者只减仓、全部平仓的条件单
+func isProtectiveOrder(o *futures.Order) bool {
+	switch orderTag(o.ClientOrderID) {
+	case tagTakeProfit, tagStopLoss, tagProtect:
+		return true
+	}
+	return o.ReduceOnly || o.ClosePosition
+}
+
+// 订单标签的显示名称
+func orderTagLabel(clientOrderID string) string {
+	tag := orderTag(clientOrderID)
+	if tag == "" {
+		return T("外部")
+	}
+	if label, ok := orderTagLabels[tag]; ok {
+		return T(label)
+	}
+	return tag
+}
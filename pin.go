@@ -0,0 +1,77 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+)
+
+const (
+	pinMinLength  = 4
+	pinIterations = 600000 // PBKDF2的迭代次数，PIN位数少，用慢哈希防止从配置文件暴力破解
+)
+
+// 全部平仓、暂停自动化、关闭自动止损和修改杠杆之前要求输入的PIN，只保存加盐的PBKDF2哈希。
+// 界面和命令行读取配置文件中的同一个pin
+type PINConfig struct {
+	Hash       string `json:"hash,omitempty"`
+	Salt       string `json:"salt,omitempty"`
+	Iterations int    `json:"iterations,omitempty"` // 为0时是旧版本的SHA-256哈希
+}
+
+func (c PINConfig) Enabled() bool {
+	return c.Hash != ""
+}
+
+func (c PINConfig) hash(pin string) string {
+	if c.Iterations == 0 {
+		sum := sha256.Sum256([]byte(c.Salt + pin))
+		return hex.EncodeToString(sum[:])
+	}
+	return hex.EncodeToString(pbkdf2SHA256([]byte(pin), []byte(c.Salt), c.Iterations, sha256.Size))
+}
+
+func (c PINConfig) Check(pin string) bool {
+	return subtle.ConstantTimeCompare([]byte(c.hash(pin)), []byte(c.Hash)) == 1
+}
+
+// 用新的随机盐生成PIN配置
+func newPINConfig(pin string) (PINConfig, error) {
+	if len(pin) < pinMinLength {
+		return PINConfig{}, fmt.Errorf(T("PIN至少需要%d位"), pinMinLength)
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return PINConfig{}, fmt.Errorf(T("生成PIN失败: %v"), err)
+	}
+	c := PINConfig{Salt: hex.EncodeToString(salt), Iterations: pinIterations}
+	c.Hash = c.hash(pin)
+	return c, nil
+}
+
+// PBKDF2-HMAC-SHA256 (RFC 8018)
+func pbkdf2SHA256(password, salt []byte, iterations, keyLen int) []byte {
+	mac := hmac.New(sha256.New, password)
+	var key []byte
+	for block := uint32(1); len(key) < keyLen; block++ {
+		mac.Reset()
+		mac.Write(salt)
+		mac.Write(binary.BigEndian.AppendUint32(nil, block))
+		u := mac.Sum(nil)
+		t := append([]byte(nil), u...)
+		for i := 1; i < iterations; i++ {
+			mac.Reset()
+			mac.Write(u)
+			u = mac.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLen]
+}
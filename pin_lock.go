@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 连续输错PIN的次数达到上限后锁定一段时间
+const (
+	pinMaxFailures = 5
+	pinLockout     = time.Minute
+)
+
+// 设置了PIN时先要求输入PIN，正确后执行onOK；没有设置PIN时直接执行。
+// 取消或输错时调用onCancel，可以为nil。需要在UI线程中调用
+func (ui *TraderUI) requirePIN(action string, onOK, onCancel func()) {
+	pin := ui.config.PIN
+	if !pin.Enabled() {
+		onOK()
+		return
+	}
+	cancel := func() {
+		if onCancel != nil {
+			onCancel()
+		}
+	}
+	if wait := time.Until(ui.pinLockedUntil); wait > 0 {
+		dialog.ShowError(fmt.Errorf(T("PIN输错次数过多，请%d秒后再试"), int(wait.Seconds())+1), ui.window)
+		cancel()
+		return
+	}
+
+	entry := widget.NewPasswordEntry()
+	title := fmt.Sprintf(T("%s需要PIN"), action)
+	dialog.ShowForm(title, T("确定"), T("取消"), []*widget.FormItem{
+		widget.NewFormItem("PIN", entry),
+	}, func(ok bool) {
+		if !ok {
+			cancel()
+			return
+		}
+		if !pin.Check(entry.Text) {
+			ui.pinFailures++
+			if ui.pinFailures >= pinMaxFailures {
+				ui.pinFailures = 0
+				ui.pinLockedUntil = time.Now().Add(pinLockout)
+			}
+			ui.notify(NoticeWarning, T("%s: PIN错误"), action)
+			dialog.ShowError(errors.New(T("PIN错误")), ui.window)
+			cancel()
+			return
+		}
+		ui.pinFailures = 0
+		// 旧版本保存的SHA-256哈希在输对后换成PBKDF2
+		if pin.Iterations == 0 {
+			if upgraded, err := newPINConfig(entry.Text); err == nil {
+				ui.config.PIN = upgraded
+				if err := ui.saveConfig(); err != nil {
+					ui.notify(NoticeWarning, "%v", err)
+				}
+			}
+		}
+		onOK()
+	}, ui.window)
+}
+
+// 设置、修改或清除PIN。已经设置PIN时需要先输入当前PIN，新PIN留空表示清除
+func (ui *TraderUI) showPINSettings() {
+	current := widget.NewPasswordEntry()
+	newPIN := widget.NewPasswordEntry()
+	confirm := widget.NewPasswordEntry()
+	newPIN.SetPlaceHolder(T("留空表示不使用PIN"))
+
+	var items []*widget.FormItem
+	if ui.config.PIN.Enabled() {
+		items = append(items, widget.NewFormItem(T("当前PIN"), current))
+	}
+	items = append(items,
+		widget.NewFormItem(T("新PIN"), newPIN),
+		widget.NewFormItem(T("确认新PIN"), confirm),
+	)
+
+	dialog.ShowForm(T("PIN锁"), T("保存"), T("取消"), items, func(ok bool) {
+		if !ok {
+			return
+		}
+		if ui.config.PIN.Enabled() && !ui.config.PIN.Check(current.Text) {
+			dialog.ShowError(errors.New(T("PIN错误")), ui.window)
+			return
+		}
+		if newPIN.Text != confirm.Text {
+			dialog.ShowError(errors.New(T("两次输入的PIN不一致")), ui.window)
+			return
+		}
+
+		var pin PINConfig
+		if newPIN.Text != "" {
+			var err error
+			if pin, err = newPINConfig(newPIN.Text); err != nil {
+				dialog.ShowError(err, ui.window)
+				return
+			}
+		}
+		ui.config.PIN = pin
+		if err := ui.saveConfig(); err != nil {
+			dialog.ShowError(err, ui.window)
+			return
+		}
+		if pin.Enabled() {
+			ui.notify(NoticeInfo, "%s", T("已设置PIN，全部平仓、暂停自动化和关闭自动止损前需要输入"))
+		} else {
+			ui.notify(NoticeInfo, "%s", T("已清除PIN"))
+		}
+	}, ui.window)
+}
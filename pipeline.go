@@ -0,0 +1,422 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// 策略发出的交易信号
+type Signal struct {
+	Strategy   string
+	Symbol     string
+	Side       futures.SideType
+	Price      float64 // 参考入场价，一般为信号K线的收盘价
+	StopLoss   float64
+	TakeProfit float64 // 0表示不设置止盈
+	Quantity   float64 // 由风控层计算
+	Reason     string
+	Time       time.Time
+}
+
+func (s *Signal) String() string {
+	text := fmt.Sprintf(T("[%s] %s %s @ %.4f 止损 %.4f"), s.Strategy, s.Symbol, s.Side, s.Price, s.StopLoss)
+	if s.TakeProfit > 0 {
+		text += fmt.Sprintf(T(" 止盈 %.4f"), s.TakeProfit)
+	}
+	if s.Quantity > 0 {
+		text += fmt.Sprintf(T(" 数量 %.4f"), s.Quantity)
+	}
+	if s.Reason != "" {
+		text += " (" + s.Reason + ")"
+	}
+	return text
+}
+
+// 策略接口。每根K线收盘后用截至该K线的数据调用Next，没有信号时返回nil
+type Strategy interface {
+	Name() string
+	Symbol() string
+	Interval() string
+	Next(klines []Kline) *Signal
+}
+
+// 风控规则，可以调整信号（如计算数量），返回错误表示拒绝该信号
+type RiskRule interface {
+	Name() string
+	Check(signal *Signal) error
+}
+
+// 执行层，把信号转换为订单
+type Executor interface {
+	Name() string
+	Execute(signal *Signal) error
+}
+
+// 单个策略的配置，同一策略可以按交易对配置多份
+type StrategyConfig struct {
+	Name     string             `json:"name"`
+	Symbol   string             `json:"symbol"`
+	Interval string             `json:"interval"`
+	Params   map[string]float64 `json:"params"`
+}
+
+// 策略流水线配置
+type PipelineConfig struct {
+	Strategies    []StrategyConfig `json:"strategies"`
+	RiskPerTrade  float64          `json:"risk_per_trade"` // 每笔交易止损时亏损的金额(USDC)，用于计算数量
+	MaxVolatility float64          `json:"max_volatility"` // 1小时年化波动率超过该值(%)时不开仓，0表示不限制
+	MaxNotional   float64          `json:"max_notional"`   // 所有持仓加上新开仓的名义价值上限，0表示不限制
+	Live          bool             `json:"live"`           // 为false时只记录信号不下单
+}
+
+type StrategyFactory func(config StrategyConfig) (Strategy, error)
+
+var strategyFactories = make(map[string]StrategyFactory)
+
+// 注册策略，可在配置中按名称选择
+func RegisterStrategy(name string, factory StrategyFactory) {
+	strategyFactories[name] = factory
+}
+
+// 已注册的策略名称
+func StrategyNames() []string {
+	var names []string
+	for name := range strategyFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// 按配置创建策略，名称为.yaml或.yml文件时从策略文件加载，见strategy_file.go
+func NewStrategy(config StrategyConfig) (Strategy, error) {
+	if isStrategyFile(config.Name) {
+		return newFileStrategy(config)
+	}
+	factory, ok := strategyFactories[config.Name]
+	if !ok {
+		return nil, fmt.Errorf(T("未知的策略: %s"), config.Name)
+	}
+	return factory(config)
+}
+
+// 流水线每次运行策略时获取的K线数量
+const pipelineKlines = 200
+
+// 策略流水线：策略发出信号，风控层依次过滤和计算数量，最后交给执行层下单。
+// 每一步都会记录日志
+type Pipeline struct {
+	client     *futures.Client
+	strategies []Strategy
+	risk       []RiskRule
+	executor   Executor
+
+	mu         sync.Mutex
+	lastCandle map[int]time.Time // 每个策略上次处理的K线时间
+	paused     bool
+
+	// 日志输出，默认使用log.Printf
+	Logf func(format string, args ...interface{})
+}
+
+func NewPipeline(client *futures.Client, strategies []Strategy, risk []RiskRule, executor Executor) *Pipeline {
+	return &Pipeline{
+		client:     client,
+		strategies: strategies,
+		risk:       risk,
+		executor:   executor,
+		lastCandle: make(map[int]time.Time),
+		Logf:       log.Printf,
+	}
+}
+
+// 按配置创建流水线，volatility用于获取最新的波动率指标，可以为nil
+func NewPipelineFromConfig(client *futures.Client, config PipelineConfig, volatility func(symbol string) *VolatilityMetrics) (*Pipeline, error) {
+	var strategies []Strategy
+	for _, c := range config.Strategies {
+		s, err := NewStrategy(c)
+		if err != nil {
+			return nil, err
+		}
+		strategies = append(strategies, s)
+	}
+
+	var risk []RiskRule
+	if config.MaxVolatility > 0 && volatility != nil {
+		risk = append(risk, &VolatilityFilter{Metrics: volatility, MaxVol1h: config.MaxVolatility})
+	}
+	filters := NewSymbolFilterCache(client)
+	risk = append(risk, &PositionSizer{RiskPerTrade: config.RiskPerTrade, Filters: filters.Get})
+	if config.MaxNotional > 0 {
+		risk = append(risk, &ExposureLimit{client: client, MaxNotional: config.MaxNotional})
+	}
+
+	var executor Executor = LogExecutor{}
+	if config.Live {
+		executor = &OrderExecutor{client: client, filters: filters}
+	}
+	return NewPipeline(client, strategies, risk, executor), nil
+}
+
+// 每隔一段时间检查一次是否有新收盘的K线
+func (p *Pipeline) Start() {
+	go func() {
+		for {
+			p.Run()
+			time.Sleep(10 * time.Second)
+		}
+	}()
+}
+
+// 暂停后不再运行策略，恢复后只处理新收盘的K线
+func (p *Pipeline) SetPaused(paused bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.paused = paused
+	if !paused {
+		p.lastCandle = make(map[int]time.Time)
+	}
+}
+
+// 对每个策略获取已收盘的K线，有新K线时运行策略并处理信号
+func (p *Pipeline) Run() {
+	p.mu.Lock()
+	paused := p.paused
+	p.mu.Unlock()
+	if paused {
+		return
+	}
+
+	for i, s := range p.strategies {
+		klines, err := fetchKlines(p.client, s.Symbol(), s.Interval(), pipelineKlines)
+		if err != nil {
+			p.Logf("[%s] %v", s.Name(), err)
+			continue
+		}
+		klines = closedKlines(klines, s.Interval())
+		if len(klines) == 0 {
+			continue
+		}
+
+		last := klines[len(klines)-1].Time
+		p.mu.Lock()
+		seen := p.lastCandle[i]
+		p.lastCandle[i] = last
+		p.mu.Unlock()
+		// 启动时只记录最新K线，不对历史K线发出信号
+		if seen.IsZero() || !last.After(seen) {
+			continue
+		}
+
+		if signal := s.Next(klines); signal != nil {
+			p.Process(signal)
+		}
+	}
+}
+
+// 信号依次经过风控和执行层
+func (p *Pipeline) Process(signal *Signal) error {
+	p.Logf(T("信号: %s"), signal)
+
+	for _, r := range p.risk {
+		if err := r.Check(signal); err != nil {
+			p.Logf(T("风控[%s]拒绝: %v"), r.Name(), err)
+			return err
+		}
+	}
+
+	if err := p.executor.Execute(signal); err != nil {
+		p.Logf(T("执行[%s]失败: %v"), p.executor.Name(), err)
+		return err
+	}
+	p.Logf(T("执行[%s]: %s"), p.executor.Name(), signal)
+	return nil
+}
+
+// 去掉最后一根未收盘的K线
+func closedKlines(klines []Kline, interval string) []Kline {
+	step := intervalDuration(interval)
+	if len(klines) > 0 && step > 0 && time.Now().Before(klines[len(klines)-1].Time.Add(step)) {
+		return klines[:len(klines)-1]
+	}
+	return klines
+}
+
+// 按止损距离计算数量，使每笔交易止损时亏损固定金额
+type PositionSizer struct {
+	RiskPerTrade float64
+	// 获取交易对的数量精度，为nil时(回测)保留两位小数
+	Filters func(symbol string) (SymbolFilters, error)
+}
+
+func (r *PositionSizer) Name() string { return "sizer" }
+
+func (r *PositionSizer) Check(signal *Signal) error {
+	if signal.Quantity > 0 {
+		return nil
+	}
+	if r.RiskPerTrade <= 0 {
+		return errors.New(T("未设置每笔风险金额"))
+	}
+	distance := math.Abs(signal.Price - signal.StopLoss)
+	if distance == 0 {
+		return errors.New(T("止损价与入场价相同"))
+	}
+	if r.Filters == nil {
+		signal.Quantity = math.Floor(r.RiskPerTrade/distance*100) / 100
+	} else {
+		filters, err := r.Filters(signal.Symbol)
+		if err != nil {
+			return err
+		}
+		signal.Quantity = filters.FloorQuantity(r.RiskPerTrade / distance)
+		if signal.Quantity < filters.MinQty {
+			return fmt.Errorf(T("计算出的数量 %s 小于最小下单量 %s"),
+				filters.FormatQuantity(signal.Quantity), filters.FormatQuantity(filters.MinQty))
+		}
+	}
+	if signal.Quantity <= 0 {
+		return errors.New(T("计算出的数量为0"))
+	}
+	return nil
+}
+
+// 波动率过高时不开仓
+type VolatilityFilter struct {
+	Metrics  func(symbol string) *VolatilityMetrics
+	MaxVol1h float64
+}
+
+func (r *VolatilityFilter) Name() string { return "volatility" }
+
+func (r *VolatilityFilter) Check(signal *Signal) error {
+	v := r.Metrics(signal.Symbol)
+	if v == nil {
+		return nil
+	}
+	if v.Vol1h > r.MaxVol1h {
+		return fmt.Errorf(T("1小时波动率 %.1f%% 超过上限 %.1f%%"), v.Vol1h, r.MaxVol1h)
+	}
+	return nil
+}
+
+// 限制所有持仓加上新开仓的名义价值
+type ExposureLimit struct {
+	client      *futures.Client
+	MaxNotional float64
+}
+
+func (r *ExposureLimit) Name() string { return "exposure" }
+
+func (r *ExposureLimit) Check(signal *Signal) error {
+	positions, err := r.client.NewGetPositionRiskService().Do(context.Background())
+	if err != nil {
+		return fmt.Errorf(T("获取持仓信息失败: %v"), err)
+	}
+
+	var total float64
+	for _, p := range positions {
+		amt, _ := strconv.ParseFloat(p.PositionAmt, 64)
+		markPrice, _ := strconv.ParseFloat(p.MarkPrice, 64)
+		total += math.Abs(amt * markPrice)
+	}
+
+	notional := signal.Quantity * signal.Price
+	if total+notional > r.MaxNotional {
+		return fmt.Errorf(T("持仓名义价值 %.0f + %.0f 超过上限 %.0f"), total, notional, r.MaxNotional)
+	}
+	return nil
+}
+
+// 只记录信号，不下单
+type LogExecutor struct{}
+
+func (LogExecutor) Name() string { return "dry-run" }
+
+func (LogExecutor) Execute(signal *Signal) error { return nil }
+
+// 市价开仓并挂止损单和止盈单
+type OrderExecutor struct {
+	client  *futures.Client
+	filters *SymbolFilterCache
+}
+
+func (e *OrderExecutor) Name() string { return "live" }
+
+func (e *OrderExecutor) Execute(signal *Signal) error {
+	filters, err := e.filters.Get(signal.Symbol)
+	if err != nil {
+		return err
+	}
+	quantity := filters.FormatQuantity(signal.Quantity)
+	closeSide := futures.SideTypeSell
+	if signal.Side == futures.SideTypeSell {
+		closeSide = futures.SideTypeBuy
+	}
+
+	_, err = e.client.NewCreateOrderService().
+		Symbol(signal.Symbol).
+		NewClientOrderID(newClientOrderID(tagStrategy)).
+		Side(signal.Side).
+		PositionSide("BOTH").
+		Type(futures.OrderTypeMarket).
+		Quantity(quantity).
+		Do(context.Background())
+	if err != nil {
+		return fmt.Errorf(T("开仓失败: %v"), err)
+	}
+
+	_, err = e.client.NewCreateOrderService().
+		Symbol(signal.Symbol).
+		NewClientOrderID(newClientOrderID(tagStrategy)).
+		Side(closeSide).
+		PositionSide("BOTH").
+		Type(futures.OrderTypeStopMarket).
+		StopPrice(filters.FormatPrice(signal.StopLoss)).
+		Quantity(quantity).
+		ReduceOnly(true).
+		Do(context.Background())
+	if err != nil {
+		// 没有止损的持仓不能留着，市价平掉刚开的仓
+		_, closeErr := e.client.NewCreateOrderService().
+			Symbol(signal.Symbol).
+			NewClientOrderID(newClientOrderID(tagClose)).
+			Side(closeSide).
+			PositionSide("BOTH").
+			Type(futures.OrderTypeMarket).
+			Quantity(quantity).
+			ReduceOnly(true).
+			Do(context.Background())
+		if closeErr != nil {
+			return fmt.Errorf(T("开仓成功，但止损单创建失败: %v，市价平仓也失败，请立即手动处理: %v"), err, closeErr)
+		}
+		return fmt.Errorf(T("开仓成功，但止损单创建失败，已市价平仓: %v"), err)
+	}
+
+	if signal.TakeProfit > 0 {
+		_, err = e.client.NewCreateOrderService().
+			Symbol(signal.Symbol).
+			NewClientOrderID(newClientOrderID(tagStrategy)).
+			Side(closeSide).
+			PositionSide("BOTH").
+			Type(futures.OrderTypeLimit).
+			TimeInForce(futures.TimeInForceTypeGTC).
+			Price(filters.FormatPrice(signal.TakeProfit)).
+			Quantity(quantity).
+			ReduceOnly(true).
+			Do(context.Background())
+		if err != nil {
+			return fmt.Errorf(T("开仓成功，但止盈单创建失败: %v"), err)
+		}
+	}
+	return nil
+}
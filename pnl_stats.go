@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 盈亏统计的周期
+const (
+	statsDay   = "day"
+	statsWeek  = "week"
+	statsMonth = "month"
+)
+
+var statsPeriods = []struct {
+	Period string
+	Label  string
+}{
+	{statsDay, "按日"},
+	{statsWeek, "按周"},
+	{statsMonth, "按月"},
+}
+
+// 一个周期内的盈亏汇总
+type PnLStats struct {
+	Start    time.Time
+	Realized float64 // 已实现盈亏
+	Fees     float64 // 手续费，为负数
+	Funding  float64 // 资金费，收取为正、支付为负
+	Trades   int     // 成交笔数
+}
+
+// 扣除手续费和资金费后的净盈亏
+func (s PnLStats) Net() float64 {
+	return s.Realized + s.Fees + s.Funding
+}
+
+// 周期的显示名称
+func (s PnLStats) Label(period string) string {
+	switch period {
+	case statsWeek:
+		return s.Start.Format("01-02") + "~"
+	case statsMonth:
+		return s.Start.Format("2006-01")
+	default:
+		return s.Start.Format("01-02")
+	}
+}
+
+// t所在周期的开始时间，按本地时间计算，周从周一开始
+func periodStart(t time.Time, period string) time.Time {
+	t = t.Local()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+	switch period {
+	case statsWeek:
+		weekday := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -weekday)
+	case statsMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.Local)
+	default:
+		return day
+	}
+}
+
+// 按周期汇总资金流水，按时间排序，没有流水的周期不输出
+func summarizePnL(incomes []IncomeRecord, period string) []PnLStats {
+	var stats []PnLStats
+	trades := make(map[string]bool)
+	for _, r := range incomes {
+		start := periodStart(r.Time, period)
+		if len(stats) == 0 || !stats[len(stats)-1].Start.Equal(start) {
+			stats = append(stats, PnLStats{Start: start})
+			trades = make(map[string]bool)
+		}
+		s := &stats[len(stats)-1]
+		switch r.Type {
+		case IncomeRealizedPnL:
+			s.Realized += r.Income
+		case IncomeCommission:
+			s.Fees += r.Income
+			// 每笔成交都有一条手续费流水
+			if !trades[r.TradeID] {
+				trades[r.TradeID] = true
+				s.Trades++
+			}
+		case IncomeFunding:
+			s.Funding += r.Income
+		}
+	}
+	return stats
+}
+
+// 盈亏统计表格，最近的周期在前
+func formatPnLStats(stats []PnLStats, period string) string {
+	var s strings.Builder
+	var total PnLStats
+
+	s.WriteString(fmt.Sprintf("%-10s %12s %10s %10s %12s %6s\n",
+		T("时间段"), T("已实现盈亏"), T("手续费"), T("资金费"), T("净盈亏"), T("成交")))
+	for i := len(stats) - 1; i >= 0; i-- {
+		st := stats[i]
+		s.WriteString(fmt.Sprintf("%-10s %12.2f %10.2f %10.2f %12.2f %6d\n",
+			st.Label(period), st.Realized, st.Fees, st.Funding, st.Net(), st.Trades))
+		total.Realized += st.Realized
+		total.Fees += st.Fees
+		total.Funding += st.Funding
+		total.Trades += st.Trades
+	}
+	s.WriteString(fmt.Sprintf("%-10s %12.2f %10.2f %10.2f %12.2f %6d",
+		T("合计"), total.Realized, total.Fees, total.Funding, total.Net(), total.Trades))
+
+	return s.String()
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// 获取交易对的持仓，没有持仓时返回nil
+func (ui *TraderUI) fetchPosition(symbol string) (*futures.PositionRisk, error) {
+	positions, err := ui.client.NewGetPositionRiskService().Symbol(symbol).Do(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf(T("获取持仓信息失败: %v"), err)
+	}
+	for _, p := range positions {
+		if amt, _ := strconv.ParseFloat(p.PositionAmt, 64); amt != 0 {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+// 确认后市价平掉交易对的持仓
+func (ui *TraderUI) confirmClosePosition(symbol string) {
+	position, err := ui.fetchPosition(symbol)
+	if err != nil {
+		dialog.ShowError(err, ui.window)
+		return
+	}
+	if position == nil {
+		dialog.ShowInformation(T("平仓"), T("无持仓"), ui.window)
+		return
+	}
+
+	amt, _ := strconv.ParseFloat(position.PositionAmt, 64)
+	unPnl, _ := strconv.ParseFloat(position.UnRealizedProfit, 64)
+	message := fmt.Sprintf(T("市价平掉 %s %.4f (未实现盈亏 %+.2f) 并取消止盈止损单？"), symbol, amt, unPnl)
+	dialog.ShowConfirm(T("平仓"), message, func(ok bool) {
+		if !ok {
+			return
+		}
+		go func() {
+			if err := ui.closePosition(position); err != nil {
+				ui.notify(NoticeError, "%v", err)
+			}
+			ui.refreshAll()
+		}()
+	}, ui.window)
+}
+
+// 只减仓市价平仓，成交后取消该交易对的止盈止损单，其他挂单保留
+func (ui *TraderUI) closePosition(position *futures.PositionRisk) error {
+	amt, _ := strconv.ParseFloat(position.PositionAmt, 64)
+	if amt == 0 {
+		return errors.New(T("无持仓"))
+	}
+	side := futures.SideTypeSell
+	if amt < 0 {
+		side = futures.SideTypeBuy
+	}
+
+	_, err := ui.client.NewCreateOrderService().
+		Symbol(position.Symbol).
+		NewClientOrderID(newClientOrderID(tagClose)).
+		Side(side).
+		PositionSide("BOTH").
+		Type(futures.OrderTypeMarket).
+		Quantity(fmt.Sprintf("%.4f", math.Abs(amt))).
+		ReduceOnly(true).
+		Do(context.Background())
+	if err != nil {
+		return fmt.Errorf(T("%s 平仓失败: %v"), position.Symbol, err)
+	}
+	ui.notify(NoticeInfo, T("已市价平仓: %s %.4f"), position.Symbol, amt)
+
+	orders, err := ui.client.NewListOpenOrdersService().Symbol(position.Symbol).Do(context.Background())
+	if err != nil {
+		return fmt.Errorf(T("获取订单失败: %v"), err)
+	}
+	for _, o := range orders {
+		if !isProtectiveOrder(o) {
+			continue
+		}
+		_, err := ui.client.NewCancelOrderService().Symbol(position.Symbol).OrderID(o.OrderID).Do(context.Background())
+		if err != nil {
+			return fmt.Errorf(T("%s 取消挂单失败: %v"), position.Symbol, err)
+		}
+	}
+	return nil
+}
+
+// 检查新的止盈止损价是否在标记价格正确的一侧，0表示不修改
+func validateExits(long bool, mark, tpPrice, slPrice float64) error {
+	if long {
+		if tpPrice > 0 && tpPrice <= mark {
+			return errors.New(T("多仓的止盈价必须高于标记价格"))
+		}
+		if slPrice > 0 && slPrice >= mark {
+			return errors.New(T("多仓的止损价必须低于标记价格"))
+		}
+		return nil
+	}
+	if tpPrice > 0 && tpPrice >= mark {
+		return errors.New(T("空仓的止盈价必须低于标记价格"))
+	}
+	if slPrice > 0 && slPrice <= mark {
+		return errors.New(T("空仓的止损价必须高于标记价格"))
+	}
+	return nil
+}
+
+// 修改持仓的止盈价和止损价，0表示不修改。交易所没有原子的撤单改单，
+// 这里先下新单、成功后再撤旧单，新单失败时旧单保留，修改过程中持仓不会失去保护
+func (ui *TraderUI) amendExits(position *futures.PositionRisk, tpPrice, slPrice float64) error {
+	amt, _ := strconv.ParseFloat(position.PositionAmt, 64)
+	if amt == 0 {
+		return errors.New(T("无持仓"))
+	}
+	markPrice, _ := strconv.ParseFloat(position.MarkPrice, 64)
+	long := amt > 0
+	if err := validateExits(long, markPrice, tpPrice, slPrice); err != nil {
+		return err
+	}
+
+	symbol := position.Symbol
+	filters, err := ui.filters.Get(symbol)
+	if err != nil {
+		return err
+	}
+	orders, err := ui.client.NewListOpenOrdersService().Symbol(symbol).Do(context.Background())
+	if err != nil {
+		return fmt.Errorf(T("获取订单失败: %v"), err)
+	}
+	tpOrders, slOrders := exitOrders(orders, symbol, long)
+
+	side := futures.SideTypeSell
+	if !long {
+		side = futures.SideTypeBuy
+	}
+	quantity := filters.FormatQuantity(math.Abs(amt))
+
+	replace := func(name string, old []*futures.Order, create *futures.CreateOrderService) error {
+		if _, err := create.Do(context.Background()); err != nil {
+			return fmt.Errorf(T("创建%s单失败: %v"), name, err)
+		}
+		for _, o := range old {
+			_, err := ui.client.NewCancelOrderService().Symbol(symbol).OrderID(o.OrderID).Do(context.Background())
+			if err != nil {
+				return fmt.Errorf(T("新%s单已创建，但取消旧%s单失败: %v"), name, name, err)
+			}
+		}
+		return nil
+	}
+
+	if tpPrice > 0 {
+		err := replace(T("止盈"), tpOrders, ui.client.NewCreateOrderService().
+			Symbol(symbol).
+			NewClientOrderID(newClientOrderID(tagTakeProfit)).
+			Side(side).
+			PositionSide("BOTH").
+			Type(futures.OrderTypeLimit).
+			TimeInForce(futures.TimeInForceTypeGTC).
+			Price(filters.FormatPrice(tpPrice)).
+			Quantity(quantity).
+			ReduceOnly(true))
+		if err != nil {
+			return err
+		}
+		ui.notify(NoticeInfo, T("已修改止盈: %s @ %s"), symbol, filters.FormatPrice(tpPrice))
+	}
+	if slPrice > 0 {
+		err := replace(T("止损"), slOrders, ui.client.NewCreateOrderService().
+			Symbol(symbol).
+			NewClientOrderID(newClientOrderID(tagStopLoss)).
+			Side(side).
+			PositionSide("BOTH").
+			Type(futures.OrderTypeStopMarket).
+			StopPrice(filters.FormatPrice(slPrice)).
+			Quantity(quantity).
+			ReduceOnly(true))
+		if err != nil {
+			return err
+		}
+		ui.notify(NoticeInfo, T("已修改止损: %s @ %s"), symbol, filters.FormatPrice(slPrice))
+	}
+	return nil
+}
+
+// 编辑持仓的交易笔记，平仓后显示在交易历史中
+func (ui *TraderUI) showPositionNote(symbol string) {
+	title := fmt.Sprintf(T("交易笔记 %s (持仓中)"), symbol)
+	ui.editNote(ui.window, title, ui.tradeDB.OpenNote(symbol), nil, func(text string, tags []string) error {
+		return ui.tradeDB.SaveOpenNote(symbol, text, tags)
+	}, nil)
+}
+
+// 止盈止损价到入场价的距离和该价格成交时的盈亏，正数为盈利
+func exitDistance(position *futures.PositionRisk, price float64) (points, pnl float64) {
+	amt, _ := strconv.ParseFloat(position.PositionAmt, 64)
+	entryPrice, _ := strconv.ParseFloat(position.EntryPrice, 64)
+	points = price - entryPrice
+	return points, points * amt
+}
+
+// 拖动K线图上的止盈止损线时显示的距离和盈亏
+func (ui *TraderUI) exitDragInfo(level ChartLevel, price float64) string {
+	position := ui.position
+	if position == nil {
+		return ""
+	}
+	points, pnl := exitDistance(position, price)
+	return fmt.Sprintf(T("距入场 %+.2f点  %+.2f U"), points, pnl)
+}
+
+// 拖动止盈止损线松开后确认，按新价格修改止盈单或止损单
+func (ui *TraderUI) confirmDragExit(level ChartLevel, price float64) {
+	position := ui.position
+	if position == nil || position.Symbol != ui.symbol {
+		return
+	}
+	if filters, err := ui.filters.Get(position.Symbol); err == nil && filters.TickSize > 0 {
+		price = roundToTickSize(price, filters.TickSize)
+	}
+	var tpPrice, slPrice float64
+	switch level.Label {
+	case T("止盈"):
+		tpPrice = price
+	case T("止损"):
+		slPrice = price
+	default:
+		return
+	}
+
+	oldPoints, oldPnl := exitDistance(position, level.Price)
+	points, pnl := exitDistance(position, price)
+	message := fmt.Sprintf(T("%s %s: %s → %s\n距入场: %+.2f点 → %+.2f点\n成交盈亏: %+.2f U → %+.2f U"),
+		position.Symbol, level.Label, formatChartPrice(level.Price), formatChartPrice(price),
+		oldPoints, points, oldPnl, pnl)
+	dialog.ShowConfirm(T("修改止盈止损"), message, func(ok bool) {
+		if !ok {
+			return
+		}
+		go func() {
+			if err := ui.amendExits(position, tpPrice, slPrice); err != nil {
+				fyne.Do(func() {
+					dialog.ShowError(err, ui.window)
+				})
+			}
+			ui.refreshAll()
+		}()
+	}, ui.window)
+}
+
+// 修改止盈止损的对话框，输入框预先填入当前的止盈止损价，留空表示不修改
+func (ui *TraderUI) showEditExits(symbol string) {
+	position, err := ui.fetchPosition(symbol)
+	if err != nil {
+		dialog.ShowError(err, ui.window)
+		return
+	}
+	if position == nil {
+		dialog.ShowInformation(T("修改止盈止损"), T("无持仓"), ui.window)
+		return
+	}
+	orders, err := ui.client.NewListOpenOrdersService().Symbol(symbol).Do(context.Background())
+	if err != nil {
+		dialog.ShowError(fmt.Errorf(T("获取订单失败: %v"), err), ui.window)
+		return
+	}
+	amt, _ := strconv.ParseFloat(position.PositionAmt, 64)
+	tpPrice, slPrice := exitPrices(orders, symbol, amt > 0)
+
+	tpEntry := widget.NewEntry()
+	slEntry := widget.NewEntry()
+	if tpPrice > 0 {
+		tpEntry.SetText(strconv.FormatFloat(tpPrice, 'f', -1, 64))
+	}
+	if slPrice > 0 {
+		slEntry.SetText(strconv.FormatFloat(slPrice, 'f', -1, 64))
+	}
+
+	title := fmt.Sprintf(T("修改止盈止损 %s"), symbol)
+	dialog.ShowForm(title, T("确定"), T("取消"), []*widget.FormItem{
+		widget.NewFormItem(T("止盈价"), tpEntry),
+		widget.NewFormItem(T("止损价"), slEntry),
+	}, func(ok bool) {
+		if !ok {
+			return
+		}
+		// 与当前价格相同的视为不修改
+		newTP, _ := strconv.ParseFloat(strings.TrimSpace(tpEntry.Text), 64)
+		newSL, _ := strconv.ParseFloat(strings.TrimSpace(slEntry.Text), 64)
+		if newTP == tpPrice {
+			newTP = 0
+		}
+		if newSL == slPrice {
+			newSL = 0
+		}
+		if newTP == 0 && newSL == 0 {
+			return
+		}
+		go func() {
+			if err := ui.amendExits(position, newTP, newSL); err != nil {
+				fyne.Do(func() {
+					dialog.ShowError(err, ui.window)
+				})
+			}
+			ui.refreshAll()
+		}()
+	}, ui.window)
+}
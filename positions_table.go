@@ -0,0 +1,300 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// 持仓表格中的一行
+type PositionRow struct {
+	Symbol      string
+	Long        bool
+	Size        float64
+	Entry       float64
+	Mark        float64
+	Liquidation float64
+	PnL         float64 // 未实现盈亏
+	ROE         float64 // 未实现盈亏占起始保证金的百分比
+	PeakProfit  float64 // 最高盈利，没有记录时为NaN
+	TakeProfit  float64 // 止盈价，没有止盈单时为0
+	StopLoss    float64 // 止损价，没有止损单时为0
+}
+
+func newPositionRow(p *futures.PositionRisk, tp, sl float64) PositionRow {
+	parse := func(s string) float64 {
+		v, _ := strconv.ParseFloat(s, 64)
+		return v
+	}
+	amt := parse(p.PositionAmt)
+	row := PositionRow{
+		Symbol:      p.Symbol,
+		Long:        amt > 0,
+		Size:        math.Abs(amt),
+		Entry:       parse(p.EntryPrice),
+		Mark:        parse(p.MarkPrice),
+		Liquidation: parse(p.LiquidationPrice),
+		PnL:         parse(p.UnRealizedProfit),
+		PeakProfit:  math.NaN(),
+		TakeProfit:  tp,
+		StopLoss:    sl,
+	}
+	if leverage := parse(p.Leverage); leverage > 0 && row.Size*row.Entry > 0 {
+		row.ROE = row.PnL / (row.Size * row.Entry / leverage) * 100
+	}
+	return row
+}
+
+// 价格距离标记价格的百分比，价格为0时返回NaN
+func (r PositionRow) distance(price float64) float64 {
+	if price == 0 || r.Mark == 0 {
+		return math.NaN()
+	}
+	return math.Abs(price-r.Mark) / r.Mark * 100
+}
+
+// 持仓表格的列。Value用于排序和着色，Text为显示内容
+type positionColumn struct {
+	Label  string
+	Width  float32
+	Value  func(PositionRow) float64
+	Text   func(PositionRow) string
+	Signed bool // 按正负着色
+}
+
+func formatOptional(v float64, format string) string {
+	if math.IsNaN(v) || v == 0 {
+		return "-"
+	}
+	return fmt.Sprintf(format, v)
+}
+
+var positionColumns = []positionColumn{
+	{Label: "交易对", Width: 100, Text: func(r PositionRow) string { return r.Symbol }},
+	{Label: "方向", Width: 50,
+		Value: func(r PositionRow) float64 {
+			if r.Long {
+				return 1
+			}
+			return -1
+		},
+		Text: func(r PositionRow) string {
+			if r.Long {
+				return T("多")
+			}
+			return T("空")
+		},
+		Signed: true},
+	{Label: "数量", Width: 80, Value: func(r PositionRow) float64 { return r.Size },
+		Text: func(r PositionRow) string { return formatFloat(r.Size) }},
+	{Label: "入场价", Width: 90, Value: func(r PositionRow) float64 { return r.Entry },
+		Text: func(r PositionRow) string { return formatChartPrice(r.Entry) }},
+	{Label: "标记价", Width: 90, Value: func(r PositionRow) float64 { return r.Mark },
+		Text: func(r PositionRow) string { return formatChartPrice(r.Mark) }},
+	{Label: "强平价", Width: 90, Value: func(r PositionRow) float64 { return r.Liquidation },
+		Text: func(r PositionRow) string { return formatOptional(r.Liquidation, "%.4f") }},
+	{Label: "未实现盈亏", Width: 100, Value: func(r PositionRow) float64 { return r.PnL },
+		Text: func(r PositionRow) string { return fmt.Sprintf("%+.2f", r.PnL) }, Signed: true},
+	{Label: "ROE", Width: 80, Value: func(r PositionRow) float64 { return r.ROE },
+		Text: func(r PositionRow) string { return fmt.Sprintf("%+.2f%%", r.ROE) }, Signed: true},
+	{Label: "最高盈利", Width: 90, Value: func(r PositionRow) float64 { return r.PeakProfit },
+		Text: func(r PositionRow) string { return formatOptional(r.PeakProfit, "%.2f") }, Signed: true},
+	{Label: "距止损", Width: 80, Value: func(r PositionRow) float64 { return r.distance(r.StopLoss) },
+		Text: func(r PositionRow) string { return formatOptional(r.distance(r.StopLoss), "%.2f%%") }},
+	{Label: "距止盈", Width: 80, Value: func(r PositionRow) float64 { return r.distance(r.TakeProfit) },
+		Text: func(r PositionRow) string { return formatOptional(r.distance(r.TakeProfit), "%.2f%%") }},
+}
+
+// 持仓表格，点击表头按该列排序，再次点击反向排序。最后一列是修改止盈止损、笔记和平仓按钮
+type PositionTable struct {
+	Table *widget.Table
+
+	mu       sync.Mutex
+	rows     []PositionRow
+	sortCol  int
+	sortDesc bool
+
+	// 点击某一行时回调
+	OnSymbolSelected func(symbol string)
+	// 点击修改止盈止损、笔记和平仓按钮时回调
+	OnEditExits func(symbol string)
+	OnEditNote  func(symbol string)
+	OnClose     func(symbol string)
+}
+
+// 操作按钮所在的列，排在数据列之后
+var positionActionCol = len(positionColumns)
+
+func NewPositionTable() *PositionTable {
+	t := &PositionTable{}
+	t.Table = widget.NewTableWithHeaders(
+		func() (int, int) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			return len(t.rows), len(positionColumns) + 1
+		},
+		func() fyne.CanvasObject {
+			// 数据列显示标签，操作列显示按钮
+			return container.NewStack(
+				widget.NewLabelWithStyle("", fyne.TextAlignTrailing, fyne.TextStyle{Monospace: true}),
+				container.NewHBox(
+					widget.NewButton(T("止盈止损"), nil),
+					widget.NewButton(T("笔记"), nil),
+					widget.NewButton(T("平仓"), nil),
+				),
+			)
+		},
+		t.updateCell,
+	)
+	t.Table.ShowHeaderColumn = false
+	t.Table.StickyColumnCount = 1
+	t.Table.CreateHeader = func() fyne.CanvasObject {
+		return widget.NewButton("", nil)
+	}
+	t.Table.UpdateHeader = t.updateHeader
+	t.Table.OnSelected = func(id widget.TableCellID) {
+		t.Table.UnselectAll()
+		t.mu.Lock()
+		var symbol string
+		if id.Row >= 0 && id.Row < len(t.rows) {
+			symbol = t.rows[id.Row].Symbol
+		}
+		t.mu.Unlock()
+		if id.Col == positionActionCol {
+			return
+		}
+		if symbol != "" && t.OnSymbolSelected != nil {
+			t.OnSymbolSelected(symbol)
+		}
+	}
+	for i, c := range positionColumns {
+		t.Table.SetColumnWidth(i, c.Width)
+	}
+	t.Table.SetColumnWidth(positionActionCol, 230)
+	return t
+}
+
+// 更新持仓数据，需要在UI线程中调用
+func (t *PositionTable) SetRows(rows []PositionRow) {
+	t.mu.Lock()
+	t.rows = rows
+	t.sortLocked()
+	t.mu.Unlock()
+	t.Table.Refresh()
+}
+
+func (t *PositionTable) sortLocked() {
+	col := positionColumns[t.sortCol]
+	less := func(a, b PositionRow) bool {
+		if col.Value == nil {
+			return col.Text(a) < col.Text(b)
+		}
+		// 没有数值的排在最后
+		va, vb := col.Value(a), col.Value(b)
+		if math.IsNaN(vb) {
+			return !math.IsNaN(va)
+		}
+		return va < vb
+	}
+	sort.SliceStable(t.rows, func(i, j int) bool {
+		if t.sortDesc {
+			return less(t.rows[j], t.rows[i])
+		}
+		return less(t.rows[i], t.rows[j])
+	})
+}
+
+func (t *PositionTable) updateHeader(id widget.TableCellID, o fyne.CanvasObject) {
+	btn := o.(*widget.Button)
+	if id.Col < 0 {
+		return
+	}
+	if id.Col == positionActionCol {
+		btn.SetText("")
+		btn.OnTapped = nil
+		return
+	}
+	t.mu.Lock()
+	text := T(positionColumns[id.Col].Label)
+	if id.Col == t.sortCol {
+		if t.sortDesc {
+			text += " ▼"
+		} else {
+			text += " ▲"
+		}
+	}
+	t.mu.Unlock()
+
+	btn.SetText(text)
+	btn.Importance = widget.LowImportance
+	btn.OnTapped = func() {
+		t.mu.Lock()
+		if t.sortCol == id.Col {
+			t.sortDesc = !t.sortDesc
+		} else {
+			t.sortCol, t.sortDesc = id.Col, false
+		}
+		t.sortLocked()
+		t.mu.Unlock()
+		t.Table.Refresh()
+	}
+}
+
+func (t *PositionTable) updateCell(id widget.TableCellID, o fyne.CanvasObject) {
+	cell := o.(*fyne.Container)
+	label := cell.Objects[0].(*widget.Label)
+	actions := cell.Objects[1].(*fyne.Container)
+	editBtn := actions.Objects[0].(*widget.Button)
+	noteBtn := actions.Objects[1].(*widget.Button)
+	closeBtn := actions.Objects[2].(*widget.Button)
+	t.mu.Lock()
+	if id.Row >= len(t.rows) {
+		t.mu.Unlock()
+		return
+	}
+	row := t.rows[id.Row]
+	t.mu.Unlock()
+
+	if id.Col == positionActionCol {
+		label.Hide()
+		actions.Show()
+		editBtn.OnTapped = func() {
+			if t.OnEditExits != nil {
+				t.OnEditExits(row.Symbol)
+			}
+		}
+		noteBtn.OnTapped = func() {
+			if t.OnEditNote != nil {
+				t.OnEditNote(row.Symbol)
+			}
+		}
+		closeBtn.Importance = widget.DangerImportance
+		closeBtn.OnTapped = func() {
+			if t.OnClose != nil {
+				t.OnClose(row.Symbol)
+			}
+		}
+		closeBtn.Refresh()
+		return
+	}
+	actions.Hide()
+	label.Show()
+
+	col := positionColumns[id.Col]
+	label.Importance = widget.MediumImportance
+	if col.Signed {
+		if v := col.Value(row); v > 0 {
+			label.Importance = widget.SuccessImportance
+		} else if v < 0 {
+			label.Importance = widget.DangerImportance
+		}
+	}
+	label.SetText(col.Text(row))
+}
@@ -0,0 +1,390 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+const (
+	ladderLevels       = 20                     // 中间价上下各显示的档数
+	ladderRefresh      = 500 * time.Millisecond // 盘口刷新间隔
+	ladderOrderRefresh = 4                      // 每刷新几次盘口查询一次挂单
+)
+
+// 价格阶梯的列
+const (
+	ladderColMyBuy = iota
+	ladderColBid
+	ladderColPrice
+	ladderColAsk
+	ladderColMySell
+)
+
+var ladderColumns = []struct {
+	Label string
+	Width float32
+}{
+	{"我的买单", 80},
+	{"买量", 90},
+	{"价格", 100},
+	{"卖量", 90},
+	{"我的卖单", 80},
+}
+
+// 价格阶梯中的一行
+type ladderRow struct {
+	Price   float64
+	Bid     float64
+	Ask     float64
+	MyBuys  []*futures.Order
+	MySells []*futures.Order
+	Entry   bool // 当前持仓的入场价
+}
+
+func orderQuantity(orders []*futures.Order) float64 {
+	var qty float64
+	for _, o := range orders {
+		orig, _ := strconv.ParseFloat(o.OrigQuantity, 64)
+		executed, _ := strconv.ParseFloat(o.ExecutedQuantity, 64)
+		qty += orig - executed
+	}
+	return qty
+}
+
+// 价格阶梯窗口，跟随主窗口的交易对。点击买卖列确认后在该价格挂限价单，勾选"一键下单"后
+// 不再确认。点击有挂单的"我的买单/我的卖单"撤销该价格的挂单，点击价格填入下单面板
+type PriceLadder struct {
+	ui       *TraderUI
+	window   fyne.Window
+	table    *widget.Table
+	status   *widget.Label
+	oneClick *widget.Check
+	book     *OrderBook
+
+	mu      sync.Mutex
+	filters SymbolFilters
+	center  float64 // 中间行的价格，0表示下次刷新时按盘口居中
+	rows    []ladderRow
+	orders  []*futures.Order
+	stopC   chan struct{}
+}
+
+func (ui *TraderUI) showPriceLadder() {
+	l := &PriceLadder{
+		ui:       ui,
+		window:   ui.app.NewWindow(T("价格阶梯")),
+		status:   widget.NewLabel(T("加载中...")),
+		oneClick: widget.NewCheck(T("一键下单"), nil),
+		book:     NewOrderBook(ui.client, ui.symbol),
+		stopC:    make(chan struct{}),
+	}
+
+	l.table = widget.NewTableWithHeaders(
+		func() (int, int) {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			return len(l.rows), len(ladderColumns)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabelWithStyle("", fyne.TextAlignTrailing, fyne.TextStyle{Monospace: true})
+		},
+		l.updateCell,
+	)
+	l.table.ShowHeaderColumn = false
+	l.table.UpdateHeader = func(id widget.TableCellID, o fyne.CanvasObject) {
+		if id.Col >= 0 {
+			o.(*widget.Label).SetText(T(ladderColumns[id.Col].Label))
+		}
+	}
+	for i, c := range ladderColumns {
+		l.table.SetColumnWidth(i, c.Width)
+	}
+	l.table.OnSelected = l.onSelected
+
+	centerBtn := widget.NewButton(T("居中"), func() {
+		l.mu.Lock()
+		l.center = 0
+		l.mu.Unlock()
+	})
+
+	l.window.SetContent(container.NewBorder(
+		container.NewHBox(centerBtn, l.oneClick, l.status),
+		nil, nil, nil,
+		l.table,
+	))
+	l.window.SetOnClosed(func() {
+		close(l.stopC)
+		l.book.Stop()
+	})
+	l.window.Resize(ui.scaled(480, 700))
+	l.window.Show()
+
+	l.book.Start()
+	go l.run()
+}
+
+func (l *PriceLadder) run() {
+	ticker := time.NewTicker(ladderRefresh)
+	defer ticker.Stop()
+
+	for i := 0; ; i++ {
+		select {
+		case <-l.stopC:
+			return
+		case <-ticker.C:
+		}
+
+		// 跟随主窗口切换交易对
+		symbol := l.ui.symbol
+		if symbol != l.book.Symbol() {
+			l.book.SetSymbol(symbol)
+			l.mu.Lock()
+			l.center = 0
+			l.orders = nil
+			l.mu.Unlock()
+			i = 0
+		}
+		if i%ladderOrderRefresh == 0 {
+			l.refreshOrders(symbol)
+		}
+		l.refresh(symbol)
+	}
+}
+
+func (l *PriceLadder) refreshOrders(symbol string) {
+	filters, err := l.ui.filters.Get(symbol)
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		return
+	}
+	orders, err := l.ui.client.NewListOpenOrdersService().Symbol(symbol).Do(context.Background())
+	if err != nil {
+		fmt.Printf(T("获取订单失败: %v\n"), err)
+		return
+	}
+
+	l.mu.Lock()
+	l.filters = filters
+	l.orders = nil
+	for _, o := range orders {
+		if o.Type == futures.OrderTypeLimit {
+			l.orders = append(l.orders, o)
+		}
+	}
+	l.mu.Unlock()
+}
+
+// 按盘口、挂单和持仓重新生成每一行
+func (l *PriceLadder) refresh(symbol string) {
+	bids, asks, ok := l.book.Levels(1000)
+	l.mu.Lock()
+	tick := l.filters.TickSize
+	if !ok || tick <= 0 || len(bids) == 0 || len(asks) == 0 {
+		l.mu.Unlock()
+		fyne.Do(func() { l.status.SetText(T("正在同步盘口...")) })
+		return
+	}
+
+	// 按最小价格单位对齐，最优价移出显示范围时重新居中
+	key := func(price float64) int64 { return int64(math.Round(price / tick)) }
+	mid := (bids[0].Price + asks[0].Price) / 2
+	if l.center == 0 || math.Abs(float64(key(mid)-key(l.center))) > ladderLevels {
+		l.center = roundToTickSize(mid, tick)
+	}
+
+	bidQty := make(map[int64]float64)
+	for _, b := range bids {
+		bidQty[key(b.Price)] = b.Quantity
+	}
+	askQty := make(map[int64]float64)
+	for _, a := range asks {
+		askQty[key(a.Price)] = a.Quantity
+	}
+	myBuys := make(map[int64][]*futures.Order)
+	mySells := make(map[int64][]*futures.Order)
+	for _, o := range l.orders {
+		price, _ := strconv.ParseFloat(o.Price, 64)
+		if o.Side == futures.SideTypeBuy {
+			myBuys[key(price)] = append(myBuys[key(price)], o)
+		} else {
+			mySells[key(price)] = append(mySells[key(price)], o)
+		}
+	}
+	var entry int64 = math.MinInt64
+	if p := l.ui.position; p != nil && p.Symbol == symbol {
+		price, _ := strconv.ParseFloat(p.EntryPrice, 64)
+		entry = key(price)
+	}
+
+	center := key(l.center)
+	l.rows = l.rows[:0]
+	for k := center + ladderLevels; k >= center-ladderLevels; k-- {
+		l.rows = append(l.rows, ladderRow{
+			Price:   float64(k) * tick,
+			Bid:     bidQty[k],
+			Ask:     askQty[k],
+			MyBuys:  myBuys[k],
+			MySells: mySells[k],
+			Entry:   k == entry,
+		})
+	}
+	filters := l.filters
+	l.mu.Unlock()
+
+	status := fmt.Sprintf(T("%s 买一 %s 卖一 %s"), symbol, filters.FormatPrice(bids[0].Price), filters.FormatPrice(asks[0].Price))
+	fyne.Do(func() {
+		l.status.SetText(status)
+		l.table.Refresh()
+	})
+}
+
+func (l *PriceLadder) updateCell(id widget.TableCellID, o fyne.CanvasObject) {
+	label := o.(*widget.Label)
+	l.mu.Lock()
+	if id.Row >= len(l.rows) {
+		l.mu.Unlock()
+		return
+	}
+	row := l.rows[id.Row]
+	filters := l.filters
+	l.mu.Unlock()
+
+	quantity := func(q float64) string {
+		if q == 0 {
+			return ""
+		}
+		return formatFloat(q)
+	}
+	label.Importance = widget.MediumImportance
+	label.TextStyle.Bold = false
+	switch id.Col {
+	case ladderColMyBuy:
+		label.Importance = widget.HighImportance
+		label.SetText(quantity(orderQuantity(row.MyBuys)))
+	case ladderColBid:
+		label.Importance = widget.SuccessImportance
+		label.SetText(quantity(row.Bid))
+	case ladderColPrice:
+		text := filters.FormatPrice(row.Price)
+		if row.Entry {
+			// 持仓入场价
+			text = "▶ " + text
+			label.Importance = widget.WarningImportance
+			label.TextStyle.Bold = true
+		}
+		label.SetText(text)
+	case ladderColAsk:
+		label.Importance = widget.DangerImportance
+		label.SetText(quantity(row.Ask))
+	case ladderColMySell:
+		label.Importance = widget.HighImportance
+		label.SetText(quantity(orderQuantity(row.MySells)))
+	}
+}
+
+func (l *PriceLadder) onSelected(id widget.TableCellID) {
+	l.table.UnselectAll()
+	l.mu.Lock()
+	if id.Row < 0 || id.Row >= len(l.rows) {
+		l.mu.Unlock()
+		return
+	}
+	row := l.rows[id.Row]
+	filters := l.filters
+	l.mu.Unlock()
+
+	symbol := l.book.Symbol()
+	price := filters.FormatPrice(row.Price)
+	switch id.Col {
+	case ladderColMyBuy:
+		if len(row.MyBuys) > 0 {
+			l.cancelOrders(symbol, row.MyBuys)
+			return
+		}
+		l.placeOrder(symbol, futures.SideTypeBuy, price)
+	case ladderColBid:
+		l.placeOrder(symbol, futures.SideTypeBuy, price)
+	case ladderColPrice:
+		l.ui.priceEntry.SetText(price)
+	case ladderColAsk:
+		l.placeOrder(symbol, futures.SideTypeSell, price)
+	case ladderColMySell:
+		if len(row.MySells) > 0 {
+			l.cancelOrders(symbol, row.MySells)
+			return
+		}
+		l.placeOrder(symbol, futures.SideTypeSell, price)
+	}
+}
+
+// 按下单面板的数量在该价格挂限价单。没有勾选一键下单、价格会立即成交或名义价值
+// 达到下单确认的阈值时先确认
+func (l *PriceLadder) placeOrder(symbol string, side futures.SideType, price string) {
+	quantity := l.ui.amountEntry.Text
+	summary := OrderSummary{Symbol: symbol, Long: side == futures.SideTypeBuy}
+	summary.Price, _ = strconv.ParseFloat(price, 64)
+	summary.Quantity, _ = strconv.ParseFloat(quantity, 64)
+	if summary.Quantity <= 0 {
+		l.ui.notify(NoticeWarning, "%s", T("请先在下单面板输入数量"))
+		return
+	}
+
+	// 买价不低于卖一或卖价不高于买一时会立即以市价成交
+	marketable := false
+	if bids, asks, ok := l.book.Levels(1); ok && len(bids) > 0 && len(asks) > 0 {
+		marketable = summary.Long && summary.Price >= asks[0].Price ||
+			!summary.Long && summary.Price <= bids[0].Price
+	}
+	if marketable {
+		summary.Warning = T("该价格会立即成交，相当于市价单")
+	}
+
+	place := func() {
+		go func() {
+			_, err := l.ui.client.NewCreateOrderService().
+				Symbol(symbol).
+				NewClientOrderID(newClientOrderID(tagManual)).
+				Side(side).
+				PositionSide("BOTH").
+				Type(futures.OrderTypeLimit).
+				TimeInForce(futures.TimeInForceTypeGTC).
+				Price(price).
+				Quantity(quantity).
+				Do(context.Background())
+			if err != nil {
+				l.ui.notify(NoticeError, T("挂单失败: %v"), err)
+				return
+			}
+			l.ui.notify(NoticeInfo, T("已挂单: %s %s %s @ %s"), symbol, side, quantity, price)
+			l.refreshOrders(symbol)
+		}()
+	}
+	if !l.oneClick.Checked || marketable || l.ui.config.OrderConfirm.Required(summary.Notional()) {
+		l.ui.confirmOrderIn(l.window, summary, place)
+		return
+	}
+	place()
+}
+
+func (l *PriceLadder) cancelOrders(symbol string, orders []*futures.Order) {
+	go func() {
+		for _, o := range orders {
+			_, err := l.ui.client.NewCancelOrderService().Symbol(symbol).OrderID(o.OrderID).Do(context.Background())
+			if err != nil {
+				l.ui.notify(NoticeError, T("撤单失败: %v"), err)
+				continue
+			}
+			l.ui.notify(NoticeInfo, T("已撤单: %s %s %s @ %s"), symbol, o.Side, o.OrigQuantity, o.Price)
+		}
+		l.refreshOrders(symbol)
+	}()
+}
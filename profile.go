@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 默认账户，使用配置文件顶层的api_key和secret_key
+const defaultProfile = "default"
+
+// 其他账户的状态文件保存在这个目录下按账户名分开的子目录中
+const profilesDir = "profiles"
+
+// API密钥档案，例如主账户和子账户
+type Profile struct {
+	Name      string `json:"name"`
+	APIKey    string `json:"api_key"`
+	SecretKey string `json:"secret_key"`
+}
+
+// 配置文件中与账户相关的字段，命令行只读取这些字段
+type profileConfig struct {
+	APIKey    string    `json:"api_key"`
+	SecretKey string    `json:"secret_key"`
+	Profiles  []Profile `json:"profiles"`
+}
+
+// 按名称查找账户，名称为空或default时返回顶层密钥
+func (c profileConfig) Find(name string) (Profile, error) {
+	if name == "" || name == defaultProfile {
+		if c.APIKey == "" || c.SecretKey == "" {
+			return Profile{}, errors.New(T("请在配置文件中填写API密钥，或设置BINANCE_API_KEY和BINANCE_SECRET_KEY环境变量"))
+		}
+		return Profile{Name: defaultProfile, APIKey: c.APIKey, SecretKey: c.SecretKey}, nil
+	}
+	for _, p := range c.Profiles {
+		if p.Name != name {
+			continue
+		}
+		if p.APIKey == "" || p.SecretKey == "" {
+			return Profile{}, fmt.Errorf(T("账户%s没有填写API密钥"), name)
+		}
+		return p, nil
+	}
+	return Profile{}, fmt.Errorf(T("未找到账户: %s"), name)
+}
+
+// 所有账户的名称，默认账户排在第一个
+func (c profileConfig) Names() []string {
+	names := []string{defaultProfile}
+	for _, p := range c.Profiles {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// 账户名用作目录名，只允许字母、数字、-和_
+func validateProfileName(name string) error {
+	if name == "" {
+		return errors.New(T("账户名不能为空"))
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return fmt.Errorf(T("账户名只能包含字母、数字、-和_: %s"), name)
+		}
+	}
+	return nil
+}
+
+// 账户的状态文件路径。默认账户沿用原来的文件，其他账户的交易数据库、提醒等
+// 放在profiles/<账户名>/下，切换账户不会互相影响
+func profilePath(profile, file string) string {
+	if profile == "" || profile == defaultProfile {
+		return file
+	}
+	return filepath.Join(profilesDir, profile, file)
+}
+
+// 确保账户的状态目录存在
+func ensureProfileDir(profile string) error {
+	if profile == "" || profile == defaultProfile {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Join(profilesDir, profile), 0700); err != nil {
+		return fmt.Errorf(T("创建账户目录失败: %v"), err)
+	}
+	return nil
+}
+
+// 从命令行参数中取出--NAME VALUE或--NAME=VALUE形式的全局参数，例如--profile和--config，
+// 返回参数值和其余参数
+func extractFlag(args []string, name string) (string, []string) {
+	var value string
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "--"+name || arg == "-"+name:
+			if i+1 < len(args) {
+				value = args[i+1]
+				i++
+			}
+		case strings.HasPrefix(arg, "--"+name+"=") || strings.HasPrefix(arg, "-"+name+"="):
+			value = arg[strings.Index(arg, "=")+1:]
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	return value, rest
+}
+
+// 从命令行参数中取出--NAME形式的全局开关，只接受两个减号，避免和子命令的参数冲突
+func extractBoolFlag(args []string, name string) (bool, []string) {
+	var found bool
+	var rest []string
+	for _, arg := range args {
+		if arg == "--"+name {
+			found = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return found, rest
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 账户菜单：勾选切换账户，切换后需要重启，运行中的自动化仍使用原账户的客户端
+func (ui *TraderUI) profileMenu() *fyne.MenuItem {
+	ui.profileItem = fyne.NewMenuItem(T("账户"), nil)
+	ui.updateProfileMenu()
+	return ui.profileItem
+}
+
+// 添加或删除账户后重新生成账户菜单
+func (ui *TraderUI) updateProfileMenu() {
+	var items []*fyne.MenuItem
+	for _, name := range ui.config.profiles().Names() {
+		name := name
+		label := name
+		if name == defaultProfile {
+			label = T("默认账户")
+		}
+		item := fyne.NewMenuItem(label, func() { ui.switchProfile(name) })
+		item.Checked = name == ui.profile
+		items = append(items, item)
+	}
+	items = append(items,
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem(T("账户管理"), ui.showProfiles),
+	)
+	ui.profileItem.ChildMenu = fyne.NewMenu("", items...)
+	if menu := ui.window.MainMenu(); menu != nil {
+		menu.Refresh()
+	}
+}
+
+// 保存要使用的账户并提示重启
+func (ui *TraderUI) switchProfile(name string) {
+	if name == ui.profile {
+		return
+	}
+	ui.config.Profile = name
+	if name == defaultProfile {
+		ui.config.Profile = ""
+	}
+	if err := ui.saveConfig(); err != nil {
+		dialog.ShowError(err, ui.window)
+		return
+	}
+	message := fmt.Sprintf(T("已切换到账户 %s，重启后生效。现在重启吗？"), name)
+	dialog.ShowConfirm(T("切换账户"), message, func(ok bool) {
+		if ok {
+			ui.restart(name)
+		}
+	}, ui.window)
+}
+
+// 以指定账户启动新的进程后退出当前进程
+func (ui *TraderUI) restart(profile string) {
+	exe, err := os.Executable()
+	if err != nil {
+		dialog.ShowError(fmt.Errorf(T("重启失败: %v"), err), ui.window)
+		return
+	}
+	// 替换原来的--profile，BINANCE_PROFILE环境变量也不会覆盖刚选择的账户
+	_, args := extractFlag(os.Args[1:], "profile")
+	cmd := exec.Command(exe, append([]string{"--profile", profile}, args...)...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		dialog.ShowError(fmt.Errorf(T("重启失败: %v"), err), ui.window)
+		return
+	}
+	ui.saveLayout()
+	ui.app.Quit()
+}
+
+// 账户管理窗口：添加和删除账户，添加时验证API密钥
+func (ui *TraderUI) showProfiles() {
+	w := ui.app.NewWindow(T("账户管理"))
+
+	var list *widget.List
+	list = widget.NewList(
+		func() int { return len(ui.config.Profiles) },
+		func() fyne.CanvasObject {
+			return container.NewBorder(nil, nil, nil, widget.NewButton(T("删除"), nil), widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			p := ui.config.Profiles[id]
+			row := o.(*fyne.Container)
+			text := fmt.Sprintf("%s  %s", p.Name, maskKey(p.APIKey))
+			if p.Name == ui.profile {
+				text += T(" (当前)")
+			}
+			row.Objects[0].(*widget.Label).SetText(text)
+			deleteBtn := row.Objects[1].(*widget.Button)
+			deleteBtn.OnTapped = func() {
+				if p.Name == ui.profile {
+					dialog.ShowError(errors.New(T("不能删除正在使用的账户")), w)
+					return
+				}
+				dialog.ShowConfirm(T("删除账户"), fmt.Sprintf(T("删除账户 %s？该账户的交易数据库和提醒文件会保留"), p.Name), func(ok bool) {
+					if !ok {
+						return
+					}
+					ui.removeProfile(p.Name)
+					if err := ui.saveConfig(); err != nil {
+						dialog.ShowError(err, w)
+					}
+					list.Refresh()
+					ui.updateProfileMenu()
+				}, w)
+			}
+		},
+	)
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder(T("如 sub1"))
+	apiKeyEntry := widget.NewEntry()
+	apiKeyEntry.SetPlaceHolder("API Key")
+	secretEntry := widget.NewPasswordEntry()
+	secretEntry.SetPlaceHolder("Secret Key")
+	status := widget.NewLabel("")
+
+	var addBtn *widget.Button
+	addBtn = widget.NewButton(T("验证并添加"), func() {
+		p := Profile{
+			Name:      strings.TrimSpace(nameEntry.Text),
+			APIKey:    strings.TrimSpace(apiKeyEntry.Text),
+			SecretKey: strings.TrimSpace(secretEntry.Text),
+		}
+		if err := validateProfileName(p.Name); err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		for _, name := range ui.config.profiles().Names() {
+			if name == p.Name {
+				dialog.ShowError(fmt.Errorf(T("账户已存在: %s"), p.Name), w)
+				return
+			}
+		}
+		if p.APIKey == "" || p.SecretKey == "" {
+			dialog.ShowError(errors.New(T("请填写API密钥")), w)
+			return
+		}
+
+		addBtn.Disable()
+		status.SetText(T("正在验证API密钥..."))
+		go func() {
+			err := validateAPIKey(p.APIKey, p.SecretKey)
+			fyne.Do(func() {
+				addBtn.Enable()
+				status.SetText("")
+				if err != nil {
+					dialog.ShowError(err, w)
+					return
+				}
+				ui.config.Profiles = append(ui.config.Profiles, p)
+				if err := ui.saveConfig(); err != nil {
+					dialog.ShowError(err, w)
+					return
+				}
+				nameEntry.SetText("")
+				apiKeyEntry.SetText("")
+				secretEntry.SetText("")
+				list.Refresh()
+				ui.updateProfileMenu()
+				status.SetText(fmt.Sprintf(T("已添加账户 %s，可以在设置-账户菜单中切换"), p.Name))
+			})
+		}()
+	})
+	addBtn.Importance = widget.HighImportance
+
+	w.SetContent(container.NewBorder(
+		widget.NewLabel(fmt.Sprintf(T("默认账户使用配置文件顶层的密钥 %s"), maskKey(ui.config.APIKey))),
+		container.NewVBox(
+			widget.NewSeparator(),
+			widget.NewForm(
+				widget.NewFormItem(T("账户名"), nameEntry),
+				widget.NewFormItem("API Key", apiKeyEntry),
+				widget.NewFormItem("Secret Key", secretEntry),
+			),
+			container.NewHBox(addBtn, status),
+		),
+		nil, nil,
+		list,
+	))
+	w.Resize(ui.scaled(520, 420))
+	w.Show()
+}
+
+func (ui *TraderUI) removeProfile(name string) {
+	var profiles []Profile
+	for _, p := range ui.config.Profiles {
+		if p.Name != name {
+			profiles = append(profiles, p)
+		}
+	}
+	ui.config.Profiles = profiles
+}
+
+// 只显示API Key的前4位和后4位
+func maskKey(key string) string {
+	if len(key) <= 8 {
+		return strings.Repeat("*", len(key))
+	}
+	return key[:4] + "..." + key[len(key)-4:]
+}
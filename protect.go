@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// 自动止盈止损和保护止盈的设置，只管理一个交易对的持仓
+type ProtectConfig struct {
+	Symbol     string  `json:"symbol"`      // 自动管理的交易对，默认SOLUSDC
+	TakeProfit float64 `json:"take_profit"` // 止盈价与入场价的距离，默认2.0
+	StopLoss   float64 `json:"stop_loss"`   // 止损价与入场价的距离，默认1.0
+	TickSize   float64 `json:"tick_size"`   // 止盈止损价的精度，默认0.01
+	ArmProfit  float64 `json:"arm_profit"`  // 最高盈利达到该值(U)后启动保护止盈，默认200
+	KeepRatio  float64 `json:"keep_ratio"`  // 盈利回撤到最高盈利的该比例时平仓，默认0.5
+}
+
+func (c *ProtectConfig) applyDefaults() {
+	if c.Symbol == "" {
+		c.Symbol = "SOLUSDC"
+	}
+	if c.TakeProfit <= 0 {
+		c.TakeProfit = 2.0
+	}
+	if c.StopLoss <= 0 {
+		c.StopLoss = 1.0
+	}
+	if c.TickSize <= 0 {
+		c.TickSize = 0.01
+	}
+	if c.ArmProfit <= 0 {
+		c.ArmProfit = 200
+	}
+	if c.KeepRatio <= 0 || c.KeepRatio >= 1 {
+		c.KeepRatio = 0.5
+	}
+}
+
+// 检查设置是否有效，用于设置窗口中的输入
+func (c *ProtectConfig) Validate() error {
+	var problems []string
+	if c.Symbol == "" {
+		problems = append(problems, T("自动管理的交易对不能为空"))
+	}
+	if c.TakeProfit <= 0 || c.StopLoss <= 0 {
+		problems = append(problems, T("止盈止损距离必须大于0"))
+	}
+	if c.TickSize <= 0 {
+		problems = append(problems, T("价格精度必须大于0"))
+	}
+	if c.ArmProfit <= 0 {
+		problems = append(problems, T("保护止盈启动盈利必须大于0"))
+	}
+	if c.KeepRatio <= 0 || c.KeepRatio >= 1 {
+		problems = append(problems, T("保留盈利比例必须在0到1之间"))
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "\n"))
+	}
+	return nil
+}
+
+// 按价格精度四舍五入并格式化，小数位数与精度一致
+func (c *ProtectConfig) FormatPrice(price float64) string {
+	return strconv.FormatFloat(roundToTickSize(price, c.TickSize), 'f', stepDecimals(c.TickSize), 64)
+}
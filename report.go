@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// 还原交易时往前多取的成交记录，报告期开始前不久开仓、期间平仓的交易也能统计
+const reportTradeLookback = 24 * time.Hour
+
+// 一段时间的交易汇总，用于每日报告
+type PeriodReport struct {
+	Period      string // day或week
+	Start, End  time.Time
+	Realized    float64
+	Fees        float64        // 手续费，正数
+	Funding     float64        // 资金费，收取为正、支付为负
+	Fills       int            // 成交笔数
+	Trades      []ClosedTrade  // 期间平仓的交易
+	Actions     map[string]int // 期间自动化操作的次数，按活动类型统计
+	MaxDrawdown float64        // 按流水时间累计净盈亏的最大回撤
+}
+
+// 扣除手续费和资金费后的净盈亏
+func (r *PeriodReport) Net() float64 {
+	return r.Realized - r.Fees + r.Funding
+}
+
+// 保护止盈平仓的次数
+func (r *PeriodReport) Interventions() int {
+	return r.Actions[ActivityProtect]
+}
+
+// 汇总[start, end)之间的资金流水、成交和活动记录，activity为nil时不统计自动化操作
+func buildPeriodReport(client *futures.Client, activity *ActivityLog, period string, start, end time.Time) (*PeriodReport, error) {
+	report := &PeriodReport{Period: period, Start: start, End: end, Actions: make(map[string]int)}
+
+	incomes, err := fetchIncomeRange(client, "", "", start, end)
+	if err != nil {
+		return nil, err
+	}
+	var equity, peak float64
+	fills := make(map[string]bool)
+	for _, in := range incomes {
+		v, _ := strconv.ParseFloat(in.Income, 64)
+		switch in.IncomeType {
+		case IncomeRealizedPnL:
+			report.Realized += v
+		case IncomeCommission:
+			report.Fees -= v
+			// 每笔成交都有一条手续费流水
+			if !fills[in.TradeID] {
+				fills[in.TradeID] = true
+				report.Fills++
+			}
+		case IncomeFunding:
+			report.Funding += v
+		default:
+			continue
+		}
+		equity += v
+		peak = math.Max(peak, equity)
+		report.MaxDrawdown = math.Max(report.MaxDrawdown, peak-equity)
+	}
+
+	if report.Fills > 0 {
+		trades, err := fetchAllAccountTrades(client, "", start.Add(-reportTradeLookback), end)
+		if err != nil {
+			return nil, err
+		}
+		records := make([]FillRecord, len(trades))
+		for i, t := range trades {
+			records[i] = newFillRecord(t)
+		}
+		for _, t := range buildClosedTrades(records) {
+			if !t.ExitTime.Before(start) && t.ExitTime.Before(end) {
+				report.Trades = append(report.Trades, t)
+			}
+		}
+	}
+
+	if activity != nil {
+		records, err := activity.Query(ActivityQuery{Since: start})
+		if err != nil {
+			return nil, fmt.Errorf(T("查询活动记录失败: %v"), err)
+		}
+		for _, a := range records {
+			if a.Time.Before(end) && a.Error == "" {
+				report.Actions[a.Action]++
+			}
+		}
+	}
+	return report, nil
+}
+
+func (r *PeriodReport) String() string {
+	var sb strings.Builder
+	title := T("交易日报")
+	if r.Period == statsWeek {
+		title = T("交易周报")
+	}
+	last := r.End.Add(-time.Nanosecond)
+	if last.Format(time.DateOnly) == r.Start.Format(time.DateOnly) {
+		fmt.Fprintf(&sb, "%s %s\n", title, r.Start.Format(time.DateOnly))
+	} else {
+		fmt.Fprintf(&sb, "%s %s ~ %s\n", title, r.Start.Format(time.DateOnly), last.Format(time.DateOnly))
+	}
+
+	wins := 0
+	for _, t := range r.Trades {
+		if t.Net() > 0 {
+			wins++
+		}
+	}
+	fmt.Fprintf(&sb, T("已实现盈亏: %+.2f\n"), r.Realized)
+	fmt.Fprintf(&sb, T("手续费: %.2f\n"), r.Fees)
+	fmt.Fprintf(&sb, T("资金费: %+.2f\n"), r.Funding)
+	fmt.Fprintf(&sb, T("净盈亏: %+.2f\n"), r.Net())
+	fmt.Fprintf(&sb, T("最大回撤: %.2f\n"), r.MaxDrawdown)
+	if len(r.Trades) > 0 {
+		fmt.Fprintf(&sb, T("平仓交易: %d笔，胜率 %.1f%%，成交 %d笔\n"),
+			len(r.Trades), float64(wins)/float64(len(r.Trades))*100, r.Fills)
+	} else {
+		fmt.Fprintf(&sb, T("平仓交易: 0笔，成交 %d笔\n"), r.Fills)
+	}
+	fmt.Fprintf(&sb, T("保护止盈平仓: %d次\n"), r.Interventions())
+	for _, a := range activityActions {
+		if a.Action != ActivityProtect && r.Actions[a.Action] > 0 {
+			fmt.Fprintf(&sb, "%s: %d\n", T(a.Label), r.Actions[a.Action])
+		}
+	}
+
+	if len(r.Trades) > 0 {
+		sb.WriteString("\n")
+		for _, t := range r.Trades {
+			sb.WriteString(t.String() + "\n")
+		}
+	}
+	return sb.String()
+}
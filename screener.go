@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// 市场筛选条件
+type ScreenerConfig struct {
+	Symbols        []string `json:"symbols"`         // 扫描的交易对
+	Interval       string   `json:"interval"`        // 计算RSI和成交量的K线周期
+	MinChange      float64  `json:"min_change"`      // 24h涨跌幅绝对值(%)
+	RSIHigh        float64  `json:"rsi_high"`        // RSI超买阈值
+	RSILow         float64  `json:"rsi_low"`         // RSI超卖阈值
+	VolumeSpike    float64  `json:"volume_spike"`    // 最新K线成交量相对均量的倍数
+	FundingExtreme float64  `json:"funding_extreme"` // 资金费率绝对值(%)
+}
+
+// 未配置的筛选条件使用默认值
+func (c *ScreenerConfig) applyDefaults() {
+	if len(c.Symbols) == 0 {
+		c.Symbols = []string{"SOLUSDC", "BTCUSDC", "ETHUSDC", "BNBUSDC", "XRPUSDC", "DOGEUSDC"}
+	}
+	if c.Interval == "" {
+		c.Interval = "1h"
+	}
+	if c.MinChange == 0 {
+		c.MinChange = 5
+	}
+	if c.RSIHigh == 0 {
+		c.RSIHigh = 70
+	}
+	if c.RSILow == 0 {
+		c.RSILow = 30
+	}
+	if c.VolumeSpike == 0 {
+		c.VolumeSpike = 3
+	}
+	if c.FundingExtreme == 0 {
+		c.FundingExtreme = 0.05
+	}
+}
+
+// 单个交易对的筛选结果
+type ScreenerResult struct {
+	Symbol      string
+	LastPrice   float64
+	Change24h   float64 // 24h涨跌幅(%)
+	RSI         float64
+	VolumeRatio float64 // 最新K线成交量 / 之前的平均成交量
+	FundingRate float64 // 当前资金费率(%)
+	Reasons     []string
+}
+
+func (r ScreenerResult) String() string {
+	return fmt.Sprintf(T("%-10s %10.4f  24h: %+6.2f%%  RSI: %5.1f  量比: %4.1f  资金费率: %+.4f%%  [%s]"),
+		r.Symbol, r.LastPrice, r.Change24h, r.RSI, r.VolumeRatio, r.FundingRate, strings.Join(r.Reasons, ", "))
+}
+
+// 扫描配置的交易对，返回满足任一条件的结果
+func runScreener(client *futures.Client, cfg ScreenerConfig) ([]ScreenerResult, error) {
+	cfg.applyDefaults()
+
+	stats, err := client.NewListPriceChangeStatsService().Do(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf(T("获取24h行情失败: %v"), err)
+	}
+	statsBySymbol := make(map[string]*futures.PriceChangeStats)
+	for _, s := range stats {
+		statsBySymbol[s.Symbol] = s
+	}
+
+	premiums, err := client.NewPremiumIndexService().Do(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf(T("获取资金费率失败: %v"), err)
+	}
+	fundingBySymbol := make(map[string]float64)
+	for _, p := range premiums {
+		rate, _ := strconv.ParseFloat(p.LastFundingRate, 64)
+		fundingBySymbol[p.Symbol] = rate * 100
+	}
+
+	var results []ScreenerResult
+	for _, symbol := range cfg.Symbols {
+		stat, ok := statsBySymbol[symbol]
+		if !ok {
+			continue
+		}
+
+		r := ScreenerResult{Symbol: symbol, FundingRate: fundingBySymbol[symbol]}
+		r.LastPrice, _ = strconv.ParseFloat(stat.LastPrice, 64)
+		r.Change24h, _ = strconv.ParseFloat(stat.PriceChangePercent, 64)
+
+		klines, err := fetchKlines(client, symbol, cfg.Interval, 50)
+		if err != nil {
+			return nil, err
+		}
+		r.RSI = calculateRSI(klines, 14)
+		r.VolumeRatio = volumeRatio(klines)
+
+		if math.Abs(r.Change24h) >= cfg.MinChange {
+			r.Reasons = append(r.Reasons, T("24h涨跌幅"))
+		}
+		if r.RSI >= cfg.RSIHigh {
+			r.Reasons = append(r.Reasons, T("RSI超买"))
+		} else if r.RSI <= cfg.RSILow {
+			r.Reasons = append(r.Reasons, T("RSI超卖"))
+		}
+		if r.VolumeRatio >= cfg.VolumeSpike {
+			r.Reasons = append(r.Reasons, T("放量"))
+		}
+		if math.Abs(r.FundingRate) >= cfg.FundingExtreme {
+			r.Reasons = append(r.Reasons, T("资金费率极端"))
+		}
+
+		if len(r.Reasons) > 0 {
+			results = append(results, r)
+		}
+	}
+
+	return results, nil
+}
+
+// 最新一根K线成交量相对之前K线平均成交量的倍数
+func volumeRatio(klines []Kline) float64 {
+	if len(klines) < 2 {
+		return 0
+	}
+	var sum float64
+	for _, k := range klines[:len(klines)-1] {
+		sum += k.Volume
+	}
+	avg := sum / float64(len(klines)-1)
+	if avg == 0 {
+		return 0
+	}
+	return klines[len(klines)-1].Volume / avg
+}
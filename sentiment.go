@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// 合约市场情绪数据：全市场多空账户比和主动买卖量
+type MarketSentiment struct {
+	Symbol string
+	Period string
+
+	LongShortRatio float64 // 多空账户数比
+	LongAccount    float64 // 多头账户占比
+	ShortAccount   float64 // 空头账户占比
+
+	TakerBuySellRatio float64 // 主动买卖量比
+	TakerBuyVol       float64 // 主动买入量
+	TakerSellVol      float64 // 主动卖出量
+
+	Time time.Time
+}
+
+// 获取最新一个周期的多空比和主动买卖量，period可选 5m/15m/30m/1h/2h/4h/6h/12h/1d
+func fetchMarketSentiment(client *futures.Client, symbol, period string) (*MarketSentiment, error) {
+	ratios, err := client.NewLongShortRatioService().
+		Symbol(symbol).
+		Period(period).
+		Limit(1).
+		Do(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf(T("获取多空比失败: %v"), err)
+	}
+	if len(ratios) == 0 {
+		return nil, fmt.Errorf(T("未找到%s的多空比数据"), symbol)
+	}
+
+	takers, err := client.NewTakerLongShortRatioService().
+		Symbol(symbol).
+		Period(period).
+		Limit(1).
+		Do(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf(T("获取主动买卖量失败: %v"), err)
+	}
+	if len(takers) == 0 {
+		return nil, fmt.Errorf(T("未找到%s的主动买卖量数据"), symbol)
+	}
+
+	s := &MarketSentiment{
+		Symbol: symbol,
+		Period: period,
+		Time:   time.UnixMilli(ratios[0].Timestamp),
+	}
+	s.LongShortRatio, _ = strconv.ParseFloat(ratios[0].LongShortRatio, 64)
+	s.LongAccount, _ = strconv.ParseFloat(ratios[0].LongAccount, 64)
+	s.ShortAccount, _ = strconv.ParseFloat(ratios[0].ShortAccount, 64)
+	s.TakerBuySellRatio, _ = strconv.ParseFloat(takers[0].BuySellRatio, 64)
+	s.TakerBuyVol, _ = strconv.ParseFloat(takers[0].BuyVol, 64)
+	s.TakerSellVol, _ = strconv.ParseFloat(takers[0].SellVol, 64)
+
+	return s, nil
+}
+
+// 生成分析面板中的市场情绪部分
+func (s *MarketSentiment) Analysis() string {
+	var analysis strings.Builder
+
+	analysis.WriteString(fmt.Sprintf(T("多空比(%s): %.2f (多 %.1f%% / 空 %.1f%%)\n"),
+		s.Period, s.LongShortRatio, s.LongAccount*100, s.ShortAccount*100))
+	analysis.WriteString(fmt.Sprintf(T("主动买卖比: %.2f (买 %.2f / 卖 %.2f)\n\n"),
+		s.TakerBuySellRatio, s.TakerBuyVol, s.TakerSellVol))
+
+	analysis.WriteString(T("市场情绪:\n"))
+	if s.LongShortRatio > 1.5 {
+		analysis.WriteString(T("- 多头账户拥挤，警惕多头踩踏\n"))
+	} else if s.LongShortRatio < 0.67 {
+		analysis.WriteString(T("- 空头账户拥挤，警惕空头回补\n"))
+	} else {
+		analysis.WriteString(T("- 多空账户比例均衡\n"))
+	}
+
+	if s.TakerBuySellRatio > 1 {
+		analysis.WriteString(T("- 主动买盘占优\n"))
+	} else {
+		analysis.WriteString(T("- 主动卖盘占优\n"))
+	}
+
+	return analysis.String()
+}
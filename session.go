@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// 当日（UTC 0点起）的开盘价、最高价和最低价
+type SessionStats struct {
+	Symbol string
+	Start  time.Time
+	Open   float64
+	High   float64
+	Low    float64
+}
+
+// 用日线获取当日统计
+func fetchSessionStats(client *futures.Client, symbol string) (*SessionStats, error) {
+	klines, err := fetchKlines(client, symbol, "1d", 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(klines) == 0 {
+		return nil, fmt.Errorf(T("未找到%s的日线数据"), symbol)
+	}
+
+	k := klines[0]
+	return &SessionStats{
+		Symbol: symbol,
+		Start:  k.Time,
+		Open:   k.Open,
+		High:   k.High,
+		Low:    k.Low,
+	}, nil
+}
+
+// 用最新价格更新最高价和最低价，两次查询日线之间也能保持准确
+func (s *SessionStats) Update(price float64) {
+	if price > s.High {
+		s.High = price
+	}
+	if price < s.Low {
+		s.Low = price
+	}
+}
+
+// 当前价格在日内区间中的位置，0为最低价，100为最高价
+func (s *SessionStats) RangePosition(price float64) float64 {
+	if s.High <= s.Low {
+		return 50
+	}
+	return (price - s.Low) / (s.High - s.Low) * 100
+}
+
+// 持仓面板中显示的一行摘要
+func (s *SessionStats) Summary(price float64) string {
+	return fmt.Sprintf(T("日内位置: %.0f%% (距高 %.2f%%, 距低 %.2f%%)"),
+		s.RangePosition(price), (s.High-price)/price*100, (price-s.Low)/price*100)
+}
+
+// 生成分析面板中的日内统计部分
+func (s *SessionStats) Analysis(price float64) string {
+	var analysis strings.Builder
+
+	analysis.WriteString(fmt.Sprintf(T("今日开盘: %.4f (%+.2f%%)\n"), s.Open, (price-s.Open)/s.Open*100))
+	analysis.WriteString(fmt.Sprintf(T("今日最高: %.4f (距离 %.2f%%)\n"), s.High, (s.High-price)/price*100))
+	analysis.WriteString(fmt.Sprintf(T("今日最低: %.4f (距离 %.2f%%)\n"), s.Low, (price-s.Low)/price*100))
+	analysis.WriteString(fmt.Sprintf(T("日内位置: %.0f%%\n"), s.RangePosition(price)))
+
+	pos := s.RangePosition(price)
+	if pos >= 90 {
+		analysis.WriteString(T("- 价格接近日内高点，空单止损可放在高点上方\n"))
+	} else if pos <= 10 {
+		analysis.WriteString(T("- 价格接近日内低点，多单止损可放在低点下方\n"))
+	}
+
+	return analysis.String()
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 设置窗口中的数值输入，应用时统一校验
+type numberField struct {
+	label string
+	entry *widget.Entry
+	min   float64 // 允许的最小值，包含
+}
+
+func newNumberField(label string, value, min float64) *numberField {
+	entry := widget.NewEntry()
+	entry.SetText(strconv.FormatFloat(value, 'f', -1, 64))
+	return &numberField{label: label, entry: entry, min: min}
+}
+
+func (f *numberField) item() *widget.FormItem {
+	return widget.NewFormItem(T(f.label), f.entry)
+}
+
+// 解析输入，无效时把问题加入problems
+func (f *numberField) value(problems *[]string) float64 {
+	v, err := strconv.ParseFloat(strings.TrimSpace(f.entry.Text), 64)
+	if err != nil || v < f.min {
+		*problems = append(*problems, fmt.Sprintf(T("%s: 请输入不小于%g的数字"), T(f.label), f.min))
+	}
+	return v
+}
+
+// 按逗号、空格或换行分隔的交易对列表，转为大写并去重
+func parseSymbols(text string) []string {
+	var symbols []string
+	seen := make(map[string]bool)
+	for _, s := range strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n' || r == '\t'
+	}) {
+		s = strings.ToUpper(s)
+		if !seen[s] {
+			seen[s] = true
+			symbols = append(symbols, s)
+		}
+	}
+	return symbols
+}
+
+// 参数设置窗口：交易对、K线、自动止盈止损、保护止盈和提醒阈值。
+// 应用时校验所有输入，通过后立即对运行中的程序生效并保存到配置文件
+func (ui *TraderUI) showSettings() {
+	w := ui.app.NewWindow(T("参数设置"))
+	config := ui.config
+	protect := ui.protectConfig()
+	spike := config.Spike
+	spike.applyDefaults()
+
+	// 交易对和K线
+	symbolEntry := widget.NewSelectEntry(config.Watchlist)
+	symbolEntry.SetText(ui.symbol)
+	watchlistEntry := widget.NewMultiLineEntry()
+	watchlistEntry.SetText(strings.Join(config.Watchlist, "\n"))
+	watchlistEntry.SetMinRowsVisible(4)
+	intervalSelect := widget.NewSelect(klineIntervals, nil)
+	intervalSelect.SetSelected(ui.interval)
+	limitSelect := widget.NewSelect(klineLimits, nil)
+	limitSelect.SetSelected(strconv.Itoa(ui.limit))
+	confirmCheck := widget.NewCheck(T("下单前确认"), nil)
+	confirmCheck.SetChecked(config.OrderConfirm.Enabled)
+	confirmField := newNumberField("不确认的名义价值上限", config.OrderConfirm.MinNotional, 0)
+
+	// 自动止盈止损和保护止盈
+	protectSymbolEntry := widget.NewEntry()
+	protectSymbolEntry.SetText(protect.Symbol)
+	takeProfitField := newNumberField("止盈距离", protect.TakeProfit, 0)
+	stopLossField := newNumberField("止损距离", protect.StopLoss, 0)
+	tickSizeField := newNumberField("价格精度", protect.TickSize, 0)
+	armProfitField := newNumberField("保护止盈启动盈利(U)", protect.ArmProfit, 0)
+	keepRatioField := newNumberField("保留盈利比例", protect.KeepRatio, 0)
+
+	// 提醒
+	liquidationField := newNumberField("强平提醒阈值(U)", config.LiquidationAlert, 0)
+	basisField := newNumberField("基差提醒(年化%)", config.BasisAlert, 0)
+	zScoreField := newNumberField("异动z-score", spike.ZScore, 0)
+	windowField := newNumberField("异动样本数量", float64(spike.Window), 2)
+	tightenCheck := widget.NewCheck(T("异动后收紧保护止盈"), nil)
+	tightenCheck.SetChecked(spike.TightenStops)
+	tightenRatioField := newNumberField("收紧后保留比例", spike.TightenRatio, 0)
+	tightenMinuteField := newNumberField("收紧持续分钟", float64(spike.TightenMinute), 1)
+
+	applyBtn := widget.NewButton(T("应用"), func() {
+		var problems []string
+		symbol := strings.ToUpper(strings.TrimSpace(symbolEntry.Text))
+		if symbol == "" {
+			problems = append(problems, T("交易对不能为空"))
+		}
+		watchlist := parseSymbols(watchlistEntry.Text)
+		if len(watchlist) == 0 {
+			problems = append(problems, T("自选列表不能为空"))
+		}
+		limit, _ := strconv.Atoi(limitSelect.Selected)
+
+		newProtect := ProtectConfig{
+			Symbol:     strings.ToUpper(strings.TrimSpace(protectSymbolEntry.Text)),
+			TakeProfit: takeProfitField.value(&problems),
+			StopLoss:   stopLossField.value(&problems),
+			TickSize:   tickSizeField.value(&problems),
+			ArmProfit:  armProfitField.value(&problems),
+			KeepRatio:  keepRatioField.value(&problems),
+		}
+		if err := newProtect.Validate(); err != nil {
+			problems = append(problems, err.Error())
+		}
+
+		orderConfirm := OrderConfirmConfig{
+			Enabled:     confirmCheck.Checked,
+			MinNotional: confirmField.value(&problems),
+		}
+
+		liquidationAlert := liquidationField.value(&problems)
+		basisAlert := basisField.value(&problems)
+		newSpike := SpikeConfig{
+			ZScore:        zScoreField.value(&problems),
+			Window:        int(windowField.value(&problems)),
+			TightenStops:  tightenCheck.Checked,
+			TightenRatio:  tightenRatioField.value(&problems),
+			TightenMinute: int(tightenMinuteField.value(&problems)),
+		}
+		if newSpike.TightenRatio <= 0 || newSpike.TightenRatio >= 1 {
+			problems = append(problems, T("收紧后保留比例必须在0到1之间"))
+		}
+
+		if len(problems) > 0 {
+			dialog.ShowError(errors.New(strings.Join(problems, "\n")), w)
+			return
+		}
+
+		// 立即生效，保护的交易对变化时清除价格异动的历史和收紧状态
+		if newProtect.Symbol != ui.protectConfig().Symbol {
+			ui.spikes.Reset()
+		}
+		ui.setProtectConfig(newProtect)
+		ui.config.OrderConfirm = orderConfirm
+		ui.config.LiquidationAlert = liquidationAlert
+		ui.liquidations.SetThreshold(liquidationAlert)
+		ui.config.BasisAlert = basisAlert
+		ui.config.Spike = newSpike
+		ui.spikes.SetConfig(newSpike)
+		ui.setWatchlist(watchlist)
+		ui.config.Chart.Interval = intervalSelect.Selected
+		ui.config.Chart.Limit = limit
+		ui.intervalSelect.SetSelected(intervalSelect.Selected)
+		ui.limitSelect.SetSelected(limitSelect.Selected)
+
+		if err := ui.saveConfig(); err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		// 切换交易对会刷新全部数据，放到后台执行
+		if symbol != ui.symbol {
+			go ui.switchSymbol(symbol)
+		}
+		ui.notify(NoticeInfo, "%s", T("设置已应用"))
+	})
+	applyBtn.Importance = widget.HighImportance
+
+	tabs := container.NewAppTabs(
+		container.NewTabItem(T("交易对"), widget.NewForm(
+			widget.NewFormItem(T("当前交易对"), symbolEntry),
+			widget.NewFormItem(T("自选列表"), watchlistEntry),
+			widget.NewFormItem(T("K线周期"), intervalSelect),
+			widget.NewFormItem(T("K线数量"), limitSelect),
+			widget.NewFormItem("", confirmCheck),
+			confirmField.item(),
+		)),
+		container.NewTabItem(T("止盈止损"), widget.NewForm(
+			widget.NewFormItem(T("自动管理的交易对"), protectSymbolEntry),
+			takeProfitField.item(),
+			stopLossField.item(),
+			tickSizeField.item(),
+			armProfitField.item(),
+			keepRatioField.item(),
+		)),
+		container.NewTabItem(T("提醒"), widget.NewForm(
+			liquidationField.item(),
+			basisField.item(),
+			zScoreField.item(),
+			windowField.item(),
+			widget.NewFormItem("", tightenCheck),
+			tightenRatioField.item(),
+			tightenMinuteField.item(),
+		)),
+	)
+
+	w.SetContent(container.NewBorder(nil, applyBtn, nil, nil, tabs))
+	w.Resize(ui.scaled(480, 420))
+	w.Show()
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/adshao/go-binance/v2"
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// 用账户接口验证API密钥，并检查密钥是否开通了期货权限
+func validateAPIKey(apiKey, secretKey string) error {
+	account, err := futures.NewClient(apiKey, secretKey).NewGetAccountService().Do(context.Background())
+	if err != nil {
+		return fmt.Errorf(T("API密钥验证失败: %v"), err)
+	}
+
+	// 密钥权限接口在现货API上，查询失败时以期货账户是否可交易为准
+	permission, err := binance.NewClient(apiKey, secretKey).NewGetAPIKeyPermission().Do(context.Background())
+	if err == nil && !permission.EnableFutures {
+		return errors.New(T("API密钥未开通期货交易权限"))
+	}
+	if !account.CanTrade {
+		return errors.New(T("期货账户当前不可交易"))
+	}
+	return nil
+}
+
+// 首次运行没有配置文件时显示的设置向导，填写并验证API密钥后写入初始配置，
+// 完成后调用onDone打开主窗口
+func showSetupWizard(a fyne.App, onDone func()) {
+	w := a.NewWindow(T("初始设置"))
+
+	apiKeyEntry := widget.NewEntry()
+	apiKeyEntry.SetPlaceHolder("API Key")
+	secretEntry := widget.NewPasswordEntry()
+	secretEntry.SetPlaceHolder("Secret Key")
+	symbolEntry := widget.NewEntry()
+	symbolEntry.SetText("SOLUSDC")
+	languageSelect := widget.NewSelect([]string{"zh", "en"}, nil)
+	languageSelect.SetSelected("zh")
+	status := widget.NewLabel(T("请填写币安API密钥，密钥需要开通期货交易权限"))
+	status.Wrapping = fyne.TextWrapWord
+
+	var saveBtn *widget.Button
+	saveBtn = widget.NewButton(T("验证并保存"), func() {
+		apiKey := strings.TrimSpace(apiKeyEntry.Text)
+		secretKey := strings.TrimSpace(secretEntry.Text)
+		symbol := strings.ToUpper(strings.TrimSpace(symbolEntry.Text))
+		if apiKey == "" || secretKey == "" {
+			dialog.ShowError(errors.New(T("请填写API密钥")), w)
+			return
+		}
+
+		saveBtn.Disable()
+		status.SetText(T("正在验证API密钥..."))
+		go func() {
+			err := validateAPIKey(apiKey, secretKey)
+			if err == nil {
+				config := Config{
+					APIKey:    apiKey,
+					SecretKey: secretKey,
+					Symbol:    symbol,
+					Language:  languageSelect.Selected,
+				}
+				err = writeConfig(&config)
+			}
+			fyne.Do(func() {
+				saveBtn.Enable()
+				if err != nil {
+					status.SetText("")
+					dialog.ShowError(err, w)
+					return
+				}
+				// 先打开主窗口再关闭向导，避免没有窗口时程序退出
+				onDone()
+				w.Close()
+			})
+		}()
+	})
+	saveBtn.Importance = widget.HighImportance
+
+	w.SetContent(container.NewVBox(
+		status,
+		widget.NewForm(
+			widget.NewFormItem("API Key", apiKeyEntry),
+			widget.NewFormItem("Secret Key", secretEntry),
+			widget.NewFormItem(T("交易对"), symbolEntry),
+			widget.NewFormItem(T("语言"), languageSelect),
+		),
+		saveBtn,
+	))
+	w.Resize(fyne.NewSize(480, 260))
+	w.Show()
+}
+
+// 写入配置文件，密钥只允许当前用户读写
+func writeConfig(config *Config) error {
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return fmt.Errorf(T("序列化配置失败: %v"), err)
+	}
+	path := configPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf(T("保存配置文件失败: %v"), err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf(T("保存配置文件失败: %v"), err)
+	}
+	return nil
+}
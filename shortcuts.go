@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// 快捷键说明，单键快捷键只在输入框没有焦点时生效
+var shortcutHelp = []struct {
+	Key    string
+	Action string
+}{
+	{"B", "切换到买入做多并聚焦价格输入框"},
+	{"S", "切换到卖出做空并聚焦价格输入框"},
+	{"Esc", "取消最近的一笔挂单"},
+	{"Ctrl+K", "市价平掉当前交易对的持仓"},
+	{"F5", "刷新K线、价格、持仓和订单"},
+	{"F1", "显示快捷键说明"},
+}
+
+func (ui *TraderUI) registerShortcuts() {
+	c := ui.window.Canvas()
+	c.SetOnTypedKey(func(e *fyne.KeyEvent) {
+		switch e.Name {
+		case fyne.KeyB:
+			ui.focusOrderForm(futures.SideTypeBuy)
+		case fyne.KeyS:
+			ui.focusOrderForm(futures.SideTypeSell)
+		case fyne.KeyEscape:
+			ui.cancelLastOrder()
+		case fyne.KeyF5:
+			go ui.refreshAll()
+		case fyne.KeyF1:
+			ui.showShortcuts()
+		}
+	})
+	c.AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyK, Modifier: fyne.KeyModifierShortcutDefault}, func(fyne.Shortcut) {
+		ui.confirmClosePosition(ui.symbol)
+	})
+}
+
+// 选择下单方向并聚焦价格输入框，价格为空时填入当前价
+func (ui *TraderUI) focusOrderForm(side futures.SideType) {
+	if side == futures.SideTypeBuy {
+		ui.sideSelect.SetSelected(T("买入做多"))
+	} else {
+		ui.sideSelect.SetSelected(T("卖出做空"))
+	}
+	if ui.priceEntry.Text == "" && ui.currentPrice > 0 {
+		ui.priceEntry.SetText(fmt.Sprintf("%.2f", ui.currentPrice))
+	}
+	ui.window.Canvas().Focus(ui.priceEntry)
+}
+
+// 确认后取消当前交易对最近创建的挂单
+func (ui *TraderUI) cancelLastOrder() {
+	symbol := ui.symbol
+	orders, err := ui.client.NewListOpenOrdersService().Symbol(symbol).Do(context.Background())
+	if err != nil {
+		dialog.ShowError(fmt.Errorf(T("获取订单失败: %v"), err), ui.window)
+		return
+	}
+	if len(orders) == 0 {
+		dialog.ShowInformation(T("取消订单"), T("无挂单"), ui.window)
+		return
+	}
+
+	// 订单号递增，最大的是最近创建的
+	last := orders[0]
+	for _, order := range orders {
+		if order.OrderID > last.OrderID {
+			last = order
+		}
+	}
+	price := last.Price
+	if last.Type == futures.OrderTypeStopMarket {
+		price = last.StopPrice
+	}
+
+	message := fmt.Sprintf(T("取消最近的挂单？\n%s %s %s@%s (%s)\n订单号: %d"),
+		symbol, last.Side, last.OrigQuantity, price, last.Type, last.OrderID)
+	dialog.ShowConfirm(T("取消订单"), message, func(ok bool) {
+		if !ok {
+			return
+		}
+		_, err := ui.client.NewCancelOrderService().
+			Symbol(symbol).
+			OrderID(last.OrderID).
+			Do(context.Background())
+		if err != nil {
+			dialog.ShowError(fmt.Errorf(T("取消订单失败: %v"), err), ui.window)
+			return
+		}
+		go ui.refreshAll()
+	}, ui.window)
+}
+
+// 立即刷新K线、价格、持仓和订单
+func (ui *TraderUI) refreshAll() {
+	ui.refreshKlines()
+	err := ui.updatePrice()
+	ui.priceBanner.Report(err)
+	if err != nil {
+		fmt.Printf(T("获取价格失败: %v\n"), err)
+	}
+	err = ui.updatePositions()
+	ui.positionsBanner.Report(err)
+	if err != nil {
+		fmt.Printf(T("获取持仓失败: %v\n"), err)
+	}
+	err = ui.updateOrders()
+	ui.ordersBanner.Report(err)
+	if err != nil {
+		fmt.Printf(T("获取订单失败: %v\n"), err)
+	}
+}
+
+func (ui *TraderUI) showShortcuts() {
+	grid := container.NewGridWithColumns(2)
+	for _, s := range shortcutHelp {
+		grid.Add(widget.NewLabelWithStyle(s.Key, fyne.TextAlignLeading, fyne.TextStyle{Monospace: true, Bold: true}))
+		grid.Add(widget.NewLabel(T(s.Action)))
+	}
+	dialog.ShowCustom(T("快捷键"), T("关闭"), grid, ui.window)
+}
+
+// 显示版本和构建信息，可以复制后附在问题反馈中
+func (ui *TraderUI) showAbout() {
+	info := currentBuildInfo()
+	copyButton := widget.NewButton(T("复制"), func() {
+		ui.app.Clipboard().SetContent(info.String())
+	})
+	content := container.NewVBox(widget.NewLabel(info.String()), copyButton)
+	dialog.ShowCustom(T("关于"), T("关闭"), content, ui.window)
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 模拟平仓的原因
+const (
+	simExitStopLoss   = "stop_loss"
+	simExitTakeProfit = "take_profit"
+	simExitProtect    = "protect"
+	simExitEnd        = "end" // 价格路径结束时仍有持仓，按最后价格计算
+)
+
+var simExitLabels = map[string]string{
+	simExitStopLoss:   "止损",
+	simExitTakeProfit: "止盈",
+	simExitProtect:    "保护止盈",
+	simExitEnd:        "数据结束",
+}
+
+// 按当前止盈止损设置模拟一笔持仓的结果
+type ProtectSimulation struct {
+	Protect    ProtectConfig
+	Long       bool
+	EntryTime  time.Time
+	EntryPrice float64
+	Quantity   float64
+	StopLoss   float64 // 止损单触发价
+	TakeProfit float64 // 止盈单挂单价
+
+	ArmedTime  time.Time // 最高盈利首次达到启动盈利的时间，未启动时为零
+	ArmedPrice float64
+	MaxProfit  float64 // 持仓期间的最高盈利
+	MaxLoss    float64 // 持仓期间的最大浮亏，正数
+
+	ExitTime   time.Time
+	ExitPrice  float64
+	ExitReason string
+	PnL        float64 // 不含手续费
+	Fees       float64
+}
+
+// 扣除手续费后的盈亏
+func (s *ProtectSimulation) Net() float64 {
+	return s.PnL - s.Fees
+}
+
+// 模拟监控程序管理一笔持仓: 入场后立即挂出止损和止盈单，最高盈利达到启动盈利后
+// 回撤到保留比例以下时市价平仓。每根K线按 开盘→离开盘较近的极值→另一个极值→收盘
+// 的顺序走一遍，逐个价格检查，和实盘每次轮询按标记价检查一样。K线周期越短越接近实际，
+// 价格异动后收紧保护止盈没有模拟。fee为单边手续费率，如0.0005
+func simulateProtect(protect ProtectConfig, long bool, entry, qty float64, klines []Kline, fee float64) (*ProtectSimulation, error) {
+	if len(klines) == 0 {
+		return nil, errors.New(T("没有价格数据"))
+	}
+	if qty <= 0 {
+		return nil, errors.New(T("数量必须大于0"))
+	}
+	if entry <= 0 {
+		entry = klines[0].Open
+	}
+
+	sim := &ProtectSimulation{
+		Protect:    protect,
+		Long:       long,
+		EntryTime:  klines[0].Time,
+		EntryPrice: entry,
+		Quantity:   qty,
+	}
+	dir := 1.0
+	if long {
+		sim.StopLoss = roundToTickSize(entry-protect.StopLoss, protect.TickSize)
+		sim.TakeProfit = roundToTickSize(entry+protect.TakeProfit, protect.TickSize)
+	} else {
+		dir = -1
+		sim.StopLoss = roundToTickSize(entry+protect.StopLoss, protect.TickSize)
+		sim.TakeProfit = roundToTickSize(entry-protect.TakeProfit, protect.TickSize)
+	}
+	pnlAt := func(price float64) float64 { return (price - entry) * qty * dir }
+
+	exit := func(k Kline, price float64, reason string) {
+		sim.ExitTime = k.Time
+		sim.ExitPrice = price
+		sim.ExitReason = reason
+		sim.PnL = pnlAt(price)
+		sim.Fees = (entry + price) * qty * fee
+	}
+
+	maxProfit := math.Inf(-1)
+	for _, k := range klines {
+		path := []float64{k.Open, k.Low, k.High, k.Close}
+		if k.High-k.Open < k.Open-k.Low {
+			path[1], path[2] = k.High, k.Low
+		}
+		for _, price := range path {
+			// 止损单和止盈单在交易所，价格一到就成交，不用等轮询
+			if long && price <= sim.StopLoss || !long && price >= sim.StopLoss {
+				exit(k, sim.StopLoss, simExitStopLoss)
+				return sim, nil
+			}
+			if long && price >= sim.TakeProfit || !long && price <= sim.TakeProfit {
+				exit(k, sim.TakeProfit, simExitTakeProfit)
+				return sim, nil
+			}
+
+			pnl := pnlAt(price)
+			sim.MaxLoss = math.Max(sim.MaxLoss, -pnl)
+			maxProfit = math.Max(maxProfit, pnl)
+			sim.MaxProfit = math.Max(sim.MaxProfit, maxProfit)
+			if sim.ArmedTime.IsZero() && maxProfit >= protect.ArmProfit {
+				sim.ArmedTime, sim.ArmedPrice = k.Time, price
+			}
+			if maxProfit >= protect.ArmProfit && pnl <= maxProfit*protect.KeepRatio {
+				exit(k, price, simExitProtect)
+				return sim, nil
+			}
+		}
+	}
+	last := klines[len(klines)-1]
+	exit(last, last.Close, simExitEnd)
+	return sim, nil
+}
+
+func (s *ProtectSimulation) String() string {
+	var sb strings.Builder
+	side := T("多")
+	if !s.Long {
+		side = T("空")
+	}
+	p := s.Protect
+	fmt.Fprintf(&sb, T("%s %s %.4f @ %s，入场 %s\n"), p.Symbol, side, s.Quantity,
+		p.FormatPrice(s.EntryPrice), s.EntryTime.Format("2006-01-02 15:04"))
+	fmt.Fprintf(&sb, T("止损 %s，止盈 %s，保护止盈启动 %g 回撤 %.0f%%\n"),
+		p.FormatPrice(s.StopLoss), p.FormatPrice(s.TakeProfit), p.ArmProfit, (1-p.KeepRatio)*100)
+	if s.ArmedTime.IsZero() {
+		fmt.Fprintf(&sb, T("保护止盈未启动，最高盈利 %.2f\n"), s.MaxProfit)
+	} else {
+		fmt.Fprintf(&sb, T("保护止盈在 %s 启动，价格 %s\n"), s.ArmedTime.Format("2006-01-02 15:04"), p.FormatPrice(s.ArmedPrice))
+	}
+	fmt.Fprintf(&sb, T("最高盈利 %.2f，最大浮亏 %.2f\n"), s.MaxProfit, s.MaxLoss)
+	fmt.Fprintf(&sb, T("%s平仓: %s @ %s\n"), T(simExitLabels[s.ExitReason]),
+		s.ExitTime.Format("2006-01-02 15:04"), p.FormatPrice(s.ExitPrice))
+	fmt.Fprintf(&sb, T("盈亏 %+.2f，手续费 %.2f，净盈亏 %+.2f\n"), s.PnL, s.Fees, s.Net())
+	return sb.String()
+}
+
+// 读取价格路径CSV。可以是export导出的K线(time,open,high,low,close)，
+// 也可以是记录的价格(time,price)，每行当作一根开高低收相同的K线。
+// 时间支持RFC3339、毫秒时间戳和"2006-01-02 15:04:05"
+func readPricePath(r io.Reader) ([]Kline, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf(T("读取价格数据失败: %v"), err)
+	}
+	if len(rows) < 2 {
+		return nil, errors.New(T("没有价格数据"))
+	}
+	cols := make(map[string]int)
+	for i, name := range rows[0] {
+		cols[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := cols["time"]; !ok {
+		return nil, errors.New(T("价格数据缺少time列"))
+	}
+	_, hasPrice := cols["price"]
+	for _, name := range []string{"open", "high", "low", "close"} {
+		if _, ok := cols[name]; !ok && !hasPrice {
+			return nil, errors.New(T("价格数据需要price列，或open/high/low/close列"))
+		}
+	}
+
+	klines := make([]Kline, 0, len(rows)-1)
+	for n, row := range rows[1:] {
+		field := func(name string) (float64, error) {
+			v, err := strconv.ParseFloat(strings.TrimSpace(row[cols[name]]), 64)
+			if err != nil {
+				return 0, fmt.Errorf(T("第%d行的%s格式错误: %s"), n+2, name, row[cols[name]])
+			}
+			return v, nil
+		}
+		t, err := parsePathTime(row[cols["time"]])
+		if err != nil {
+			return nil, fmt.Errorf(T("第%d行的time格式错误: %s"), n+2, row[cols["time"]])
+		}
+		k := Kline{Time: t}
+		if hasPrice {
+			if k.Close, err = field("price"); err != nil {
+				return nil, err
+			}
+			k.Open, k.High, k.Low = k.Close, k.Close, k.Close
+		} else {
+			for _, f := range []struct {
+				name string
+				v    *float64
+			}{{"open", &k.Open}, {"high", &k.High}, {"low", &k.Low}, {"close", &k.Close}} {
+				if *f.v, err = field(f.name); err != nil {
+					return nil, err
+				}
+			}
+		}
+		klines = append(klines, k)
+	}
+	return klines, nil
+}
+
+func parsePathTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.UnixMilli(ms), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation(time.DateTime, s, time.Local)
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// 提示音事件
+type SoundEvent string
+
+const (
+	SoundFill       SoundEvent = "fill"       // 持仓变化（成交）
+	SoundStop       SoundEvent = "stop"       // 止损单触发
+	SoundAlert      SoundEvent = "alert"      // 价格等提醒触发
+	SoundDisconnect SoundEvent = "disconnect" // 与交易所的连接断开
+)
+
+// 事件的显示名称和提示音的音调(Hz)，每个音调播放120毫秒
+var soundEvents = []struct {
+	Event SoundEvent
+	Label string
+	Tones []float64
+}{
+	{SoundFill, "持仓成交", []float64{880, 1320}},
+	{SoundStop, "止损触发", []float64{660, 440, 330}},
+	{SoundAlert, "价格提醒", []float64{988, 0, 988}},
+	{SoundDisconnect, "连接断开", []float64{300, 0, 300, 0, 300}},
+}
+
+// 提示音设置，默认关闭
+type SoundConfig struct {
+	Enabled bool         `json:"enabled"`
+	Volume  float64      `json:"volume"`          // 音量0~1，默认0.6
+	Muted   []SoundEvent `json:"muted,omitempty"` // 不播放提示音的事件
+}
+
+func (c *SoundConfig) applyDefaults() {
+	if c.Volume <= 0 || c.Volume > 1 {
+		c.Volume = 0.6
+	}
+}
+
+func (c *SoundConfig) EventEnabled(event SoundEvent) bool {
+	if !c.Enabled {
+		return false
+	}
+	for _, e := range c.Muted {
+		if e == event {
+			return false
+		}
+	}
+	return true
+}
+
+// 提示音播放。没有引入音频库，按音量生成WAV文件后调用系统自带的播放命令
+type SoundPlayer struct {
+	mu      sync.Mutex
+	config  SoundConfig
+	playing bool
+}
+
+func NewSoundPlayer(config SoundConfig) *SoundPlayer {
+	config.applyDefaults()
+	return &SoundPlayer{config: config}
+}
+
+func (p *SoundPlayer) SetConfig(config SoundConfig) {
+	config.applyDefaults()
+	p.mu.Lock()
+	p.config = config
+	p.mu.Unlock()
+}
+
+// 播放事件的提示音，事件未启用或正在播放其他提示音时忽略
+func (p *SoundPlayer) Play(event SoundEvent) {
+	p.mu.Lock()
+	if !p.config.EventEnabled(event) || p.playing {
+		p.mu.Unlock()
+		return
+	}
+	p.playing = true
+	volume := p.config.Volume
+	p.mu.Unlock()
+
+	go func() {
+		defer func() {
+			p.mu.Lock()
+			p.playing = false
+			p.mu.Unlock()
+		}()
+		if err := playSound(event, volume); err != nil {
+			fmt.Printf(T("播放提示音失败: %v\n"), err)
+		}
+	}()
+}
+
+func playSound(event SoundEvent, volume float64) error {
+	var tones []float64
+	for _, e := range soundEvents {
+		if e.Event == event {
+			tones = e.Tones
+		}
+	}
+
+	path := filepath.Join(os.TempDir(), "trader_sound_"+string(event)+".wav")
+	if err := os.WriteFile(path, toneWAV(tones, volume), 0644); err != nil {
+		return err
+	}
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("afplay", path)
+	case "windows":
+		cmd = exec.Command("powershell", "-NoProfile", "-Command",
+			fmt.Sprintf("(New-Object Media.SoundPlayer '%s').PlaySync()", path))
+	default:
+		player := "paplay"
+		if _, err := exec.LookPath(player); err != nil {
+			player = "aplay"
+		}
+		cmd = exec.Command(player, path)
+	}
+	return cmd.Run()
+}
+
+// 生成16位单声道WAV，音调为0时是静音，每个音调首尾淡入淡出避免爆音
+func toneWAV(tones []float64, volume float64) []byte {
+	const (
+		sampleRate = 22050
+		toneLength = sampleRate * 120 / 1000
+		fade       = sampleRate * 10 / 1000
+	)
+
+	samples := make([]int16, 0, len(tones)*toneLength)
+	for _, freq := range tones {
+		for i := 0; i < toneLength; i++ {
+			if freq == 0 {
+				samples = append(samples, 0)
+				continue
+			}
+			amp := volume * 0.8
+			if i < fade {
+				amp *= float64(i) / fade
+			} else if i > toneLength-fade {
+				amp *= float64(toneLength-i) / fade
+			}
+			v := amp * math.Sin(2*math.Pi*freq*float64(i)/sampleRate)
+			samples = append(samples, int16(v*math.MaxInt16))
+		}
+	}
+
+	var buf bytes.Buffer
+	dataSize := uint32(len(samples) * 2)
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, 36+dataSize)
+	buf.WriteString("WAVEfmt ")
+	binary.Write(&buf, binary.LittleEndian, struct {
+		Size          uint32
+		Format        uint16
+		Channels      uint16
+		SampleRate    uint32
+		ByteRate      uint32
+		BlockAlign    uint16
+		BitsPerSample uint16
+	}{
+		Size:          16,             // fmt块大小
+		Format:        1,              // PCM
+		Channels:      1,              // 单声道
+		SampleRate:    sampleRate,     // 采样率
+		ByteRate:      sampleRate * 2, // 每秒字节数
+		BlockAlign:    2,              // 每个采样的字节数
+		BitsPerSample: 16,             // 位深
+	})
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, dataSize)
+	binary.Write(&buf, binary.LittleEndian, samples)
+	return buf.Bytes()
+}
+
+// 与上次刷新的持仓比较，持仓数量变化时播放成交提示音。
+// 有止损单的持仓减少、止损单消失且上次标记价格离止损更近时视为止损触发
+func (ui *TraderUI) playPositionSounds(rows []PositionRow) {
+	current := make(map[string]PositionRow, len(rows))
+	for _, row := range rows {
+		current[row.Symbol] = row
+	}
+	last := ui.lastPositions
+	ui.lastPositions = current
+	if last == nil {
+		return
+	}
+
+	signed := func(row PositionRow, ok bool) float64 {
+		if !ok {
+			return 0
+		}
+		if row.Long {
+			return row.Size
+		}
+		return -row.Size
+	}
+	filled := false
+	for symbol, prev := range last {
+		row, ok := current[symbol]
+		nearStop := prev.TakeProfit == 0 || prev.distance(prev.StopLoss) < prev.distance(prev.TakeProfit)
+		if prev.StopLoss > 0 && nearStop && (!ok || row.Size < prev.Size && row.StopLoss == 0) {
+			ui.sound.Play(SoundStop)
+			return
+		}
+		if signed(row, ok) != signed(prev, true) {
+			filled = true
+		}
+	}
+	for symbol := range current {
+		if _, ok := last[symbol]; !ok {
+			filled = true
+		}
+	}
+	if filled {
+		ui.sound.Play(SoundFill)
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// 价格异动检测设置
+type SpikeConfig struct {
+	ZScore        float64 `json:"zscore"`         // 收益率偏离均值多少个标准差视为异动，0表示不检测
+	Window        int     `json:"window"`         // 计算均值和标准差的收益率数量
+	TightenStops  bool    `json:"tighten_stops"`  // 异动后自动收紧保护止盈
+	TightenRatio  float64 `json:"tighten_ratio"`  // 收紧后保留的最高盈利比例，默认0.75
+	TightenMinute int     `json:"tighten_minute"` // 收紧持续的分钟数，默认15
+}
+
+func (c *SpikeConfig) applyDefaults() {
+	if c.Window <= 0 {
+		c.Window = 100
+	}
+	if c.TightenRatio <= 0 || c.TightenRatio >= 1 {
+		c.TightenRatio = 0.75
+	}
+	if c.TightenMinute <= 0 {
+		c.TightenMinute = 15
+	}
+}
+
+// 一次价格异动
+type Spike struct {
+	Source string // tick 或 K线周期
+	Return float64
+	ZScore float64
+	Time   time.Time
+}
+
+func (s *Spike) String() string {
+	return fmt.Sprintf(T("%s %s异动: %+.2f%% (z=%.1f)"), s.Time.Format("15:04:05"), s.Source, s.Return*100, s.ZScore)
+}
+
+// 价格异动检测，对逐笔价格和最新K线的收益率计算z-score
+type SpikeDetector struct {
+	config SpikeConfig
+
+	mu          sync.Mutex
+	lastPrice   float64
+	returns     []float64
+	lastCandle  time.Time
+	tightenedAt time.Time
+
+	// 检测到异动时回调
+	OnSpike func(spike *Spike)
+}
+
+func NewSpikeDetector(config SpikeConfig) *SpikeDetector {
+	config.applyDefaults()
+	return &SpikeDetector{config: config}
+}
+
+// 修改检测设置，设置窗口应用后立即生效
+func (d *SpikeDetector) SetConfig(config SpikeConfig) {
+	config.applyDefaults()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.config = config
+	if len(d.returns) > config.Window {
+		d.returns = d.returns[len(d.returns)-config.Window:]
+	}
+}
+
+// 用最新价格检测逐笔异动
+func (d *SpikeDetector) Observe(price float64) *Spike {
+	if price <= 0 {
+		return nil
+	}
+
+	d.mu.Lock()
+	if d.config.ZScore <= 0 {
+		d.mu.Unlock()
+		return nil
+	}
+	last := d.lastPrice
+	d.lastPrice = price
+	if last <= 0 || last == price {
+		// 价格未变化的tick不计入，避免低波动时标准差过小
+		d.mu.Unlock()
+		return nil
+	}
+
+	r := math.Log(price / last)
+	var spike *Spike
+	if z, ok := zScore(d.returns, r); ok && math.Abs(z) >= d.config.ZScore {
+		spike = &Spike{Source: "tick", Return: r, ZScore: z, Time: time.Now()}
+		d.tightenedAt = spike.Time
+	}
+	d.returns = append(d.returns, r)
+	if len(d.returns) > d.config.Window {
+		d.returns = d.returns[len(d.returns)-d.config.Window:]
+	}
+	d.mu.Unlock()
+
+	d.notify(spike)
+	return spike
+}
+
+// 保护的交易对变化时清除价格历史和收紧状态，之前交易对的异动不再收紧新交易对
+func (d *SpikeDetector) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.lastPrice = 0
+	d.returns = nil
+	d.lastCandle = time.Time{}
+	d.tightenedAt = time.Time{}
+}
+
+// 检测最新一根K线相对之前K线的异动，同一根K线只提醒一次
+func (d *SpikeDetector) CheckCandles(klines []Kline, interval string) *Spike {
+	d.mu.Lock()
+	config := d.config
+	d.mu.Unlock()
+	if config.ZScore <= 0 || len(klines) < 3 {
+		return nil
+	}
+
+	returns := logReturns(klines)
+	if len(returns) < 2 {
+		return nil
+	}
+	history := returns[:len(returns)-1]
+	if len(history) > config.Window {
+		history = history[len(history)-config.Window:]
+	}
+	r := returns[len(returns)-1]
+	z, ok := zScore(history, r)
+	if !ok || math.Abs(z) < config.ZScore {
+		return nil
+	}
+
+	// 切换周期时显示的历史K线不提醒
+	last := klines[len(klines)-1].Time
+	if step := intervalDuration(interval); step > 0 && time.Since(last) > 2*step {
+		return nil
+	}
+
+	d.mu.Lock()
+	if last.Equal(d.lastCandle) {
+		d.mu.Unlock()
+		return nil
+	}
+	d.lastCandle = last
+	spike := &Spike{Source: interval, Return: r, ZScore: z, Time: time.Now()}
+	d.tightenedAt = spike.Time
+	d.mu.Unlock()
+
+	d.notify(spike)
+	return spike
+}
+
+func (d *SpikeDetector) notify(spike *Spike) {
+	if spike != nil && d.OnSpike != nil {
+		d.OnSpike(spike)
+	}
+}
+
+// 保护止盈平仓线：曾经盈利超过触发线后，盈利回落到最高盈利的该比例时平仓。
+// 异动后的一段时间内收紧为配置的比例
+func (d *SpikeDetector) ProtectRatio(keepRatio float64) float64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.config.TightenStops && !d.tightenedAt.IsZero() &&
+		time.Since(d.tightenedAt) < time.Duration(d.config.TightenMinute)*time.Minute {
+		return d.config.TightenRatio
+	}
+	return keepRatio
+}
+
+// 计算r相对样本的z-score，样本太少或标准差为0时返回false
+func zScore(samples []float64, r float64) (float64, bool) {
+	if len(samples) < 20 {
+		return 0, false
+	}
+
+	var mean float64
+	for _, s := range samples {
+		mean += s
+	}
+	mean /= float64(len(samples))
+
+	var variance float64
+	for _, s := range samples {
+		variance += (s - mean) * (s - mean)
+	}
+	std := math.Sqrt(variance / float64(len(samples)-1))
+	if std == 0 {
+		return 0, false
+	}
+	return (r - mean) / std, true
+}
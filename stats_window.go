@@ -0,0 +1,321 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 每种周期统计的时间范围
+var statsRange = map[string]time.Duration{
+	statsDay:   30 * 24 * time.Hour,
+	statsWeek:  26 * 7 * 24 * time.Hour,
+	statsMonth: 365 * 24 * time.Hour,
+}
+
+// 账户统计窗口，数据来自本地交易数据库
+func (ui *TraderUI) showStatsWindow() {
+	w := ui.app.NewWindow(T("账户统计"))
+
+	tabs := container.NewAppTabs(
+		container.NewTabItem(T("盈亏统计"), ui.pnlStatsTab(w)),
+		container.NewTabItem(T("权益曲线"), ui.equityTab()),
+		container.NewTabItem(T("交易历史"), ui.tradeHistoryTab(w)),
+	)
+	w.SetContent(tabs)
+	w.Resize(ui.scaled(760, 560))
+	w.Show()
+}
+
+// 按日/周/月汇总已实现盈亏、手续费、资金费和成交笔数
+func (ui *TraderUI) pnlStatsTab(w fyne.Window) fyne.CanvasObject {
+	pnlChart := NewBarChart(func(v float64) string { return fmt.Sprintf("%.2f", v) })
+	tradesChart := NewBarChart(func(v float64) string { return strconv.Itoa(int(v)) })
+	table := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+
+	period := statsDay
+	render := func() {
+		stats := summarizePnL(ui.tradeDB.Incomes(periodStart(time.Now().Add(-statsRange[period]), period)), period)
+		var labels []string
+		var pnl, trades []float64
+		for _, s := range stats {
+			labels = append(labels, s.Label(period))
+			pnl = append(pnl, s.Net())
+			trades = append(trades, float64(s.Trades))
+		}
+		pnlChart.SetData(T("净盈亏"), labels, pnl)
+		tradesChart.SetData(T("成交笔数"), labels, trades)
+		table.SetText(formatPnLStats(stats, period))
+	}
+
+	var periodLabels []string
+	for _, p := range statsPeriods {
+		periodLabels = append(periodLabels, T(p.Label))
+	}
+	periodSelect := widget.NewSelect(periodLabels, func(label string) {
+		for _, p := range statsPeriods {
+			if T(p.Label) == label {
+				period = p.Period
+			}
+		}
+		render()
+	})
+	periodSelect.SetSelected(T(statsPeriods[0].Label))
+
+	status := widget.NewLabel("")
+	var syncBtn *widget.Button
+	syncBtn = widget.NewButton(T("同步"), func() {
+		syncBtn.Disable()
+		status.SetText(T("同步中..."))
+		go func() {
+			added, err := ui.tradeDB.Sync()
+			fyne.Do(func() {
+				syncBtn.Enable()
+				if err != nil {
+					status.SetText("")
+					dialog.ShowError(err, w)
+					return
+				}
+				status.SetText(fmt.Sprintf(T("%s 新增%d条流水"), time.Now().Format("15:04:05"), added))
+				render()
+			})
+		}()
+	})
+
+	return container.NewBorder(
+		container.NewHBox(periodSelect, syncBtn, status),
+		nil, nil, nil,
+		container.NewVSplit(
+			container.NewGridWithRows(2, pnlChart, tradesChart),
+			container.NewVScroll(table),
+		),
+	)
+}
+
+// 权益曲线的时间范围，0表示全部
+var equityRanges = []struct {
+	Label string
+	Range time.Duration
+}{
+	{"最近7天", 7 * 24 * time.Hour},
+	{"最近30天", 30 * 24 * time.Hour},
+	{"最近90天", 90 * 24 * time.Hour},
+	{"全部", 0},
+}
+
+// 权益和钱包余额曲线，两者之差是未实现盈亏
+func (ui *TraderUI) equityTab() fyne.CanvasObject {
+	chart := NewLineChart(func(v float64) string { return fmt.Sprintf("%.2f", v) })
+	summary := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+
+	var span time.Duration
+	render := func() {
+		var since time.Time
+		if span > 0 {
+			since = time.Now().Add(-span)
+		}
+		snapshots := ui.tradeDB.EquitySnapshots(since)
+
+		var times []time.Time
+		var equity, balance []float64
+		for _, s := range snapshots {
+			times = append(times, s.Time)
+			equity = append(equity, s.Equity())
+			balance = append(balance, s.Balance)
+		}
+		chart.SetData(T("账户权益"), times, []LineSeries{
+			{Label: T("权益"), Color: color.NRGBA{R: 52, G: 152, B: 219, A: 255}, Values: equity},
+			{Label: T("钱包余额"), Color: color.NRGBA{R: 149, G: 165, B: 166, A: 255}, Values: balance},
+		})
+		summary.SetText(equitySummary(snapshots))
+	}
+
+	var rangeLabels []string
+	for _, r := range equityRanges {
+		rangeLabels = append(rangeLabels, T(r.Label))
+	}
+	rangeSelect := widget.NewSelect(rangeLabels, func(label string) {
+		for _, r := range equityRanges {
+			if T(r.Label) == label {
+				span = r.Range
+			}
+		}
+		render()
+	})
+	rangeSelect.SetSelected(T(equityRanges[1].Label))
+
+	refreshBtn := widget.NewButton(T("刷新"), render)
+
+	return container.NewBorder(
+		container.NewHBox(rangeSelect, refreshBtn),
+		summary, nil, nil,
+		chart,
+	)
+}
+
+// 区间内的权益变化和最大回撤
+func equitySummary(snapshots []EquitySnapshot) string {
+	if len(snapshots) == 0 {
+		return T("暂无权益快照，界面运行时每5分钟记录一次")
+	}
+
+	first, last := snapshots[0].Equity(), snapshots[len(snapshots)-1].Equity()
+	peak, maxDrawdown := first, 0.0
+	for _, s := range snapshots {
+		equity := s.Equity()
+		if equity > peak {
+			peak = equity
+		}
+		if peak > 0 && (peak-equity)/peak*100 > maxDrawdown {
+			maxDrawdown = (peak - equity) / peak * 100
+		}
+	}
+	change := 0.0
+	if first > 0 {
+		change = (last - first) / first * 100
+	}
+	return fmt.Sprintf(T("起始权益: %.2f  当前权益: %.2f  变化: %+.2f (%+.2f%%)  最大回撤: %.2f%%"),
+		first, last, last-first, change, maxDrawdown)
+}
+
+// 已平仓交易列表，按交易对和平仓日期筛选，最近的在前
+func (ui *TraderUI) tradeHistoryTab(w fyne.Window) fyne.CanvasObject {
+	var trades []ClosedTrade
+	list := widget.NewList(
+		func() int { return len(trades) },
+		func() fyne.CanvasObject {
+			return widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			t := trades[len(trades)-1-id]
+			text := t.String()
+			if t.Snapshot != "" {
+				text += T(" [截图]")
+			}
+			if t.Note != nil {
+				text += " | " + t.Note.Summary()
+			}
+			o.(*widget.Label).SetText(text)
+		},
+	)
+	summary := widget.NewLabel("")
+
+	// 交易对为空时显示全部
+	symbolEntry := widget.NewSelectEntry(append([]string{""}, ui.config.Watchlist...))
+	symbolEntry.SetPlaceHolder(T("全部交易对"))
+	fromEntry := widget.NewEntry()
+	fromEntry.SetText(time.Now().AddDate(0, 0, -30).Format("2006-01-02"))
+	toEntry := widget.NewEntry()
+	toEntry.SetPlaceHolder(T("今天"))
+	// 标签为空时不按标签筛选
+	tagEntry := widget.NewEntry()
+	tagEntry.SetPlaceHolder(T("全部标签"))
+
+	query := func() {
+		from, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(fromEntry.Text), time.Local)
+		if err != nil {
+			dialog.ShowError(errors.New(T("日期格式错误，应为YYYY-MM-DD")), w)
+			return
+		}
+		var until time.Time
+		if text := strings.TrimSpace(toEntry.Text); text != "" {
+			to, err := time.ParseInLocation("2006-01-02", text, time.Local)
+			if err != nil {
+				dialog.ShowError(errors.New(T("日期格式错误，应为YYYY-MM-DD")), w)
+				return
+			}
+			until = to.AddDate(0, 0, 1)
+		}
+
+		symbol := strings.ToUpper(strings.TrimSpace(symbolEntry.Text))
+		trades = ui.tradeDB.ClosedTrades(symbol, from, until)
+		if tag := strings.TrimLeft(strings.TrimSpace(tagEntry.Text), "#"); tag != "" {
+			var tagged []ClosedTrade
+			for _, t := range trades {
+				if t.Note != nil && t.Note.HasTag(tag) {
+					tagged = append(tagged, t)
+				}
+			}
+			trades = tagged
+		}
+		list.Refresh()
+		summary.SetText(closedTradeSummary(trades))
+	}
+	symbolEntry.OnSubmitted = func(string) { query() }
+	tagEntry.OnSubmitted = func(string) { query() }
+	query()
+
+	// 点击交易编辑笔记，有平仓截图时可以查看
+	list.OnSelected = func(id widget.ListItemID) {
+		list.Unselect(id)
+		t := trades[len(trades)-1-id]
+		var note TradeNote
+		if t.Note != nil {
+			note = *t.Note
+		}
+		title := fmt.Sprintf(T("交易笔记 %s %s %s"), t.Symbol, t.Direction(), t.ExitTime.Format("01-02 15:04"))
+		var extra fyne.CanvasObject
+		if t.Snapshot != "" {
+			extra = widget.NewButton(T("查看平仓截图"), func() { ui.showTradeSnapshot(t) })
+		}
+		ui.editNote(w, title, note, extra, func(text string, tags []string) error {
+			return ui.tradeDB.SaveTradeNote(t, text, tags)
+		}, query)
+	}
+
+	return container.NewBorder(
+		container.NewHBox(
+			widget.NewLabel(T("交易对")), symbolEntry,
+			widget.NewLabel(T("从")), fromEntry,
+			widget.NewLabel(T("到")), toEntry,
+			widget.NewLabel(T("标签")), tagEntry,
+			widget.NewButton(T("查询"), query),
+		),
+		summary, nil, nil,
+		list,
+	)
+}
+
+// 编辑交易笔记的对话框，extra不为nil时显示在输入框下方。保存成功后调用onSaved
+func (ui *TraderUI) editNote(w fyne.Window, title string, note TradeNote, extra fyne.CanvasObject, save func(text string, tags []string) error, onSaved func()) {
+	textEntry := widget.NewMultiLineEntry()
+	textEntry.SetText(note.Text)
+	textEntry.SetMinRowsVisible(6)
+	textEntry.SetPlaceHolder(T("入场理由、执行情况、复盘..."))
+	tagsEntry := widget.NewEntry()
+	tagsEntry.SetText(strings.Join(note.Tags, ", "))
+	tagsEntry.SetPlaceHolder(T("用逗号或空格分隔，如 突破, 逆势"))
+
+	content := container.NewVBox(
+		widget.NewForm(
+			widget.NewFormItem(T("笔记"), textEntry),
+			widget.NewFormItem(T("标签"), tagsEntry),
+		),
+	)
+	if extra != nil {
+		content.Add(extra)
+	}
+
+	d := dialog.NewCustomConfirm(title, T("保存"), T("取消"), content, func(ok bool) {
+		if !ok {
+			return
+		}
+		if err := save(strings.TrimSpace(textEntry.Text), parseTags(tagsEntry.Text)); err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		if onSaved != nil {
+			onSaved()
+		}
+	}, w)
+	d.Resize(ui.scaled(480, 360))
+	d.Show()
+}
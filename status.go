@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 超过该时间没有刷新数据时标记为过期
+const staleUpdateTime = 10 * time.Second
+
+// 窗口底部的状态栏：交易所连接、数据流、数据更新时间、延迟、请求权重和自动化状态
+type StatusBar struct {
+	exchange   *widget.Label
+	stream     *widget.Label
+	updated    *widget.Label
+	latency    *widget.Label
+	weight     *widget.Label
+	automation *widget.Label
+}
+
+func newStatusLabel() *widget.Label {
+	return widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+}
+
+func NewStatusBar() *StatusBar {
+	return &StatusBar{
+		exchange:   newStatusLabel(),
+		stream:     newStatusLabel(),
+		updated:    newStatusLabel(),
+		latency:    newStatusLabel(),
+		weight:     newStatusLabel(),
+		automation: newStatusLabel(),
+	}
+}
+
+func (s *StatusBar) Content() fyne.CanvasObject {
+	return container.NewHBox(s.exchange, s.stream, s.updated, s.latency, s.weight, s.automation)
+}
+
+func setStatus(label *widget.Label, text string, importance widget.Importance) {
+	label.Importance = importance
+	label.SetText(text)
+}
+
+// 刷新状态栏，每秒调用一次
+func (ui *TraderUI) updateStatusBar() {
+	latency, weight := ui.apiMonitor.Stats()
+	last := ui.lastUpdate.Load()
+	age := time.Since(time.UnixMilli(last))
+	disconnected := ui.disconnected.Load()
+	streamConnected := ui.liquidations.Connected()
+	paused := ui.paused.Load()
+
+	s := ui.statusBar
+	fyne.Do(func() {
+		if disconnected {
+			setStatus(s.exchange, T("交易所: 已断开"), widget.DangerImportance)
+		} else {
+			setStatus(s.exchange, T("交易所: 已连接"), widget.SuccessImportance)
+		}
+
+		if streamConnected {
+			setStatus(s.stream, T("数据流: 正常"), widget.SuccessImportance)
+		} else {
+			setStatus(s.stream, T("数据流: 断开"), widget.DangerImportance)
+		}
+
+		switch {
+		case last == 0:
+			setStatus(s.updated, T("更新: -"), widget.MediumImportance)
+		case age > staleUpdateTime:
+			setStatus(s.updated, fmt.Sprintf(T("更新: %d秒前"), int(age.Seconds())), widget.WarningImportance)
+		default:
+			setStatus(s.updated, fmt.Sprintf(T("更新: %d秒前"), int(age.Seconds())), widget.MediumImportance)
+		}
+
+		setStatus(s.latency, fmt.Sprintf(T("延迟: %dms"), latency.Milliseconds()), widget.MediumImportance)
+
+		importance := widget.MediumImportance
+		if weight > weightLimit*8/10 {
+			importance = widget.WarningImportance
+		}
+		setStatus(s.weight, fmt.Sprintf(T("权重: %d/%d"), weight, weightLimit), importance)
+
+		if paused {
+			setStatus(s.automation, T("自动化: 已暂停"), widget.WarningImportance)
+		} else {
+			setStatus(s.automation, T("自动化: 运行中"), widget.SuccessImportance)
+		}
+	})
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+func init() {
+	RegisterStrategy("ema_cross", newEMACrossStrategy)
+}
+
+// EMA交叉策略：快线上穿慢线做多，下穿做空，止损放在ATR的若干倍之外
+//
+// 参数: fast 快线周期(默认9)，slow 慢线周期(默认21)，atr ATR周期(默认14)，
+// stop 止损ATR倍数(默认2)，target 止盈ATR倍数(默认0，不设置止盈)
+type emaCrossStrategy struct {
+	symbol   string
+	interval string
+	fast     int
+	slow     int
+	atr      int
+	stop     float64
+	target   float64
+}
+
+func newEMACrossStrategy(config StrategyConfig) (Strategy, error) {
+	param := func(name string, def float64) float64 {
+		if v, ok := config.Params[name]; ok {
+			return v
+		}
+		return def
+	}
+
+	s := &emaCrossStrategy{
+		symbol:   config.Symbol,
+		interval: config.Interval,
+		fast:     int(param("fast", 9)),
+		slow:     int(param("slow", 21)),
+		atr:      int(param("atr", 14)),
+		stop:     param("stop", 2),
+		target:   param("target", 0),
+	}
+	if s.symbol == "" {
+		s.symbol = "SOLUSDC"
+	}
+	if s.interval == "" {
+		s.interval = "15m"
+	}
+	if s.fast <= 0 || s.slow <= s.fast {
+		return nil, fmt.Errorf(T("EMA周期设置错误: fast=%d slow=%d"), s.fast, s.slow)
+	}
+	if s.atr <= 0 || s.stop <= 0 {
+		return nil, fmt.Errorf(T("ATR止损设置错误: atr=%d stop=%.2f"), s.atr, s.stop)
+	}
+	return s, nil
+}
+
+func (s *emaCrossStrategy) Name() string     { return "ema_cross" }
+func (s *emaCrossStrategy) Symbol() string   { return s.symbol }
+func (s *emaCrossStrategy) Interval() string { return s.interval }
+
+func (s *emaCrossStrategy) Next(klines []Kline) *Signal {
+	n := len(klines)
+	if n < s.slow+1 || n < s.atr+1 {
+		return nil
+	}
+
+	fast := calculateEMA(klines, s.fast)
+	slow := calculateEMA(klines, s.slow)
+	prevDiff := fast[n-2] - slow[n-2]
+	diff := fast[n-1] - slow[n-1]
+
+	var side futures.SideType
+	var reason string
+	switch {
+	case prevDiff <= 0 && diff > 0:
+		side = futures.SideTypeBuy
+		reason = fmt.Sprintf(T("EMA%d上穿EMA%d"), s.fast, s.slow)
+	case prevDiff >= 0 && diff < 0:
+		side = futures.SideTypeSell
+		reason = fmt.Sprintf(T("EMA%d下穿EMA%d"), s.fast, s.slow)
+	default:
+		return nil
+	}
+
+	price := klines[n-1].Close
+	atr := calculateATR(klines, s.atr)
+	signal := &Signal{
+		Strategy: s.Name(),
+		Symbol:   s.symbol,
+		Side:     side,
+		Price:    price,
+		Reason:   reason,
+		Time:     klines[n-1].Time,
+	}
+	if side == futures.SideTypeBuy {
+		signal.StopLoss = price - atr*s.stop
+		if s.target > 0 {
+			signal.TakeProfit = price + atr*s.target
+		}
+	} else {
+		signal.StopLoss = price + atr*s.stop
+		if s.target > 0 {
+			signal.TakeProfit = price - atr*s.target
+		}
+	}
+	return signal
+}
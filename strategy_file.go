@@ -0,0 +1,386 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"gopkg.in/yaml.v3"
+)
+
+// 声明式策略文件，不用重新编译就能定义策略，例如:
+//
+//	name: rsi_pullback
+//	symbol: SOLUSDC
+//	interval: 15m
+//	entry:
+//	  long:  ["close > ema(200)", "rsi(14) < 30"]
+//	  short: ["close < ema(200)", "rsi(14) > 70"]
+//	exits:
+//	  stop_loss: atr(14) * 2   # 止损距离
+//	  take_profit: atr(14) * 3 # 止盈距离，不填时不设置止盈
+//	sizing:
+//	  risk_per_trade: 10       # 每笔交易止损时亏损的金额，或用quantity指定固定数量
+//	live: false
+//
+// 同一方向的条件全部满足时发出信号。条件是 表达式 比较符 表达式，比较符为
+// < <= > >= crosses_above crosses_below，后两个表示上一根K线不满足、这一根满足。
+// 表达式为数字、open/high/low/close/volume，或ema(n)/sma(n)/rsi(n)/atr(n)，
+// 可以再乘除加减一个数字，如 ema(50) * 1.01
+type StrategyFile struct {
+	Name     string `yaml:"name"`
+	Symbol   string `yaml:"symbol"`
+	Interval string `yaml:"interval"`
+	Entry    struct {
+		Long  []string `yaml:"long"`
+		Short []string `yaml:"short"`
+	} `yaml:"entry"`
+	Exits struct {
+		StopLoss   string `yaml:"stop_loss"`
+		TakeProfit string `yaml:"take_profit"`
+	} `yaml:"exits"`
+	Sizing struct {
+		RiskPerTrade  float64 `yaml:"risk_per_trade"`
+		Quantity      float64 `yaml:"quantity"`
+		MaxNotional   float64 `yaml:"max_notional"`
+		MaxVolatility float64 `yaml:"max_volatility"`
+	} `yaml:"sizing"`
+	Live bool `yaml:"live"`
+}
+
+// 策略名以.yaml或.yml结尾时按策略文件加载
+func isStrategyFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+func loadStrategyFile(path string) (*StrategyFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf(T("读取策略文件失败: %v"), err)
+	}
+	var f StrategyFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf(T("解析策略文件失败: %v"), err)
+	}
+	if f.Name == "" {
+		f.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if f.Symbol == "" {
+		f.Symbol = "SOLUSDC"
+	}
+	f.Symbol = strings.ToUpper(f.Symbol)
+	if f.Interval == "" {
+		f.Interval = "15m"
+	}
+	return &f, nil
+}
+
+// 策略流水线的风控和执行设置，策略本身由NewStrategy按文件创建
+func (f *StrategyFile) PipelineConfig(path string) PipelineConfig {
+	return PipelineConfig{
+		Strategies:    []StrategyConfig{{Name: path, Symbol: f.Symbol, Interval: f.Interval}},
+		RiskPerTrade:  f.Sizing.RiskPerTrade,
+		MaxVolatility: f.Sizing.MaxVolatility,
+		MaxNotional:   f.Sizing.MaxNotional,
+		Live:          f.Live,
+	}
+}
+
+// 按策略文件创建策略，config中的交易对和周期覆盖文件中的设置
+func newFileStrategy(config StrategyConfig) (Strategy, error) {
+	f, err := loadStrategyFile(config.Name)
+	if err != nil {
+		return nil, err
+	}
+	s := &fileStrategy{name: f.Name, symbol: f.Symbol, interval: f.Interval, quantity: f.Sizing.Quantity}
+	if config.Symbol != "" {
+		s.symbol = strings.ToUpper(config.Symbol)
+	}
+	if config.Interval != "" {
+		s.interval = config.Interval
+	}
+
+	if len(f.Entry.Long) == 0 && len(f.Entry.Short) == 0 {
+		return nil, errors.New(T("策略文件没有入场条件"))
+	}
+	for _, c := range f.Entry.Long {
+		cond, err := parseCondition(c)
+		if err != nil {
+			return nil, err
+		}
+		s.long = append(s.long, cond)
+	}
+	for _, c := range f.Entry.Short {
+		cond, err := parseCondition(c)
+		if err != nil {
+			return nil, err
+		}
+		s.short = append(s.short, cond)
+	}
+	if f.Exits.StopLoss == "" {
+		return nil, errors.New(T("策略文件没有设置止损(exits.stop_loss)"))
+	}
+	if s.stop, err = parseExpr(f.Exits.StopLoss); err != nil {
+		return nil, err
+	}
+	if f.Exits.TakeProfit != "" {
+		if s.target, err = parseExpr(f.Exits.TakeProfit); err != nil {
+			return nil, err
+		}
+	}
+	if f.Sizing.RiskPerTrade <= 0 && f.Sizing.Quantity <= 0 {
+		return nil, errors.New(T("策略文件需要设置sizing.risk_per_trade或sizing.quantity"))
+	}
+
+	for _, e := range s.exprs() {
+		s.bars = max(s.bars, e.bars())
+	}
+	if s.bars >= pipelineKlines {
+		return nil, fmt.Errorf(T("指标周期太长，实盘只获取最近%d根K线"), pipelineKlines)
+	}
+	return s, nil
+}
+
+// 从策略文件加载的策略
+type fileStrategy struct {
+	name     string
+	symbol   string
+	interval string
+	long     []condition
+	short    []condition
+	stop     *expr // 止损距离
+	target   *expr // 止盈距离，nil表示不设置止盈
+	quantity float64
+	bars     int // 计算所有指标需要的K线数量
+}
+
+func (s *fileStrategy) Name() string     { return s.name }
+func (s *fileStrategy) Symbol() string   { return s.symbol }
+func (s *fileStrategy) Interval() string { return s.interval }
+
+func (s *fileStrategy) exprs() []*expr {
+	var exprs []*expr
+	for _, c := range append(append([]condition{}, s.long...), s.short...) {
+		exprs = append(exprs, c.left, c.right)
+	}
+	exprs = append(exprs, s.stop)
+	if s.target != nil {
+		exprs = append(exprs, s.target)
+	}
+	return exprs
+}
+
+func (s *fileStrategy) Next(klines []Kline) *Signal {
+	n := len(klines)
+	if n < s.bars+1 {
+		return nil
+	}
+
+	var side futures.SideType
+	var conds []condition
+	switch {
+	case len(s.long) > 0 && allConditions(s.long, klines):
+		side, conds = futures.SideTypeBuy, s.long
+	case len(s.short) > 0 && allConditions(s.short, klines):
+		side, conds = futures.SideTypeSell, s.short
+	default:
+		return nil
+	}
+
+	reasons := make([]string, len(conds))
+	for i, c := range conds {
+		reasons[i] = c.text
+	}
+	price := klines[n-1].Close
+	stop := s.stop.eval(klines, n-1)
+	if stop <= 0 {
+		return nil
+	}
+	signal := &Signal{
+		Strategy: s.name,
+		Symbol:   s.symbol,
+		Side:     side,
+		Price:    price,
+		Quantity: s.quantity,
+		Reason:   strings.Join(reasons, ", "),
+		Time:     klines[n-1].Time,
+	}
+	var target float64
+	if s.target != nil {
+		target = s.target.eval(klines, n-1)
+	}
+	if side == futures.SideTypeBuy {
+		signal.StopLoss = price - stop
+		if target > 0 {
+			signal.TakeProfit = price + target
+		}
+	} else {
+		signal.StopLoss = price + stop
+		if target > 0 {
+			signal.TakeProfit = price - target
+		}
+	}
+	return signal
+}
+
+func allConditions(conds []condition, klines []Kline) bool {
+	for _, c := range conds {
+		if !c.match(klines) {
+			return false
+		}
+	}
+	return true
+}
+
+// 入场条件，如 rsi(14) < 30
+type condition struct {
+	text        string
+	left, right *expr
+	op          string
+}
+
+var conditionOps = []string{"crosses_above", "crosses_below", "<=", ">=", "<", ">"}
+
+func parseCondition(s string) (condition, error) {
+	for _, op := range conditionOps {
+		i := strings.Index(s, op)
+		if i < 0 {
+			continue
+		}
+		left, err := parseExpr(s[:i])
+		if err != nil {
+			return condition{}, err
+		}
+		right, err := parseExpr(s[i+len(op):])
+		if err != nil {
+			return condition{}, err
+		}
+		return condition{text: strings.TrimSpace(s), left: left, right: right, op: op}, nil
+	}
+	return condition{}, fmt.Errorf(T("条件缺少比较符: %s"), s)
+}
+
+// 最后一根K线是否满足条件
+func (c condition) match(klines []Kline) bool {
+	i := len(klines) - 1
+	l, r := c.left.eval(klines, i), c.right.eval(klines, i)
+	switch c.op {
+	case "<":
+		return l < r
+	case "<=":
+		return l <= r
+	case ">":
+		return l > r
+	case ">=":
+		return l >= r
+	}
+	pl, pr := c.left.eval(klines, i-1), c.right.eval(klines, i-1)
+	if c.op == "crosses_above" {
+		return pl <= pr && l > r
+	}
+	return pl >= pr && l < r
+}
+
+// 表达式: 数字、K线字段或指标，可以再和一个数字做一次运算
+type expr struct {
+	number float64
+	field  string // open/high/low/close/volume，或指标名
+	period int    // 指标周期
+	op     byte   // 0表示没有运算
+	arg    float64
+}
+
+var exprFields = map[string]bool{"open": true, "high": true, "low": true, "close": true, "volume": true}
+var exprIndicators = map[string]bool{"ema": true, "sma": true, "rsi": true, "atr": true}
+
+func parseExpr(s string) (*expr, error) {
+	s = strings.TrimSpace(s)
+	e := &expr{}
+	if i := strings.LastIndexAny(s, "*/+-"); i > 0 {
+		arg, err := strconv.ParseFloat(strings.TrimSpace(s[i+1:]), 64)
+		if err != nil {
+			return nil, fmt.Errorf(T("表达式格式错误: %s"), s)
+		}
+		e.op, e.arg = s[i], arg
+		s = strings.TrimSpace(s[:i])
+	}
+
+	if v, err := strconv.ParseFloat(s, 64); err == nil {
+		e.number = v
+		return e, nil
+	}
+	if exprFields[s] {
+		e.field = s
+		return e, nil
+	}
+	lp, rp := strings.Index(s, "("), strings.LastIndex(s, ")")
+	if lp > 0 && rp == len(s)-1 && exprIndicators[s[:lp]] {
+		period, err := strconv.Atoi(strings.TrimSpace(s[lp+1 : rp]))
+		if err != nil || period <= 0 {
+			return nil, fmt.Errorf(T("指标周期错误: %s"), s)
+		}
+		e.field, e.period = s[:lp], period
+		return e, nil
+	}
+	return nil, fmt.Errorf(T("表达式格式错误: %s"), s)
+}
+
+// 计算需要的K线数量
+func (e *expr) bars() int {
+	if e.period > 0 {
+		return e.period + 1
+	}
+	return 1
+}
+
+// 第i根K线收盘时的值
+func (e *expr) eval(klines []Kline, i int) float64 {
+	k := klines[:i+1]
+	var v float64
+	switch e.field {
+	case "":
+		v = e.number
+	case "open":
+		v = k[i].Open
+	case "high":
+		v = k[i].High
+	case "low":
+		v = k[i].Low
+	case "close":
+		v = k[i].Close
+	case "volume":
+		v = k[i].Volume
+	case "ema":
+		v = calculateEMA(k, e.period)[i]
+	case "sma":
+		for _, x := range k[max(0, len(k)-e.period):] {
+			v += x.Close
+		}
+		v /= float64(e.period)
+	case "rsi":
+		v = calculateRSI(k, e.period)
+	case "atr":
+		v = calculateATR(k, e.period)
+	}
+
+	switch e.op {
+	case '*':
+		v *= e.arg
+	case '/':
+		if e.arg == 0 {
+			return math.NaN()
+		}
+		v /= e.arg
+	case '+':
+		v += e.arg
+	case '-':
+		v -= e.arg
+	}
+	return v
+}
@@ -0,0 +1,58 @@
+package main
+
+import "github.com/adshao/go-binance/v2/futures"
+
+func init() {
+	RegisterStrategy("protective", newProtectiveStrategy)
+}
+
+// 保护策略：回测监控程序管理持仓的方式。入场沿用EMA交叉信号，止损止盈放在入场价
+// 固定距离之外，最高盈利达到arm后回撤到keep比例时平仓，与ProtectConfig一致
+//
+// 参数: fast、slow同ema_cross，sl 止损距离(默认1)，tp 止盈距离(默认2)，
+// arm 启动保护止盈的盈利(U，默认200)，keep 保留的最高盈利比例(默认0.5)
+type protectiveStrategy struct {
+	entry   Strategy
+	protect ProtectConfig
+}
+
+func newProtectiveStrategy(config StrategyConfig) (Strategy, error) {
+	entry, err := newEMACrossStrategy(config)
+	if err != nil {
+		return nil, err
+	}
+	protect := ProtectConfig{
+		Symbol:     config.Symbol,
+		StopLoss:   config.Params["sl"],
+		TakeProfit: config.Params["tp"],
+		ArmProfit:  config.Params["arm"],
+		KeepRatio:  config.Params["keep"],
+	}
+	protect.applyDefaults()
+	return &protectiveStrategy{entry: entry, protect: protect}, nil
+}
+
+func (s *protectiveStrategy) Name() string     { return "protective" }
+func (s *protectiveStrategy) Symbol() string   { return s.entry.Symbol() }
+func (s *protectiveStrategy) Interval() string { return s.entry.Interval() }
+
+func (s *protectiveStrategy) Next(klines []Kline) *Signal {
+	signal := s.entry.Next(klines)
+	if signal == nil {
+		return nil
+	}
+	signal.Strategy = s.Name()
+	if signal.Side == futures.SideTypeBuy {
+		signal.StopLoss = signal.Price - s.protect.StopLoss
+		signal.TakeProfit = signal.Price + s.protect.TakeProfit
+	} else {
+		signal.StopLoss = signal.Price + s.protect.StopLoss
+		signal.TakeProfit = signal.Price - s.protect.TakeProfit
+	}
+	return signal
+}
+
+// 回测时的保护止盈参数
+func (s *protectiveStrategy) ProtectExit() (arm, keep float64) {
+	return s.protect.ArmProfit, s.protect.KeepRatio
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// 交易对的价格精度、数量精度和保证金资产
+type SymbolFilters struct {
+	TickSize    float64
+	StepSize    float64
+	MinQty      float64
+	MarginAsset string
+}
+
+// 按数量精度向下取整，保证不超过可用保证金
+func (f SymbolFilters) FloorQuantity(qty float64) float64 {
+	if f.StepSize <= 0 {
+		return qty
+	}
+	return math.Floor(qty/f.StepSize+1e-9) * f.StepSize
+}
+
+// 按数量精度格式化，小数位数与精度一致
+func (f SymbolFilters) FormatQuantity(qty float64) string {
+	return strconv.FormatFloat(qty, 'f', stepDecimals(f.StepSize), 64)
+}
+
+// 按价格精度四舍五入并格式化
+func (f SymbolFilters) FormatPrice(price float64) string {
+	if f.TickSize > 0 {
+		price = roundToTickSize(price, f.TickSize)
+	}
+	return strconv.FormatFloat(price, 'f', stepDecimals(f.TickSize), 64)
+}
+
+// 精度对应的小数位数，如0.01为2、0.05为2、1为0
+func stepDecimals(step float64) int {
+	if step <= 0 {
+		return 0
+	}
+	return int(math.Max(0, math.Ceil(-math.Log10(step)-1e-9)))
+}
+
+// 交易规则缓存，交易对的精度很少变化，每个交易对只查询一次
+type SymbolFilterCache struct {
+	client *futures.Client
+
+	mu      sync.Mutex
+	filters map[string]SymbolFilters
+}
+
+func NewSymbolFilterCache(client *futures.Client) *SymbolFilterCache {
+	return &SymbolFilterCache{client: client, filters: make(map[string]SymbolFilters)}
+}
+
+func (c *SymbolFilterCache) Get(symbol string) (SymbolFilters, error) {
+	c.mu.Lock()
+	filters, ok := c.filters[symbol]
+	c.mu.Unlock()
+	metrics.Cache(metricFilterCache, ok)
+	if ok {
+		return filters, nil
+	}
+
+	info, err := c.client.NewExchangeInfoService().Do(context.Background())
+	if err != nil {
+		return SymbolFilters{}, fmt.Errorf(T("获取交易规则失败: %v"), err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, s := range info.Symbols {
+		f := SymbolFilters{MarginAsset: s.MarginAsset}
+		if price := s.PriceFilter(); price != nil {
+			f.TickSize, _ = strconv.ParseFloat(price.TickSize, 64)
+		}
+		if lot := s.LotSizeFilter(); lot != nil {
+			f.StepSize, _ = strconv.ParseFloat(lot.StepSize, 64)
+			f.MinQty, _ = strconv.ParseFloat(lot.MinQuantity, 64)
+		}
+		c.filters[s.Symbol] = f
+	}
+	filters, ok = c.filters[symbol]
+	if !ok {
+		return SymbolFilters{}, fmt.Errorf(T("未找到交易对: %s"), symbol)
+	}
+	return filters, nil
+}
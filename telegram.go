@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Telegram机器人设置。机器人向chat_id推送通知，commands中的命令只接受来自chat_id、
+// 并且发送者在allowed_users中(为空时不限发送者)的消息
+type TelegramConfig struct {
+	Token        string   `json:"token"`                   // 机器人令牌，也可以用TELEGRAM_BOT_TOKEN设置
+	ChatID       int64    `json:"chat_id"`                 // 接收通知的聊天，也可以用TELEGRAM_CHAT_ID设置
+	AllowedUsers []int64  `json:"allowed_users,omitempty"` // 可以发送命令的用户ID
+	Commands     []string `json:"commands,omitempty"`      // 启用的命令: positions/close/pause/resume，为空时不接受命令
+	Events       []string `json:"events,omitempty"`        // 推送的事件类型，为空时推送所有事件
+}
+
+// 机器人命令，args为命令后面的参数，返回回复的内容
+type TelegramCommand func(args []string) (string, error)
+
+// Telegram通知渠道和命令机器人
+type Telegram struct {
+	config   TelegramConfig
+	client   *http.Client
+	commands map[string]TelegramCommand
+}
+
+func NewTelegram(config TelegramConfig) (*Telegram, error) {
+	if config.Token == "" || config.ChatID == 0 {
+		return nil, errors.New(T("Telegram需要设置token和chat_id"))
+	}
+	return &Telegram{
+		config:   config,
+		client:   &http.Client{Timeout: 60 * time.Second},
+		commands: make(map[string]TelegramCommand),
+	}, nil
+}
+
+func (b *Telegram) Name() string { return "telegram" }
+
+func (b *Telegram) Notify(event NotifyEvent) error {
+	return b.send(b.config.ChatID, event.Text())
+}
+
+// 注册命令，只有配置的commands中启用的命令生效
+func (b *Telegram) Handle(name string, cmd TelegramCommand) {
+	if slices.Contains(b.config.Commands, name) {
+		b.commands[name] = cmd
+	}
+}
+
+// 有启用的命令时在后台接收消息
+func (b *Telegram) Start() {
+	if len(b.commands) == 0 {
+		return
+	}
+	go b.poll()
+}
+
+type telegramUser struct {
+	ID int64 `json:"id"`
+}
+
+type telegramUpdate struct {
+	UpdateID int64 `json:"update_id"`
+	Message  *struct {
+		From *telegramUser `json:"from"`
+		Chat struct {
+			ID int64 `json:"id"`
+		} `json:"chat"`
+		Text string `json:"text"`
+	} `json:"message"`
+}
+
+func (b *Telegram) poll() {
+	// 跳过启动前积压的消息，避免执行很久以前发送的平仓命令
+	var offset int64
+	var pending []telegramUpdate
+	if err := b.call("getUpdates", map[string]interface{}{"offset": -1, "timeout": 0}, &pending); err != nil {
+		warnf(T("获取Telegram消息失败: %v"), err)
+	}
+	if len(pending) > 0 {
+		offset = pending[len(pending)-1].UpdateID + 1
+	}
+
+	for {
+		var updates []telegramUpdate
+		err := b.call("getUpdates", map[string]interface{}{
+			"offset":          offset,
+			"timeout":         30,
+			"allowed_updates": []string{"message"},
+		}, &updates)
+		if err != nil {
+			warnf(T("获取Telegram消息失败: %v"), err)
+			time.Sleep(10 * time.Second)
+			continue
+		}
+		for _, u := range updates {
+			offset = u.UpdateID + 1
+			if u.Message != nil {
+				b.handleMessage(u.Message.Chat.ID, u.Message.From, u.Message.Text)
+			}
+		}
+	}
+}
+
+func (b *Telegram) handleMessage(chatID int64, from *telegramUser, text string) {
+	if !strings.HasPrefix(text, "/") {
+		return
+	}
+	if chatID != b.config.ChatID || (len(b.config.AllowedUsers) > 0 && (from == nil || !slices.Contains(b.config.AllowedUsers, from.ID))) {
+		warnf(T("忽略未授权的Telegram命令: 聊天 %d %s"), chatID, text)
+		return
+	}
+
+	fields := strings.Fields(text)
+	// 群组中的命令可能带有机器人名，如 /positions@my_bot
+	name, _, _ := strings.Cut(strings.TrimPrefix(fields[0], "/"), "@")
+	var reply string
+	if cmd, ok := b.commands[name]; ok {
+		out, err := cmd(fields[1:])
+		if err != nil {
+			out = fmt.Sprintf(T("执行失败: %v"), err)
+		}
+		reply = out
+		log.Printf(T("执行Telegram命令: %s"), text)
+	} else {
+		names := make([]string, 0, len(b.commands))
+		for n := range b.commands {
+			names = append(names, "/"+n)
+		}
+		sort.Strings(names)
+		reply = fmt.Sprintf(T("可用命令: %s"), strings.Join(names, " "))
+	}
+	if err := b.send(chatID, reply); err != nil {
+		warnf(T("%s通知发送失败: %v"), b.Name(), err)
+	}
+}
+
+func (b *Telegram) send(chatID int64, text string) error {
+	return b.call("sendMessage", map[string]interface{}{"chat_id": chatID, "text": text}, nil)
+}
+
+// 调用Bot API，结果解析到result
+func (b *Telegram) call(method string, params interface{}, result interface{}) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf(T("序列化请求失败: %v"), err)
+	}
+	resp, err := b.client.Post("https://api.telegram.org/bot"+b.config.Token+"/"+method, "application/json", bytes.NewReader(body))
+	if err != nil {
+		// 错误信息中的URL包含令牌，不能打印
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf(T("请求Telegram失败: %v"), err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf(T("读取Telegram响应失败: %v"), err)
+	}
+	var r struct {
+		OK          bool            `json:"ok"`
+		Description string          `json:"description"`
+		Result      json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(data, &r); err != nil {
+		return fmt.Errorf(T("解析Telegram响应失败: %v"), err)
+	}
+	if !r.OK {
+		return fmt.Errorf(T("Telegram返回错误 %d: %s"), resp.StatusCode, r.Description)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(r.Result, result)
+}
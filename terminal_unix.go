@@ -0,0 +1,48 @@
+//go:build !windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// 把终端切换到raw模式，按键不用回车就能读到，返回恢复终端的函数
+func enableRawTerminal() (func(), error) {
+	state, err := stty("-g")
+	if err != nil {
+		return nil, fmt.Errorf(T("读取终端设置失败: %v"), err)
+	}
+	if _, err := stty("raw", "-echo"); err != nil {
+		return nil, fmt.Errorf(T("设置终端失败: %v"), err)
+	}
+	return func() { stty(state) }, nil
+}
+
+// 关闭输入回显，用于输入PIN，返回恢复终端的函数
+func disableEcho() (func(), error) {
+	if _, err := stty("-echo"); err != nil {
+		return nil, fmt.Errorf(T("设置终端失败: %v"), err)
+	}
+	return func() { stty("echo") }, nil
+}
+
+// 终端的列数和行数，获取失败时使用默认大小
+func terminalSize() (cols, rows int) {
+	out, err := stty("size")
+	if err == nil {
+		if _, err := fmt.Sscan(out, &rows, &cols); err == nil && rows > 0 && cols > 0 {
+			return cols, rows
+		}
+	}
+	return defaultTerminalSize()
+}
@@ -0,0 +1,16 @@
+//go:build windows
+
+package main
+
+// Windows终端没有stty，按键需要回车后才能读到
+func enableRawTerminal() (func(), error) {
+	return func() {}, nil
+}
+
+func disableEcho() (func(), error) {
+	return func() {}, nil
+}
+
+func terminalSize() (cols, rows int) {
+	return defaultTerminalSize()
+}
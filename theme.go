@@ -0,0 +1,229 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+)
+
+// 界面主题
+const (
+	themeLight    = "light"
+	themeDark     = "dark"
+	themeContrast = "contrast"
+)
+
+// 主题和显示名称
+var themeOptions = []struct {
+	Name  string
+	Label string
+}{
+	{themeLight, "浅色"},
+	{themeDark, "深色"},
+	{themeContrast, "高对比度"},
+}
+
+// 显示设置，用于高分辨率屏幕或小屏幕笔记本
+type DisplayConfig struct {
+	Scale       float32 `json:"scale"`        // 界面缩放，默认1
+	FontScale   float32 `json:"font_scale"`   // 在界面缩放的基础上再缩放文字，默认1
+	ChartWidth  float32 `json:"chart_width"`  // K线图最小宽度，默认600
+	ChartHeight float32 `json:"chart_height"` // K线图最小高度，默认340
+	// K线图配色和样式
+	ChartStyle ChartStyle `json:"chart_style"`
+}
+
+func (c *DisplayConfig) applyDefaults() {
+	if c.Scale <= 0 {
+		c.Scale = 1
+	}
+	if c.FontScale <= 0 {
+		c.FontScale = 1
+	}
+	if c.ChartWidth <= 0 {
+		c.ChartWidth = 600
+	}
+	if c.ChartHeight <= 0 {
+		c.ChartHeight = 340
+	}
+	c.ChartStyle.applyDefaults()
+}
+
+// K线图配色方案
+const (
+	chartStyleWestern = "western"
+	chartStyleCN      = "cn"
+	chartStyleCustom  = "custom"
+)
+
+var chartStyleOptions = []struct {
+	Name  string
+	Label string
+}{
+	{chartStyleWestern, "绿涨红跌"},
+	{chartStyleCN, "红涨绿跌"},
+	{chartStyleCustom, "自定义"},
+}
+
+// K线图样式。颜色为#RRGGBB格式，背景和网格为空时跟随主题
+type ChartStyle struct {
+	Preset     string  `json:"preset"`     // 配色方案: western/cn/custom，默认western
+	UpColor    string  `json:"up_color"`   // 自定义配色的阳线颜色
+	DownColor  string  `json:"down_color"` // 自定义配色的阴线颜色
+	Background string  `json:"background"` // 图表背景
+	Grid       string  `json:"grid"`       // 价格网格
+	WickWidth  float32 `json:"wick_width"` // 影线宽度，默认1
+}
+
+func (s *ChartStyle) applyDefaults() {
+	if s.Preset == "" {
+		s.Preset = chartStyleWestern
+	}
+	if s.WickWidth <= 0 {
+		s.WickWidth = 1
+	}
+}
+
+// 解析#RRGGBB格式的颜色
+func parseHexColor(s string) (color.Color, bool) {
+	var r, g, b uint8
+	if len(s) != 7 {
+		return nil, false
+	}
+	if _, err := fmt.Sscanf(s, "#%02x%02x%02x", &r, &g, &b); err != nil {
+		return nil, false
+	}
+	return color.NRGBA{R: r, G: g, B: b, A: 255}, true
+}
+
+func formatHexColor(c color.Color) string {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return fmt.Sprintf("#%02X%02X%02X", n.R, n.G, n.B)
+}
+
+// K线图使用的自定义颜色，阳线和阴线颜色随主题和配色方案变化
+const (
+	colorNameCandleUp        fyne.ThemeColorName = "candleUp"
+	colorNameCandleDown      fyne.ThemeColorName = "candleDown"
+	colorNameChartBackground fyne.ThemeColorName = "chartBackground"
+	colorNameChartGrid       fyne.ThemeColorName = "chartGrid"
+
+	sizeNameCandleWick fyne.ThemeSizeName = "candleWick"
+)
+
+// 交易界面主题：在Fyne默认主题的基础上固定浅色或深色，
+// 高对比度主题使用纯黑背景和更亮的前景色
+type traderTheme struct {
+	variant  fyne.ThemeVariant
+	contrast bool
+	display  DisplayConfig
+}
+
+func newTraderTheme(name string, display DisplayConfig) fyne.Theme {
+	display.applyDefaults()
+	t := &traderTheme{variant: theme.VariantLight, display: display}
+	switch name {
+	case themeDark:
+		t.variant = theme.VariantDark
+	case themeContrast:
+		t.variant = theme.VariantDark
+		t.contrast = true
+	}
+	return t
+}
+
+func (t *traderTheme) Color(name fyne.ThemeColorName, _ fyne.ThemeVariant) color.Color {
+	style := t.display.ChartStyle
+	switch name {
+	case colorNameCandleUp, colorNameCandleDown:
+		up := name == colorNameCandleUp
+		switch style.Preset {
+		case chartStyleCN:
+			// 红涨绿跌，颜色与绿涨红跌对调
+			up = !up
+		case chartStyleCustom:
+			custom := style.DownColor
+			if up {
+				custom = style.UpColor
+			}
+			if c, ok := parseHexColor(custom); ok {
+				return c
+			}
+		}
+		return t.candleColor(up)
+	case colorNameChartBackground:
+		if c, ok := parseHexColor(style.Background); ok {
+			return c
+		}
+		return t.Color(theme.ColorNameBackground, t.variant)
+	case colorNameChartGrid:
+		if c, ok := parseHexColor(style.Grid); ok {
+			return c
+		}
+		return t.Color(theme.ColorNameSeparator, t.variant)
+	}
+
+	if t.contrast {
+		switch name {
+		case theme.ColorNameBackground, theme.ColorNameMenuBackground, theme.ColorNameOverlayBackground:
+			return color.Black
+		case theme.ColorNameInputBackground, theme.ColorNameHeaderBackground:
+			return color.NRGBA{R: 20, G: 20, B: 20, A: 255}
+		case theme.ColorNameForeground:
+			return color.White
+		case theme.ColorNameSeparator, theme.ColorNameInputBorder:
+			return color.NRGBA{R: 110, G: 110, B: 110, A: 255}
+		case theme.ColorNamePlaceHolder:
+			return color.NRGBA{R: 190, G: 190, B: 190, A: 255}
+		case theme.ColorNamePrimary, theme.ColorNameFocus:
+			return color.NRGBA{R: 255, G: 214, B: 0, A: 255}
+		case theme.ColorNameForegroundOnPrimary:
+			return color.Black
+		}
+	}
+	return theme.DefaultTheme().Color(name, t.variant)
+}
+
+// 绿涨红跌配色的阳线(up)或阴线颜色
+func (t *traderTheme) candleColor(up bool) color.Color {
+	if up {
+		if t.contrast {
+			return color.NRGBA{R: 0, G: 230, B: 118, A: 255}
+		}
+		return color.NRGBA{R: 38, G: 166, B: 154, A: 255}
+	}
+	if t.contrast {
+		return color.NRGBA{R: 255, G: 64, B: 64, A: 255}
+	}
+	return color.NRGBA{R: 239, G: 83, B: 80, A: 255}
+}
+
+func (t *traderTheme) Font(style fyne.TextStyle) fyne.Resource {
+	return theme.DefaultTheme().Font(style)
+}
+
+func (t *traderTheme) Icon(name fyne.ThemeIconName) fyne.Resource {
+	return theme.DefaultTheme().Icon(name)
+}
+
+// 所有尺寸按界面缩放，文字再按字体缩放
+func (t *traderTheme) Size(name fyne.ThemeSizeName) float32 {
+	if name == sizeNameCandleWick {
+		return t.display.ChartStyle.WickWidth
+	}
+	size := theme.DefaultTheme().Size(name) * t.display.Scale
+	switch name {
+	case theme.SizeNameText, theme.SizeNameCaptionText, theme.SizeNameHeadingText, theme.SizeNameSubHeadingText:
+		size *= t.display.FontScale
+	}
+	return size
+}
+
+// 给颜色设置透明度
+func withAlpha(c color.Color, alpha uint8) color.Color {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	n.A = alpha
+	return n
+}
@@ -0,0 +1,33 @@
+//go:build !windows
+
+package main
+
+import (
+	"fmt"
+	"os/exec"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver"
+)
+
+// X11下用wmctrl设置_NET_WM_STATE_ABOVE，需要在窗口显示之后调用。
+// macOS和Wayland没有不依赖cgo的办法，返回错误
+func setAlwaysOnTop(w fyne.Window) error {
+	native, ok := w.(driver.NativeWindow)
+	if !ok {
+		return errUnsupportedTopmost()
+	}
+	var handle uintptr
+	native.RunNative(func(context any) {
+		if ctx, ok := context.(driver.X11WindowContext); ok {
+			handle = ctx.WindowHandle
+		}
+	})
+	if handle == 0 {
+		return errUnsupportedTopmost()
+	}
+	if _, err := exec.LookPath("wmctrl"); err != nil {
+		return fmt.Errorf(T("需要安装wmctrl: %v"), err)
+	}
+	return exec.Command("wmctrl", "-i", "-r", fmt.Sprintf("0x%x", handle), "-b", "add,above").Run()
+}
@@ -0,0 +1,37 @@
+//go:build windows
+
+package main
+
+import (
+	"syscall"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver"
+)
+
+var procSetWindowPos = syscall.NewLazyDLL("user32.dll").NewProc("SetWindowPos")
+
+// 通过SetWindowPos把窗口设为置顶，需要在窗口显示之后调用
+func setAlwaysOnTop(w fyne.Window) error {
+	native, ok := w.(driver.NativeWindow)
+	if !ok {
+		return errUnsupportedTopmost()
+	}
+	var err error
+	native.RunNative(func(context any) {
+		ctx, ok := context.(driver.WindowsWindowContext)
+		if !ok {
+			err = errUnsupportedTopmost()
+			return
+		}
+		const (
+			hwndTopmost = ^uintptr(0) // HWND_TOPMOST = -1
+			swpNoSize   = 0x0001
+			swpNoMove   = 0x0002
+		)
+		if r, _, callErr := procSetWindowPos.Call(ctx.HWND, hwndTopmost, 0, 0, 0, 0, swpNoSize|swpNoMove); r == 0 {
+			err = callErr
+		}
+	})
+	return err
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// 资金流水类型
+const (
+	IncomeRealizedPnL = "REALIZED_PNL"
+	IncomeCommission  = "COMMISSION"
+	IncomeFunding     = "FUNDING_FEE"
+)
+
+// 第一次同步时获取的历史长度
+const tradeDBHistory = 90 * 24 * time.Hour
+
+// 权益快照的最小间隔
+const equitySnapshotInterval = 5 * time.Minute
+
+// 一条资金流水
+type IncomeRecord struct {
+	Time    time.Time `json:"time"`
+	Symbol  string    `json:"symbol"`
+	Type    string    `json:"type"`
+	Income  float64   `json:"income"`
+	Asset   string    `json:"asset"`
+	TranID  int64     `json:"tran_id"`
+	TradeID string    `json:"trade_id,omitempty"`
+}
+
+func (r IncomeRecord) key() string {
+	return fmt.Sprintf("%s_%d_%s", r.Type, r.TranID, r.TradeID)
+}
+
+// 账户权益快照，权益 = 钱包余额 + 未实现盈亏
+type EquitySnapshot struct {
+	Time       time.Time `json:"time"`
+	Balance    float64   `json:"balance"`
+	Unrealized float64   `json:"unrealized"`
+}
+
+func (s EquitySnapshot) Equity() float64 {
+	return s.Balance + s.Unrealized
+}
+
+// 一笔成交
+type FillRecord struct {
+	Time            time.Time `json:"time"`
+	Symbol          string    `json:"symbol"`
+	ID              int64     `json:"id"`
+	OrderID         int64     `json:"order_id"`
+	Side            string    `json:"side"`
+	Price           float64   `json:"price"`
+	Quantity        float64   `json:"qty"`
+	RealizedPnL     float64   `json:"realized_pnl"`
+	Commission      float64   `json:"commission"`
+	CommissionAsset string    `json:"commission_asset"`
+}
+
+func newFillRecord(t *futures.AccountTrade) FillRecord {
+	price, _ := strconv.ParseFloat(t.Price, 64)
+	qty, _ := strconv.ParseFloat(t.Quantity, 64)
+	pnl, _ := strconv.ParseFloat(t.RealizedPnl, 64)
+	commission, _ := strconv.ParseFloat(t.Commission, 64)
+	return FillRecord{
+		Time:            time.UnixMilli(t.Time),
+		Symbol:          t.Symbol,
+		ID:              t.ID,
+		OrderID:         t.OrderID,
+		Side:            string(t.Side),
+		Price:           price,
+		Quantity:        qty,
+		RealizedPnL:     pnl,
+		Commission:      commission,
+		CommissionAsset: t.CommissionAsset,
+	}
+}
+
+// 买入为正、卖出为负的成交数量
+func (f FillRecord) SignedQuantity() float64 {
+	if f.Side == string(futures.SideTypeSell) {
+		return -f.Quantity
+	}
+	return f.Quantity
+}
+
+// 持仓期间的最大浮盈和最大浮亏，以入场价的百分比表示
+type Excursion struct {
+	Favorable float64 `json:"mfe"`
+	Adverse   float64 `json:"mae"`
+}
+
+// 平仓时自动保存的K线图截图
+type ChartSnapshot struct {
+	Symbol string    `json:"symbol"`
+	Time   time.Time `json:"time"`
+	Path   string    `json:"path"`
+}
+
+type tradeDBData struct {
+	Incomes    []IncomeRecord       `json:"incomes"`
+	Fills      []FillRecord         `json:"fills,omitempty"`
+	FillCursor map[string]time.Time `json:"fill_cursor,omitempty"` // 每个交易对已同步成交记录的最后一条手续费流水时间
+	Excursions map[string]Excursion `json:"excursions,omitempty"`  // 按交易ID保存
+	Equity     []EquitySnapshot     `json:"equity,omitempty"`
+	Snapshots  []ChartSnapshot      `json:"snapshots,omitempty"`
+	Notes      []TradeNote          `json:"notes,omitempty"`
+}
+
+// 本地交易数据库，保存在JSON文件中。资金流水和成交记录从交易所增量同步，
+// 重启后只获取最后一条记录之后的数据；权益快照由界面定时记录
+type TradeDB struct {
+	client *futures.Client
+	path   string
+
+	mu   sync.Mutex
+	data tradeDBData
+}
+
+func OpenTradeDB(client *futures.Client, path string) (*TradeDB, error) {
+	db := &TradeDB{client: client, path: path}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return db, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf(T("读取交易数据库失败: %v"), err)
+	}
+	if err := json.Unmarshal(data, &db.data); err != nil {
+		return nil, fmt.Errorf(T("解析交易数据库失败: %v"), err)
+	}
+	return db, nil
+}
+
+func (db *TradeDB) saveLocked() error {
+	data, err := json.Marshal(db.data)
+	if err != nil {
+		return fmt.Errorf(T("序列化交易数据库失败: %v"), err)
+	}
+	if err := os.WriteFile(db.path, data, 0644); err != nil {
+		return fmt.Errorf(T("保存交易数据库失败: %v"), err)
+	}
+	return nil
+}
+
+// 同步资金流水和有新成交的交易对的成交记录，再计算新平仓交易的最大浮盈浮亏，
+// 返回新增的资金流水条数
+func (db *TradeDB) Sync() (int, error) {
+	incomes, err := db.syncIncomes()
+	if err != nil {
+		return 0, err
+	}
+
+	// 每笔成交都有一条手续费流水，只需要同步有游标之后的手续费流水的交易对。
+	// 游标在成交记录保存后才前移，上次同步失败的交易对下次会重新获取
+	for symbol, r := range db.pendingFills() {
+		if err := db.syncFills(symbol, r.since.Add(-time.Second), r.until); err != nil {
+			return len(incomes), err
+		}
+	}
+
+	return len(incomes), db.updateExcursions()
+}
+
+// 需要同步成交记录的时间范围，从游标之后的第一条手续费流水到最后一条
+type fillRange struct {
+	since, until time.Time
+}
+
+// 找出游标之后还有手续费流水的交易对。没有游标的交易对(旧版本的数据库)
+// 从已保存的最后一条成交开始
+func (db *TradeDB) pendingFills() map[string]fillRange {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	cursor := make(map[string]time.Time, len(db.data.FillCursor))
+	for symbol, t := range db.data.FillCursor {
+		cursor[symbol] = t
+	}
+	for _, f := range db.data.Fills {
+		if _, ok := db.data.FillCursor[f.Symbol]; !ok && f.Time.After(cursor[f.Symbol]) {
+			cursor[f.Symbol] = f.Time
+		}
+	}
+
+	pending := make(map[string]fillRange)
+	for _, r := range db.data.Incomes {
+		if r.Type != IncomeCommission || !r.Time.After(cursor[r.Symbol]) {
+			continue
+		}
+		p, ok := pending[r.Symbol]
+		if !ok || r.Time.Before(p.since) {
+			p.since = r.Time
+		}
+		if r.Time.After(p.until) {
+			p.until = r.Time
+		}
+		pending[r.Symbol] = p
+	}
+	return pending
+}
+
+// 同步最后一条记录之后的资金流水，返回新增的记录
+func (db *TradeDB) syncIncomes() ([]IncomeRecord, error) {
+	db.mu.Lock()
+	since := time.Now().Add(-tradeDBHistory)
+	if n := len(db.data.Incomes); n > 0 {
+		since = db.data.Incomes[n-1].Time.Add(time.Millisecond)
+	}
+	db.mu.Unlock()
+
+	incomes, err := fetchIncomeHistory(db.client, "", since)
+	if err != nil {
+		return nil, err
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	seen := make(map[string]bool, len(db.data.Incomes))
+	for _, r := range db.data.Incomes {
+		seen[r.key()] = true
+	}
+	var added []IncomeRecord
+	for _, in := range incomes {
+		income, _ := strconv.ParseFloat(in.Income, 64)
+		r := IncomeRecord{
+			Time:    time.UnixMilli(in.Time),
+			Symbol:  in.Symbol,
+			Type:    in.IncomeType,
+			Income:  income,
+			Asset:   in.Asset,
+			TranID:  in.TranID,
+			TradeID: in.TradeID,
+		}
+		if seen[r.key()] {
+			continue
+		}
+		seen[r.key()] = true
+		added = append(added, r)
+	}
+	if len(added) == 0 {
+		return nil, nil
+	}
+	db.data.Incomes = append(db.data.Incomes, added...)
+	sort.SliceStable(db.data.Incomes, func(i, j int) bool {
+		return db.data.Incomes[i].Time.Before(db.data.Incomes[j].Time)
+	})
+	return added, db.saveLocked()
+}
+
+// 同步交易对从since开始的成交记录，保存后把游标移到until
+func (db *TradeDB) syncFills(symbol string, since, until time.Time) error {
+	trades, err := fetchAccountTrades(db.client, symbol, since)
+	if err != nil {
+		return err
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	seen := make(map[int64]bool)
+	for _, f := range db.data.Fills {
+		if f.Symbol == symbol {
+			seen[f.ID] = true
+		}
+	}
+	added := 0
+	for _, t := range trades {
+		if seen[t.ID] {
+			continue
+		}
+		seen[t.ID] = true
+		db.data.Fills = append(db.data.Fills, newFillRecord(t))
+		added++
+	}
+	if added > 0 {
+		sort.SliceStable(db.data.Fills, func(i, j int) bool {
+			return db.data.Fills[i].Time.Before(db.data.Fills[j].Time)
+		})
+	}
+	if db.data.FillCursor == nil {
+		db.data.FillCursor = make(map[string]time.Time)
+	}
+	db.data.FillCursor[symbol] = until
+	return db.saveLocked()
+}
+
+// 记录权益快照，距离上一个快照不足equitySnapshotInterval时忽略
+func (db *TradeDB) RecordEquity(balance, unrealized float64) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	now := time.Now()
+	if n := len(db.data.Equity); n > 0 && now.Sub(db.data.Equity[n-1].Time) < equitySnapshotInterval {
+		return nil
+	}
+	db.data.Equity = append(db.data.Equity, EquitySnapshot{Time: now, Balance: balance, Unrealized: unrealized})
+	return db.saveLocked()
+}
+
+// 记录平仓截图，查询交易时按交易对和平仓时间关联
+func (db *TradeDB) AddSnapshot(s ChartSnapshot) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.data.Snapshots = append(db.data.Snapshots, s)
+	return db.saveLocked()
+}
+
+// 从since开始的权益快照，按时间排序
+func (db *TradeDB) EquitySnapshots(since time.Time) []EquitySnapshot {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	i := sort.Search(len(db.data.Equity), func(i int) bool {
+		return !db.data.Equity[i].Time.Before(since)
+	})
+	return append([]EquitySnapshot(nil), db.data.Equity[i:]...)
+}
+
+// 从since开始的资金流水，按时间排序
+func (db *TradeDB) Incomes(since time.Time) []IncomeRecord {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	i := sort.Search(len(db.data.Incomes), func(i int) bool {
+		return !db.data.Incomes[i].Time.Before(since)
+	})
+	return append([]IncomeRecord(nil), db.data.Incomes[i:]...)
+}
@@ -2,11 +2,18 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"math"
+	"net/http"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/adshao/go-binance/v2"
@@ -15,29 +22,124 @@ import (
 
 type TraderCLI struct {
 	client     *futures.Client
+	spotClient *binance.Client
+	monitor    *APIMonitor // 期货客户端的请求延迟和已用权重
 	maxProfit  map[string]float64
 	positions  map[string]float64
 	lastPosition map[string]*futures.PositionRisk
 	lastUpdate   map[string]time.Time
+
+	// 持仓相关性监控
+	correlation       *CorrelationMonitor
+	lastCorrelationWarn string
+
+	// 价格提醒
+	alerts *AlertManager
+
+	// 自动化决策的活动记录
+	activity *ActivityLog
+
+	// 每个管理的交易对一个监控实例，第一个是主交易对，交互模式下可以在运行时修改它的参数。
+	// 见cli_engine.go
+	engines     []*symbolEngine
+	strategies  []ProtectConfig // 配置文件中按交易对的止盈止损设置
+	spikeConfig SpikeConfig
+
+	// 按交易对暂停的自动止损、自动止盈和保护止盈，启动时读取配置文件中的paused_rules，
+	// 运行中用 automation 命令修改
+	automation *AutomationRules
+
+	// 账户名，用于区分不同账户的状态文件
+	profile string
+
+	// 修改杠杆和保证金模式前需要输入的PIN，与界面共用配置文件中的pin
+	pin PINConfig
+
+	// 监控循环的轮询间隔
+	pollInterval time.Duration
+
+	// 5分钟内强平名义价值超过该值时提醒，0表示不提醒
+	liquidationAlert float64
+
+	// run --strategy指定的策略文件，为空时使用STRATEGY_CONFIG
+	strategyFile string
+
+	// 控制接口的监听地址和监控循环的运行状态，见cli_control.go
+	controlAddr string
+	engine      engineState
+
+	// 推送通知，见cli_notify.go
+	notifyConfig NotifyConfig
+	notify       NotifyHub
+
+	// MQTT发布，没有设置时为nil，见cli_mqtt.go
+	mqttConfig *MQTTConfig
+	mqtt       *MQTT
+	mqttBusy   atomic.Bool // 上一次持仓快照还在发送
 }
 
-func NewTraderCLI(apiKey, secretKey string) (*TraderCLI, error) {
+// profile为账户名，用于区分不同账户的状态文件，为空时使用默认账户
+func NewTraderCLI(apiKey, secretKey, profile string, config *appConfig) (*TraderCLI, error) {
 	client := binance.NewFuturesClient(apiKey, secretKey)
-	
-	return &TraderCLI{
+	monitor := NewAPIMonitor()
+	client.HTTPClient = &http.Client{Transport: monitor}
+
+	alerts, err := LoadAlertManager(profilePath(profile, "alerts.json"))
+	if err != nil {
+		return nil, fmt.Errorf(T("加载提醒失败: %v"), err)
+	}
+	alerts.RegisterChannel("log", func(alert *Alert, value float64) {
+		log.Printf(T("%s提醒: %s"), alert.Type.Label(), alert.Message(value))
+	})
+
+	t := &TraderCLI{
 		client:     client,
+		spotClient: binance.NewClient(apiKey, secretKey),
+		monitor:    monitor,
 		maxProfit:  make(map[string]float64),
 		positions:  make(map[string]float64),
 		lastPosition: make(map[string]*futures.PositionRisk),
 		lastUpdate:   make(map[string]time.Time),
-	}, nil
+		correlation:  NewCorrelationMonitor(client, "1h", 100, 0.8),
+		alerts:       alerts,
+		activity:     NewActivityLog(profilePath(profile, activityFile), "cli"),
+		profile:      profile,
+		pollInterval: time.Second,
+
+		liquidationAlert: config.LiquidationAlert,
+		controlAddr:      config.Control,
+		strategies:       config.Strategies,
+		spikeConfig:      config.Spike,
+		notifyConfig:     config.Notify,
+		mqttConfig:       config.MQTT,
+		pin:              config.PIN,
+		automation:       NewAutomationRules(config.PausedRules),
+	}
+	if t.controlAddr == "" {
+		t.controlAddr = defaultControlAddr
+	}
+	t.engines = []*symbolEngine{newSymbolEngine(config.Protect, config.Spike)}
+	if err := t.setExtraSymbols(nil, t.protectConfig()); err != nil {
+		return nil, err
+	}
+	return t, nil
 }
 
-// 取消所有止盈止损单
-func (t *TraderCLI) cancelAllTPSL(currentAmt float64) error {
-	orders, err := t.client.NewListOpenOrdersService().Symbol("SOLUSDC").Do(context.Background())
+// 主交易对的止盈止损参数
+func (t *TraderCLI) protectConfig() ProtectConfig {
+	return t.engines[0].config()
+}
+
+// 修改主交易对的止盈止损参数，没有设置的参数使用默认值
+func (t *TraderCLI) setProtectConfig(config ProtectConfig) {
+	t.engines[0].setConfig(config)
+}
+
+// 取消所有止盈止损单，reason记录到活动记录中
+func (t *TraderCLI) cancelAllTPSL(symbol string, currentAmt float64, reason string) error {
+	orders, err := t.client.NewListOpenOrdersService().Symbol(symbol).Do(context.Background())
 	if err != nil {
-		return fmt.Errorf("获取订单失败: %v", err)
+		return fmt.Errorf(T("获取订单失败: %v"), err)
 	}
 
 	for _, order := range orders {
@@ -53,15 +155,31 @@ func (t *TraderCLI) cancelAllTPSL(currentAmt float64) error {
 			}
 
 			_, err := t.client.NewCancelOrderService().
-				Symbol("SOLUSDC").
+				Symbol(symbol).
 				OrderID(order.OrderID).
 				Do(context.Background())
-			
+
+			price, _ := strconv.ParseFloat(order.Price, 64)
+			if price == 0 {
+				price, _ = strconv.ParseFloat(order.StopPrice, 64)
+			}
+			qty, _ := strconv.ParseFloat(order.OrigQuantity, 64)
+			activity := Activity{
+				Symbol:   symbol,
+				Action:   ActivityCancel,
+				Price:    price,
+				Quantity: qty,
+				Reason:   reason,
+				Inputs:   map[string]float64{"position": currentAmt, "order_id": float64(order.OrderID)},
+			}
 			if err != nil {
-				log.Printf("取消订单失败 [OrderID: %d]: %v", order.OrderID, err)
+				activity.Error = err.Error()
+				t.activity.Record(activity)
+				warnf(T("取消订单失败 [OrderID: %d]: %v"), order.OrderID, err)
 				continue
 			}
-			log.Printf("已取消订单 [OrderID: %d, Type: %s]", order.OrderID, order.Type)
+			t.activity.Record(activity)
+			log.Printf(T("已取消订单 [OrderID: %d, Type: %s]"), order.OrderID, order.Type)
 		}
 	}
 	return nil
@@ -73,11 +191,12 @@ func (t *TraderCLI) checkAndSetStopLoss(position *futures.PositionRisk) error {
 	if amt == 0 {
 		return nil
 	}
+	protect := t.protectConfig()
 
 	// 获取当前订单
-	orders, err := t.client.NewListOpenOrdersService().Symbol("SOLUSDC").Do(context.Background())
+	orders, err := t.client.NewListOpenOrdersService().Symbol(position.Symbol).Do(context.Background())
 	if err != nil {
-		return fmt.Errorf("获取订单失败: %v", err)
+		return fmt.Errorf(T("获取订单失败: %v"), err)
 	}
 
 	// 检查是否已有止损单
@@ -95,9 +214,9 @@ func (t *TraderCLI) checkAndSetStopLoss(position *futures.PositionRisk) error {
 
 	// 如果没有有效的止损单，重新设置
 	if !hasValidStopLoss {
-		log.Printf("没有有效的止损单，重新设置止盈止损")
-		if err := t.cancelAllTPSL(amt); err != nil {
-			return fmt.Errorf("取消订单失败: %v", err)
+		log.Print(T("没有有效的止损单，重新设置止盈止损"))
+		if err := t.cancelAllTPSL(position.Symbol, amt, T("没有有效的止损单，重新设置止盈止损")); err != nil {
+			return fmt.Errorf(T("取消订单失败: %v"), err)
 		}
 		// 等待两秒，确保订单已经被取消
 		time.Sleep(2 * time.Second)
@@ -109,91 +228,95 @@ func (t *TraderCLI) checkAndSetStopLoss(position *futures.PositionRisk) error {
 		side := futures.SideTypeSell
 		positionSide := futures.PositionSideTypeLong
 		if amt > 0 {
-			// 多仓，止损价格在入场价下方100点
-			stopPrice = entryPrice - 1.0  // 1.0 = 100点/100
+			// 多仓，止损价格在入场价下方
+			stopPrice = entryPrice - protect.StopLoss
 			side = futures.SideTypeSell
 			positionSide = futures.PositionSideTypeLong
 		} else {
-			// 空仓，止损价格在入场价上方100点
-			stopPrice = entryPrice + 1.0  // 1.0 = 100点/100
+			// 空仓，止损价格在入场价上方
+			stopPrice = entryPrice + protect.StopLoss
 			side = futures.SideTypeBuy
 			positionSide = futures.PositionSideTypeShort
 		}
 
-		// 将价格四舍五入到0.01
-		stopPrice = roundToTickSize(stopPrice, 0.01)
+		// 将价格四舍五入到交易对的最小价格单位
+		stopPrice = roundToTickSize(stopPrice, protect.TickSize)
 
 		// 创建止损市价单
 		_, err := t.client.NewCreateOrderService().
-			Symbol("SOLUSDC").
+			Symbol(position.Symbol).
+			NewClientOrderID(newClientOrderID(tagStopLoss)).
 			Side(side).
 			PositionSide(positionSide).
 			Type(futures.OrderTypeStopMarket).
-			StopPrice(fmt.Sprintf("%.2f", stopPrice)).
+			StopPrice(protect.FormatPrice(stopPrice)).
 			Quantity(fmt.Sprintf("%.4f", math.Abs(amt))).
 			Do(context.Background())
 		
 		if err != nil {
-			return fmt.Errorf("创建止损单失败: %v", err)
+			return fmt.Errorf(T("创建止损单失败: %v"), err)
 		}
-		log.Printf("已设置止损单，价格: %.2f", stopPrice)
+		log.Printf(T("已设置止损单，价格: %.2f"), stopPrice)
 	}
 
 	return nil
 }
 
-func (t *TraderCLI) checkProtectiveStopProfit(position *futures.PositionRisk) error {
+// 按交易对监控实例的参数检查一个持仓的止盈止损和保护止盈
+func (t *TraderCLI) checkProtectiveStopProfit(e *symbolEngine, position *futures.PositionRisk) error {
 	amt, _ := strconv.ParseFloat(position.PositionAmt, 64)
+	protect := e.config()
 	
 	// 确定仓位方向
 	var direction string
 	if amt > 0 {
-		direction = "多"
+		direction = T("多")
 	} else if amt < 0 {
-		direction = "空"
+		direction = T("空")
 	} else {
-		direction = "无"
+		direction = T("无")
 		// 没有持仓时，清除记录并撤销所有止盈止损单
 		delete(t.maxProfit, position.Symbol)
-		if err := t.cancelAllTPSL(0); err != nil {
-			return fmt.Errorf("取消订单失败: %v", err)
+		if err := t.cancelAllTPSL(position.Symbol, 0, T("没有持仓")); err != nil {
+			return fmt.Errorf(T("取消订单失败: %v"), err)
 		}
-		log.Printf("没有持仓，已撤销所有止盈止损单")
+		debugf(T("没有持仓，已撤销所有止盈止损单"))
 		return nil
 	}
 
-	log.Printf("当前%s仓，数量: %.4f", direction, math.Abs(amt))
+	debugf(T("当前%s仓，数量: %.4f"), direction, math.Abs(amt))
 
 	entryPrice, _ := strconv.ParseFloat(position.EntryPrice, 64)
 	unPnl, _ := strconv.ParseFloat(position.UnRealizedProfit, 64)
 
 	// 获取当前订单
-	orders, err := t.client.NewListOpenOrdersService().Symbol("SOLUSDC").Do(context.Background())
+	orders, err := t.client.NewListOpenOrdersService().Symbol(position.Symbol).Do(context.Background())
 	if err != nil {
-		return fmt.Errorf("获取订单失败: %v", err)
+		return fmt.Errorf(T("获取订单失败: %v"), err)
 	}
 
 	// 检查上次的仓位和入场价
 	lastAmt := 0.0
 	lastEntryPrice := 0.0
-	if lastPos, ok := t.lastPosition["SOLUSDC"]; ok {
+	if lastPos, ok := t.lastPosition[position.Symbol]; ok {
 		lastAmt, _ = strconv.ParseFloat(lastPos.PositionAmt, 64)
 		lastEntryPrice, _ = strconv.ParseFloat(lastPos.EntryPrice, 64)
 	}
 
 	// 如果仓位或入场价变化，取消所有订单
 	if math.Abs(lastAmt-amt) > 0.0001 || math.Abs(lastEntryPrice-entryPrice) > 0.01 {
-		log.Printf("仓位或入场价变化，准备重新设置订单")
-		log.Printf("旧仓位: %.4f, 新仓位: %.4f", lastAmt, amt)
-		log.Printf("旧入场价: %.2f, 新入场价: %.2f", lastEntryPrice, entryPrice)
-		if err := t.cancelAllTPSL(amt); err != nil {
-			return fmt.Errorf("取消订单失败: %v", err)
+		log.Print(T("仓位或入场价变化，准备重新设置订单"))
+		debugf(T("旧仓位: %.4f, 新仓位: %.4f"), lastAmt, amt)
+		debugf(T("旧入场价: %.2f, 新入场价: %.2f"), lastEntryPrice, entryPrice)
+		reason := fmt.Sprintf(T("仓位从 %.4f 变为 %.4f，入场价从 %.2f 变为 %.2f"), lastAmt, amt, lastEntryPrice, entryPrice)
+		if err := t.cancelAllTPSL(position.Symbol, amt, reason); err != nil {
+			return fmt.Errorf(T("取消订单失败: %v"), err)
 		}
 		time.Sleep(1 * time.Second)
 		// 重新获取订单
-		orders, err = t.client.NewListOpenOrdersService().Symbol("SOLUSDC").Do(context.Background())
+		orders, err = t.client.NewListOpenOrdersService().Symbol(position.Symbol).Do(context.Background())
 		if err != nil {
-			return fmt.Errorf("获取订单失败: %v", err)
+			return fmt.Errorf(T("获取订单失败: %v"), err)
 		}
 	}
 
@@ -205,10 +328,10 @@ func (t *TraderCLI) checkProtectiveStopProfit(position *futures.PositionRisk) er
 		if math.Abs(qty - math.Abs(amt)) <= 0.0001 {
 			if order.Type == futures.OrderTypeStopMarket {
 				hasValidStopLoss = true
-				log.Printf("发现有效止损单: 数量=%.4f, 价格=%.2f", qty, order.StopPrice)
+				debugf(T("发现有效止损单: 数量=%.4f, 价格=%.2f"), qty, order.StopPrice)
 			} else if order.Type == futures.OrderTypeLimit {
 				hasValidTakeProfit = true
-				log.Printf("发现有效止盈单: 数量=%.4f, 价格=%.2f", qty, order.Price)
+				debugf(T("发现有效止盈单: 数量=%.4f, 价格=%.2f"), qty, order.Price)
 			}
 		}
 	}
@@ -216,9 +339,9 @@ func (t *TraderCLI) checkProtectiveStopProfit(position *futures.PositionRisk) er
 	// 如果没有持仓，不需要设置止盈止损单
 	if amt == 0 {
 		if len(orders) > 0 {
-			log.Printf("没有持仓，但发现%d个订单，准备清除", len(orders))
-			if err := t.cancelAllTPSL(amt); err != nil {
-				return fmt.Errorf("取消订单失败: %v", err)
+			log.Printf(T("没有持仓，但发现%d个订单，准备清除"), len(orders))
+			if err := t.cancelAllTPSL(position.Symbol, amt, T("没有持仓")); err != nil {
+				return fmt.Errorf(T("取消订单失败: %v"), err)
 			}
 		}
 		return nil
@@ -227,103 +350,141 @@ func (t *TraderCLI) checkProtectiveStopProfit(position *futures.PositionRisk) er
 	// 如果缺少任何一种订单，只设置缺少的订单
 	if !hasValidStopLoss || !hasValidTakeProfit {
 		if !hasValidStopLoss {
-			log.Printf("缺少止损订单，准备设置")
+			debugf(T("缺少止损订单，准备设置"))
 		}
 		if !hasValidTakeProfit {
-			log.Printf("缺少止盈订单，准备设置")
+			debugf(T("缺少止盈订单，准备设置"))
 		}
 
-		// 设置止损单
-		if !hasValidStopLoss {
+		// 设置止损单，自动止损暂停时跳过
+		if !hasValidStopLoss && t.automation.Enabled(position.Symbol, RuleStopLoss) {
 			stopPrice := entryPrice
 			side := futures.SideTypeSell
 			positionSide := futures.PositionSideTypeLong
 			if amt > 0 {
-				// 多仓，止损价格在入场价下方100点
-				stopPrice = entryPrice - 1.0
+				// 多仓，止损价格在入场价下方
+				stopPrice = entryPrice - protect.StopLoss
 				side = futures.SideTypeSell
 				positionSide = futures.PositionSideTypeLong
-				log.Printf("设置多仓止损单，入场价: %.2f，止损价: %.2f", entryPrice, stopPrice)
+				log.Printf(T("设置多仓止损单，入场价: %.2f，止损价: %.2f"), entryPrice, stopPrice)
 			} else {
-				// 空仓，止损价格在入场价上方100点
-				stopPrice = entryPrice + 1.0
+				// 空仓，止损价格在入场价上方
+				stopPrice = entryPrice + protect.StopLoss
 				side = futures.SideTypeBuy
 				positionSide = futures.PositionSideTypeShort
-				log.Printf("设置空仓止损单，入场价: %.2f，止损价: %.2f", entryPrice, stopPrice)
+				log.Printf(T("设置空仓止损单，入场价: %.2f，止损价: %.2f"), entryPrice, stopPrice)
 			}
 
 			// 创建止损单
 			stopOrder := t.client.NewCreateOrderService().
-				Symbol("SOLUSDC").
+				Symbol(position.Symbol).
+				NewClientOrderID(newClientOrderID(tagStopLoss)).
 				Side(side).
 				PositionSide(positionSide).
 				Type(futures.OrderTypeStopMarket).
 				Quantity(fmt.Sprintf("%.4f", math.Abs(amt))).
-				StopPrice(fmt.Sprintf("%.2f", stopPrice)).
+				StopPrice(protect.FormatPrice(stopPrice)).
 				WorkingType("CONTRACT_PRICE")
 
 			_, err = stopOrder.Do(context.Background())
+			activity := Activity{
+				Symbol:   position.Symbol,
+				Action:   ActivityStopLoss,
+				Price:    stopPrice,
+				Quantity: math.Abs(amt),
+				Reason:   fmt.Sprintf(T("%s %.4f 没有找到有效止损单"), positionDirection(amt), math.Abs(amt)),
+				Inputs:   map[string]float64{"entry": entryPrice, "stop_loss": protect.StopLoss},
+			}
 			if err != nil {
-				return fmt.Errorf("设置止损单失败: %v", err)
+				activity.Error = err.Error()
+				t.activity.Record(activity)
+				return fmt.Errorf(T("设置止损单失败: %v"), err)
 			}
-			log.Printf("已设置止损单，价格: %.2f", stopPrice)
+			t.activity.Record(activity)
+			log.Printf(T("已设置止损单，价格: %.2f"), stopPrice)
 		}
 
-		// 设置止盈单
-		if !hasValidTakeProfit {
+		// 设置止盈单，自动止盈暂停时跳过
+		if !hasValidTakeProfit && t.automation.Enabled(position.Symbol, RuleTakeProfit) {
 			var takeProfitPrice float64
 			side := futures.SideTypeSell
 			positionSide := futures.PositionSideTypeLong
 			if amt > 0 {
-				// 多仓，止盈价格在入场价上方100点
-				takeProfitPrice = entryPrice + 2.0
+				// 多仓，止盈价格在入场价上方
+				takeProfitPrice = entryPrice + protect.TakeProfit
 				side = futures.SideTypeSell
 				positionSide = futures.PositionSideTypeLong
-				log.Printf("设置多仓止盈单，入场价: %.2f，止盈价: %.2f", entryPrice, takeProfitPrice)
+				log.Printf(T("设置多仓止盈单，入场价: %.2f，止盈价: %.2f"), entryPrice, takeProfitPrice)
 			} else {
-				// 空仓，止盈价格在入场价下方100点
-				takeProfitPrice = entryPrice - 2.0
+				// 空仓，止盈价格在入场价下方
+				takeProfitPrice = entryPrice - protect.TakeProfit
 				side = futures.SideTypeBuy
 				positionSide = futures.PositionSideTypeShort
-				log.Printf("设置空仓止盈单，入场价: %.2f，止盈价: %.2f", entryPrice, takeProfitPrice)
+				log.Printf(T("设置空仓止盈单，入场价: %.2f，止盈价: %.2f"), entryPrice, takeProfitPrice)
 			}
 
 			// 创建止盈单
 			profitOrder := t.client.NewCreateOrderService().
-				Symbol("SOLUSDC").
+				Symbol(position.Symbol).
+				NewClientOrderID(newClientOrderID(tagTakeProfit)).
 				Side(side).
 				PositionSide(positionSide).
 				Type(futures.OrderTypeLimit).
 				TimeInForce(futures.TimeInForceTypeGTC).
 				Quantity(fmt.Sprintf("%.4f", math.Abs(amt))).
-				Price(fmt.Sprintf("%.2f", takeProfitPrice)).
+				Price(protect.FormatPrice(takeProfitPrice)).
 				WorkingType("CONTRACT_PRICE")
 
 			_, err = profitOrder.Do(context.Background())
+			activity := Activity{
+				Symbol:   position.Symbol,
+				Action:   ActivityTakeProfit,
+				Price:    takeProfitPrice,
+				Quantity: math.Abs(amt),
+				Reason:   fmt.Sprintf(T("%s %.4f 没有找到有效止盈单"), positionDirection(amt), math.Abs(amt)),
+				Inputs:   map[string]float64{"entry": entryPrice, "take_profit": protect.TakeProfit},
+			}
 			if err != nil {
-				return fmt.Errorf("设置止盈单失败: %v", err)
+				activity.Error = err.Error()
+				t.activity.Record(activity)
+				return fmt.Errorf(T("设置止盈单失败: %v"), err)
 			}
-			log.Printf("已设置止盈单，价格: %.2f", takeProfitPrice)
+			t.activity.Record(activity)
+			log.Printf(T("已设置止盈单，价格: %.2f"), takeProfitPrice)
 		}
 	}
 
 	// 更新最高盈利
 	maxProfit := t.maxProfit[position.Symbol]
+	prevMaxProfit := maxProfit
 	if maxProfit == 0 || unPnl > maxProfit {
 		t.maxProfit[position.Symbol] = unPnl
 		maxProfit = unPnl
 	}
 
+	// 检查未实现盈亏和盈利回撤提醒
+	t.alerts.Check(position.Symbol, AlertPnL, unPnl)
+	if maxProfit > 0 {
+		t.alerts.Check(position.Symbol, AlertDrawdown, (maxProfit-unPnl)/maxProfit*100)
+	}
+
+	// 最高盈利首次达到启动盈利时保护止盈启动
+	if prevMaxProfit < protect.ArmProfit && maxProfit >= protect.ArmProfit {
+		t.alerts.Fire(position.Symbol, AlertArmed, unPnl)
+	}
+
 	// 打印持仓信息
-	positionType := "多"
+	positionType := T("多")
 	if amt < 0 {
-		positionType = "空"
+		positionType = T("空")
 	}
-	log.Printf("持仓信息 - 方向: %s, 数量: %.4f, 入场价: %.2f, 未实现盈亏: %.2f, 最高盈利: %.2f",
+	debugf(T("持仓信息 - 方向: %s, 数量: %.4f, 入场价: %.2f, 未实现盈亏: %.2f, 最高盈利: %.2f"),
 		positionType, math.Abs(amt), entryPrice, unPnl, maxProfit)
 
-	// 如果曾经盈利超过200U，且当前回撤超过50%，执行市价平仓
-	if maxProfit >= 200 && unPnl <= maxProfit*0.5 {
+	// 如果曾经盈利超过启动盈利，且当前回撤到保留比例以下（价格异动后收紧），执行市价平仓。
+	// 保护止盈暂停时只记录最高盈利
+	keepRatio := e.spikes.ProtectRatio(protect.KeepRatio)
+	if t.automation.Enabled(position.Symbol, RuleProtect) && maxProfit >= protect.ArmProfit && unPnl <= maxProfit*keepRatio {
 		side := futures.SideTypeSell
 		positionSide := futures.PositionSideTypeLong
 		if amt < 0 {
@@ -333,18 +494,33 @@ func (t *TraderCLI) checkProtectiveStopProfit(position *futures.PositionRisk) er
 
 		// 市价平仓
 		_, err := t.client.NewCreateOrderService().
-			Symbol("SOLUSDC").
+			Symbol(position.Symbol).
+			NewClientOrderID(newClientOrderID(tagProtect)).
 			Side(side).
 			PositionSide(positionSide).
 			Type(futures.OrderTypeMarket).
 			Quantity(fmt.Sprintf("%.4f", math.Abs(amt))).
 			Do(context.Background())
 
+		markPrice, _ := strconv.ParseFloat(position.MarkPrice, 64)
+		activity := Activity{
+			Symbol:   position.Symbol,
+			Action:   ActivityProtect,
+			Price:    markPrice,
+			Quantity: math.Abs(amt),
+			Reason: fmt.Sprintf(T("最高盈利 %.2f 超过启动盈利 %.2f，当前盈利 %.2f 回撤到 %.0f%% 以下"),
+				maxProfit, protect.ArmProfit, unPnl, keepRatio*100),
+			Inputs: map[string]float64{"max_profit": maxProfit, "pnl": unPnl, "arm_profit": protect.ArmProfit, "keep_ratio": keepRatio},
+		}
 		if err != nil {
-			return fmt.Errorf("保护止盈平仓失败: %v", err)
+			activity.Error = err.Error()
+			t.activity.Record(activity)
+			return fmt.Errorf(T("保护止盈平仓失败: %v"), err)
 		}
+		t.activity.Record(activity)
 
-		log.Printf("触发保护止盈，最高盈利: %.2f，当前盈利: %.2f", maxProfit, unPnl)
+		log.Printf(T("触发保护止盈，最高盈利: %.2f，当前盈利: %.2f"), maxProfit, unPnl)
+		t.alerts.Fire(position.Symbol, AlertFired, unPnl)
 		delete(t.maxProfit, position.Symbol)
 	}
 
@@ -357,89 +533,644 @@ func roundToTickSize(price float64, tickSize float64) float64 {
 }
 
 func (t *TraderCLI) run() error {
-	log.Printf("交易系统启动...")
+	log.Print(T("交易系统启动..."))
+
+	// 控制接口启动失败不影响监控本身
+	if err := t.startControl(); err != nil {
+		warnf(T("启动控制接口失败: %v"), err)
+	}
+	// MQTT也是推送事件的渠道，在startNotify之前添加
+	if err := t.startMQTT(); err != nil {
+		return err
+	}
+	if err := t.startNotify(); err != nil {
+		return err
+	}
+
+	// 设置了liquidation_alert或LIQUIDATION_ALERT时监控强平订单流，连环爆仓时提醒
+	if t.liquidationAlert > 0 {
+		for _, symbol := range t.managedSymbols() {
+			monitor := NewLiquidationMonitor(symbol, 5*time.Minute, t.liquidationAlert)
+			monitor.OnCascade = func(longNotional, shortNotional float64) {
+				warnf(T("%s连环爆仓提醒: 5分钟内强平 多头 %.0f / 空头 %.0f，注意保护止损"), symbol, longNotional, shortNotional)
+				t.notify.Send(NotifyEvent{
+					Type:     EventAlert,
+					Symbol:   symbol,
+					Severity: SeverityCritical,
+					Title:    fmt.Sprintf(T("%s连环爆仓提醒"), symbol),
+					Message:  fmt.Sprintf(T("5分钟内强平 多头 %.0f / 空头 %.0f，注意保护止损"), longNotional, shortNotional),
+					Fields:   map[string]float64{"long_notional": longNotional, "short_notional": shortNotional},
+				})
+			}
+			monitor.Start()
+		}
+	}
+
+	// 指定了run --strategy或设置了STRATEGY_CONFIG时按配置文件运行策略流水线
+	path := t.strategyFile
+	if path == "" {
+		path = os.Getenv("STRATEGY_CONFIG")
+	}
+	if path != "" {
+		pipeline, err := t.loadPipeline(path)
+		if err != nil {
+			return err
+		}
+		t.engine.mu.Lock()
+		t.engine.pipeline = pipeline
+		t.engine.mu.Unlock()
+		pipeline.Start()
+	}
 
 	for {
-		// 检查缓存的持仓信息是否仍然有效（5秒内）
-		var currentPosition *futures.PositionRisk
-		if lastPos, ok := t.lastPosition["SOLUSDC"]; ok {
-			if lastUpdate, ok := t.lastUpdate["SOLUSDC"]; ok {
-				if time.Since(lastUpdate) < 5*time.Second {
-					currentPosition = lastPos
-				}
+		// 任一交易对的持仓缓存超过5秒时重新获取，一次请求更新所有交易对
+		stale := false
+		for _, e := range t.engines {
+			// 每次循环重新读取交易对，交互模式下可能已经修改
+			symbol := e.config().Symbol
+			if _, ok := t.lastPosition[symbol]; !ok || time.Since(t.lastUpdate[symbol]) >= 5*time.Second {
+				stale = true
 			}
 		}
+		metrics.Cache(metricPositionCache, !stale)
 
-		// 如果缓存无效，获取新的持仓信息
-		if currentPosition == nil {
-			log.Printf("获取持仓信息...")
+		if stale {
+			debugf(T("获取持仓信息..."))
 			positions, err := t.client.NewGetPositionRiskService().Do(context.Background())
 			if err != nil {
-				log.Printf("获取持仓信息失败: %v", err)
+				warnf(T("获取持仓信息失败: %v"), err)
+				if t.engine.failed(err) {
+					t.notify.Send(NotifyEvent{Type: EventError, Severity: SeverityCritical, Title: T("与交易所的连接异常"), Message: err.Error()})
+				}
 				time.Sleep(5 * time.Second)  // 失败后等待5秒
 				continue
 			}
 
-			log.Printf("获取到 %d 个持仓信息", len(positions))
-
-			// 查找SOLUSDC持仓
-			log.Printf("开始查找SOLUSDC持仓信息...")
-			// 打印所有非零持仓
-			for _, p := range positions {
-				amt, _ := strconv.ParseFloat(p.PositionAmt, 64)
-				if amt != 0 {
-					log.Printf("发现持仓: Symbol=%s, PositionAmt=%s, EntryPrice=%s", p.Symbol, p.PositionAmt, p.EntryPrice)
-					// 如果是SOLUSDC，直接使用这个持仓信息
-					if p.Symbol == "SOLUSDC" {
-						log.Printf("找到SOLUSDC有效持仓 - Symbol: %s, PositionAmt: %s, EntryPrice: %s, MarkPrice: %s, UnRealizedProfit: %s, LiquidationPrice: %s, Leverage: %s, MarginType: %s",
-							p.Symbol, p.PositionAmt, p.EntryPrice, p.MarkPrice,
-							p.UnRealizedProfit, p.LiquidationPrice, p.Leverage, p.MarginType)
-						currentPosition = p
-						// 更新缓存
-						t.lastPosition["SOLUSDC"] = p
-						t.lastUpdate["SOLUSDC"] = time.Now()
-						break
-					}
+			debugf(T("获取到 %d 个持仓信息"), len(positions))
+			t.engine.polled(positions)
+			t.publishPositions(positions)
+
+			// 检查持仓之间的相关性，警告内容变化时才打印
+			if warnings, err := t.correlation.Check(positions); err != nil {
+				warnf(T("计算持仓相关性失败: %v"), err)
+			} else if key := fmt.Sprint(warnings); key != t.lastCorrelationWarn {
+				for _, w := range warnings {
+					warnf("%s", w)
 				}
+				t.lastCorrelationWarn = key
 			}
 
-			// 如果没有找到持仓，创建一个空持仓
-			if currentPosition == nil {
-				currentPosition = &futures.PositionRisk{Symbol: "SOLUSDC", PositionAmt: "0"}
-				t.lastPosition["SOLUSDC"] = currentPosition
-				t.lastUpdate["SOLUSDC"] = time.Now()
+			for _, e := range t.engines {
+				t.updatePosition(e, positions)
 			}
+			t.checkPositionAlerts(positions)
 		}
 
-		// 处理持仓信息
-		amt, _ := strconv.ParseFloat(currentPosition.PositionAmt, 64)
-		log.Printf("检查 SOLUSDC 持仓，数量: %.4f", amt)
-		
-		// 检查止盈止损
-		if err := t.checkProtectiveStopProfit(currentPosition); err != nil {
-			log.Printf("检查止盈止损失败: %v", err)
+		for _, e := range t.engines {
+			t.checkEngine(e)
 		}
 
-		// 等待一秒
-		time.Sleep(time.Second)
+		// 检查所有交易对的价格和RSI提醒，不限于正在监控的交易对
+		if err := checkMarketAlerts(t.client, t.alerts); err != nil {
+			warnf(T("检查价格提醒失败: %v"), err)
+		}
+
+		// 等待下一次轮询
+		time.Sleep(t.pollInterval)
 	}
 }
 
+// 检查没有监控实例的持仓的盈亏和盈利回撤提醒，监控中的交易对在检查保护止盈时处理
+func (t *TraderCLI) checkPositionAlerts(positions []*futures.PositionRisk) {
+	managed := t.managedSymbols()
+	for _, p := range positions {
+		if slices.Contains(managed, p.Symbol) {
+			continue
+		}
+		if amt, _ := strconv.ParseFloat(p.PositionAmt, 64); amt == 0 {
+			delete(t.maxProfit, p.Symbol)
+			continue
+		}
+		unPnl, _ := strconv.ParseFloat(p.UnRealizedProfit, 64)
+		maxProfit, ok := t.maxProfit[p.Symbol]
+		if !ok || unPnl > maxProfit {
+			maxProfit = unPnl
+			t.maxProfit[p.Symbol] = maxProfit
+		}
+		t.alerts.Check(p.Symbol, AlertPnL, unPnl)
+		if maxProfit > 0 {
+			t.alerts.Check(p.Symbol, AlertDrawdown, (maxProfit-unPnl)/maxProfit*100)
+		}
+	}
+}
+
+// 从持仓列表中找出监控实例的交易对的持仓并缓存，没有持仓时缓存一个空持仓
+func (t *TraderCLI) updatePosition(e *symbolEngine, positions []*futures.PositionRisk) {
+	symbol := e.config().Symbol
+	debugf(T("开始查找%s持仓信息..."), symbol)
+	current := &futures.PositionRisk{Symbol: symbol, PositionAmt: "0"}
+	for _, p := range positions {
+		amt, _ := strconv.ParseFloat(p.PositionAmt, 64)
+		if amt != 0 && p.Symbol == symbol {
+			debugf(T("找到有效持仓 - Symbol: %s, PositionAmt: %s, EntryPrice: %s, MarkPrice: %s, UnRealizedProfit: %s, LiquidationPrice: %s, Leverage: %s, MarginType: %s"),
+				p.Symbol, p.PositionAmt, p.EntryPrice, p.MarkPrice,
+				p.UnRealizedProfit, p.LiquidationPrice, p.Leverage, p.MarginType)
+			current = p
+			// 用标记价格检测价格异动
+			if markPrice, err := strconv.ParseFloat(p.MarkPrice, 64); err == nil {
+				e.spikes.Observe(markPrice)
+			}
+			break
+		}
+	}
+	t.lastPosition[symbol] = current
+	t.lastUpdate[symbol] = time.Now()
+}
+
+// 检查一个交易对的止盈止损和保护止盈
+func (t *TraderCLI) checkEngine(e *symbolEngine) {
+	symbol := e.config().Symbol
+	currentPosition, ok := t.lastPosition[symbol]
+	if !ok {
+		return
+	}
+
+	// 处理持仓信息
+	amt, _ := strconv.ParseFloat(currentPosition.PositionAmt, 64)
+	debugf(T("检查 %s 持仓，数量: %.4f"), symbol, amt)
+
+	// 紧急停止后不再设置止盈止损，直到通过控制接口恢复
+	if t.engine.isPaused() {
+		debugf(T("自动化已暂停，跳过%s的止盈止损检查"), symbol)
+		return
+	}
+
+	// 检查止盈止损，紧急停止时等这一轮结束再撤单平仓。拿到锁后再确认一次，
+	// 避免紧急停止刚平完仓又设置止盈止损
+	t.engine.cycle.Lock()
+	if !t.engine.isPaused() {
+		if err := t.checkProtectiveStopProfit(e, currentPosition); err != nil {
+			warnf(T("%s 检查止盈止损失败: %v"), symbol, err)
+		}
+	}
+	t.engine.cycle.Unlock()
+	t.engine.setMaxProfit(symbol, t.maxProfit[symbol])
+}
+
+// 加载策略流水线配置，可以是JSON格式的流水线配置，也可以是YAML策略文件(见strategy_file.go)
+func (t *TraderCLI) loadPipeline(path string) (*Pipeline, error) {
+	var config PipelineConfig
+	if isStrategyFile(path) {
+		f, err := loadStrategyFile(path)
+		if err != nil {
+			return nil, err
+		}
+		config = f.PipelineConfig(path)
+		log.Printf(T("加载策略文件 %s: %s %s %s"), path, f.Name, f.Symbol, f.Interval)
+	} else {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf(T("读取策略配置失败: %v"), err)
+		}
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf(T("解析策略配置失败: %v"), err)
+		}
+	}
+
+	pipeline, err := NewPipelineFromConfig(t.client, config, func(symbol string) *VolatilityMetrics {
+		v, err := fetchVolatilityMetrics(t.client, symbol)
+		if err != nil {
+			warnf(T("计算%s波动率失败: %v"), symbol, err)
+			return nil
+		}
+		return v
+	})
+	if err != nil {
+		return nil, fmt.Errorf(T("创建策略流水线失败: %v"), err)
+	}
+	return pipeline, nil
+}
+
+// 扫描多个交易对，列出满足筛选条件的交易对
+func (t *TraderCLI) screener(args []string) error {
+	fs := flag.NewFlagSet("screener", flag.ContinueOnError)
+	symbols := fs.String("symbols", "", T("扫描的交易对，逗号分隔"))
+	var cfg ScreenerConfig
+	fs.StringVar(&cfg.Interval, "interval", "1h", T("计算RSI和成交量的K线周期"))
+	fs.Float64Var(&cfg.MinChange, "min-change", 5, T("24h涨跌幅绝对值(%)"))
+	fs.Float64Var(&cfg.RSIHigh, "rsi-high", 70, T("RSI超买阈值"))
+	fs.Float64Var(&cfg.RSILow, "rsi-low", 30, T("RSI超卖阈值"))
+	fs.Float64Var(&cfg.VolumeSpike, "volume-spike", 3, T("最新K线成交量相对均量的倍数"))
+	fs.Float64Var(&cfg.FundingExtreme, "funding-extreme", 0.05, T("资金费率绝对值(%)"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *symbols != "" {
+		cfg.Symbols = strings.Split(*symbols, ",")
+	}
+
+	results, err := runScreener(t.client, cfg)
+	if err != nil {
+		return err
+	}
+
+	if len(results) == 0 {
+		fmt.Println(T("没有交易对满足筛选条件"))
+		return nil
+	}
+	for _, r := range results {
+		fmt.Println(r)
+	}
+	return nil
+}
+
+// 显示USDT/USDC永续合约的涨跌榜和成交额榜
+func (t *TraderCLI) movers(args []string) error {
+	fs := flag.NewFlagSet("movers", flag.ContinueOnError)
+	n := fs.Int("n", 10, T("每个榜单显示的数量"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	movers, err := fetchTopMovers(t.client, *n)
+	if err != nil {
+		return err
+	}
+	fmt.Print(movers)
+	return nil
+}
+
+// 显示期现基差
+func (t *TraderCLI) basis(args []string) error {
+	fs := flag.NewFlagSet("basis", flag.ContinueOnError)
+	symbol := fs.String("symbol", "SOLUSDC", T("交易对"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	b, err := fetchBasis(t.spotClient, t.client, *symbol)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s %s\n", b.Symbol, b)
+	return nil
+}
+
+// 导出K线、成交记录或资金流水到CSV。按日期导出对账数据:
+// export -from 2024-01-01 -to 2024-02-01 -format csv，不指定-type时同时导出成交记录和资金流水，每种一个文件
+func (t *TraderCLI) export(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	var opts ExportOptions
+	kinds := fs.String("type", ExportKlines, T("导出类型，多个用逗号分隔: ")+strings.Join(exportKinds, "/"))
+	fs.StringVar(&opts.Symbol, "symbol", "", T("交易对，K线默认SOLUSDC，成交记录和资金流水为空时导出全部交易对"))
+	fs.StringVar(&opts.Interval, "interval", "1h", T("K线周期"))
+	fs.IntVar(&opts.Limit, "limit", 1000, T("导出最近多少根K线"))
+	dates := dateRangeFlags(fs, 30, T("导出最近多少天的成交记录或资金流水"))
+	format := fs.String("format", "csv", T("导出格式，目前只支持csv"))
+	path := fs.String("o", "", T("输出文件，默认按交易对和类型命名，只能用于单个类型"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "csv" {
+		return fmt.Errorf(T("不支持的导出格式: %s"), *format)
+	}
+
+	opts.Symbol = strings.ToUpper(opts.Symbol)
+	var err error
+	if opts.Since, opts.Until, err = dates.parse(); err != nil {
+		return err
+	}
+
+	// 按日期导出时默认导出对账需要的成交记录和资金流水
+	typeSet := false
+	fs.Visit(func(f *flag.Flag) { typeSet = typeSet || f.Name == "type" })
+	if !typeSet && dates.explicit() {
+		*kinds = ExportTrades + "," + ExportIncome
+	}
+	list := strings.Split(*kinds, ",")
+	if *path != "" && len(list) > 1 {
+		return errors.New(T("导出多个类型时不能用-o指定文件"))
+	}
+	for i, kind := range list {
+		list[i] = strings.TrimSpace(kind)
+		if !slices.Contains(exportKinds, list[i]) {
+			return fmt.Errorf(T("不支持的导出类型: %s"), list[i])
+		}
+	}
+
+	cache := NewKlineCache(t.client, "kline_cache", 0)
+	for _, kind := range list {
+		opts := opts
+		opts.Kind = kind
+		if kind == ExportKlines && opts.Symbol == "" {
+			opts.Symbol = "SOLUSDC"
+		}
+		out := *path
+		if out == "" {
+			out = opts.DefaultPath()
+		}
+		if err := t.exportFile(cache, opts, out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 导出一种数据到文件
+func (t *TraderCLI) exportFile(cache *KlineCache, opts ExportOptions, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf(T("创建导出文件失败: %v"), err)
+	}
+	defer f.Close()
+
+	n, err := runExport(t.client, cache, opts, f)
+	if err != nil {
+		return err
+	}
+	fmt.Printf(T("已导出%d行到 %s\n"), n, path)
+	return nil
+}
+
+// 显示挂单；指定-history时分页显示订单历史，每页末尾给出下一页的-before和-before-id参数
+func (t *TraderCLI) orders(args []string) error {
+	fs := flag.NewFlagSet("orders", flag.ContinueOnError)
+	symbol := fs.String("symbol", "SOLUSDC", T("交易对"))
+	history := fs.Bool("history", false, T("显示订单历史而不是挂单"))
+	n := fs.Int("n", 50, T("每页显示的订单数量"))
+	before := fs.Int64("before", 0, T("只显示该时间(毫秒时间戳)之前的订单，用于翻页"))
+	beforeID := fs.Int64("before-id", 0, T("同时包括-before这一毫秒中订单号小于该值的订单，用于翻页"))
+	output := outputFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+
+	if !*history {
+		return t.openOrders(strings.ToUpper(*symbol), *output)
+	}
+
+	var since time.Time
+	if *before > 0 {
+		since = time.UnixMilli(*before)
+	}
+	page, err := fetchOrderHistory(t.client, strings.ToUpper(*symbol), since, *beforeID, *n)
+	if err != nil {
+		return err
+	}
+
+	// json输出时next_before和next_before_id为下一页的-before和-before-id参数，没有下一页时为0
+	if *output == outputJSON {
+		var next int64
+		if !page.Next.IsZero() {
+			next = page.Next.UnixMilli()
+		}
+		return writeJSON(struct {
+			Orders       []OrderJSON `json:"orders"`
+			NextBefore   int64       `json:"next_before"`
+			NextBeforeID int64       `json:"next_before_id"`
+		}{ordersJSON(page.Orders), next, page.NextID})
+	}
+
+	if len(page.Orders) == 0 {
+		fmt.Println(T("没有订单"))
+		return nil
+	}
+	for _, o := range page.Orders {
+		fmt.Println(formatHistoryOrder(o))
+	}
+	if !page.Next.IsZero() {
+		fmt.Printf(T("下一页: orders -history -symbol %s -n %d -before %d -before-id %d\n"), strings.ToUpper(*symbol), *n, page.Next.UnixMilli(), page.NextID)
+	}
+	return nil
+}
+
+// 查询自动化决策的活动记录
+func (t *TraderCLI) activityLog(args []string) error {
+	fs := flag.NewFlagSet("activity", flag.ContinueOnError)
+	symbol := fs.String("symbol", "", T("交易对，为空时显示全部"))
+	action := fs.String("action", "", T("决策类型: ")+strings.Join(activityActionNames(), "/"))
+	since := fs.Duration("since", 24*time.Hour, T("显示最近多长时间的记录，0表示全部"))
+	n := fs.Int("n", 100, T("最多显示多少条"))
+	output := outputFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+
+	q := ActivityQuery{Symbol: strings.ToUpper(*symbol), Action: *action, Limit: *n}
+	if *since > 0 {
+		q.Since = time.Now().Add(-*since)
+	}
+	activities, err := t.activity.Query(q)
+	if err != nil {
+		return err
+	}
+	if *output == outputJSON {
+		if activities == nil {
+			activities = []Activity{}
+		}
+		return writeJSON(activities)
+	}
+	if len(activities) == 0 {
+		fmt.Println(T("没有活动记录"))
+		return nil
+	}
+	for _, a := range activities {
+		fmt.Printf("[%s] %s\n", a.Source, a)
+	}
+	return nil
+}
+
+// 用历史K线回测策略
+func (t *TraderCLI) backtest(args []string) error {
+	fs := flag.NewFlagSet("backtest", flag.ContinueOnError)
+	var config StrategyConfig
+	fs.StringVar(&config.Name, "strategy", "ema_cross", T("策略: ")+strings.Join(StrategyNames(), "/")+T("，或策略文件(.yaml)"))
+	fs.StringVar(&config.Symbol, "symbol", "SOLUSDC", T("交易对"))
+	fs.StringVar(&config.Interval, "interval", "15m", T("K线周期"))
+	params := fs.String("params", "", T("策略参数，如 fast=9,slow=21,stop=2"))
+	limit := fs.Int("limit", 1000, T("回测使用的K线数量，指定-from时忽略"))
+	from := fs.String("from", "", T("起始日期(YYYY-MM-DD)，指定后忽略-limit"))
+	to := fs.String("to", "", T("结束日期(YYYY-MM-DD，不含当天)，默认到现在"))
+	riskPerTrade := fs.Float64("risk", 10, T("每笔交易止损时亏损的金额(USDC)"))
+	fee := fs.Float64("fee", 0.05, T("单边手续费率(%)"))
+	verbose := fs.Bool("v", false, T("显示每笔交易"))
+	report := fs.String("report", "", T("HTML回测报告的路径，默认按策略和交易对命名，none表示不生成"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// 策略文件没有指定-symbol和-interval时使用文件中的设置
+	if isStrategyFile(config.Name) {
+		set := make(map[string]bool)
+		fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+		if !set["symbol"] {
+			config.Symbol = ""
+		}
+		if !set["interval"] {
+			config.Interval = ""
+		}
+	}
+	config.Symbol = strings.ToUpper(config.Symbol)
+	var err error
+	if config.Params, err = parseStrategyParams(*params); err != nil {
+		return err
+	}
+
+	strategy, err := NewStrategy(config)
+	if err != nil {
+		return err
+	}
+	klines, err := t.backtestKlines(strategy.Symbol(), strategy.Interval(), *limit, *from, *to)
+	if err != nil {
+		return err
+	}
+
+	result := runBacktest(strategy, klines,
+		[]RiskRule{&PositionSizer{RiskPerTrade: *riskPerTrade}}, *fee/100)
+	if *verbose {
+		for _, trade := range result.Trades {
+			fmt.Println(&trade)
+		}
+		fmt.Println()
+	}
+	fmt.Print(result)
+
+	if *report == "none" {
+		return nil
+	}
+	if *report == "" {
+		*report = fmt.Sprintf("backtest_%s_%s_%s_%s.html", strategy.Name(), strategy.Symbol(), strategy.Interval(), time.Now().Format("20060102_150405"))
+	}
+	f, err := os.Create(*report)
+	if err != nil {
+		return fmt.Errorf(T("创建回测报告失败: %v"), err)
+	}
+	defer f.Close()
+	if err := writeBacktestReport(f, result); err != nil {
+		return err
+	}
+	fmt.Printf(T("回测报告已保存到 %s\n"), *report)
+	return nil
+}
+
+// 解析策略参数，如 fast=9,slow=21,stop=2
+func parseStrategyParams(s string) (map[string]float64, error) {
+	params := make(map[string]float64)
+	if s == "" {
+		return params, nil
+	}
+	for _, kv := range strings.Split(s, ",") {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf(T("策略参数格式错误: %s"), kv)
+		}
+		v, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf(T("策略参数格式错误: %s"), kv)
+		}
+		params[strings.TrimSpace(parts[0])] = v
+	}
+	return params, nil
+}
+
+// 回测使用的已收盘K线。指定from时读取[from, to)之间的本地历史数据，否则使用K线缓存中最近的limit根
+func (t *TraderCLI) backtestKlines(symbol, interval string, limit int, from, to string) ([]Kline, error) {
+	if from == "" {
+		klines, err := NewKlineCache(t.client, "kline_cache", 0).Get(symbol, interval, limit)
+		if err != nil {
+			return nil, err
+		}
+		return closedKlines(klines, interval), nil
+	}
+
+	start, err := time.ParseInLocation(time.DateOnly, from, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf(T("日期格式错误: %s"), from)
+	}
+	end := time.Now()
+	if to != "" {
+		if end, err = time.ParseInLocation(time.DateOnly, to, time.Local); err != nil {
+			return nil, fmt.Errorf(T("日期格式错误: %s"), to)
+		}
+	}
+	// 优先使用download下载的历史数据，缺少的部分先下载到本地
+	store := NewHistoryStore(t.client, historyDir, nil)
+	if _, err := store.DownloadKlines(symbol, interval, start, end); err != nil {
+		return nil, err
+	}
+	klines, err := store.Klines(symbol, interval, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return closedKlines(klines, interval), nil
+}
+
 func main() {
-	// 从环境变量获取API密钥
-	apiKey := os.Getenv("BINANCE_API_KEY")
-	secretKey := os.Getenv("BINANCE_SECRET_KEY")
+	// 配置优先级: 命令行参数 > 环境变量 > 配置文件，见app_config.go
+	args := parseConfigFlags(os.Args[1:])
 
-	if apiKey == "" || secretKey == "" {
-		log.Fatal("请设置BINANCE_API_KEY和BINANCE_SECRET_KEY环境变量")
+	// 日志级别和格式: --quiet、--verbose、--log-format，见cli_log.go
+	args, err := parseLogFlags(args)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	config, err := loadAppConfig(configPath())
+	if err != nil {
+		fatalf("%v", err)
+	}
+
+	// 配置language为en或设置TRADER_LANG=en时使用英文
+	if err := SetLanguage(config.Language); err != nil {
+		warnf("%v", err)
+	}
+
+	// 子命令，不指定时运行监控
+	name := "run"
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" || name == "-h" || name == "--help" {
+		printCLIUsage()
+		return
+	}
+	if name == "--version" {
+		name = "version"
+	}
+	cmd := findCLICommand(name)
+	if cmd == nil {
+		printCLIUsage()
+		fatalf(T("未知命令: %s"), name)
 	}
 
-	trader, err := NewTraderCLI(apiKey, secretKey)
+	// 默认账户的密钥可以来自配置文件或BINANCE_API_KEY和BINANCE_SECRET_KEY
+	profile := config.Profile
+	p, err := config.Find(profile)
+	if err != nil && !offlineCLICommands[name] {
+		fatalf("%v", err)
+	}
+	if profile != "" {
+		if err := ensureProfileDir(profile); err != nil {
+			fatalf("%v", err)
+		}
+		log.Printf(T("使用账户: %s"), profile)
+	}
+
+	trader, err := NewTraderCLI(p.APIKey, p.SecretKey, profile, config)
 	if err != nil {
-		log.Fatalf("创建交易系统失败: %v", err)
+		fatalf(T("创建交易系统失败: %v"), err)
 	}
 
-	if err := trader.run(); err != nil {
-		log.Fatalf("交易系统运行失败: %v", err)
+	if err := cmd.Run(trader, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		fatalf(T(cmd.Fail), err)
 	}
 }
@@ -3,37 +3,132 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"image/color"
 	"math"
+	"net/http"
 	"os"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
-	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/data/binding"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
+	"github.com/adshao/go-binance/v2"
 	"github.com/adshao/go-binance/v2/futures"
-	"gonum.org/v1/plot"
-	"gonum.org/v1/plot/vg"
-	"gonum.org/v1/plot/vg/draw"
-	"image/color"
 )
 
 type Config struct {
 	APIKey    string `json:"api_key"`
 	SecretKey string `json:"secret_key"`
+	// 其他账户的API密钥，以及当前使用的账户，为空时使用顶层的密钥
+	Profiles []Profile `json:"profiles,omitempty"`
+	Profile  string    `json:"profile,omitempty"`
 	TakeProfit struct {
 		Long  float64 `json:"LONG"`
 		Short float64 `json:"SHORT"`
 	} `json:"take_profit"`
+	// 5分钟内强平名义价值超过该值时提醒，0表示不提醒
+	LiquidationAlert float64 `json:"liquidation_alert"`
+	// 市场筛选条件
+	Screener ScreenerConfig `json:"screener"`
+	// 年化基差绝对值超过该值(%)时提醒，0表示不提醒
+	BasisAlert float64 `json:"basis_alert"`
+	// 启用的分析器，为空时使用 ta/stats/market，加入llm启用AI点评
+	AnalysisProviders []string `json:"analysis_providers"`
+	// AI点评使用的LLM接口
+	LLM LLMConfig `json:"llm"`
+	// K线图设置
+	Chart struct {
+		Interval string `json:"interval"` // K线周期，默认5m
+		Limit    int    `json:"limit"`    // K线数量，默认50
+		Mode     string `json:"mode"`     // 图表模式: candle/heikin-ashi/line/area
+		RSI      bool   `json:"rsi"`      // 显示RSI副图
+		MACD     bool   `json:"macd"`     // 显示MACD副图
+		// 当前交易对平仓时自动保存K线图截图
+		AutoSnapshot bool   `json:"auto_snapshot"`
+		SnapshotDir  string `json:"snapshot_dir"` // 截图保存目录，默认snapshots
+	} `json:"chart"`
+	// K线缓存目录，默认kline_cache
+	KlineCacheDir string `json:"kline_cache_dir"`
+	// 价格异动检测
+	Spike SpikeConfig `json:"spike"`
+	// 策略流水线，没有配置策略时不启动
+	Pipeline PipelineConfig `json:"pipeline"`
+	// 当前交易对，切换后自动保存，默认SOLUSDC
+	Symbol string `json:"symbol"`
+	// 自选列表
+	Watchlist []string `json:"watchlist"`
+	// 界面主题: light/dark/contrast，默认light
+	Theme string `json:"theme"`
+	// 界面语言: zh/en，默认zh，修改后重启生效
+	Language string `json:"language"`
+	// 界面缩放、字体大小和K线图尺寸
+	Display DisplayConfig `json:"display"`
+	// 成交、止损触发、提醒和连接断开的提示音
+	Sound SoundConfig `json:"sound"`
+	// 自动止盈止损和保护止盈
+	Protect ProtectConfig `json:"protect"`
+	// 命令行同时管理的其他交易对，界面不使用，保存配置时保留
+	Strategies []ProtectConfig `json:"strategies,omitempty"`
+	// 命令行监控程序的推送通知和MQTT发布，界面不使用，保存配置时保留
+	Notify NotifyConfig `json:"notify"`
+	MQTT   *MQTTConfig  `json:"mqtt,omitempty"`
+	// 按交易对暂停的自动化规则
+	PausedRules map[string][]AutomationRule `json:"paused_rules,omitempty"`
+	// 下单前确认
+	OrderConfirm OrderConfirmConfig `json:"order_confirm"`
+	// 全部平仓、暂停自动化和关闭自动止损前需要输入的PIN
+	PIN PINConfig `json:"pin"`
+}
+
+// 图表模式
+const (
+	chartModeCandle     = "candle"
+	chartModeHeikinAshi = "heikin-ashi"
+	chartModeLine       = "line"
+	chartModeArea       = "area"
+)
+
+// 图表模式和显示名称
+var chartModes = []struct {
+	Mode  string
+	Label string
+}{
+	{chartModeCandle, "蜡烛图"},
+	{chartModeHeikinAshi, "Heikin-Ashi"},
+	{chartModeLine, "折线图"},
+	{chartModeArea, "面积图"},
+}
+
+// 自选列表中的一项
+type watchItem struct {
+	Symbol string
+	Price  float64
+	Change float64 // 24h涨跌幅(%)
 }
 
+// 可选的K线周期和数量
+var (
+	klineIntervals = []string{"1m", "3m", "5m", "15m", "30m", "1h", "2h", "4h", "6h", "12h", "1d"}
+	klineLimits    = []string{"50", "100", "200", "500"}
+)
+
+// 按百分比计算下单数量时预留的开仓手续费率和维持保证金率，按名义价值计算，
+// 避免MAX下单因保证金不足被拒绝或开仓后马上接近强平
+const (
+	orderFeeBuffer   = 0.0005
+	orderMaintBuffer = 0.005
+)
+
 type Kline struct {
 	Time   time.Time
 	Open   float64
@@ -47,16 +142,72 @@ type TraderUI struct {
 	app          fyne.App
 	window       fyne.Window
 	client       *futures.Client
+	spotClient   *binance.Client
+	config       *Config
 	currentPriceLabel *widget.Label
-	klineChart   *canvas.Image
+	klineChart   *CandleChart
 	analysisLabel *widget.Label
+	moversLabel  *widget.Label
+	accountLabel *widget.Label
 	positionsList *widget.List
+	positionTable *PositionTable
 	ordersList   *widget.List
 	positions    binding.UntypedList
 	orders       binding.UntypedList
 	klines       []Kline
 	currentPrice float64
 
+	// 各面板的错误横幅，轮询失败时显示
+	chartBanner     *ErrorBanner
+	priceBanner     *ErrorBanner
+	accountBanner   *ErrorBanner
+	positionsBanner *ErrorBanner
+	ordersBanner    *ErrorBanner
+
+	// 当前交易对的持仓，无持仓时为nil
+	position *futures.PositionRisk
+
+	// 当前使用的账户，交易数据库、提醒和截图按账户分开保存
+	profile     string
+	profileItem *fyne.MenuItem
+
+	// PIN连续输错的次数，以及锁定到的时间
+	pinFailures    int
+	pinLockedUntil time.Time
+
+	// 当前交易对和自选列表
+	symbol        string
+	symbolSelect  *widget.SelectEntry
+	watchlist     binding.UntypedList
+	watchlistView *widget.List
+
+	// 系统托盘菜单
+	trayMenu  *fyne.Menu
+	trayPnL   *fyne.MenuItem
+	trayPause *fyne.MenuItem
+
+	// 暂停保护止盈、自动止盈止损和策略流水线
+	paused atomic.Bool
+
+	// 自动止盈止损和保护止盈的设置，以及按交易对暂停的规则
+	protect    atomic.Pointer[ProtectConfig]
+	automation *AutomationRules
+
+	// 主界面分栏和可以隐藏的面板，布局保存在偏好设置中
+	split  *container.Split
+	panels map[string]fyne.CanvasObject
+
+	// K线周期、数量和图表模式
+	interval        string
+	limit           int
+	chartMode       string
+	intervalButtons map[string]*widget.Button
+	intervalSelect  *widget.Select
+	limitSelect     *widget.Select
+
+	// 本地K线缓存，切换周期时先显示缓存的数据
+	klineCache *KlineCache
+
 	// 下单表单
 	sideSelect   *widget.Select
 	priceEntry   *widget.Entry
@@ -65,82 +216,286 @@ type TraderUI struct {
 
 	// 跟踪最高盈利
 	maxProfit map[string]float64
+
+	// 交易对的价格和数量精度
+	filters *SymbolFilterCache
+
+	// 跟踪持仓资金费
+	funding *FundingTracker
+
+	// 多空比和主动买卖量
+	sentiment *MarketSentiment
+
+	// 强平订单流监控
+	liquidations *LiquidationMonitor
+
+	// 持仓相关性监控
+	correlation *CorrelationMonitor
+
+	// 期现基差
+	basis          *Basis
+	lastBasisAlert time.Time
+
+	// 已实现波动率
+	volatility *VolatilityMetrics
+
+	// 当日开盘价、最高价和最低价
+	session *SessionStats
+
+	// 价格异动检测
+	spikes *SpikeDetector
+
+	// 策略流水线
+	pipeline *Pipeline
+
+	// 价格提醒
+	alerts *AlertManager
+
+	// 自动操作的通知记录和提示
+	notices *Notices
+
+	// 程序输出的日志，供日志窗口查看
+	logs *LogBuffer
+
+	// 本地交易数据库，用于盈亏统计
+	tradeDB *TradeDB
+
+	// 自动化决策的活动记录
+	activity *ActivityLog
+
+	// 提示音，上次刷新的持仓用于判断成交和止损触发
+	sound         *SoundPlayer
+	lastPositions map[string]PositionRow
+
+	// 状态栏：API延迟和请求权重、上次成功获取价格的时间(毫秒)、交易所是否断开
+	statusBar    *StatusBar
+	apiMonitor   *APIMonitor
+	lastUpdate   atomic.Int64
+	disconnected atomic.Bool
 }
 
 func (ui *TraderUI) initUI() {
-	// 恢复默认颜色主题
-	ui.app.Settings().SetTheme(theme.DefaultTheme())
+	// 使用配置的颜色主题
+	ui.app.Settings().SetTheme(newTraderTheme(ui.config.Theme, ui.config.Display))
 
 	// 创建价格显示
-	priceLabel := widget.NewLabelWithStyle("当前价格", fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
-	ui.currentPriceLabel = widget.NewLabelWithStyle("加载中...", fyne.TextAlignCenter, fyne.TextStyle{Monospace: true, Bold: true})
+	priceLabel := widget.NewLabelWithStyle(T("当前价格"), fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
+	ui.currentPriceLabel = widget.NewLabelWithStyle(T("加载中..."), fyne.TextAlignCenter, fyne.TextStyle{Monospace: true, Bold: true})
+
+	// 交易对选择，可以直接输入自选列表之外的交易对
+	ui.symbolSelect = widget.NewSelectEntry(ui.config.Watchlist)
+	ui.symbolSelect.SetText(ui.symbol)
+	ui.symbolSelect.OnChanged = func(symbol string) {
+		// 从下拉列表选择时切换，手动输入时等按回车再切换
+		for _, s := range ui.config.Watchlist {
+			if s == symbol {
+				go ui.switchSymbol(symbol)
+			}
+		}
+	}
+	ui.symbolSelect.OnSubmitted = func(symbol string) {
+		go ui.switchSymbol(symbol)
+	}
+
+	// 创建各面板的错误横幅，重试时重新执行对应的轮询
+	ui.chartBanner = NewErrorBanner(ui.updateKlines)
+	ui.priceBanner = NewErrorBanner(ui.updatePrice)
+	ui.accountBanner = NewErrorBanner(ui.updateAccount)
+	ui.positionsBanner = NewErrorBanner(ui.updatePositions)
+	ui.ordersBanner = NewErrorBanner(ui.updateOrders)
 
 	priceCard := widget.NewCard("", "", container.NewVBox(
+		ui.priceBanner.Content(),
+		ui.symbolSelect,
 		priceLabel,
 		ui.currentPriceLabel,
 	))
 
+	// 创建账户余额和保证金显示
+	ui.accountLabel = widget.NewLabelWithStyle(T("加载中..."), fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	accountCard := widget.NewCard(T("账户"), "", container.NewVBox(ui.accountBanner.Content(), ui.accountLabel))
+
 	// 创建下单表单
-	ui.sideSelect = widget.NewSelect([]string{"买入做多", "卖出做空"}, nil)
-	ui.sideSelect.SetSelected("买入做多")
+	ui.sideSelect = widget.NewSelect([]string{T("买入做多"), T("卖出做空")}, nil)
+	ui.sideSelect.SetSelected(T("买入做多"))
 
 	ui.priceEntry = widget.NewEntry()
-	ui.priceEntry.SetPlaceHolder("输入价格")
+	ui.priceEntry.SetPlaceHolder(T("输入价格"))
 	ui.priceEntry.TextStyle = fyne.TextStyle{Monospace: true}
 
 	ui.amountEntry = widget.NewEntry()
-	ui.amountEntry.SetPlaceHolder("输入数量")
+	ui.amountEntry.SetPlaceHolder(T("输入数量"))
 	ui.amountEntry.TextStyle = fyne.TextStyle{Monospace: true}
 
+	// 按可用保证金的比例快速填写数量
+	quantityBar := container.NewGridWithColumns(4)
+	for _, percent := range []float64{10, 25, 50, 100} {
+		percent := percent
+		label := fmt.Sprintf("%.0f%%", percent)
+		if percent == 100 {
+			label = "MAX"
+		}
+		quantityBar.Add(widget.NewButton(label, func() {
+			ui.fillQuantity(percent)
+		}))
+	}
+
 	ui.stopLossEntry = widget.NewEntry()
-	ui.stopLossEntry.SetPlaceHolder("输入止损价格")
+	ui.stopLossEntry.SetPlaceHolder(T("输入止损价格"))
 	ui.stopLossEntry.TextStyle = fyne.TextStyle{Monospace: true}
 
-	submitBtn := widget.NewButton("下单", func() {
+	submitBtn := widget.NewButton(T("下单"), func() {
 		ui.submitOrder()
 	})
 	submitBtn.Importance = widget.HighImportance  // 高亮显示下单按钮
 
 	orderForm := widget.NewCard("", "", container.NewVBox(  // 使用Card包装下单表单
-		widget.NewLabelWithStyle("下单", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle(T("下单"), fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
 		container.NewGridWithColumns(2,
-			widget.NewLabelWithStyle("方向", fyne.TextAlignTrailing, fyne.TextStyle{}),
+			widget.NewLabelWithStyle(T("方向"), fyne.TextAlignTrailing, fyne.TextStyle{}),
 			ui.sideSelect,
-			widget.NewLabelWithStyle("价格", fyne.TextAlignTrailing, fyne.TextStyle{}),
+			widget.NewLabelWithStyle(T("价格"), fyne.TextAlignTrailing, fyne.TextStyle{}),
 			ui.priceEntry,
-			widget.NewLabelWithStyle("数量", fyne.TextAlignTrailing, fyne.TextStyle{}),
+			widget.NewLabelWithStyle(T("数量"), fyne.TextAlignTrailing, fyne.TextStyle{}),
 			ui.amountEntry,
-			widget.NewLabelWithStyle("止损价格", fyne.TextAlignTrailing, fyne.TextStyle{}),
+			layout.NewSpacer(),
+			quantityBar,
+			widget.NewLabelWithStyle(T("止损价格"), fyne.TextAlignTrailing, fyne.TextStyle{}),
 			ui.stopLossEntry,
 		),
 		container.NewPadded(submitBtn),  // 添加padding使按钮更突出
 	))
 
 	// 创建K线图显示
-	ui.klineChart = &canvas.Image{
-		FillMode: canvas.ImageFillOriginal,
-	}
-	ui.klineChart.SetMinSize(fyne.NewSize(180, 120))  // 缩小到原来的60%
+	ui.klineChart = NewCandleChart()
+	ui.klineChart.SetMinSize(ui.scaled(ui.config.Display.ChartWidth, ui.config.Display.ChartHeight))
+	ui.klineChart.OnSecondaryTapped = ui.showChartMenu
+	ui.klineChart.LevelDragInfo = ui.exitDragInfo
+	ui.klineChart.OnLevelDragged = ui.confirmDragExit
+	ui.klineChart.SetIndicators(ui.config.Chart.RSI, ui.config.Chart.MACD)
 
 	// 创建分析区域
 	ui.analysisLabel = widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
 	ui.analysisLabel.Wrapping = fyne.TextWrapBreak
 	analysisScroll := container.NewVScroll(ui.analysisLabel)
-	analysisScroll.SetMinSize(fyne.NewSize(180, 213))  // 增加三分之一（160 * 1.33 ≈ 213）
+	analysisScroll.SetMinSize(ui.scaled(180, 213))  // 增加三分之一（160 * 1.33 ≈ 213）
+
+	// 创建涨跌榜区域
+	ui.moversLabel = widget.NewLabelWithStyle(T("加载中..."), fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	moversScroll := container.NewVScroll(ui.moversLabel)
+	moversScroll.SetMinSize(ui.scaled(180, 150))
+
+	// 创建K线周期和数量选择
+	intervalSelect := widget.NewSelect(klineIntervals, nil)
+	ui.intervalSelect = intervalSelect
+	intervalSelect.SetSelected(ui.interval)
+	intervalSelect.OnChanged = func(interval string) {
+		ui.highlightInterval(interval)
+		go ui.switchInterval(interval)
+	}
+
+	// 常用周期快捷切换按钮
+	intervalBar := container.NewHBox()
+	ui.intervalButtons = make(map[string]*widget.Button)
+	for _, interval := range []string{"1m", "5m", "15m", "1h", "4h", "1d"} {
+		interval := interval
+		btn := widget.NewButton(interval, func() {
+			intervalSelect.SetSelected(interval)
+		})
+		ui.intervalButtons[interval] = btn
+		intervalBar.Add(btn)
+	}
+	ui.highlightInterval(ui.interval)
+	limitSelect := widget.NewSelect(klineLimits, nil)
+	ui.limitSelect = limitSelect
+	limitSelect.SetSelected(strconv.Itoa(ui.limit))
+	limitSelect.OnChanged = func(limit string) {
+		ui.limit, _ = strconv.Atoi(limit)
+		go ui.refreshKlines()
+	}
+
+	// 创建图表模式选择
+	var modeLabels []string
+	for _, m := range chartModes {
+		modeLabels = append(modeLabels, T(m.Label))
+	}
+	modeSelect := widget.NewSelect(modeLabels, nil)
+	for _, m := range chartModes {
+		if m.Mode == ui.chartMode {
+			modeSelect.SetSelected(T(m.Label))
+		}
+	}
+	modeSelect.OnChanged = func(label string) {
+		for _, m := range chartModes {
+			if T(m.Label) == label {
+				ui.chartMode = m.Mode
+			}
+		}
+		go ui.switchInterval(ui.interval)
+	}
 
-	chartContainer := widget.NewCard("价格走势", "", container.NewVBox(
+	// RSI和MACD副图开关，选择会保存到配置文件
+	rsiCheck := widget.NewCheck("RSI", nil)
+	rsiCheck.SetChecked(ui.config.Chart.RSI)
+	macdCheck := widget.NewCheck("MACD", nil)
+	macdCheck.SetChecked(ui.config.Chart.MACD)
+	toggleIndicators := func(bool) {
+		ui.config.Chart.RSI = rsiCheck.Checked
+		ui.config.Chart.MACD = macdCheck.Checked
+		ui.klineChart.SetIndicators(rsiCheck.Checked, macdCheck.Checked)
+		if err := ui.saveConfig(); err != nil {
+			fmt.Printf("%v\n", err)
+		}
+	}
+	rsiCheck.OnChanged = toggleIndicators
+	macdCheck.OnChanged = toggleIndicators
+
+	// 导出K线图，以及平仓时自动截图的开关
+	snapshotBtn := widget.NewButtonWithIcon("", theme.MediaPhotoIcon(), ui.exportChartSnapshot)
+	autoSnapshotCheck := widget.NewCheck(T("平仓截图"), func(checked bool) {
+		ui.config.Chart.AutoSnapshot = checked
+		if err := ui.saveConfig(); err != nil {
+			fmt.Printf("%v\n", err)
+		}
+	})
+	autoSnapshotCheck.Checked = ui.config.Chart.AutoSnapshot
+
+	analysisCard := widget.NewCard(T("技术分析"), "", analysisScroll)
+	moversCard := widget.NewCard(T("涨跌榜"), "", moversScroll)
+
+	chartContainer := widget.NewCard(T("价格走势"), "", container.NewVBox(
+		ui.chartBanner.Content(),
+		container.NewHBox(
+			intervalBar,
+			layout.NewSpacer(),
+			widget.NewLabel(T("周期")),
+			intervalSelect,
+			widget.NewLabel(T("数量")),
+			limitSelect,
+			modeSelect,
+			rsiCheck,
+			macdCheck,
+			autoSnapshotCheck,
+			snapshotBtn,
+		),
 		widget.NewSeparator(),
 		container.NewVBox(
 			container.NewPadded(ui.klineChart),
-			widget.NewCard(
-				"技术分析",
-				"",
-				analysisScroll,
-			),
+			analysisCard,
+			moversCard,
 		),
 	))
 
-	// 创建持仓列表
+	// 创建持仓表格，点击一行切换到该交易对，操作列可以修改止盈止损、编辑笔记和平仓
+	ui.positionTable = NewPositionTable()
+	ui.positionTable.OnSymbolSelected = func(symbol string) {
+		go ui.switchSymbol(symbol)
+	}
+	ui.positionTable.OnEditExits = ui.showEditExits
+	ui.positionTable.OnEditNote = ui.showPositionNote
+	ui.positionTable.OnClose = ui.confirmClosePosition
+
+	// 持仓表格下方显示当前交易对的资金费、日内位置和相关性提醒
 	ui.positions = binding.NewUntypedList()
 	ui.positionsList = widget.NewListWithData(
 		ui.positions,
@@ -171,26 +526,29 @@ func (ui *TraderUI) initUI() {
 
 	// 创建持仓和订单列表
 	positionsScroll := container.NewVScroll(ui.positionsList)
-	positionsScroll.SetMinSize(fyne.NewSize(100, 150))  // 设置滚动区域最小尺寸
+	positionsScroll.SetMinSize(ui.scaled(100, 60))  // 设置滚动区域最小尺寸
+	positionsSplit := container.NewVSplit(ui.positionTable.Table, positionsScroll)
+	positionsSplit.SetOffset(0.65)
 	positionsCard := widget.NewCard(
-		"持仓", 
+		T("持仓"), 
 		"", 
-		positionsScroll,
+		container.NewBorder(ui.positionsBanner.Content(), nil, nil, nil, positionsSplit),
 	)
 	positionsCard.Resize(fyne.NewSize(0, 100))  // 设置卡片尺寸
 
 	ordersScroll := container.NewVScroll(ui.ordersList)
-	ordersScroll.SetMinSize(fyne.NewSize(100, 100))  // 设置滚动区域最小尺寸
+	ordersScroll.SetMinSize(ui.scaled(100, 100))  // 设置滚动区域最小尺寸
 	ordersCard := widget.NewCard(
-		"订单", 
+		T("订单"), 
 		"", 
-		ordersScroll,
+		container.NewBorder(ui.ordersBanner.Content(), nil, nil, nil, ordersScroll),
 	)
 	ordersCard.Resize(fyne.NewSize(0, 50))  // 设置卡片尺寸
 
 	// 创建右侧面板
 	rightPanel := container.NewVBox(
 		priceCard,
+		accountCard,
 		orderForm,
 		container.NewGridWithRows(2,  // 使用网格布局并排显示持仓和订单
 			positionsCard,
@@ -210,10 +568,130 @@ func (ui *TraderUI) initUI() {
 		rightContainer,
 	)
 	content.SetOffset(0.65)  // 让右侧面板占35%
+	ui.split = content
+
+	// 左侧自选列表
+	ui.watchlist = binding.NewUntypedList()
+	ui.watchlistView = widget.NewListWithData(
+		ui.watchlist,
+		func() fyne.CanvasObject {
+			// 每项两行: 交易对和价格
+			return widget.NewLabelWithStyle("\n", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+		},
+		func(i binding.DataItem, o fyne.CanvasObject) {
+			if val, err := i.(binding.Untyped).Get(); err == nil {
+				item := val.(*watchItem)
+				text := item.Symbol
+				if item.Price > 0 {
+					text = fmt.Sprintf("%s\n%.4f %+.2f%%", item.Symbol, item.Price, item.Change)
+				}
+				o.(*widget.Label).SetText(text)
+			}
+		},
+	)
+	ui.watchlistView.OnSelected = func(id widget.ListItemID) {
+		ui.watchlistView.UnselectAll()
+		if val, err := ui.watchlist.GetValue(id); err == nil {
+			go ui.switchSymbol(val.(*watchItem).Symbol)
+		}
+	}
+	removeBtn := widget.NewButton(T("移除当前"), func() {
+		ui.removeFromWatchlist(ui.symbol)
+	})
+	watchlistScroll := container.NewVScroll(ui.watchlistView)
+	watchlistScroll.SetMinSize(ui.scaled(140, 0))
+	watchlistCard := widget.NewCard(T("自选"), "", container.NewBorder(nil, removeBtn, nil, nil, watchlistScroll))
+	ui.setWatchlist(nil)
+
+	// 设置窗口内容和大小，恢复上次的窗口布局
+	ui.panels = map[string]fyne.CanvasObject{
+		prefPanelWatchlist: watchlistCard,
+		prefPanelAnalysis:  analysisCard,
+		prefPanelMovers:    moversCard,
+		prefPanelAccount:   accountCard,
+	}
+	ui.window.Resize(ui.scaled(800, 700))
+	ui.statusBar = NewStatusBar()
+	ui.window.SetContent(ui.notices.Overlay(container.NewBorder(nil, ui.statusBar.Content(), watchlistCard, nil, content)))
+	ui.restoreLayout()
+	// 有系统托盘时关闭窗口只是隐藏，保护止盈和自动止盈止损继续运行
+	tray := ui.setupTray()
+	ui.window.SetCloseIntercept(func() {
+		ui.saveLayout()
+		if tray {
+			ui.window.Hide()
+			return
+		}
+		ui.window.Close()
+	})
 
-	// 设置窗口内容和大小
-	ui.window.Resize(fyne.NewSize(800, 700))
-	ui.window.SetContent(content)
+	// 工具和设置菜单
+	themeMenu := fyne.NewMenuItem(T("主题"), nil)
+	var themeItems []*fyne.MenuItem
+	for _, option := range themeOptions {
+		name := option.Name
+		item := fyne.NewMenuItem(T(option.Label), nil)
+		item.Checked = name == ui.config.Theme || (ui.config.Theme == "" && name == themeLight)
+		item.Action = func() {
+			for _, i := range themeItems {
+				i.Checked = i == item
+			}
+			ui.setTheme(name)
+		}
+		themeItems = append(themeItems, item)
+	}
+	themeMenu.ChildMenu = fyne.NewMenu("", themeItems...)
+
+	// 语言切换后需要重启，已经创建的控件不会重新翻译
+	languageMenu := fyne.NewMenuItem("语言 / Language", nil)
+	var languageItems []*fyne.MenuItem
+	for _, option := range languageOptions {
+		code := option.Code
+		item := fyne.NewMenuItem(option.Label, nil)
+		item.Checked = code == normalizeLanguage(ui.config.Language)
+		item.Action = func() {
+			for _, i := range languageItems {
+				i.Checked = i == item
+			}
+			ui.window.MainMenu().Refresh()
+			ui.config.Language = code
+			if err := ui.saveConfig(); err != nil {
+				dialog.ShowError(err, ui.window)
+				return
+			}
+			dialog.ShowInformation(T("设置"), T("语言设置已保存，重启后生效"), ui.window)
+		}
+		languageItems = append(languageItems, item)
+	}
+	languageMenu.ChildMenu = fyne.NewMenu("", languageItems...)
+
+	ui.window.SetMainMenu(fyne.NewMainMenu(
+		fyne.NewMenu(T("工具"),
+			fyne.NewMenuItem(T("市场筛选"), ui.showScreener),
+			fyne.NewMenuItem(T("提醒"), ui.showAlerts),
+			fyne.NewMenuItem(T("导出CSV"), ui.showExport),
+			fyne.NewMenuItem(T("账户统计"), ui.showStatsWindow),
+			fyne.NewMenuItem(T("订单历史"), ui.showOrderHistory),
+			fyne.NewMenuItem(T("通知记录"), ui.showNotices),
+			fyne.NewMenuItem(T("日志"), ui.showLogs),
+			fyne.NewMenuItem(T("活动记录"), ui.showActivity),
+			fyne.NewMenuItem(T("自动化控制"), ui.showAutomationPanel),
+			fyne.NewMenuItem(T("价格阶梯"), ui.showPriceLadder),
+		),
+		fyne.NewMenu(T("设置"),
+			fyne.NewMenuItem(T("参数设置"), ui.showSettings),
+			themeMenu, languageMenu, ui.profileMenu(),
+			fyne.NewMenuItem(T("显示设置"), ui.showDisplaySettings),
+			fyne.NewMenuItem(T("声音设置"), ui.showSoundSettings),
+			fyne.NewMenuItem(T("PIN锁"), ui.showPINSettings),
+		),
+		ui.viewMenu(),
+		fyne.NewMenu(T("帮助"),
+			fyne.NewMenuItem(T("快捷键"), ui.showShortcuts),
+			fyne.NewMenuItem(T("关于"), ui.showAbout),
+		),
+	))
+	ui.registerShortcuts()
 
 	// 启动数据更新
 	ui.startDataUpdater()
@@ -221,7 +699,7 @@ func (ui *TraderUI) initUI() {
 
 func (ui *TraderUI) submitOrder() {
 	side := futures.SideTypeBuy
-	if ui.sideSelect.Selected == "卖出做空" {
+	if ui.sideSelect.Selected == T("卖出做空") {
 		side = futures.SideTypeSell
 	}
 
@@ -229,9 +707,85 @@ func (ui *TraderUI) submitOrder() {
 	quantity := ui.amountEntry.Text
 	stopLoss := ui.stopLossEntry.Text
 
+	// 价格和数量无效时不确认，由交易所返回错误
+	summary := OrderSummary{Symbol: ui.symbol, Long: side == futures.SideTypeBuy}
+	summary.Price, _ = strconv.ParseFloat(price, 64)
+	summary.Quantity, _ = strconv.ParseFloat(quantity, 64)
+	summary.StopLoss, _ = strconv.ParseFloat(stopLoss, 64)
+	if summary.Notional() > 0 && ui.config.OrderConfirm.Required(summary.Notional()) {
+		// 杠杆在后台获取，拿到后再在界面线程显示确认框
+		go func() {
+			summary.Leverage = ui.symbolLeverage(summary.Symbol)
+			fyne.Do(func() {
+				ui.confirmOrder(summary, func() {
+					ui.placeOrder(side, price, quantity, stopLoss)
+				})
+			})
+		}()
+		return
+	}
+	ui.placeOrder(side, price, quantity, stopLoss)
+}
+
+// 按保证金资产可用余额的百分比和杠杆计算下单数量，按数量精度向下取整。
+// 价格为空时按最新价格计算
+func (ui *TraderUI) fillQuantity(percent float64) {
+	symbol := ui.symbol
+	price, _ := strconv.ParseFloat(ui.priceEntry.Text, 64)
+	if price <= 0 {
+		price = ui.currentPrice
+	}
+	if price <= 0 {
+		return
+	}
+
+	go func() {
+		qty, filters, err := ui.quantityForPercent(symbol, price, percent)
+		fyne.Do(func() {
+			if err != nil {
+				dialog.ShowError(err, ui.window)
+				return
+			}
+			ui.amountEntry.SetText(filters.FormatQuantity(qty))
+		})
+	}()
+}
+
+func (ui *TraderUI) quantityForPercent(symbol string, price, percent float64) (float64, SymbolFilters, error) {
+	filters, err := ui.filters.Get(symbol)
+	if err != nil {
+		return 0, filters, err
+	}
+	account, err := fetchAccountSummary(ui.client)
+	if err != nil {
+		return 0, filters, err
+	}
+	var available float64
+	for _, a := range account.Assets {
+		if a.Asset == filters.MarginAsset {
+			available = a.AvailableBalance
+		}
+	}
+	leverage := ui.symbolLeverage(symbol)
+	if leverage <= 0 {
+		leverage = 1
+	}
+
+	// 每单位名义价值需要的保证金，加上开仓手续费和维持保证金的余量
+	marginRate := 1/leverage + orderFeeBuffer + orderMaintBuffer
+	qty := filters.FloorQuantity(available * percent / 100 / marginRate / price)
+	if qty <= 0 || qty < filters.MinQty {
+		return 0, filters, fmt.Errorf(T("可用保证金不足，最小下单数量为%s"), filters.FormatQuantity(filters.MinQty))
+	}
+	return qty, filters, nil
+}
+
+// 提交限价单，设置了止损价格时同时创建止损单
+func (ui *TraderUI) placeOrder(side futures.SideType, price, quantity, stopLoss string) {
 	// 创建主订单
 	order, err := ui.client.NewCreateOrderService().
-		Symbol("SOLUSDC").
+		Symbol(ui.symbol).
+		NewClientOrderID(newClientOrderID(tagManual)).
 		Side(side).
 		PositionSide("BOTH").  // 双向持仓模式
 		Type(futures.OrderTypeLimit).
@@ -253,7 +807,8 @@ func (ui *TraderUI) submitOrder() {
 		}
 
 		_, err = ui.client.NewCreateOrderService().
-			Symbol("SOLUSDC").
+			Symbol(ui.symbol).
+			NewClientOrderID(newClientOrderID(tagStopLoss)).
 			Side(stopSide).
 			PositionSide("BOTH").
 			Type(futures.OrderTypeStopMarket).
@@ -263,118 +818,60 @@ func (ui *TraderUI) submitOrder() {
 			Do(context.Background())
 
 		if err != nil {
-			dialog.ShowError(fmt.Errorf("主订单已成功，但止损单创建失败: %v", err), ui.window)
+			dialog.ShowError(fmt.Errorf(T("主订单已成功，但止损单创建失败: %v"), err), ui.window)
 			return
 		}
 	}
 
-	dialog.ShowInformation("下单成功", fmt.Sprintf("订单ID: %d", order.OrderID), ui.window)
+	dialog.ShowInformation(T("下单成功"), fmt.Sprintf(T("订单ID: %d"), order.OrderID), ui.window)
 }
 
 func (ui *TraderUI) updateKlines() error {
-	klines, err := ui.client.NewKlinesService().
-		Symbol("SOLUSDC").
-		Interval("5m").        // 使用5分钟K线
-		Limit(50).            // 获取50根K线
-		Do(context.Background())
+	symbol, interval := ui.symbol, ui.interval
+	klines, err := ui.klineCache.Get(symbol, interval, ui.limit)
 	if err != nil {
-		return fmt.Errorf("获取K线数据失败: %v", err)
-	}
-
-	// 将K线数据转换为我们的格式
-	ui.klines = make([]Kline, len(klines))
-	for i, k := range klines {
-		open, _ := strconv.ParseFloat(k.Open, 64)
-		high, _ := strconv.ParseFloat(k.High, 64)
-		low, _ := strconv.ParseFloat(k.Low, 64)
-		close, _ := strconv.ParseFloat(k.Close, 64)
-		volume, _ := strconv.ParseFloat(k.Volume, 64)
-		ui.klines[i] = Kline{
-			Time:   time.Unix(k.OpenTime/1000, 0),
-			Open:   open,
-			High:   high,
-			Low:    low,
-			Close:  close,
-			Volume: volume,
-		}
-	}
-
-	// 创建一个新的图表
-	p := plot.New()
-
-	p.Title.Text = "SOL/USDC K线图"
-	p.X.Label.Text = "时间"
-	p.Y.Label.Text = "价格"
-
-	// 设置图表边距和样式
-	p.X.Padding = 0
-	p.Y.Padding = 0
-	p.X.Min = -1
-	p.X.Max = float64(len(ui.klines))
-
-	// 计算价格范围
-	minPrice := ui.klines[0].Low
-	maxPrice := ui.klines[0].High
-	for _, k := range ui.klines {
-		if k.Low < minPrice {
-			minPrice = k.Low
-		}
-		if k.High > maxPrice {
-			maxPrice = k.High
-		}
+		return err
 	}
-	padding := (maxPrice - minPrice) * 0.01
-	p.Y.Min = minPrice - padding
-	p.Y.Max = maxPrice + padding
 
-	candlePlotter := &CandlePlotter{
-		Klines: ui.klines,
-		Width:  0.8,
+	// 获取期间已经切换了交易对或周期，丢弃结果
+	if symbol != ui.symbol || interval != ui.interval {
+		return nil
 	}
+	return ui.renderKlines(klines, interval)
+}
 
-	p.Add(candlePlotter)
+// 切换K线周期，有缓存时先显示缓存的数据再刷新
+func (ui *TraderUI) switchInterval(interval string) {
+	ui.interval = interval
 
-	// 设置更多的X轴时间标签
-	ticks := make([]plot.Tick, 5)
-	for i := 0; i < 5; i++ {
-		pos := float64(i) * float64(len(ui.klines)-1) / 4
-		idx := int(pos)
-		if idx >= len(ui.klines) {
-			idx = len(ui.klines) - 1
-		}
-		ticks[i] = plot.Tick{
-			Value: pos,
-			Label: ui.klines[idx].Time.Format("15:04"),
+	if cached, ok := ui.klineCache.Cached(ui.symbol, interval, ui.limit); ok {
+		if err := ui.renderKlines(cached, interval); err != nil {
+			fmt.Printf(T("显示K线失败: %v\n"), err)
 		}
 	}
-	p.X.Tick.Marker = plot.ConstantTicks(ticks)
 
-	// 创建一个临时文件来保存图表
-	tmpFile, err := os.CreateTemp("", "kline-*.png")
-	if err != nil {
-		return fmt.Errorf("创建临时文件失败: %v", err)
-	}
-	defer os.Remove(tmpFile.Name())
-
-	// 保存图表到临时文件
-	if err := p.Save(9.6*vg.Inch, 5.4*vg.Inch, tmpFile.Name()); err != nil {  // 缩小到原来的60%
-		return fmt.Errorf("保存K线图失败: %v", err)
-	}
+	ui.refreshKlines()
+}
 
-	// 读取临时文件内容
-	imgData, err := os.ReadFile(tmpFile.Name())
-	if err != nil {
-		return fmt.Errorf("读取K线图失败: %v", err)
-	}
+// 绘制K线图并更新技术分析
+func (ui *TraderUI) renderKlines(klines []Kline, interval string) error {
+	ui.klines = klines
 
-	// 在UI线程中更新图表
+	// 在UI线程中更新图表，Heikin-Ashi模式只改变绘制的K线，分析仍使用原始K线
+	title := fmt.Sprintf(T("%s %s K线图"), ui.symbol, interval)
+	profile := calculateVolumeProfile(ui.klines, 24)
+	mode := ui.chartMode
 	fyne.Do(func() {
-		ui.klineChart.Resource = fyne.NewStaticResource("kline.png", imgData)
-		ui.klineChart.Refresh()
+		ui.klineChart.SetData(title, interval, klines, mode, profile)
 	})
 
+	// 显示的是保护的交易对时检查K线异动，RSI提醒在checkMarketAlerts中按5分钟K线检查
+	if ui.symbol == ui.protectConfig().Symbol {
+		ui.spikes.CheckCandles(klines, interval)
+	}
+
 	// 更新技术分析
-	analysis := ui.analyzeKlines(ui.klines)
+	analysis := ui.analyzeKlines(ui.klines, interval)
 	fyne.Do(func() {
 		ui.analysisLabel.SetText(analysis)
 	})
@@ -382,114 +879,201 @@ func (ui *TraderUI) updateKlines() error {
 	return nil
 }
 
-func (ui *TraderUI) analyzeKlines(klines []Kline) string {
-	if len(klines) < 2 {
-		return "数据不足以进行分析"
-	}
-
-	var analysis strings.Builder
-
-	// 计算涨跌幅
-	lastClose := klines[len(klines)-1].Close
-	prevClose := klines[len(klines)-2].Close
-	change := (lastClose - prevClose) / prevClose * 100
-
-	// 计算成交量变化
-	lastVol := klines[len(klines)-1].Volume
-	prevVol := klines[len(klines)-2].Volume
-	volChange := (lastVol - prevVol) / prevVol * 100
-
-	// 计算RSI
-	rsi := ui.calculateRSI(klines, 14)
-
-	analysis.WriteString(fmt.Sprintf("24h涨跌幅: %.2f%%\n", change))
-	analysis.WriteString(fmt.Sprintf("成交量变化: %.2f%%\n", volChange))
-	analysis.WriteString(fmt.Sprintf("RSI(14): %.2f\n\n", rsi))
-
-	// 添加简单分析结论
-	analysis.WriteString("市场分析:\n")
-	if change > 0 {
-		analysis.WriteString("- 价格呈上涨趋势\n")
-	} else {
-		analysis.WriteString("- 价格呈下跌趋势\n")
-	}
-
-	if volChange > 0 {
-		analysis.WriteString("- 成交量放大，市场活跃度增加\n")
-	} else {
-		analysis.WriteString("- 成交量萎缩，市场活跃度下降\n")
-	}
-
-	if rsi > 70 {
-		analysis.WriteString("- RSI超买，可能存在回调风险\n")
-	} else if rsi < 30 {
-		analysis.WriteString("- RSI超卖，可能存在反弹机会\n")
-	} else {
-		analysis.WriteString("- RSI处于中性区间\n")
+func (ui *TraderUI) analyzeKlines(klines []Kline, interval string) string {
+	input := &AnalysisInput{
+		Symbol:       ui.symbol,
+		Interval:     interval,
+		Klines:       klines,
+		Sentiment:    ui.sentiment,
+		Volatility:   ui.volatility,
+		Session:      ui.session,
+		Basis:        ui.basis,
+		Liquidations: ui.liquidations,
 	}
-
-	return analysis.String()
+	return runAnalysis(ui.config.AnalysisProviders, input)
 }
 
-func (ui *TraderUI) calculateRSI(klines []Kline, period int) float64 {
-	if len(klines) < period+1 {
-		return 50 // 数据不足时返回中性值
-	}
+// 按界面缩放换算尺寸
+func (ui *TraderUI) scaled(width, height float32) fyne.Size {
+	scale := ui.config.Display.Scale
+	return fyne.NewSize(width*scale, height*scale)
+}
 
-	var gains, losses float64
-	for i := len(klines) - period; i < len(klines); i++ {
-		change := klines[i].Close - klines[i-1].Close
-		if change > 0 {
-			gains += change
-		} else {
-			losses -= change
-		}
+// 切换颜色主题并保存到配置文件
+func (ui *TraderUI) setTheme(name string) {
+	ui.config.Theme = name
+	ui.app.Settings().SetTheme(newTraderTheme(name, ui.config.Display))
+	ui.window.MainMenu().Refresh()
+	if err := ui.saveConfig(); err != nil {
+		fmt.Printf("%v\n", err)
 	}
+}
 
-	if losses == 0 {
-		return 100
-	}
+// 当前生效的保护设置，设置窗口修改后立即生效
+func (ui *TraderUI) protectConfig() ProtectConfig {
+	return *ui.protect.Load()
+}
 
-	rs := gains / losses
-	return 100 - (100 / (1 + rs))
+func (ui *TraderUI) setProtectConfig(config ProtectConfig) {
+	config.applyDefaults()
+	ui.config.Protect = config
+	ui.protect.Store(&config)
 }
 
+// 读取配置文件，路径和环境变量的优先级见app_config.go
 func (ui *TraderUI) loadConfig() (*Config, error) {
-	data, err := os.ReadFile("config.json")
+	data, err := os.ReadFile(configPath())
 	if err != nil {
-		return nil, fmt.Errorf("读取配置文件失败: %v", err)
+		return nil, fmt.Errorf(T("读取配置文件失败: %v"), err)
 	}
 
 	var config Config
 	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("解析配置文件失败: %v", err)
-	}
-
-	if config.APIKey == "" || config.SecretKey == "" {
-		return nil, fmt.Errorf("请在config.json中填写API密钥")
+		return nil, fmt.Errorf(T("解析配置文件失败: %v"), err)
 	}
 
 	return &config, nil
 }
 
-func (ui *TraderUI) NewTraderUI() (*TraderUI, error) {
+// 配置文件中的账户
+func (c *Config) profiles() profileConfig {
+	return profileConfig{APIKey: c.APIKey, SecretKey: c.SecretKey, Profiles: c.Profiles}
+}
+
+// 保存配置到配置文件，用于记住界面上的选择
+func (ui *TraderUI) saveConfig() error {
+	return writeConfig(ui.config)
+}
+
+func (ui *TraderUI) NewTraderUI(a fyne.App) (*TraderUI, error) {
 	config, err := ui.loadConfig()
 	if err != nil {
-		return nil, fmt.Errorf("加载配置失败: %v", err)
+		return nil, fmt.Errorf(T("加载配置失败: %v"), err)
+	}
+	if err := SetLanguage(envString("TRADER_LANG", config.Language)); err != nil {
+		fmt.Printf("%v\n", err)
+	}
+
+	profile, err := config.profiles().withEnv().Find(activeProfile(config.Profile))
+	if err != nil {
+		return nil, err
 	}
+	if err := ensureProfileDir(profile.Name); err != nil {
+		return nil, err
+	}
+	ui.profile = profile.Name
 
 	// 创建期货客户端，使用期货的API接口
-	futuresClient := futures.NewClient(config.APIKey, config.SecretKey)
+	futuresClient := futures.NewClient(profile.APIKey, profile.SecretKey)
+	ui.apiMonitor = NewAPIMonitor()
+	futuresClient.HTTPClient = &http.Client{Transport: ui.apiMonitor}
 
-	a := app.New()
-	w := a.NewWindow("币安期货交易")
+	title := T("币安期货交易")
+	if profile.Name != defaultProfile {
+		title += " - " + profile.Name
+	}
+	w := a.NewWindow(title)
 
 	ui.app = a
 	ui.window = w
 	ui.client = futuresClient
+	ui.spotClient = binance.NewClient(profile.APIKey, profile.SecretKey)
+	ui.config = config
+	ui.symbol = config.Symbol
+	if ui.symbol == "" {
+		ui.symbol = "SOLUSDC"
+	}
+	if len(config.Watchlist) == 0 {
+		config.Watchlist = []string{ui.symbol}
+	}
+	ui.interval = config.Chart.Interval
+	if interval := a.Preferences().String(prefChartInterval); interval != "" {
+		ui.interval = interval
+	}
+	if ui.interval == "" {
+		ui.interval = "5m"
+	}
+	ui.limit = config.Chart.Limit
+	if ui.limit <= 0 {
+		ui.limit = 50
+	}
+	config.Display.applyDefaults()
+	ui.setProtectConfig(config.Protect)
+	ui.automation = NewAutomationRules(config.PausedRules)
+	ui.chartMode = config.Chart.Mode
+	if ui.chartMode == "" {
+		ui.chartMode = chartModeCandle
+	}
 	ui.positions = binding.NewUntypedList()
 	ui.orders = binding.NewUntypedList()
 	ui.maxProfit = make(map[string]float64)
+	ui.notices = NewNotices()
+	ui.sound = NewSoundPlayer(config.Sound)
+	cacheDir := config.KlineCacheDir
+	if cacheDir == "" {
+		cacheDir = "kline_cache"
+	}
+	ui.klineCache = NewKlineCache(futuresClient, cacheDir, 2000)
+	ui.funding = NewFundingTracker(futuresClient)
+	ui.filters = NewSymbolFilterCache(futuresClient)
+
+	// 监控强平订单流，连环爆仓时发送系统通知
+	ui.liquidations = NewLiquidationMonitor(ui.symbol, 5*time.Minute, envFloat("LIQUIDATION_ALERT", config.LiquidationAlert))
+	ui.liquidations.OnCascade = func(longNotional, shortNotional float64) {
+		ui.app.SendNotification(fyne.NewNotification(T("连环爆仓提醒"),
+			fmt.Sprintf(T("%s 5分钟内强平: 多头 %.0f / 空头 %.0f"), ui.symbol, longNotional, shortNotional)))
+	}
+	ui.liquidations.Start()
+
+	// AI点评分析器，在analysis_providers中加入llm后启用
+	RegisterAnalysisProvider(NewLLMAnalysisProvider(config.LLM))
+
+	// 检测价格异动，发送系统通知
+	spikeConfig := config.Spike.withEnv()
+	ui.spikes = NewSpikeDetector(spikeConfig)
+	ui.spikes.OnSpike = func(spike *Spike) {
+		text := ui.protectConfig().Symbol + " " + spike.String()
+		if spikeConfig.TightenStops {
+			text += T("，已收紧保护止盈")
+		}
+		ui.app.SendNotification(fyne.NewNotification(T("价格异动提醒"), text))
+	}
+
+	// 加载提醒，桌面通知渠道发送系统通知
+	alerts, err := LoadAlertManager(profilePath(ui.profile, "alerts.json"))
+	if err != nil {
+		return nil, fmt.Errorf(T("加载提醒失败: %v"), err)
+	}
+	ui.alerts = alerts
+	ui.alerts.RegisterChannel("desktop", func(alert *Alert, value float64) {
+		ui.app.SendNotification(fyne.NewNotification(fmt.Sprintf(T("%s提醒"), alert.Type.Label()), alert.Message(value)))
+	})
+	ui.alerts.RegisterChannel("sound", func(alert *Alert, value float64) {
+		ui.sound.Play(SoundAlert)
+	})
+
+	// 本地交易数据库
+	tradeDB, err := OpenTradeDB(futuresClient, profilePath(ui.profile, "trade_db.json"))
+	if err != nil {
+		return nil, err
+	}
+	ui.tradeDB = tradeDB
+	ui.activity = NewActivityLog(profilePath(ui.profile, activityFile), "ui")
+
+	// 策略流水线
+	if len(config.Pipeline.Strategies) > 0 {
+		pipeline, err := NewPipelineFromConfig(futuresClient, config.Pipeline, ui.volatilityFor)
+		if err != nil {
+			return nil, fmt.Errorf(T("创建策略流水线失败: %v"), err)
+		}
+		pipeline.Logf = func(format string, args ...interface{}) {
+			ui.notify(NoticeInfo, format, args...)
+		}
+		ui.pipeline = pipeline
+	}
+
+	// 用最近100根1小时K线计算持仓之间的相关性
+	ui.correlation = NewCorrelationMonitor(futuresClient, "1h", 100, 0.8)
 
 	// 初始化UI组件
 	ui.initUI()
@@ -497,17 +1081,30 @@ func (ui *TraderUI) NewTraderUI() (*TraderUI, error) {
 	return ui, nil
 }
 
+// 策略风控使用的波动率，当前交易对使用定时更新的数据，其他交易对实时计算
+func (ui *TraderUI) volatilityFor(symbol string) *VolatilityMetrics {
+	if v := ui.volatility; v != nil && v.Symbol == symbol {
+		return v
+	}
+	v, err := fetchVolatilityMetrics(ui.client, symbol)
+	if err != nil {
+		fmt.Printf(T("计算%s波动率失败: %v\n"), symbol, err)
+		return nil
+	}
+	return v
+}
+
 func (ui *TraderUI) getCurrentPrice() (float64, error) {
-	ticker, err := ui.client.NewPremiumIndexService().Symbol("SOLUSDC").Do(context.Background())
+	ticker, err := ui.client.NewPremiumIndexService().Symbol(ui.symbol).Do(context.Background())
 	if err != nil {
-		return 0, fmt.Errorf("获取价格失败: %v", err)
+		return 0, fmt.Errorf(T("获取价格失败: %v"), err)
 	}
 	if len(ticker) == 0 {
-		return 0, fmt.Errorf("未找到SOLUSDC的价格")
+		return 0, fmt.Errorf(T("未找到%s的价格"), ui.symbol)
 	}
 	price, err := strconv.ParseFloat(ticker[0].MarkPrice, 64)
 	if err != nil {
-		return 0, fmt.Errorf("解析价格失败: %v", err)
+		return 0, fmt.Errorf(T("解析价格失败: %v"), err)
 	}
 	return price, nil
 }
@@ -522,6 +1119,11 @@ func (ui *TraderUI) updatePrice() error {
 	fyne.Do(func() {
 		ui.currentPriceLabel.SetText(fmt.Sprintf("%.4f USDC", price))
 	})
+
+	// 用最新价格更新日内最高最低价
+	if ui.session != nil {
+		ui.session.Update(price)
+	}
 	return nil
 }
 
@@ -532,9 +1134,9 @@ func (ui *TraderUI) checkAndSetTakeProfit(position *futures.PositionRisk) error
 	}
 
 	// 获取当前订单
-	orders, err := ui.client.NewListOpenOrdersService().Symbol("SOLUSDC").Do(context.Background())
+	orders, err := ui.client.NewListOpenOrdersService().Symbol(position.Symbol).Do(context.Background())
 	if err != nil {
-		return fmt.Errorf("获取订单失败: %v", err)
+		return fmt.Errorf(T("获取订单失败: %v"), err)
 	}
 
 	// 检查是否已有止盈单
@@ -550,39 +1152,53 @@ func (ui *TraderUI) checkAndSetTakeProfit(position *futures.PositionRisk) error
 
 	// 如果没有止盈单，创建一个
 	if !hasTakeProfit {
+		protect := ui.protectConfig()
 		side := futures.SideTypeSell
 		positionSide := futures.PositionSideTypeLong
 		var price float64
 
 		if amt > 0 {
-			// 多仓，止盈价格在入场价上方200点
-			price = entryPrice + 2.0  // 2.0 = 200点/100
+			// 多仓，止盈价格在入场价上方
+			price = entryPrice + protect.TakeProfit
 			side = futures.SideTypeSell
 			positionSide = futures.PositionSideTypeLong
 		} else {
-			// 空仓，止盈价格在入场价下方200点
-			price = entryPrice - 2.0  // 2.0 = 200点/100
+			// 空仓，止盈价格在入场价下方
+			price = entryPrice - protect.TakeProfit
 			side = futures.SideTypeBuy
 			positionSide = futures.PositionSideTypeShort
 		}
 
-		// 将价格四舍五入到0.01（SOL的最小价格单位）
-		price = roundToTickSize(price, 0.01)
+		// 将价格四舍五入到交易对的最小价格单位
+		price = roundToTickSize(price, protect.TickSize)
 
 		// 创建限价止盈单
 		_, err := ui.client.NewCreateOrderService().
-			Symbol("SOLUSDC").
+			Symbol(position.Symbol).
+			NewClientOrderID(newClientOrderID(tagTakeProfit)).
 			Side(side).
 			PositionSide(positionSide).
 			Type(futures.OrderTypeLimit).
 			TimeInForce(futures.TimeInForceTypeGTC).  // GTC: Good Till Cancel
-			Price(fmt.Sprintf("%.2f", price)).  // 使用2位小数
+			Price(protect.FormatPrice(price)).  // 小数位数与价格精度一致
 			Quantity(fmt.Sprintf("%.4f", math.Abs(amt))).
 			Do(context.Background())
-		
+
+		activity := Activity{
+			Symbol:   position.Symbol,
+			Action:   ActivityTakeProfit,
+			Price:    price,
+			Quantity: math.Abs(amt),
+			Reason:   fmt.Sprintf(T("%s %.4f 没有找到有效止盈单"), positionDirection(amt), math.Abs(amt)),
+			Inputs:   map[string]float64{"entry": entryPrice, "take_profit": protect.TakeProfit},
+		}
 		if err != nil {
-			return fmt.Errorf("创建止盈单失败: %v", err)
+			activity.Error = err.Error()
+			ui.activity.Record(activity)
+			return fmt.Errorf(T("创建止盈单失败: %v"), err)
 		}
+		ui.activity.Record(activity)
+		ui.notify(NoticeInfo, T("已自动设置止盈单: %s %s %.4f @ %.2f"), position.Symbol, side, math.Abs(amt), price)
 	}
 
 	return nil
@@ -595,9 +1211,9 @@ func (ui *TraderUI) checkAndSetStopLoss(position *futures.PositionRisk) error {
 	}
 
 	// 获取当前止损订单
-	orders, err := ui.client.NewListOpenOrdersService().Symbol("SOLUSDC").Do(context.Background())
+	orders, err := ui.client.NewListOpenOrdersService().Symbol(position.Symbol).Do(context.Background())
 	if err != nil {
-		return fmt.Errorf("获取订单失败: %v", err)
+		return fmt.Errorf(T("获取订单失败: %v"), err)
 	}
 
 	// 检查是否已有止损单
@@ -613,37 +1229,51 @@ func (ui *TraderUI) checkAndSetStopLoss(position *futures.PositionRisk) error {
 
 	// 如果没有止损单，创建一个
 	if !hasStopLoss {
+		protect := ui.protectConfig()
 		stopPrice := entryPrice
 		side := futures.SideTypeSell
 		positionSide := futures.PositionSideTypeLong
 		if amt > 0 {
-			// 多仓，止损价格在入场价下方100点
-			stopPrice = entryPrice - 1.0  // 1.0 = 100点/100
+			// 多仓，止损价格在入场价下方
+			stopPrice = entryPrice - protect.StopLoss
 			side = futures.SideTypeSell
 			positionSide = futures.PositionSideTypeLong
 		} else {
-			// 空仓，止损价格在入场价上方100点
-			stopPrice = entryPrice + 1.0  // 1.0 = 100点/100
+			// 空仓，止损价格在入场价上方
+			stopPrice = entryPrice + protect.StopLoss
 			side = futures.SideTypeBuy
 			positionSide = futures.PositionSideTypeShort
 		}
 
-		// 将价格四舍五入到0.01
-		stopPrice = roundToTickSize(stopPrice, 0.01)
+		// 将价格四舍五入到交易对的最小价格单位
+		stopPrice = roundToTickSize(stopPrice, protect.TickSize)
 
 		// 创建止损市价单
 		_, err := ui.client.NewCreateOrderService().
-			Symbol("SOLUSDC").
+			Symbol(position.Symbol).
+			NewClientOrderID(newClientOrderID(tagStopLoss)).
 			Side(side).
 			PositionSide(positionSide).  // 设置持仓方向
 			Type(futures.OrderTypeStopMarket).
-			StopPrice(fmt.Sprintf("%.2f", stopPrice)).  // 使用2位小数
+			StopPrice(protect.FormatPrice(stopPrice)).  // 小数位数与价格精度一致
 			Quantity(fmt.Sprintf("%.4f", math.Abs(amt))).
 			Do(context.Background())
-		
+
+		activity := Activity{
+			Symbol:   position.Symbol,
+			Action:   ActivityStopLoss,
+			Price:    stopPrice,
+			Quantity: math.Abs(amt),
+			Reason:   fmt.Sprintf(T("%s %.4f 没有找到有效止损单"), positionDirection(amt), math.Abs(amt)),
+			Inputs:   map[string]float64{"entry": entryPrice, "stop_loss": protect.StopLoss},
+		}
 		if err != nil {
-			return fmt.Errorf("创建止损单失败: %v", err)
+			activity.Error = err.Error()
+			ui.activity.Record(activity)
+			return fmt.Errorf(T("创建止损单失败: %v"), err)
 		}
+		ui.activity.Record(activity)
+		ui.notify(NoticeInfo, T("已自动设置止损单: %s %s %.4f @ %.2f"), position.Symbol, side, math.Abs(amt), stopPrice)
 	}
 
 	return nil
@@ -657,6 +1287,7 @@ func (ui *TraderUI) checkProtectiveStopProfit(position *futures.PositionRisk) er
 	}
 
 	unPnl, _ := strconv.ParseFloat(position.UnRealizedProfit, 64)
+	prevMaxProfit := ui.maxProfit[position.Symbol]
 	
 	// 更新最高盈利
 	if _, exists := ui.maxProfit[position.Symbol]; !exists {
@@ -666,9 +1297,27 @@ func (ui *TraderUI) checkProtectiveStopProfit(position *futures.PositionRisk) er
 	}
 
 	maxProfit := ui.maxProfit[position.Symbol]
+
+	// 检查未实现盈亏和盈利回撤提醒
+	ui.alerts.Check(position.Symbol, AlertPnL, unPnl)
+	if maxProfit > 0 {
+		ui.alerts.Check(position.Symbol, AlertDrawdown, (maxProfit-unPnl)/maxProfit*100)
+	}
+
+	// 最高盈利首次达到启动盈利时保护止盈启动
+	protect := ui.protectConfig()
+	if prevMaxProfit < protect.ArmProfit && maxProfit >= protect.ArmProfit {
+		ui.alerts.Fire(position.Symbol, AlertArmed, unPnl)
+	}
 	
-	// 如果曾经盈利超过200U，且当前回撤超过50%，执行市价平仓
-	if maxProfit >= 200 && unPnl <= maxProfit*0.5 {
+	// 暂停保护止盈时只跟踪最高盈利和提醒，不平仓
+	if !ui.automation.Enabled(position.Symbol, RuleProtect) {
+		return nil
+	}
+
+	// 如果曾经盈利超过启动盈利，且当前回撤到保留比例以下（价格异动后收紧），执行市价平仓
+	keepRatio := ui.spikes.ProtectRatio(protect.KeepRatio)
+	if maxProfit >= protect.ArmProfit && unPnl <= maxProfit*keepRatio {
 		side := futures.SideTypeSell
 		positionSide := futures.PositionSideTypeLong
 		if amt < 0 {
@@ -678,16 +1327,33 @@ func (ui *TraderUI) checkProtectiveStopProfit(position *futures.PositionRisk) er
 
 		// 市价平仓
 		_, err := ui.client.NewCreateOrderService().
-			Symbol("SOLUSDC").
+			Symbol(position.Symbol).
+			NewClientOrderID(newClientOrderID(tagProtect)).
 			Side(side).
 			PositionSide(positionSide).
 			Type(futures.OrderTypeMarket).
 			Quantity(fmt.Sprintf("%.4f", math.Abs(amt))).
 			Do(context.Background())
 
+		markPrice, _ := strconv.ParseFloat(position.MarkPrice, 64)
+		activity := Activity{
+			Symbol:   position.Symbol,
+			Action:   ActivityProtect,
+			Price:    markPrice,
+			Quantity: math.Abs(amt),
+			Reason: fmt.Sprintf(T("最高盈利 %.2f 超过启动盈利 %.2f，当前盈利 %.2f 回撤到 %.0f%% 以下"),
+				maxProfit, protect.ArmProfit, unPnl, keepRatio*100),
+			Inputs: map[string]float64{"max_profit": maxProfit, "pnl": unPnl, "arm_profit": protect.ArmProfit, "keep_ratio": keepRatio},
+		}
 		if err != nil {
-			return fmt.Errorf("保护止盈平仓失败: %v", err)
+			activity.Error = err.Error()
+			ui.activity.Record(activity)
+			return fmt.Errorf(T("保护止盈平仓失败: %v"), err)
 		}
+		ui.activity.Record(activity)
+
+		ui.notify(NoticeWarning, T("保护止盈已市价平仓: %s 最高盈利 %.2f，平仓时盈亏 %.2f"), position.Symbol, maxProfit, unPnl)
+		ui.alerts.Fire(position.Symbol, AlertFired, unPnl)
 
 		// 平仓后清除记录
 		delete(ui.maxProfit, position.Symbol)
@@ -696,134 +1362,243 @@ func (ui *TraderUI) checkProtectiveStopProfit(position *futures.PositionRisk) er
 	return nil
 }
 
+// 检查不做保护止盈的持仓的盈亏和盈利回撤提醒，平仓后清除最高盈利
+func (ui *TraderUI) checkPositionAlerts(p *futures.PositionRisk) {
+	if amt, _ := strconv.ParseFloat(p.PositionAmt, 64); amt == 0 {
+		delete(ui.maxProfit, p.Symbol)
+		return
+	}
+	unPnl, _ := strconv.ParseFloat(p.UnRealizedProfit, 64)
+	maxProfit, ok := ui.maxProfit[p.Symbol]
+	if !ok || unPnl > maxProfit {
+		maxProfit = unPnl
+		ui.maxProfit[p.Symbol] = maxProfit
+	}
+	ui.alerts.Check(p.Symbol, AlertPnL, unPnl)
+	if maxProfit > 0 {
+		ui.alerts.Check(p.Symbol, AlertDrawdown, (maxProfit-unPnl)/maxProfit*100)
+	}
+}
+
 func (ui *TraderUI) updatePositions() error {
 	positions, err := ui.client.NewGetPositionRiskService().Do(context.Background())
 	if err != nil {
-		return fmt.Errorf("获取持仓信息失败: %v", err)
+		return fmt.Errorf(T("获取持仓信息失败: %v"), err)
 	}
 
 	var positionTexts []interface{}
+	var rows []PositionRow
+	var levels []ChartLevel
+	var current *futures.PositionRisk
+	var totalPnl float64
+	var openPositions int
+	protect := ui.protectConfig()
 	for _, p := range positions {
-		if p.Symbol == "SOLUSDC" {
+		if amt, _ := strconv.ParseFloat(p.PositionAmt, 64); amt != 0 {
+			unPnl, _ := strconv.ParseFloat(p.UnRealizedProfit, 64)
+			totalPnl += unPnl
+			openPositions++
+		}
+
+		// 用保护的交易对的标记价格检测价格异动
+		if p.Symbol == protect.Symbol {
+			if markPrice, err := strconv.ParseFloat(p.MarkPrice, 64); err == nil {
+				ui.spikes.Observe(markPrice)
+			}
+		}
+
+		// 暂停自动化时不检查保护止盈，也不自动设置止盈止损
+		if p.Symbol == protect.Symbol && !ui.paused.Load() {
 			// 检查保护止盈
 			if err := ui.checkProtectiveStopProfit(p); err != nil {
-				fmt.Printf("检查保护止盈失败: %v\n", err)
+				ui.notify(NoticeError, T("检查保护止盈失败: %v"), err)
 			}
 
 			// 检查并设置止盈
-			if err := ui.checkAndSetTakeProfit(p); err != nil {
-				fmt.Printf("设置止盈失败: %v\n", err)
+			if ui.automation.Enabled(p.Symbol, RuleTakeProfit) {
+				if err := ui.checkAndSetTakeProfit(p); err != nil {
+					ui.notify(NoticeError, T("设置止盈失败: %v"), err)
+				}
 			}
 			// 检查并设置止损
-			if err := ui.checkAndSetStopLoss(p); err != nil {
-				fmt.Printf("设置止损失败: %v\n", err)
+			if ui.automation.Enabled(p.Symbol, RuleStopLoss) {
+				if err := ui.checkAndSetStopLoss(p); err != nil {
+					ui.notify(NoticeError, T("设置止损失败: %v"), err)
+				}
 			}
+		} else {
+			// 其他持仓的盈亏和盈利回撤提醒
+			ui.checkPositionAlerts(p)
+		}
 
+		// 更新当前交易对的累计资金费
+		if p.Symbol == ui.symbol {
 			amt, _ := strconv.ParseFloat(p.PositionAmt, 64)
+			funding, err := ui.funding.Update(p.Symbol, amt)
+			if err != nil {
+				fmt.Printf(T("更新资金费失败: %v\n"), err)
+			}
 			if amt != 0 {
-				entryPrice, _ := strconv.ParseFloat(p.EntryPrice, 64)
+				current = p
 				unPnl, _ := strconv.ParseFloat(p.UnRealizedProfit, 64)
-				
-				// 获取止盈止损订单
-				orders, err := ui.client.NewListOpenOrdersService().Symbol("SOLUSDC").Do(context.Background())
-				if err != nil {
-					fmt.Printf("获取订单失败: %v\n", err)
-					continue
-				}
+				positionTexts = append(positionTexts, fmt.Sprintf(T("%s 累计资金费: %.4f 净盈亏: %.4f"),
+					p.Symbol, funding, unPnl+funding))
 
-				var tpPrice, slPrice float64
-				for _, order := range orders {
-					price, _ := strconv.ParseFloat(order.Price, 64)
-					// 确定方向
-					isLong := amt > 0
-					
-					// 多仓：
-					// - 止盈应该是卖单(SELL)且价格高于入场价
-					// - 止损应该是卖单(SELL)且价格低于入场价
-					// 空仓：
-					// - 止盈应该是买单(BUY)且价格低于入场价
-					// - 止损应该是买单(BUY)且价格高于入场价
-					if isLong {
-						if order.Side == futures.SideTypeSell {
-							if price > entryPrice {
-								tpPrice = price
-							} else {
-								slPrice = price
-							}
-						}
-					} else {
-						if order.Side == futures.SideTypeBuy {
-							if price < entryPrice {
-								tpPrice = price
-							} else {
-								slPrice = price
-							}
-						}
-					}
+				// 添加日内区间位置
+				if ui.session != nil {
+					markPrice, _ := strconv.ParseFloat(p.MarkPrice, 64)
+					positionTexts = append(positionTexts, ui.session.Summary(markPrice))
 				}
+			}
+		}
+	}
 
-				// 确定方向
-				direction := "多"
-				if amt < 0 {
-					direction = "空"
-				}
+	// 获取所有交易对的挂单，用于找出每个持仓的止盈止损价
+	if openPositions > 0 {
+		orders, err := ui.client.NewListOpenOrdersService().Do(context.Background())
+		if err != nil {
+			fmt.Printf(T("获取订单失败: %v\n"), err)
+		}
+		for _, p := range positions {
+			amt, _ := strconv.ParseFloat(p.PositionAmt, 64)
+			if amt == 0 {
+				continue
+			}
+			tpPrice, slPrice := exitPrices(orders, p.Symbol, amt > 0)
 
-				// 格式化持仓信息
-				text := fmt.Sprintf(
-					"方向: %s\n数量: %.4f\n入场价: %.4f\n未实现盈亏: %.4f\n最高盈利: %.4f\n",
-					direction, math.Abs(amt), entryPrice, unPnl, ui.maxProfit[p.Symbol],
-				)
-				
-				// 添加止盈止损信息
-				if tpPrice > 0 {
-					text += fmt.Sprintf("止盈价: %.4f (%.1f点)\n", 
-						tpPrice, math.Abs(tpPrice-entryPrice)*100)
-				}
-				if slPrice > 0 {
-					text += fmt.Sprintf("止损价: %.4f (%.1f点)", 
-						slPrice, math.Abs(slPrice-entryPrice)*100)
-				}
-				
-				positionTexts = append(positionTexts, text)
+			row := newPositionRow(p, tpPrice, slPrice)
+			if peak, ok := ui.maxProfit[p.Symbol]; ok {
+				row.PeakProfit = peak
+			}
+			rows = append(rows, row)
+
+			// 在K线图上显示当前交易对的入场价、止盈止损价和强平价
+			if p.Symbol == ui.symbol {
+				entryPrice, _ := strconv.ParseFloat(p.EntryPrice, 64)
+				liquidationPrice, _ := strconv.ParseFloat(p.LiquidationPrice, 64)
+				levels = positionLevels(entryPrice, tpPrice, slPrice, liquidationPrice)
 			}
 		}
 	}
 
-	if len(positionTexts) == 0 {
-		positionTexts = append(positionTexts, "无持仓")
+	// 检查持仓之间的相关性
+	warnings, err := ui.correlation.Check(positions)
+	if err != nil {
+		fmt.Printf(T("计算持仓相关性失败: %v\n"), err)
+	}
+	for _, w := range warnings {
+		positionTexts = append(positionTexts, w.String())
 	}
 
-	return ui.positions.Set(positionTexts)
-}
+	if len(rows) == 0 {
+		positionTexts = append(positionTexts, T("无持仓"))
+	}
 
-func (ui *TraderUI) updateOrders() error {
-	orders, err := ui.client.NewListOpenOrdersService().Symbol("SOLUSDC").Do(context.Background())
-	if err != nil {
-		return fmt.Errorf("获取订单失败: %v", err)
+	// 当前交易对平仓时，在清除价格线之前截图
+	symbol := ui.symbol
+	snapshot := false
+	if _, ok := ui.lastPositions[symbol]; ok && ui.config.Chart.AutoSnapshot {
+		snapshot = true
+		for _, row := range rows {
+			if row.Symbol == symbol {
+				snapshot = false
+			}
+		}
 	}
 
-	var orderTexts []interface{}
-	for _, order := range orders {
-		price := order.Price
+	ui.position = current
+	ui.playPositionSounds(rows)
+	fyne.Do(func() {
+		if snapshot {
+			ui.saveCloseSnapshot(symbol)
+		}
+		ui.positionTable.SetRows(rows)
+		ui.klineChart.SetLevels(levels)
+	})
+	ui.updateTray(totalPnl, openPositions)
+
+	return ui.positions.Set(positionTexts)
+}
+
+// 从挂单中找出持仓的止盈价和止损价，没有时为0
+func exitPrices(orders []*futures.Order, symbol string, long bool) (tpPrice, slPrice float64) {
+	tpOrders, slOrders := exitOrders(orders, symbol, long)
+	if len(tpOrders) > 0 {
+		tpPrice = orderPrice(tpOrders[len(tpOrders)-1])
+	}
+	if len(slOrders) > 0 {
+		slPrice = orderPrice(slOrders[len(slOrders)-1])
+	}
+	return tpPrice, slPrice
+}
+
+// 从挂单中找出持仓的止盈单和止损单，只看平仓方向的保护单(只减仓、平仓或止盈止损标签)。
+// 按订单标签区分，外部订单按类型区分: 止损类为止损，止盈类和限价单为止盈。
+// 不能按价格和入场价比较，止损移到保本以上后仍然是止损
+func exitOrders(orders []*futures.Order, symbol string, long bool) (tpOrders, slOrders []*futures.Order) {
+	closeSide := futures.SideTypeSell
+	if !long {
+		closeSide = futures.SideTypeBuy
+	}
+	for _, order := range orders {
+		if order.Symbol != symbol || order.Side != closeSide || !isProtectiveOrder(order) {
+			continue
+		}
+		switch orderTag(order.ClientOrderID) {
+		case tagTakeProfit:
+			tpOrders = append(tpOrders, order)
+			continue
+		case tagStopLoss:
+			slOrders = append(slOrders, order)
+			continue
+		}
+		switch order.Type {
+		case futures.OrderTypeStop, futures.OrderTypeStopMarket, futures.OrderTypeTrailingStopMarket:
+			slOrders = append(slOrders, order)
+		case futures.OrderTypeTakeProfit, futures.OrderTypeTakeProfitMarket, futures.OrderTypeLimit:
+			tpOrders = append(tpOrders, order)
+		}
+	}
+	return tpOrders, slOrders
+}
+
+// 订单的价格，止损市价单只有触发价
+func orderPrice(order *futures.Order) float64 {
+	price, _ := strconv.ParseFloat(order.Price, 64)
+	if price == 0 {
+		price, _ = strconv.ParseFloat(order.StopPrice, 64)
+	}
+	return price
+}
+
+func (ui *TraderUI) updateOrders() error {
+	orders, err := ui.client.NewListOpenOrdersService().Symbol(ui.symbol).Do(context.Background())
+	if err != nil {
+		return fmt.Errorf(T("获取订单失败: %v"), err)
+	}
+
+	var orderTexts []interface{}
+	for _, order := range orders {
+		price := order.Price
 		qty := order.OrigQuantity
 
 		// 根据订单类型显示不同信息
 		var priceInfo string
 		if order.Type == futures.OrderTypeLimit {
-			priceInfo = fmt.Sprintf("价格: %s", price)
+			priceInfo = fmt.Sprintf(T("价格: %s"), price)
 		} else if order.Type == futures.OrderTypeStopMarket {
-			priceInfo = fmt.Sprintf("触发价: %s", order.StopPrice)
+			priceInfo = fmt.Sprintf(T("触发价: %s"), order.StopPrice)
 		}
 
 		// 添加取消按钮
-		text := fmt.Sprintf("[x] %s %s@%s (%s)\n    订单号: %d",
+		text := fmt.Sprintf(T("[x] %s %s@%s (%s)\n    订单号: %d"),
 			order.Side, qty, priceInfo, order.Type, order.OrderID)
 		
 		orderTexts = append(orderTexts, text)
 	}
 
 	if len(orderTexts) == 0 {
-		orderTexts = append(orderTexts, "无挂单")
+		orderTexts = append(orderTexts, T("无挂单"))
 	}
 
 	return ui.orders.Set(orderTexts)
@@ -848,154 +1623,1082 @@ func (ui *TraderUI) handleOrderClick(id widget.ListItemID) {
 	// 提取订单号
 	var orderId int64
 	if _, err := fmt.Sscanf(orderText, "%*s %*s %*s %*s %*s %d", &orderId); err != nil {
-		fmt.Printf("解析订单号失败: %v\n", err)
+		fmt.Printf(T("解析订单号失败: %v\n"), err)
 		return
 	}
 
 	// 确认取消
-	dialog.ShowConfirm("取消订单", "确定要取消这个订单吗？", func(ok bool) {
+	symbol := ui.symbol
+	dialog.ShowConfirm(T("取消订单"), T("确定要取消这个订单吗？"), func(ok bool) {
 		if !ok {
 			return
 		}
 		// 取消订单
 		_, err := ui.client.NewCancelOrderService().
-			Symbol("SOLUSDC").
+			Symbol(symbol).
 			OrderID(orderId).
 			Do(context.Background())
 
 		if err != nil {
-			dialog.ShowError(fmt.Errorf("取消订单失败: %v", err), ui.window)
+			dialog.ShowError(fmt.Errorf(T("取消订单失败: %v"), err), ui.window)
 		}
 	}, ui.window)
 }
 
-func (ui *TraderUI) startDataUpdater() {
-	// 更新K线数据
+// 图表右键菜单：在点击的价格下限价单、填入下单止损价，或把持仓的止盈止损移到该价格。
+// 价格按交易对的价格精度取整，交易规则在后台获取后再显示菜单
+func (ui *TraderUI) showChartMenu(price float64, pos fyne.Position) {
+	symbol := ui.symbol
 	go func() {
-		for {
-			if err := ui.updateKlines(); err != nil {
-				fmt.Printf("更新K线失败: %v\n", err)
+		filters, err := ui.filters.Get(symbol)
+		fyne.Do(func() {
+			if err != nil {
+				dialog.ShowError(err, ui.window)
+				return
 			}
-			time.Sleep(5 * time.Second)
-		}
+			if symbol == ui.symbol {
+				ui.showChartMenuItems(filters, price, pos)
+			}
+		})
 	}()
+}
 
-	// 更新价格和订单数据
-	go func() {
-		for {
-			// 更新价格
-			if err := ui.updatePrice(); err != nil {
-				fmt.Printf("获取价格失败: %v\n", err)
+func (ui *TraderUI) showChartMenuItems(filters SymbolFilters, price float64, pos fyne.Position) {
+	text := filters.FormatPrice(price)
+	price, _ = strconv.ParseFloat(text, 64)
+
+	items := []*fyne.MenuItem{
+		fyne.NewMenuItem(T("限价买入 @ ")+text, func() {
+			ui.confirmChartOrder(filters, futures.SideTypeBuy, price)
+		}),
+		fyne.NewMenuItem(T("限价卖出 @ ")+text, func() {
+			ui.confirmChartOrder(filters, futures.SideTypeSell, price)
+		}),
+		fyne.NewMenuItem(T("设为下单止损价 ")+text, func() {
+			ui.stopLossEntry.SetText(text)
+		}),
+	}
+	if position := ui.position; position != nil {
+		items = append(items,
+			fyne.NewMenuItemSeparator(),
+			fyne.NewMenuItem(T("止盈移到 ")+text, func() {
+				ui.confirmMoveExit(filters, position, futures.OrderTypeLimit, price)
+			}),
+			fyne.NewMenuItem(T("止损移到 ")+text, func() {
+				ui.confirmMoveExit(filters, position, futures.OrderTypeStopMarket, price)
+			}),
+		)
+	}
+	widget.ShowPopUpMenuAtRelativePosition(fyne.NewMenu("", items...), ui.window.Canvas(), pos, ui.klineChart)
+}
+
+// 确认后在指定价格下限价单，数量使用下单面板的数量
+func (ui *TraderUI) confirmChartOrder(filters SymbolFilters, side futures.SideType, price float64) {
+	quantity := ui.amountEntry.Text
+	amount, err := strconv.ParseFloat(quantity, 64)
+	if err != nil || amount <= 0 {
+		dialog.ShowError(errors.New(T("请先在下单面板输入数量")), ui.window)
+		return
+	}
+
+	symbol := ui.symbol
+	name := T("买入做多")
+	if side == futures.SideTypeSell {
+		name = T("卖出做空")
+	}
+	message := fmt.Sprintf(T("%s 限价%s\n价格: %s\n数量: %s\n名义价值: %.2f %s"),
+		symbol, name, filters.FormatPrice(price), quantity, price*amount, filters.MarginAsset)
+	if (side == futures.SideTypeBuy && price > ui.currentPrice) ||
+		(side == futures.SideTypeSell && price < ui.currentPrice) {
+		message += fmt.Sprintf(T("\n\n注意: 当前价 %s，该订单会立即成交"), filters.FormatPrice(ui.currentPrice))
+	}
+
+	dialog.ShowConfirm(T("确认下单"), message, func(ok bool) {
+		if !ok {
+			return
+		}
+		order, err := ui.client.NewCreateOrderService().
+			Symbol(symbol).
+			NewClientOrderID(newClientOrderID(tagManual)).
+			Side(side).
+			PositionSide("BOTH").
+			Type(futures.OrderTypeLimit).
+			TimeInForce(futures.TimeInForceTypeGTC).
+			Price(filters.FormatPrice(price)).
+			Quantity(quantity).
+			Do(context.Background())
+		if err != nil {
+			dialog.ShowError(fmt.Errorf(T("下单失败: %v"), err), ui.window)
+			return
+		}
+		dialog.ShowInformation(T("下单成功"), fmt.Sprintf(T("订单ID: %d"), order.OrderID), ui.window)
+	}, ui.window)
+}
+
+// 把持仓的止盈单(限价单)或止损单(止损市价单)移到指定价格。
+// 先创建新订单再取消旧订单，避免中间出现没有保护的时间
+func (ui *TraderUI) confirmMoveExit(filters SymbolFilters, position *futures.PositionRisk, orderType futures.OrderType, price float64) {
+	amt, _ := strconv.ParseFloat(position.PositionAmt, 64)
+	markPrice, _ := strconv.ParseFloat(position.MarkPrice, 64)
+	long := amt > 0
+	closeSide := futures.SideTypeSell
+	if !long {
+		closeSide = futures.SideTypeBuy
+	}
+
+	name, exitTag := T("止盈"), tagTakeProfit
+	if orderType == futures.OrderTypeStopMarket {
+		name, exitTag = T("止损"), tagStopLoss
+	}
+	// 多仓止盈和空仓止损在当前价上方，多仓止损和空仓止盈在当前价下方
+	above := long == (orderType == futures.OrderTypeLimit)
+	if (above && price <= markPrice) || (!above && price >= markPrice) {
+		dialog.ShowError(fmt.Errorf(T("%s价 %s 与标记价格 %s 的方向不符"), name, filters.FormatPrice(price), filters.FormatPrice(markPrice)), ui.window)
+		return
+	}
+
+	orders, err := ui.client.NewListOpenOrdersService().Symbol(position.Symbol).Do(context.Background())
+	if err != nil {
+		dialog.ShowError(fmt.Errorf(T("获取订单失败: %v"), err), ui.window)
+		return
+	}
+	var existing []*futures.Order
+	for _, order := range orders {
+		if order.Side == closeSide && order.Type == orderType {
+			existing = append(existing, order)
+		}
+	}
+
+	quantity := filters.FormatQuantity(math.Abs(amt))
+	message := fmt.Sprintf(T("%s %s单\n新价格: %s\n数量: %s"), position.Symbol, name, filters.FormatPrice(price), quantity)
+	for _, order := range existing {
+		old := order.Price
+		if orderType == futures.OrderTypeStopMarket {
+			old = order.StopPrice
+		}
+		message += fmt.Sprintf(T("\n取消原%s单: %s (订单号 %d)"), name, old, order.OrderID)
+	}
+
+	dialog.ShowConfirm(T("移动")+name, message, func(ok bool) {
+		if !ok {
+			return
+		}
+		service := ui.client.NewCreateOrderService().
+			Symbol(position.Symbol).
+			NewClientOrderID(newClientOrderID(exitTag)).
+			Side(closeSide).
+			PositionSide("BOTH").
+			Type(orderType).
+			Quantity(quantity).
+			ReduceOnly(true)
+		if orderType == futures.OrderTypeLimit {
+			service = service.TimeInForce(futures.TimeInForceTypeGTC).Price(filters.FormatPrice(price))
+		} else {
+			service = service.StopPrice(filters.FormatPrice(price))
+		}
+		if _, err := service.Do(context.Background()); err != nil {
+			dialog.ShowError(fmt.Errorf(T("创建%s单失败: %v"), name, err), ui.window)
+			return
+		}
+
+		for _, order := range existing {
+			_, err := ui.client.NewCancelOrderService().
+				Symbol(position.Symbol).
+				OrderID(order.OrderID).
+				Do(context.Background())
+			if err != nil {
+				dialog.ShowError(fmt.Errorf(T("新%s单已创建，但取消原订单失败: %v"), name, err), ui.window)
+				return
 			}
+		}
+	}, ui.window)
+}
 
-			// 更新持仓
-			if err := ui.updatePositions(); err != nil {
-				fmt.Printf("获取持仓失败: %v\n", err)
+// 显示市场筛选窗口
+func (ui *TraderUI) showScreener() {
+	w := ui.app.NewWindow(T("市场筛选"))
+
+	results := binding.NewStringList()
+	list := widget.NewListWithData(
+		results,
+		func() fyne.CanvasObject {
+			return widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+		},
+		func(i binding.DataItem, o fyne.CanvasObject) {
+			o.(*widget.Label).Bind(i.(binding.String))
+		},
+	)
+
+	status := widget.NewLabel("")
+	var scanBtn *widget.Button
+	scan := func() {
+		scanBtn.Disable()
+		status.SetText(T("扫描中..."))
+		go func() {
+			matches, err := runScreener(ui.client, ui.config.Screener)
+			fyne.Do(func() {
+				scanBtn.Enable()
+				if err != nil {
+					status.SetText("")
+					dialog.ShowError(err, w)
+					return
+				}
+
+				var texts []string
+				for _, m := range matches {
+					texts = append(texts, m.String())
+				}
+				results.Set(texts)
+				status.SetText(fmt.Sprintf(T("%s 共%d个交易对满足条件"), time.Now().Format("15:04:05"), len(matches)))
+			})
+		}()
+	}
+	scanBtn = widget.NewButton(T("扫描"), scan)
+
+	w.SetContent(container.NewBorder(
+		container.NewHBox(scanBtn, status),
+		nil, nil, nil,
+		list,
+	))
+	w.Resize(fyne.NewSize(800, 300))
+	w.Show()
+	scan()
+}
+
+// 显示提醒管理窗口
+func (ui *TraderUI) showAlerts() {
+	w := ui.app.NewWindow(T("提醒"))
+
+	alerts := binding.NewUntypedList()
+	refresh := func() {
+		var items []interface{}
+		for _, a := range ui.alerts.List() {
+			items = append(items, a)
+		}
+		alerts.Set(items)
+	}
+
+	list := widget.NewListWithData(
+		alerts,
+		func() fyne.CanvasObject {
+			return widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+		},
+		func(i binding.DataItem, o fyne.CanvasObject) {
+			if val, err := i.(binding.Untyped).Get(); err == nil {
+				o.(*widget.Label).SetText(val.(*Alert).String())
+			}
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		val, err := alerts.GetValue(id)
+		list.UnselectAll()
+		if err != nil {
+			return
+		}
+		alert := val.(*Alert)
+		dialog.ShowConfirm(T("删除提醒"), fmt.Sprintf(T("确定要删除提醒 %s 吗？"), alert), func(ok bool) {
+			if !ok {
+				return
+			}
+			if err := ui.alerts.Remove(alert.ID); err != nil {
+				dialog.ShowError(err, w)
 			}
+			refresh()
+		}, w)
+	}
 
-			// 更新订单
-			if err := ui.updateOrders(); err != nil {
-				fmt.Printf("获取订单失败: %v\n", err)
+	alertTypes := []AlertType{AlertPrice, AlertRSI, AlertPnL, AlertDrawdown, AlertProtect}
+	typeLabels := make([]string, len(alertTypes))
+	for i, t := range alertTypes {
+		typeLabels[i] = t.Label()
+	}
+
+	symbolEntry := widget.NewEntry()
+	symbolEntry.SetText(ui.symbol)
+	valueEntry := widget.NewEntry()
+	valueEntry.SetPlaceHolder(T("阈值"))
+	valueEntry.TextStyle = fyne.TextStyle{Monospace: true}
+	conditionSelect := widget.NewSelect(nil, nil)
+	repeatCheck := widget.NewCheck(T("重复提醒"), nil)
+	channelGroup := widget.NewCheckGroup(ui.alerts.ChannelNames(), nil)
+	channelGroup.Horizontal = true
+
+	var selectedType AlertType
+	var conditions []AlertCondition
+	typeSelect := widget.NewSelect(typeLabels, func(label string) {
+		for i, l := range typeLabels {
+			if l == label {
+				selectedType = alertTypes[i]
 			}
+		}
+		conditions = alertConditions(selectedType)
+		var options []string
+		for _, c := range conditions {
+			options = append(options, c.Label())
+		}
+		conditionSelect.Options = options
+		conditionSelect.SetSelected(options[0])
 
-			time.Sleep(2 * time.Second)
+		// 保护止盈事件提醒没有阈值
+		if selectedType == AlertProtect {
+			valueEntry.Disable()
+		} else {
+			valueEntry.Enable()
 		}
-	}()
+	})
+	typeSelect.SetSelected(AlertPrice.Label())
+
+	addBtn := widget.NewButton(T("添加"), func() {
+		alert := Alert{
+			Symbol:   strings.ToUpper(strings.TrimSpace(symbolEntry.Text)),
+			Type:     selectedType,
+			Repeat:   repeatCheck.Checked,
+			Channels: channelGroup.Selected,
+		}
+		for _, c := range conditions {
+			if c.Label() == conditionSelect.Selected {
+				alert.Condition = c
+			}
+		}
+		if selectedType != AlertProtect {
+			value, err := strconv.ParseFloat(valueEntry.Text, 64)
+			if err != nil {
+				dialog.ShowError(fmt.Errorf(T("阈值格式错误: %v"), err), w)
+				return
+			}
+			alert.Value = value
+		}
+		if _, err := ui.alerts.Add(alert); err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		valueEntry.SetText("")
+		refresh()
+	})
+
+	form := container.NewVBox(
+		container.NewGridWithColumns(4, symbolEntry, typeSelect, conditionSelect, valueEntry),
+		container.NewHBox(repeatCheck, widget.NewLabel(T("通知渠道:")), channelGroup, layout.NewSpacer(), addBtn),
+	)
+
+	w.SetContent(container.NewBorder(
+		form,
+		widget.NewLabel(T("点击提醒可删除，未选择通知渠道时发送到所有渠道")),
+		nil, nil,
+		list,
+	))
+	w.Resize(fyne.NewSize(600, 350))
+	refresh()
+	w.Show()
 }
 
-func (ui *TraderUI) Show() {
-	ui.window.ShowAndRun()
+// 显示导出窗口，把K线、成交记录或资金流水导出为CSV
+func (ui *TraderUI) showExport() {
+	w := ui.app.NewWindow(T("导出CSV"))
+
+	kindLabels := []string{T("K线"), T("成交记录"), T("资金流水")}
+	kindSelect := widget.NewSelect(kindLabels, nil)
+	intervalSelect := widget.NewSelect(klineIntervals, nil)
+	intervalSelect.SetSelected(ui.interval)
+	limitEntry := widget.NewEntry()
+	limitEntry.SetText("1000")
+	daysEntry := widget.NewEntry()
+	daysEntry.SetText("30")
+
+	// K线只需要周期和数量，成交记录和资金流水只需要天数
+	kindSelect.OnChanged = func(label string) {
+		if label == kindLabels[0] {
+			intervalSelect.Enable()
+			limitEntry.Enable()
+			daysEntry.Disable()
+		} else {
+			intervalSelect.Disable()
+			limitEntry.Disable()
+			daysEntry.Enable()
+		}
+	}
+	kindSelect.SetSelected(kindLabels[0])
+
+	status := widget.NewLabel("")
+	var exportBtn *widget.Button
+	exportBtn = widget.NewButton(T("导出..."), func() {
+		opts := ExportOptions{Symbol: ui.symbol, Interval: intervalSelect.Selected}
+		for i, l := range kindLabels {
+			if l == kindSelect.Selected {
+				opts.Kind = exportKinds[i]
+			}
+		}
+		limit, err := strconv.Atoi(limitEntry.Text)
+		if err != nil || limit <= 0 {
+			dialog.ShowError(errors.New(T("K线数量格式错误")), w)
+			return
+		}
+		days, err := strconv.Atoi(daysEntry.Text)
+		if err != nil || days <= 0 {
+			dialog.ShowError(errors.New(T("天数格式错误")), w)
+			return
+		}
+		opts.Limit = limit
+		opts.Since = time.Now().AddDate(0, 0, -days)
+
+		save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			if writer == nil {
+				return
+			}
+
+			exportBtn.Disable()
+			status.SetText(T("导出中..."))
+			go func() {
+				defer writer.Close()
+				n, err := runExport(ui.client, ui.klineCache, opts, writer)
+				fyne.Do(func() {
+					exportBtn.Enable()
+					if err != nil {
+						status.SetText("")
+						dialog.ShowError(err, w)
+						return
+					}
+					status.SetText(fmt.Sprintf(T("已导出%d行到 %s"), n, writer.URI().Name()))
+				})
+			}()
+		}, w)
+		save.SetFileName(opts.DefaultPath())
+		save.Show()
+	})
+
+	w.SetContent(container.NewVBox(
+		widget.NewForm(
+			widget.NewFormItem(T("类型"), kindSelect),
+			widget.NewFormItem(T("K线周期"), intervalSelect),
+			widget.NewFormItem(T("K线数量"), limitEntry),
+			widget.NewFormItem(T("最近天数"), daysEntry),
+		),
+		container.NewHBox(exportBtn, status),
+	))
+	w.Resize(fyne.NewSize(500, 400))
+	w.Show()
 }
 
-func main() {
-	ui, err := NewTraderUI()
+// 高亮当前周期的快捷按钮
+func (ui *TraderUI) highlightInterval(interval string) {
+	for iv, btn := range ui.intervalButtons {
+		if iv == interval {
+			btn.Importance = widget.HighImportance
+		} else {
+			btn.Importance = widget.MediumImportance
+		}
+		btn.Refresh()
+	}
+}
+
+// 订单历史窗口，每次加载一页，点击加载更多向前翻页
+func (ui *TraderUI) showOrderHistory() {
+	w := ui.app.NewWindow(T("订单历史"))
+
+	orders := binding.NewStringList()
+	list := widget.NewListWithData(
+		orders,
+		func() fyne.CanvasObject {
+			return widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+		},
+		func(i binding.DataItem, o fyne.CanvasObject) {
+			o.(*widget.Label).Bind(i.(binding.String))
+		},
+	)
+
+	symbolEntry := widget.NewSelectEntry(ui.config.Watchlist)
+	symbolEntry.SetText(ui.symbol)
+	status := widget.NewLabel("")
+	var symbol string
+	var next time.Time
+	var nextID int64
+	var loadBtn, moreBtn *widget.Button
+
+	// reset为true时从最新的订单开始加载
+	load := func(reset bool) {
+		if reset {
+			symbol = strings.ToUpper(strings.TrimSpace(symbolEntry.Text))
+			next, nextID = time.Time{}, 0
+			orders.Set(nil)
+		}
+		if symbol == "" {
+			return
+		}
+		loadBtn.Disable()
+		moreBtn.Disable()
+		status.SetText(T("加载中..."))
+		go func() {
+			page, err := fetchOrderHistory(ui.client, symbol, next, nextID, 50)
+			fyne.Do(func() {
+				loadBtn.Enable()
+				if err != nil {
+					status.SetText("")
+					dialog.ShowError(err, w)
+					return
+				}
+				for _, o := range page.Orders {
+					orders.Append(formatHistoryOrder(o))
+				}
+				next, nextID = page.Next, page.NextID
+				if !next.IsZero() {
+					moreBtn.Enable()
+				}
+				status.SetText(fmt.Sprintf(T("%s 已加载%d个订单"), symbol, orders.Length()))
+			})
+		}()
+	}
+	loadBtn = widget.NewButton(T("查询"), func() { load(true) })
+	moreBtn = widget.NewButton(T("加载更多"), func() { load(false) })
+	symbolEntry.OnSubmitted = func(string) { load(true) }
+
+	w.SetContent(container.NewBorder(
+		container.NewHBox(widget.NewLabel(T("交易对")), symbolEntry, loadBtn, status),
+		moreBtn, nil, nil,
+		list,
+	))
+	w.Resize(ui.scaled(820, 480))
+	w.Show()
+	load(true)
+}
+
+// 显示设置窗口：界面缩放、字体大小和K线图尺寸，应用后立即生效并保存到配置文件
+func (ui *TraderUI) showDisplaySettings() {
+	w := ui.app.NewWindow(T("显示设置"))
+	display := ui.config.Display
+
+	newSlider := func(min, max, step float64, value float32, format string) (*widget.Slider, *widget.Label) {
+		label := widget.NewLabel(fmt.Sprintf(format, value))
+		slider := widget.NewSlider(min, max)
+		slider.Step = step
+		slider.SetValue(float64(value))
+		slider.OnChanged = func(v float64) {
+			label.SetText(fmt.Sprintf(format, v))
+		}
+		return slider, label
+	}
+	scaleSlider, scaleLabel := newSlider(0.5, 3, 0.05, display.Scale, "%.2fx")
+	fontSlider, fontLabel := newSlider(0.5, 2.5, 0.05, display.FontScale, "%.2fx")
+	widthSlider, widthLabel := newSlider(300, 2000, 20, display.ChartWidth, "%.0f")
+	heightSlider, heightLabel := newSlider(180, 1200, 20, display.ChartHeight, "%.0f")
+
+	// K线图样式：配色方案、自定义颜色和影线宽度
+	style := display.ChartStyle
+	style.applyDefaults()
+	wickSlider, wickLabel := newSlider(1, 5, 0.5, style.WickWidth, "%.1f")
+
+	var presetLabels []string
+	for _, o := range chartStyleOptions {
+		presetLabels = append(presetLabels, T(o.Label))
+	}
+	presetSelect := widget.NewSelect(presetLabels, nil)
+	for _, o := range chartStyleOptions {
+		if o.Name == style.Preset {
+			presetSelect.SetSelected(T(o.Label))
+		}
+	}
+
+	// 颜色按钮显示当前颜色，点击打开取色器。空值表示使用默认颜色
+	colorButton := func(value *string, title string) fyne.CanvasObject {
+		btn := widget.NewButton("", nil)
+		update := func() {
+			if *value == "" {
+				btn.SetText(T("默认"))
+			} else {
+				btn.SetText(*value)
+			}
+		}
+		btn.OnTapped = func() {
+			picker := dialog.NewColorPicker(title, "", func(c color.Color) {
+				*value = formatHexColor(c)
+				update()
+			}, w)
+			picker.Advanced = true
+			if c, ok := parseHexColor(*value); ok {
+				picker.SetColor(c)
+			}
+			picker.Show()
+		}
+		update()
+		return container.NewBorder(nil, nil, nil, widget.NewButton(T("重置"), func() {
+			*value = ""
+			update()
+		}), btn)
+	}
+	upButton := colorButton(&style.UpColor, T("阳线颜色"))
+	downButton := colorButton(&style.DownColor, T("阴线颜色"))
+
+	applyBtn := widget.NewButton(T("应用"), func() {
+		for _, o := range chartStyleOptions {
+			if T(o.Label) == presetSelect.Selected {
+				style.Preset = o.Name
+			}
+		}
+		style.WickWidth = float32(wickSlider.Value)
+		ui.config.Display = DisplayConfig{
+			Scale:       float32(scaleSlider.Value),
+			FontScale:   float32(fontSlider.Value),
+			ChartWidth:  float32(widthSlider.Value),
+			ChartHeight: float32(heightSlider.Value),
+			ChartStyle:  style,
+		}
+		ui.app.Settings().SetTheme(newTraderTheme(ui.config.Theme, ui.config.Display))
+		ui.klineChart.SetMinSize(ui.scaled(ui.config.Display.ChartWidth, ui.config.Display.ChartHeight))
+		if err := ui.saveConfig(); err != nil {
+			dialog.ShowError(err, w)
+		}
+	})
+	applyBtn.Importance = widget.HighImportance
+
+	w.SetContent(container.NewVBox(
+		container.NewGridWithColumns(3,
+			widget.NewLabel(T("界面缩放")), scaleSlider, scaleLabel,
+			widget.NewLabel(T("字体大小")), fontSlider, fontLabel,
+			widget.NewLabel(T("图表宽度")), widthSlider, widthLabel,
+			widget.NewLabel(T("图表高度")), heightSlider, heightLabel,
+			widget.NewLabel(T("影线宽度")), wickSlider, wickLabel,
+		),
+		widget.NewLabel(T("窗口和面板的最小尺寸重启后按新的缩放计算")),
+		widget.NewSeparator(),
+		widget.NewForm(
+			widget.NewFormItem(T("K线配色"), presetSelect),
+			widget.NewFormItem(T("阳线颜色"), upButton),
+			widget.NewFormItem(T("阴线颜色"), downButton),
+			widget.NewFormItem(T("图表背景"), colorButton(&style.Background, T("图表背景"))),
+			widget.NewFormItem(T("网格颜色"), colorButton(&style.Grid, T("网格颜色"))),
+		),
+		widget.NewLabel(T("阳线和阴线颜色只在自定义配色下生效，背景和网格默认跟随主题")),
+		applyBtn,
+	))
+	w.Resize(fyne.NewSize(480, 460))
+	w.Show()
+}
+
+// 声音设置窗口：总开关、每种事件的开关和音量，保存到配置文件
+func (ui *TraderUI) showSoundSettings() {
+	w := ui.app.NewWindow(T("声音设置"))
+	sound := ui.config.Sound
+	sound.applyDefaults()
+
+	enabledCheck := widget.NewCheck(T("启用提示音"), nil)
+	enabledCheck.SetChecked(sound.Enabled)
+
+	eventChecks := make(map[SoundEvent]*widget.Check)
+	eventBox := container.NewVBox()
+	for _, e := range soundEvents {
+		event := e.Event
+		check := widget.NewCheck(T(e.Label), nil)
+		check.SetChecked(true)
+		for _, muted := range sound.Muted {
+			if muted == event {
+				check.SetChecked(false)
+			}
+		}
+		eventChecks[event] = check
+		eventBox.Add(container.NewHBox(check, widget.NewButton(T("试听"), func() {
+			NewSoundPlayer(SoundConfig{Enabled: true, Volume: sound.Volume}).Play(event)
+		})))
+	}
+
+	volumeLabel := widget.NewLabel(fmt.Sprintf("%.0f%%", sound.Volume*100))
+	volumeSlider := widget.NewSlider(0.05, 1)
+	volumeSlider.Step = 0.05
+	volumeSlider.SetValue(sound.Volume)
+	volumeSlider.OnChanged = func(v float64) {
+		sound.Volume = v
+		volumeLabel.SetText(fmt.Sprintf("%.0f%%", v*100))
+	}
+
+	applyBtn := widget.NewButton(T("应用"), func() {
+		config := SoundConfig{Enabled: enabledCheck.Checked, Volume: volumeSlider.Value}
+		for _, e := range soundEvents {
+			if !eventChecks[e.Event].Checked {
+				config.Muted = append(config.Muted, e.Event)
+			}
+		}
+		ui.config.Sound = config
+		ui.sound.SetConfig(config)
+		if err := ui.saveConfig(); err != nil {
+			dialog.ShowError(err, w)
+		}
+	})
+	applyBtn.Importance = widget.HighImportance
+
+	w.SetContent(container.NewVBox(
+		enabledCheck,
+		eventBox,
+		container.NewBorder(nil, nil, widget.NewLabel(T("音量")), volumeLabel, volumeSlider),
+		applyBtn,
+	))
+	w.Resize(fyne.NewSize(360, 280))
+	w.Show()
+}
+
+// 立即刷新K线图，切换周期或数量时调用
+func (ui *TraderUI) refreshKlines() {
+	err := ui.updateKlines()
+	ui.chartBanner.Report(err)
 	if err != nil {
-		fmt.Println(err)
+		fmt.Printf(T("更新K线失败: %v\n"), err)
+	}
+}
+
+func (ui *TraderUI) updateAccount() error {
+	account, err := fetchAccountSummary(ui.client)
+	if err != nil {
+		return err
+	}
+	fyne.Do(func() {
+		ui.accountLabel.SetText(account.String())
+	})
+	return ui.tradeDB.RecordEquity(account.WalletBalance, account.UnrealizedProfit)
+}
+
+// 以下更新函数在获取期间切换了交易对时丢弃结果
+func (ui *TraderUI) updateSession() {
+	session, err := fetchSessionStats(ui.client, ui.symbol)
+	if err != nil {
+		fmt.Printf(T("获取日内统计失败: %v\n"), err)
+	} else if session.Symbol == ui.symbol {
+		ui.session = session
+	}
+}
+
+func (ui *TraderUI) updateVolatility() {
+	volatility, err := fetchVolatilityMetrics(ui.client, ui.symbol)
+	if err != nil {
+		fmt.Printf(T("计算波动率失败: %v\n"), err)
+	} else if volatility.Symbol == ui.symbol {
+		ui.volatility = volatility
+	}
+}
+
+// 基差异常时每30分钟最多提醒一次
+func (ui *TraderUI) updateBasis() {
+	basis, err := fetchBasis(ui.spotClient, ui.client, ui.symbol)
+	if err != nil {
+		fmt.Printf(T("获取期现基差失败: %v\n"), err)
 		return
 	}
-	ui.Show()
+	if basis.Symbol != ui.symbol {
+		return
+	}
+	ui.basis = basis
+	if ui.config.BasisAlert > 0 && math.Abs(basis.Annualized) >= ui.config.BasisAlert &&
+		time.Since(ui.lastBasisAlert) >= 30*time.Minute {
+		ui.lastBasisAlert = time.Now()
+		ui.app.SendNotification(fyne.NewNotification(T("基差异常提醒"),
+			fmt.Sprintf(T("%s 年化基差 %+.2f%%"), basis.Symbol, basis.Annualized)))
+	}
 }
 
-type CandlePlotter struct {
-	Klines []Kline
-	Width  float64
+func (ui *TraderUI) updateSentiment() {
+	sentiment, err := fetchMarketSentiment(ui.client, ui.symbol, "5m")
+	if err != nil {
+		fmt.Printf(T("获取市场情绪失败: %v\n"), err)
+	} else if sentiment.Symbol == ui.symbol {
+		ui.sentiment = sentiment
+	}
 }
 
-func (cp *CandlePlotter) Plot(c draw.Canvas, p *plot.Plot) {
-	trX, trY := p.Transforms(&c)
+// 切换交易对，图表、下单表单和持仓面板都跟随切换，选择会保存到配置文件
+func (ui *TraderUI) switchSymbol(symbol string) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if symbol == "" || symbol == ui.symbol {
+		return
+	}
+
+	ui.symbol = symbol
+	ui.position = nil
+	ui.session = nil
+	ui.volatility = nil
+	ui.basis = nil
+	ui.sentiment = nil
+	ui.liquidations.SetSymbol(symbol)
+
+	// 新输入的交易对加入自选列表
+	watchlist := ui.config.Watchlist
+	found := false
+	for _, s := range watchlist {
+		if s == symbol {
+			found = true
+		}
+	}
+	if !found {
+		watchlist = append(watchlist, symbol)
+	}
+	ui.config.Symbol = symbol
+	ui.setWatchlist(watchlist)
+	if err := ui.saveConfig(); err != nil {
+		fmt.Printf("%v\n", err)
+	}
+
+	fyne.Do(func() {
+		ui.symbolSelect.SetText(symbol)
+		ui.currentPriceLabel.SetText(T("加载中..."))
+		ui.priceEntry.SetText("")
+		ui.stopLossEntry.SetText("")
+		ui.klineChart.SetLevels(nil)
+	})
+
+	// 立即刷新，不等下一次定时更新
+	if cached, ok := ui.klineCache.Cached(symbol, ui.interval, ui.limit); ok {
+		if err := ui.renderKlines(cached, ui.interval); err != nil {
+			fmt.Printf(T("显示K线失败: %v\n"), err)
+		}
+	}
+	ui.refreshAll()
+	ui.updateSession()
+	ui.updateVolatility()
+	ui.updateBasis()
+	ui.updateSentiment()
+}
 
-	for i, k := range cp.Klines {
-		x := float64(i)
+// 设置自选列表，watchlist为nil时只刷新显示
+func (ui *TraderUI) setWatchlist(watchlist []string) {
+	if watchlist != nil {
+		ui.config.Watchlist = watchlist
+	}
 
-		// 转换坐标
-		xmin := trX(x - cp.Width/2)
-		xmax := trX(x + cp.Width/2)
-		yopen := trY(k.Open)
-		yclose := trY(k.Close)
-		yhigh := trY(k.High)
-		ylow := trY(k.Low)
+	var items []interface{}
+	for _, s := range ui.config.Watchlist {
+		items = append(items, &watchItem{Symbol: s})
+	}
+	fyne.Do(func() {
+		ui.symbolSelect.SetOptions(ui.config.Watchlist)
+		ui.watchlist.Set(items)
+	})
+}
 
-		var path vg.Path
+// 从自选列表移除交易对，至少保留一个
+func (ui *TraderUI) removeFromWatchlist(symbol string) {
+	var watchlist []string
+	for _, s := range ui.config.Watchlist {
+		if s != symbol {
+			watchlist = append(watchlist, s)
+		}
+	}
+	if len(watchlist) == 0 {
+		return
+	}
+	ui.setWatchlist(watchlist)
+	if err := ui.saveConfig(); err != nil {
+		fmt.Printf("%v\n", err)
+	}
+	go ui.switchSymbol(watchlist[0])
+}
 
-		// 画影线
-		var vs []vg.Point
-		vs = append(vs, vg.Point{X: trX(x), Y: yhigh})
-		vs = append(vs, vg.Point{X: trX(x), Y: ylow})
-		c.StrokeLines(draw.LineStyle{
-			Color: color.Black,
-			Width: vg.Points(0.5),
-		}, vs)
+// 用24h行情更新自选列表的价格和涨跌幅
+func (ui *TraderUI) updateWatchlist() error {
+	stats, err := ui.client.NewListPriceChangeStatsService().Do(context.Background())
+	if err != nil {
+		return fmt.Errorf(T("获取24h行情失败: %v"), err)
+	}
 
-		// 画蜡烛体
-		path.Move(vg.Point{X: xmin, Y: yopen})
-		path.Line(vg.Point{X: xmax, Y: yopen})
-		path.Line(vg.Point{X: xmax, Y: yclose})
-		path.Line(vg.Point{X: xmin, Y: yclose})
-		path.Close()
+	bySymbol := make(map[string]*watchItem)
+	for _, st := range stats {
+		price, _ := strconv.ParseFloat(st.LastPrice, 64)
+		change, _ := strconv.ParseFloat(st.PriceChangePercent, 64)
+		bySymbol[st.Symbol] = &watchItem{Symbol: st.Symbol, Price: price, Change: change}
+	}
 
-		// 根据涨跌设置填充颜色
-		if k.Close >= k.Open {
-			// 阳线：白色填充
-			c.SetColor(color.White)
+	var items []interface{}
+	for _, s := range ui.config.Watchlist {
+		if item, ok := bySymbol[s]; ok {
+			items = append(items, item)
 		} else {
-			// 阴线：黑色填充
-			c.SetColor(color.Black)
+			items = append(items, &watchItem{Symbol: s})
 		}
-		c.Fill(path)
-
-		// 统一使用黑色边框
-		c.SetColor(color.Black)
-		c.Stroke(path)
 	}
+	fyne.Do(func() {
+		ui.watchlist.Set(items)
+	})
+	return nil
 }
 
-func (cp *CandlePlotter) DataRange() (xmin, xmax, ymin, ymax float64) {
-	xmin = -1
-	xmax = float64(len(cp.Klines))
+func (ui *TraderUI) startDataUpdater() {
+	// 运行策略流水线
+	if ui.pipeline != nil {
+		ui.pipeline.Start()
+	}
 
-	ymin = cp.Klines[0].Low
-	ymax = cp.Klines[0].High
+	// 刷新状态栏
+	go func() {
+		for {
+			ui.updateStatusBar()
+			time.Sleep(time.Second)
+		}
+	}()
 
-	for _, k := range cp.Klines {
-		if k.Low < ymin {
-			ymin = k.Low
+	// 更新K线数据
+	go func() {
+		for {
+			ui.refreshKlines()
+			time.Sleep(5 * time.Second)
 		}
-		if k.High > ymax {
-			ymax = k.High
+	}()
+
+	// 更新涨跌榜
+	go func() {
+		for {
+			movers, err := fetchTopMovers(ui.client, 5)
+			if err != nil {
+				fmt.Printf(T("获取涨跌榜失败: %v\n"), err)
+			} else {
+				fyne.Do(func() {
+					ui.moversLabel.SetText(movers.String())
+				})
+			}
+			time.Sleep(time.Minute)
 		}
-	}
+	}()
+
+	// 更新账户余额和保证金
+	go func() {
+		for {
+			err := ui.updateAccount()
+			ui.accountBanner.Report(err)
+			if err != nil {
+				fmt.Printf("%v\n", err)
+			}
+			time.Sleep(5 * time.Second)
+		}
+	}()
+
+	// 同步资金流水到本地交易数据库
+	go func() {
+		for {
+			if _, err := ui.tradeDB.Sync(); err != nil {
+				fmt.Printf(T("同步交易数据库失败: %v\n"), err)
+			}
+			time.Sleep(10 * time.Minute)
+		}
+	}()
+
+	// 更新当日开盘价、最高价和最低价
+	go func() {
+		for {
+			ui.updateSession()
+			time.Sleep(time.Minute)
+		}
+	}()
+
+	// 更新已实现波动率
+	go func() {
+		for {
+			ui.updateVolatility()
+			time.Sleep(time.Minute)
+		}
+	}()
+
+	// 更新期现基差
+	go func() {
+		for {
+			ui.updateBasis()
+			time.Sleep(30 * time.Second)
+		}
+	}()
+
+	// 更新多空比和主动买卖量，数据按5分钟周期更新，每分钟查询一次
+	go func() {
+		for {
+			ui.updateSentiment()
+			time.Sleep(time.Minute)
+		}
+	}()
+
+	// 更新自选列表的价格
+	go func() {
+		for {
+			if err := ui.updateWatchlist(); err != nil {
+				fmt.Printf(T("更新自选列表失败: %v\n"), err)
+			}
+			time.Sleep(10 * time.Second)
+		}
+	}()
+
+	// 检查所有交易对的价格和RSI提醒，不限于图表显示的交易对
+	go func() {
+		for {
+			if err := checkMarketAlerts(ui.client, ui.alerts); err != nil {
+				fmt.Printf(T("检查价格提醒失败: %v\n"), err)
+			}
+			time.Sleep(5 * time.Second)
+		}
+	}()
+
+	// 更新价格和订单数据
+	go func() {
+		for {
+			// 更新价格
+			err := ui.updatePrice()
+			ui.priceBanner.Report(err)
+			if err != nil {
+				fmt.Printf(T("获取价格失败: %v\n"), err)
+				if !ui.disconnected.Swap(true) {
+					ui.sound.Play(SoundDisconnect)
+				}
+			} else {
+				ui.disconnected.Store(false)
+				ui.lastUpdate.Store(time.Now().UnixMilli())
+			}
 
-	// 添加5%的边距
-	padding := (ymax - ymin) * 0.05
-	ymin -= padding
-	ymax += padding
+			// 更新持仓
+			err = ui.updatePositions()
+			ui.positionsBanner.Report(err)
+			if err != nil {
+				fmt.Printf(T("获取持仓失败: %v\n"), err)
+			}
+
+			// 更新订单
+			err = ui.updateOrders()
+			ui.ordersBanner.Report(err)
+			if err != nil {
+				fmt.Printf(T("获取订单失败: %v\n"), err)
+			}
+
+			time.Sleep(2 * time.Second)
+		}
+	}()
+}
+
+func (ui *TraderUI) Show() {
+	ui.window.ShowAndRun()
+}
+
+func main() {
+	parseConfigFlags(os.Args[1:])
+	a := app.NewWithID(appID)
+
+	// 首次运行没有配置文件时先显示设置向导
+	if !configExists() {
+		showSetupWizard(a, func() {
+			ui, err := NewTraderUI(a)
+			if err != nil {
+				fmt.Println(err)
+				a.Quit()
+				return
+			}
+			ui.window.Show()
+		})
+		a.Run()
+		return
+	}
 
-	return
+	ui, err := NewTraderUI(a)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	ui.Show()
 }
 
-func NewTraderUI() (*TraderUI, error) {
-	ui := &TraderUI{}
-	return ui.NewTraderUI()
+func NewTraderUI(a fyne.App) (*TraderUI, error) {
+	ui := &TraderUI{logs: CaptureOutput()}
+	return ui.NewTraderUI(a)
 }
 
 // 四舍五入到指定精度
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// 创建系统托盘菜单，当前平台不支持托盘时返回false
+func (ui *TraderUI) setupTray() bool {
+	desk, ok := ui.app.(desktop.App)
+	if !ok {
+		return false
+	}
+
+	ui.trayPnL = fyne.NewMenuItem(T("无持仓"), nil)
+	ui.trayPnL.Disabled = true
+	ui.trayPause = fyne.NewMenuItem(T("暂停自动化"), ui.toggleAutomation)
+	ui.trayMenu = fyne.NewMenu(T("币安期货交易"),
+		ui.trayPnL,
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem(T("显示窗口"), func() {
+			ui.window.Show()
+			ui.window.RequestFocus()
+		}),
+		ui.trayPause,
+		fyne.NewMenuItem(T("全部平仓"), ui.confirmFlattenAll),
+	)
+	desk.SetSystemTrayMenu(ui.trayMenu)
+	desk.SetSystemTrayIcon(theme.ComputerIcon())
+	return true
+}
+
+// 在托盘菜单中显示持仓数量和总未实现盈亏
+func (ui *TraderUI) updateTray(pnl float64, positions int) {
+	if ui.trayMenu == nil {
+		return
+	}
+	text := T("无持仓")
+	if positions > 0 {
+		text = fmt.Sprintf(T("%d个持仓 未实现盈亏: %+.2f"), positions, pnl)
+	}
+	fyne.Do(func() {
+		ui.trayPnL.Label = text
+		ui.trayMenu.Refresh()
+	})
+}
+
+// 暂停或恢复保护止盈、自动止盈止损和策略流水线，设置了PIN时暂停需要输入PIN
+func (ui *TraderUI) toggleAutomation() {
+	if !ui.paused.Load() {
+		ui.window.Show()
+		ui.window.RequestFocus()
+		ui.requirePIN(T("暂停自动化"), func() {
+			ui.setPaused(true)
+			ui.app.SendNotification(fyne.NewNotification(T("自动化已暂停"), T("保护止盈和自动止盈止损不会执行")))
+		}, nil)
+		return
+	}
+	ui.setPaused(false)
+	ui.app.SendNotification(fyne.NewNotification(T("自动化已恢复"), T("保护止盈和自动止盈止损已恢复运行")))
+}
+
+// 确认后市价平掉所有持仓并取消这些交易对的挂单，设置了PIN时先输入PIN
+func (ui *TraderUI) confirmFlattenAll() {
+	ui.window.Show()
+	ui.window.RequestFocus()
+	ui.requirePIN(T("全部平仓"), ui.showFlattenAll, nil)
+}
+
+func (ui *TraderUI) showFlattenAll() {
+	positions, err := ui.client.NewGetPositionRiskService().Do(context.Background())
+	if err != nil {
+		dialog.ShowError(fmt.Errorf(T("获取持仓信息失败: %v"), err), ui.window)
+		return
+	}
+	var open []*futures.PositionRisk
+	var lines []string
+	for _, p := range positions {
+		amt, _ := strconv.ParseFloat(p.PositionAmt, 64)
+		if amt == 0 {
+			continue
+		}
+		open = append(open, p)
+		unPnl, _ := strconv.ParseFloat(p.UnRealizedProfit, 64)
+		lines = append(lines, fmt.Sprintf("%s %.4f (%+.2f)", p.Symbol, amt, unPnl))
+	}
+	if len(open) == 0 {
+		dialog.ShowInformation(T("全部平仓"), T("无持仓"), ui.window)
+		return
+	}
+
+	message := T("市价平掉以下持仓并取消挂单？") + "\n" + strings.Join(lines, "\n")
+	dialog.ShowConfirm(T("全部平仓"), message, func(ok bool) {
+		if !ok {
+			return
+		}
+		var failed []string
+		for _, p := range open {
+			if err := ui.flattenPosition(p); err != nil {
+				failed = append(failed, err.Error())
+			}
+		}
+		if len(failed) > 0 {
+			dialog.ShowError(fmt.Errorf("%s", strings.Join(failed, "\n")), ui.window)
+		}
+		go ui.refreshAll()
+	}, ui.window)
+}
+
+// 市价平仓后取消交易对的所有挂单。先平仓，平仓失败时止损单仍然保护着持仓
+func (ui *TraderUI) flattenPosition(position *futures.PositionRisk) error {
+	amt, _ := strconv.ParseFloat(position.PositionAmt, 64)
+	side := futures.SideTypeSell
+	if amt < 0 {
+		side = futures.SideTypeBuy
+	}
+	filters, err := ui.filters.Get(position.Symbol)
+	if err != nil {
+		return fmt.Errorf("%s %v", position.Symbol, err)
+	}
+
+	_, err = ui.client.NewCreateOrderService().
+		Symbol(position.Symbol).
+		NewClientOrderID(newClientOrderID(tagClose)).
+		Side(side).
+		PositionSide("BOTH").
+		Type(futures.OrderTypeMarket).
+		Quantity(filters.FormatQuantity(math.Abs(amt))).
+		ReduceOnly(true).
+		Do(context.Background())
+	if err != nil {
+		return fmt.Errorf(T("%s 平仓失败: %v"), position.Symbol, err)
+	}
+	err = ui.client.NewCancelAllOpenOrdersService().Symbol(position.Symbol).Do(context.Background())
+	if err != nil {
+		return fmt.Errorf(T("%s 已平仓，但取消挂单失败: %v"), position.Symbol, err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// 订单的一次成交
+type Fill struct {
+	Symbol        string
+	Side          futures.SideType
+	Price         float64
+	Quantity      float64
+	RealizedPnL   float64
+	ClientOrderID string
+	Time          time.Time
+}
+
+// 账户数据流，目前只处理订单成交。listenKey每30分钟续期，断开后重新获取并重连
+type UserStream struct {
+	client *futures.Client
+	OnFill func(Fill)
+}
+
+func NewUserStream(client *futures.Client) *UserStream {
+	return &UserStream{client: client}
+}
+
+func (s *UserStream) Start() {
+	go func() {
+		for {
+			listenKey, err := s.client.NewStartUserStreamService().Do(context.Background())
+			if err != nil {
+				warnf(T("获取账户数据流失败: %v"), err)
+				time.Sleep(30 * time.Second)
+				continue
+			}
+			doneC, _, err := futures.WsUserDataServe(listenKey, s.handleEvent, func(err error) {
+				log.Printf(T("账户数据流错误: %v"), err)
+			})
+			if err != nil {
+				warnf(T("订阅账户数据流失败: %v"), err)
+				time.Sleep(5 * time.Second)
+				continue
+			}
+
+			keepalive := time.NewTicker(30 * time.Minute)
+		wait:
+			for {
+				select {
+				case <-keepalive.C:
+					if err := s.client.NewKeepaliveUserStreamService().ListenKey(listenKey).Do(context.Background()); err != nil {
+						warnf(T("账户数据流续期失败: %v"), err)
+					}
+				case <-doneC:
+					break wait
+				}
+			}
+			keepalive.Stop()
+			metrics.Inc(metricWSReconnects)
+			time.Sleep(time.Second)
+		}
+	}()
+}
+
+func (s *UserStream) handleEvent(event *futures.WsUserDataEvent) {
+	if event.Event != futures.UserDataEventTypeOrderTradeUpdate {
+		return
+	}
+	u := event.OrderTradeUpdate
+	if u.ExecutionType != futures.OrderExecutionTypeTrade || s.OnFill == nil {
+		return
+	}
+	price, _ := strconv.ParseFloat(u.LastFilledPrice, 64)
+	qty, _ := strconv.ParseFloat(u.LastFilledQty, 64)
+	pnl, _ := strconv.ParseFloat(u.RealizedPnL, 64)
+	s.OnFill(Fill{
+		Symbol:        u.Symbol,
+		Side:          u.Side,
+		Price:         price,
+		Quantity:      qty,
+		RealizedPnL:   pnl,
+		ClientOrderID: u.ClientOrderID,
+		Time:          time.UnixMilli(u.TradeTime),
+	})
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// 构建信息，发布时用ldflags写入:
+//
+//	go build -ldflags "-X main.buildVersion=v1.2.0 -X main.buildCommit=$(git rev-parse --short HEAD) -X main.buildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// 没有写入时从Go工具链记录的vcs信息中读取提交和时间
+var (
+	buildVersion = "dev"
+	buildCommit  = ""
+	buildDate    = ""
+)
+
+// 当前程序的版本、提交、构建时间和Go版本，反馈问题时附上
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Date      string `json:"date"`
+	Modified  bool   `json:"modified,omitempty"` // 构建时工作区有未提交的修改
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+}
+
+func currentBuildInfo() BuildInfo {
+	info := BuildInfo{
+		Version:   buildVersion,
+		Commit:    buildCommit,
+		Date:      buildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = s.Value
+			}
+		case "vcs.time":
+			if info.Date == "" {
+				info.Date = s.Value
+			}
+		case "vcs.modified":
+			info.Modified = s.Value == "true"
+		}
+	}
+	if len(info.Commit) > 12 {
+		info.Commit = info.Commit[:12]
+	}
+	return info
+}
+
+// 一行的版本说明，如 protect v1.2.0 (3f2a9c1d0e4b, 2024-05-01T08:00:00Z) go1.23.0 linux/amd64
+func (b BuildInfo) String() string {
+	var details []string
+	if b.Commit != "" {
+		commit := b.Commit
+		if b.Modified {
+			commit += "-dirty"
+		}
+		details = append(details, commit)
+	}
+	if b.Date != "" {
+		details = append(details, b.Date)
+	}
+	text := "protect " + b.Version
+	if len(details) > 0 {
+		text += " (" + strings.Join(details, ", ") + ")"
+	}
+	return fmt.Sprintf("%s %s %s", text, b.GoVersion, b.Platform)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// 已实现波动率指标，供分析面板和风控模块使用
+type VolatilityMetrics struct {
+	Symbol string
+	Vol1h  float64 // 最近1小时年化波动率(%)
+	Vol24h float64 // 最近24小时年化波动率(%)
+	ATR    float64 // 5分钟K线的ATR(14)
+	Time   time.Time
+}
+
+// 用5分钟K线计算最近1小时和24小时的年化已实现波动率
+func fetchVolatilityMetrics(client *futures.Client, symbol string) (*VolatilityMetrics, error) {
+	klines, err := fetchKlines(client, symbol, "5m", 289)
+	if err != nil {
+		return nil, err
+	}
+
+	interval := intervalDuration("5m")
+	return &VolatilityMetrics{
+		Symbol: symbol,
+		Vol1h:  realizedVolatility(klines, interval, 12),
+		Vol24h: realizedVolatility(klines, interval, 288),
+		ATR:    calculateATR(klines, 14),
+		Time:   time.Now(),
+	}, nil
+}
+
+// 计算最近window个收益率的年化已实现波动率(%)
+func realizedVolatility(klines []Kline, interval time.Duration, window int) float64 {
+	returns := logReturns(klines)
+	if len(returns) > window {
+		returns = returns[len(returns)-window:]
+	}
+	if len(returns) < 2 || interval <= 0 {
+		return 0
+	}
+
+	var mean float64
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	var variance float64
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	variance /= float64(len(returns) - 1)
+
+	periodsPerYear := float64(365*24*time.Hour) / float64(interval)
+	return math.Sqrt(variance*periodsPerYear) * 100
+}
+
+// 生成分析面板中的波动率部分
+func (v *VolatilityMetrics) Analysis() string {
+	var analysis strings.Builder
+
+	analysis.WriteString(fmt.Sprintf(T("已实现波动率(1h): %.1f%%\n"), v.Vol1h))
+	analysis.WriteString(fmt.Sprintf(T("已实现波动率(24h): %.1f%%\n"), v.Vol24h))
+	analysis.WriteString(fmt.Sprintf("ATR(14, 5m): %.4f\n", v.ATR))
+
+	if v.Vol24h > 0 && v.Vol1h > v.Vol24h*1.5 {
+		analysis.WriteString(T("- 短期波动率明显放大，注意止损距离\n"))
+	} else if v.Vol24h > 0 && v.Vol1h < v.Vol24h*0.5 {
+		analysis.WriteString(T("- 短期波动率收缩，可能酝酿突破\n"))
+	}
+
+	return analysis.String()
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 成交量分布（按价格统计的成交量直方图）
+type VolumeProfile struct {
+	Low     float64
+	High    float64
+	BinSize float64
+	Bins    []float64
+
+	POC    int // 成交量最大的价格区间
+	VALow  int // 价值区下沿所在区间
+	VAHigh int // 价值区上沿所在区间
+}
+
+// 根据K线计算成交量分布，每根K线的成交量平均分配到其最高价和最低价之间
+func calculateVolumeProfile(klines []Kline, bins int) *VolumeProfile {
+	if len(klines) == 0 || bins <= 0 {
+		return nil
+	}
+
+	low := klines[0].Low
+	high := klines[0].High
+	for _, k := range klines {
+		if k.Low < low {
+			low = k.Low
+		}
+		if k.High > high {
+			high = k.High
+		}
+	}
+	if high <= low {
+		return nil
+	}
+
+	vp := &VolumeProfile{
+		Low:     low,
+		High:    high,
+		BinSize: (high - low) / float64(bins),
+		Bins:    make([]float64, bins),
+	}
+
+	for _, k := range klines {
+		first := vp.binIndex(k.Low)
+		last := vp.binIndex(k.High)
+		perBin := k.Volume / float64(last-first+1)
+		for i := first; i <= last; i++ {
+			vp.Bins[i] += perBin
+		}
+	}
+
+	var total float64
+	for i, v := range vp.Bins {
+		total += v
+		if v > vp.Bins[vp.POC] {
+			vp.POC = i
+		}
+	}
+
+	// 从POC向两侧扩展，直到覆盖70%的成交量
+	vp.VALow, vp.VAHigh = vp.POC, vp.POC
+	covered := vp.Bins[vp.POC]
+	for covered < total*0.7 {
+		var below, above float64 = -1, -1
+		if vp.VALow > 0 {
+			below = vp.Bins[vp.VALow-1]
+		}
+		if vp.VAHigh < bins-1 {
+			above = vp.Bins[vp.VAHigh+1]
+		}
+		if below < 0 && above < 0 {
+			break
+		}
+		if above >= below {
+			vp.VAHigh++
+			covered += above
+		} else {
+			vp.VALow--
+			covered += below
+		}
+	}
+
+	return vp
+}
+
+func (vp *VolumeProfile) binIndex(price float64) int {
+	i := int((price - vp.Low) / vp.BinSize)
+	if i < 0 {
+		return 0
+	}
+	if i >= len(vp.Bins) {
+		return len(vp.Bins) - 1
+	}
+	return i
+}
+
+// 区间中间价
+func (vp *VolumeProfile) binPrice(i int) float64 {
+	return vp.Low + (float64(i)+0.5)*vp.BinSize
+}
+
+func (vp *VolumeProfile) POCPrice() float64 {
+	return vp.binPrice(vp.POC)
+}
+
+// 价值区的下沿和上沿价格
+func (vp *VolumeProfile) ValueArea() (float64, float64) {
+	return vp.Low + float64(vp.VALow)*vp.BinSize, vp.Low + float64(vp.VAHigh+1)*vp.BinSize
+}
+
+// 生成分析面板中的成交量分布部分
+func (vp *VolumeProfile) Analysis(price float64) string {
+	var analysis strings.Builder
+
+	val, vah := vp.ValueArea()
+	analysis.WriteString(fmt.Sprintf("POC: %.2f\n", vp.POCPrice()))
+	analysis.WriteString(fmt.Sprintf(T("价值区: %.2f - %.2f\n"), val, vah))
+
+	if price > vah {
+		analysis.WriteString(T("- 价格位于价值区上方，接受度较低\n"))
+	} else if price < val {
+		analysis.WriteString(T("- 价格位于价值区下方，接受度较低\n"))
+	} else {
+		analysis.WriteString(T("- 价格位于价值区内\n"))
+	}
+
+	return analysis.String()
+}
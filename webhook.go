@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 通用webhook设置，事件以JSON格式POST到url:
+//
+//	{"type":"fill","time":"...","symbol":"SOLUSDC","title":"...","message":"...","fields":{"price":135.2}}
+//
+// 设置了secret时请求带有X-Protect-Timestamp和X-Protect-Signature头，签名为
+// sha256=hex(HMAC-SHA256(secret, 时间戳 + "." + 请求体))，接收方据此验证来源并拒绝过期的请求
+type WebhookConfig struct {
+	Name    string   `json:"name,omitempty"` // 渠道名，提醒的channels中使用，默认webhook、webhook2……
+	URL     string   `json:"url"`
+	Secret  string   `json:"secret,omitempty"`
+	Retries int      `json:"retries,omitempty"` // 网络错误、429和5xx时的重试次数，默认3
+	Events  []string `json:"events,omitempty"`  // 推送的事件类型，为空时推送所有事件
+}
+
+// 把事件POST到用户配置的URL，失败时按1s、2s、4s……间隔重试
+type Webhook struct {
+	config WebhookConfig
+	client *http.Client
+}
+
+func NewWebhook(config WebhookConfig) (*Webhook, error) {
+	if config.URL == "" {
+		return nil, errors.New(T("webhook需要设置url"))
+	}
+	if config.Name == "" {
+		config.Name = "webhook"
+	}
+	if config.Retries <= 0 {
+		config.Retries = 3
+	}
+	return &Webhook{config: config, client: &http.Client{Timeout: 15 * time.Second}}, nil
+}
+
+func (w *Webhook) Name() string { return w.config.Name }
+
+func (w *Webhook) Notify(event NotifyEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf(T("序列化请求失败: %v"), err)
+	}
+
+	// 投递ID在重试时不变，接收方可以用来去重
+	id := make([]byte, 16)
+	rand.Read(id)
+	delivery := hex.EncodeToString(id)
+
+	delay := time.Second
+	for attempt := 0; ; attempt++ {
+		retry, err := w.post(event.Type, delivery, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= w.config.Retries {
+			return err
+		}
+		debugf(T("%s发送失败，%s后重试: %v"), w.config.Name, delay, err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// 发送一次，返回失败时是否值得重试
+func (w *Webhook) post(eventType, delivery string, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, w.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf(T("创建请求失败: %v"), err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Protect-Event", eventType)
+	req.Header.Set("X-Protect-Delivery", delivery)
+	if w.config.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set("X-Protect-Timestamp", timestamp)
+		req.Header.Set("X-Protect-Signature", "sha256="+webhookSignature(w.config.Secret, timestamp, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf(T("请求webhook失败: %v"), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 300 {
+		return false, nil
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err = fmt.Errorf(T("webhook返回错误 %d: %s"), resp.StatusCode, strings.TrimSpace(string(data)))
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500, err
+}
+
+func webhookSignature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}